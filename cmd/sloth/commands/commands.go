@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"io"
+	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 
@@ -31,6 +32,8 @@ type RootConfig struct {
 	NoLog      bool
 	NoColor    bool
 	LoggerType string
+	// Timeout is the max run time of the command, 0 is unlimited.
+	Timeout time.Duration
 
 	// Global instances.
 	Stdin  io.Reader
@@ -48,6 +51,7 @@ func NewRootConfig(app *kingpin.Application) *RootConfig {
 	app.Flag("no-log", "Disable logger.").BoolVar(&c.NoLog)
 	app.Flag("no-color", "Disable logger color.").BoolVar(&c.NoColor)
 	app.Flag("logger", "Selects the logger type.").Default(LoggerTypeDefault).EnumVar(&c.LoggerType, LoggerTypeDefault, LoggerTypeJSON)
+	app.Flag("timeout", "The max run time of the command (e.g: a hung plugin or upload in CI), 0 disables it.").Default("0s").DurationVar(&c.Timeout)
 
 	return c
 }
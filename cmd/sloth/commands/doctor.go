@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	prometheusmodel "github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/log"
+)
+
+type doctorCommand struct {
+	slosInput            []string
+	sliPluginsPaths      []string
+	sliPluginsTimeout    time.Duration
+	sloPeriodWindowsPath string
+	sloPeriod            string
+	followSymlinks       bool
+}
+
+// NewDoctorCommand returns the doctor command.
+func NewDoctorCommand(app *kingpin.Application) Command {
+	c := &doctorCommand{}
+	cmd := app.Command("doctor", "Checks the environment health (SLI plugins, SLO period windows and SLO specs discovery).")
+	cmd.Flag("input", "SLO spec discovery path, will discover recursively all YAML files (can be repeated).").Short('i').StringsVar(&c.slosInput)
+	cmd.Flag("follow-symlinks", "Follows the symlinked directories while discovering the SLO specs, every directory is discovered once.").BoolVar(&c.followSymlinks)
+	cmd.Flag("sli-plugins-path", "The path to SLI plugins (can be repeated), if not set it disable plugins support.").Short('p').StringsVar(&c.sliPluginsPaths)
+	cmd.Flag("sli-plugins-timeout", "The max execution time of an SLI plugin, if 0 plugins run without time limit.").Default("0s").DurationVar(&c.sliPluginsTimeout)
+	cmd.Flag("slo-period-windows-path", "The directory path to custom SLO period windows catalog (replaces default ones).").StringVar(&c.sloPeriodWindowsPath)
+	cmd.Flag("default-slo-period", "The default SLO period windows to be used for the SLOs.").Default("30d").StringVar(&c.sloPeriod)
+
+	return c
+}
+
+func (d doctorCommand) Name() string { return "doctor" }
+func (d doctorCommand) Run(ctx context.Context, config RootConfig) error {
+	logger := config.Logger.WithValues(log.Kv{"window": d.sloPeriod})
+
+	checks := []struct {
+		name  string
+		check func() (string, error)
+	}{
+		{name: "SLI plugins", check: func() (string, error) {
+			pluginRepo, err := createPluginLoader(ctx, logger, d.sliPluginsPaths, d.sliPluginsTimeout)
+			if err != nil {
+				return "", err
+			}
+			plugins, err := pluginRepo.ListSLIPlugins(ctx)
+			if err != nil {
+				return "", fmt.Errorf("could not list SLI plugins: %w", err)
+			}
+			return fmt.Sprintf("%d plugins loaded", len(plugins)), nil
+		}},
+
+		{name: "SLO period windows", check: func() (string, error) {
+			sp, err := prometheusmodel.ParseDuration(d.sloPeriod)
+			if err != nil {
+				return "", fmt.Errorf("invalid SLO period duration: %w", err)
+			}
+
+			var wfs fs.FS
+			if d.sloPeriodWindowsPath != "" {
+				wfs = os.DirFS(d.sloPeriodWindowsPath)
+			}
+			windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{
+				FS:     wfs,
+				Logger: logger,
+			})
+			if err != nil {
+				return "", fmt.Errorf("could not load SLO period windows repository: %w", err)
+			}
+
+			_, err = windowsRepo.GetWindows(ctx, time.Duration(sp))
+			if err != nil {
+				return "", fmt.Errorf("invalid default slo period: %w", err)
+			}
+			return fmt.Sprintf("%s windows resolved", sp), nil
+		}},
+
+		{name: "SLO specs discovery", check: func() (string, error) {
+			if len(d.slosInput) == 0 {
+				return "skipped, no input set", nil
+			}
+
+			sloPaths, err := discoverSLOManifests(logger, nil, nil, nil, d.followSymlinks, d.slosInput...)
+			if err != nil {
+				return "", fmt.Errorf("could not discover files: %w", err)
+			}
+			if len(sloPaths) == 0 {
+				return "", fmt.Errorf("0 slo specs have been discovered")
+			}
+			return fmt.Sprintf("%d spec files discovered", len(sloPaths)), nil
+		}},
+	}
+
+	failed := 0
+	for _, c := range checks {
+		msg, err := c.check()
+		if err != nil {
+			failed++
+			fmt.Fprintf(config.Stdout, "[FAIL] %s: %s\n", c.name, err)
+			continue
+		}
+		fmt.Fprintf(config.Stdout, "[PASS] %s: %s\n", c.name, msg)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -9,12 +10,14 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	openslov1alpha "github.com/OpenSLO/oslo/pkg/manifest/v1alpha"
 	prometheusmodel "github.com/prometheus/common/model"
 	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
 
 	"github.com/slok/sloth/internal/alert"
 	"github.com/slok/sloth/internal/app/generate"
@@ -22,6 +25,7 @@ import (
 	"github.com/slok/sloth/internal/k8sprometheus"
 	"github.com/slok/sloth/internal/log"
 	"github.com/slok/sloth/internal/openslo"
+	"github.com/slok/sloth/internal/output"
 	"github.com/slok/sloth/internal/prometheus"
 	kubernetesv1 "github.com/slok/sloth/pkg/kubernetes/api/sloth/v1"
 	prometheusv1 "github.com/slok/sloth/pkg/prometheus/api/v1"
@@ -30,39 +34,171 @@ import (
 type generateCommand struct {
 	slosInput             string
 	slosOut               string
+	outUploaders          map[string]string
 	slosExcludeRegex      string
 	slosIncludeRegex      string
+	contentIncludes       []string
+	followSymlinks        bool
 	disableRecordings     bool
+	omitMetadataRules     bool
 	disableAlerts         bool
+	splitByKind           bool
 	disableOptimizedRules bool
 	extraLabels           map[string]string
 	idLabels              map[string]string
+	serviceNamespaces     map[string]string
 	sliPluginsPaths       []string
+	sliPluginsTimeout     time.Duration
+	checkSLIPlugins       bool
+	specPolicyPluginsPath []string
+	sliWindowPlaceholder  string
+	sliSmoothingWindow    time.Duration
+	sliFreshnessWindow    time.Duration
+	policyRule            bool
+	objectiveDriftRule    bool
+	descriptionLabel      bool
 	sloPeriodWindowsPath  string
 	sloPeriod             string
+	experimentalOpenSLOV2 bool
+	rulesFormat           string
+	vmalertDebug          bool
+	vmalertUpdateEntries  int
+	alertsWithSLOLabels   bool
+	alertsPendingRules    bool
+	exportOpenSLO         bool
+	objectivePrecision    int
+	redactedRulesOut      string
+	lokiRulesOut          string
+	dashboardsOut         string
+	reportOut             string
+	mergeInto             string
+	objectiveIDLabel      bool
+	sliObjectiveLabels    bool
+	sliSuccessRules       bool
+	namespaceFrom         string
+	k8sSplit              string
+	severityMapping       map[string]string
+	pageSeverity          string
+	ticketSeverity        string
+	pageReceiver          string
+	ticketReceiver        string
+	noDataAlertSeverity   string
+	noDataAlertMinFor     time.Duration
+	alertsLimit           int
+	pageMinObjective      float64
+	minBudgetConsumed     float64
+	alertNameFromID       bool
+	alertsAvgOverTime     bool
+	defaultAnnotations    string
+	overrides             string
+	serviceBudgetAlert    float64
+	specHash              bool
+	sourceLabel           bool
+	onlySLOs              []string
+	inputEncoding         string
+	shareSLIQueries       bool
+	recordNameTemplate    string
+	groupNameTemplate     string
 }
 
 // NewGenerateCommand returns the generate command.
 func NewGenerateCommand(app *kingpin.Application) Command {
-	c := &generateCommand{extraLabels: map[string]string{}, idLabels: map[string]string{}}
+	c := newGenerateCommand()
 	cmd := app.Command("generate", "Generates Prometheus SLOs.")
+	c.registerFlags(cmd)
+
+	return c
+}
+
+func newGenerateCommand() *generateCommand {
+	return &generateCommand{extraLabels: map[string]string{}, idLabels: map[string]string{}, serviceNamespaces: map[string]string{}, severityMapping: map[string]string{}, outUploaders: map[string]string{}}
+}
+
+// registerFlags registers the generation flags on the command, these are shared with the commands
+// that wrap the generation.
+func (c *generateCommand) registerFlags(cmd *kingpin.CmdClause) {
 	cmd.Flag("input", "SLO spec input file path or directory (if directory is used, slos will be discovered recursively and out must be a directory).").Short('i').StringVar(&c.slosInput)
-	cmd.Flag("out", "Generated rules output file path or directory. If `-` it will use stdout (if input is a directory this must be a directory).").Default("-").Short('o').StringVar(&c.slosOut)
+	cmd.Flag("out", "Generated rules output file path or directory. If `-` it will use stdout (if input is a directory this must be a directory). Remote destinations can be used with URLs (e.g `s3://bucket/key`).").Default("-").Short('o').StringVar(&c.slosOut)
+	cmd.Flag("out-uploader", "Overrides or adds the command that uploads the output for a remote destination URL scheme, the URL is the last argument and the data is on stdin (`scheme=command` form, can be repeated, by default `s3` and `gs` use the aws and gsutil CLIs).").StringMapVar(&c.outUploaders)
 	cmd.Flag("fs-exclude", "Filter regex to ignore matched discovered SLO file paths (used with directory based input/output).").Short('e').StringVar(&c.slosExcludeRegex)
 	cmd.Flag("fs-include", "Filter regex to include matched discovered SLO file paths, everything else will be ignored. Exclude has preference (used with directory based input/output).").Short('n').StringVar(&c.slosIncludeRegex)
+	cmd.Flag("content-include", "Filter to include only the discovered SLO files with a YAML key value on their content matching the regex, in 'key=regex' form or 'key!=regex' to negate it (e.g 'service=^api-', can be repeated, all need to match, used with directory based input/output).").StringsVar(&c.contentIncludes)
+	cmd.Flag("follow-symlinks", "Follows the symlinked directories while discovering the SLO specs, every directory is discovered once.").BoolVar(&c.followSymlinks)
 
 	cmd.Flag("extra-labels", "Extra labels that will be added to all the generated Prometheus rules ('key=value' form, can be repeated).").Short('l').StringMapVar(&c.extraLabels)
 	cmd.Flag("id-labels", "Id labels that used as filters for generated recording rules. These will also be added as extra labels ('key=value' form, can be repeated).").Short('d').StringMapVar(&c.idLabels)
+	cmd.Flag("service-namespace", "Maps an SLO service to a namespace that will be set as the `namespace` label on all the service SLO rules ('service=namespace' form, can be repeated).").StringMapVar(&c.serviceNamespaces)
+	cmd.Flag("objective-id-label", "Adds the SLO objective as an ID label (`sloth_objective`) so the same SLI with multiple objectives doesn't collide.").BoolVar(&c.objectiveIDLabel)
+	cmd.Flag("sli-objective-labels", "Adds the SLO objective and error budget percents as labels (`sloth_objective` and `sloth_error_budget`) on the SLI recording rules.").BoolVar(&c.sliObjectiveLabels)
+	cmd.Flag("sli-success-rules", "Generates an additional SLI success recording rule (`slo:sli_success:ratio_rate<window>`) for every SLI recording rule window, with the complement of the SLI error ratio.").BoolVar(&c.sliSuccessRules)
 	cmd.Flag("disable-recordings", "Disables recording rules generation.").BoolVar(&c.disableRecordings)
+	cmd.Flag("omit-metadata-rules", "Disables the metadata recording rules generation, the SLI recording rules will be generated.").BoolVar(&c.omitMetadataRules)
 	cmd.Flag("disable-alerts", "Disables alert rules generation.").BoolVar(&c.disableAlerts)
+	cmd.Flag("split-by-kind", "Writes the recording rules and the alert rules on different files (`recording.yaml` and `alerts.yaml`) of the output directory, so they can be deployed to different rulers.").BoolVar(&c.splitByKind)
 	cmd.Flag("sli-plugins-path", "The path to SLI plugins (can be repeated), if not set it disable plugins support.").Short('p').StringsVar(&c.sliPluginsPaths)
+	cmd.Flag("sli-plugins-timeout", "The max execution time of an SLI plugin, if 0 plugins run without time limit.").Default("0s").DurationVar(&c.sliPluginsTimeout)
+	cmd.Flag("check-sli-plugins", "Checks that all the SLI plugins referenced by the specs exist before generating, failing with all the missing plugins and the SLOs using them.").BoolVar(&c.checkSLIPlugins)
+	cmd.Flag("spec-policy-plugins-path", "The path to spec policy plugins (`policy.go` files, can be repeated), these receive the loaded SLOs of every spec before generating them and can reject or transform them.").StringsVar(&c.specPolicyPluginsPath)
+	cmd.Flag("sli-window-placeholder", "A custom SLI queries window placeholder token (e.g `$__range`) that will be used as the `{{.window}}` template variable on Prometheus specs.").StringVar(&c.sliWindowPlaceholder)
 	cmd.Flag("slo-period-windows-path", "The directory path to custom SLO period windows catalog (replaces default ones).").StringVar(&c.sloPeriodWindowsPath)
 	cmd.Flag("default-slo-period", "The default SLO period windows to be used for the SLOs.").Default("30d").StringVar(&c.sloPeriod)
+	cmd.Flag("experimental-openslo-v2", "Enables the experimental OpenSLO v2alpha specs support (only a subset of the spec is supported).").BoolVar(&c.experimentalOpenSLOV2)
 	cmd.Flag("disable-optimized-rules", "If enabled it will disable optimized generated rules.").BoolVar(&c.disableOptimizedRules)
-
-	return c
+	cmd.Flag("sli-smoothing-window", "If set, it will generate an additional smoothed SLI recording rule for every SLI recording rule, averaged over this window.").Default("0s").DurationVar(&c.sliSmoothingWindow)
+	cmd.Flag("sli-freshness-window", "If set, it will generate an additional metadata recording rule for every SLO with the seconds since the SLI last reported data, up to this window.").Default("0s").DurationVar(&c.sliFreshnessWindow)
+	cmd.Flag("slo-policy-rule", "Generates an additional `sloth_slo_policy_info` metadata recording rule for every SLO with the objective, period window and error budget percent as labels (`sloth_objective`, `sloth_window` and `sloth_error_budget`).").BoolVar(&c.policyRule)
+	cmd.Flag("slo-objective-drift-rule", "Generates an additional `sloth_slo_objective_target` metadata recording rule for every SLO with the objective as the value and only the SLO ID labels, so the objective changes are shown as steps over time.").BoolVar(&c.objectiveDriftRule)
+	cmd.Flag("slo-description-label", "Adds the SLO description as the `sloth_description` label of the `sloth_slo_info` metadata recording rule.").BoolVar(&c.descriptionLabel)
+	cmd.Flag("share-sli-queries", "Records the counter event SLI queries used by multiple SLOs of the same spec once, as shared recording rules on their own group referenced by the SLOs.").BoolVar(&c.shareSLIQueries)
+	cmd.Flag("record-name-template", "A Go template to name the SLI recording rules (e.g `{{ .Role }}:ratio_rate{{ .Window }}{{ .SmoothingWindow }}`), it has `.ID`, `.Name`, `.Service`, `.Role`, `.Window` and `.SmoothingWindow` fields.").StringVar(&c.recordNameTemplate)
+	cmd.Flag("group-name-template", "A Go template to name the SLO rule groups (e.g `slo-{{ .Service }}-{{ .Name }}-{{ .Kind }}`), it has `.ID`, `.Name`, `.Service` and `.Kind` fields, every group kind of a SLO needs a different name.").StringVar(&c.groupNameTemplate)
+	cmd.Flag("alerts-with-slo-labels", "Adds the SLO labels to the generated alert rules labels (alert labels have precedence).").BoolVar(&c.alertsWithSLOLabels)
+	cmd.Flag("alerts-pending-recording-rules", "Generates a recording rule for every alert with its firing condition as 0 or 1 (to track the pending state), on the recordings group.").BoolVar(&c.alertsPendingRules)
+	cmd.Flag("sli-no-data-alert-min-for", "The minimum `for` of the SLI no data alert (e.g the scrape interval), shorter ones will be clamped to this value, 0 disables it.").Default("0s").DurationVar(&c.noDataAlertMinFor)
+	cmd.Flag("alerts-limit", "The max number of alerts every SLO alert rules group can produce (Prometheus rule group `limit`), to avoid alert storms on high cardinality SLIs, 0 disables it (not supported on Kubernetes specs).").IntVar(&c.alertsLimit)
+	cmd.Flag("page-min-objective", "Disables the page alerts of the SLOs with an objective below this one (e.g 99), the ticket alerts are kept.").Float64Var(&c.pageMinObjective)
+	cmd.Flag("alerts-service-budget-remaining", "Generates an alert for every service that fires when its worst SLO has less than this percent of the SLO period error budget remaining (e.g 10), 0 disables it.").Float64Var(&c.serviceBudgetAlert)
+	cmd.Flag("alerts-min-budget-consumed", "The percent of the SLO period error budget (e.g 10) that needs to be consumed for the burn rate alerts to fire, 0 disables it.").Float64Var(&c.minBudgetConsumed)
+	cmd.Flag("alert-name-from-id", "Uses the SLO ID (`sloth_id`) as the burn rate alerts name instead of the spec alert name, so renaming an SLO that keeps its ID doesn't break the alert silences.").BoolVar(&c.alertNameFromID)
+	cmd.Flag("alerts-avg-over-time", "The burn rate alerts use `avg_over_time` of the alert shortest window SLI recording rule over every alert window instead of every window SLI recording rule, reducing the query cost (the SLI ratios average is not weighted by the events).").BoolVar(&c.alertsAvgOverTime)
+	cmd.Flag("default-annotations", "The YAML file path with the annotations ('key: value' map) set on all the generated SLO alerts, the SLO alert annotations have precedence.").StringVar(&c.defaultAnnotations)
+	cmd.Flag("overrides", "The YAML file path with the SLO fields overrides keyed by SLO ID (`objective`, `disablePageAlert` and `disableTicketAlert`), applied on the loaded SLOs before generating them.").StringVar(&c.overrides)
+	cmd.Flag("severity-mapping", "Maps the alerts page and ticket severities to custom `severity` label values, the `sloth_severity` label is not mapped ('page=value' or 'ticket=value' form, can be repeated).").StringMapVar(&c.severityMapping)
+	cmd.Flag("page-severity", "The `severity` label value set on the generated page alerts (e.g critical), the alert spec labels have precedence.").StringVar(&c.pageSeverity)
+	cmd.Flag("ticket-severity", "The `severity` label value set on the generated ticket alerts (e.g warning), the alert spec labels have precedence.").StringVar(&c.ticketSeverity)
+	cmd.Flag("page-receiver", "The `receiver` label value set on the generated page alerts to route them (e.g pagerduty), the alert spec labels have precedence.").StringVar(&c.pageReceiver)
+	cmd.Flag("ticket-receiver", "The `receiver` label value set on the generated ticket alerts to route them (e.g jira), the alert spec labels have precedence.").StringVar(&c.ticketReceiver)
+	cmd.Flag("sli-no-data-alert-severity", "If set, it will generate an `absent` based alert for every SLO that fires when the SLI has no data, using this severity label value.").StringVar(&c.noDataAlertSeverity)
+	cmd.Flag("objective-precision", "The number of significant digits used on the generated alert thresholds, 0 disables the rounding.").Default("0").IntVar(&c.objectivePrecision)
+	cmd.Flag("export-openslo", "Exports the Prometheus specs as OpenSLO v2alpha manifests (including alert policies) instead of generating the rules, the SLIs can't use the `{{.window}}` template.").BoolVar(&c.exportOpenSLO)
+	cmd.Flag("emit-dashboards", "The directory path where a Grafana dashboard JSON per SLO will be written.").StringVar(&c.dashboardsOut)
+	cmd.Flag("merge-into", "The Prometheus rules file path where the generated rules will be merged, replacing only the generated SLOs rule groups (used instead of the output).").StringVar(&c.mergeInto)
+	cmd.Flag("report", "The file path where a JSON report of the generated rule groups, rule names and group content hashes will be written.").StringVar(&c.reportOut)
+	cmd.Flag("redacted-rules-out", "The file path where the redacted query fragments helper recording rules will be written (`<<redact:FRAGMENT>>` markup).").StringVar(&c.redactedRulesOut)
+	cmd.Flag("loki-rules-out", "The file path where the Loki recording rules of the LogQL based SLIs will be written.").StringVar(&c.lokiRulesOut)
+	cmd.Flag("source-label", "Adds the source spec file path as the `sloth_source` label of the SLO info metadata rules.").BoolVar(&c.sourceLabel)
+	cmd.Flag("spec-hash", "Embeds a hash of the source spec and Sloth version on the generated output (as a comment or a Kubernetes annotation) for drift detection.").BoolVar(&c.specHash)
+	cmd.Flag("only-slo", "Generates only the SLOs with these IDs (comma separated, can be repeated), unknown IDs will fail.").StringsVar(&c.onlySLOs)
+	cmd.Flag("input-encoding", "The encoding of the SLO spec files, transcoded to UTF-8 before loading them.").Default(inputEncodingUTF8).EnumVar(&c.inputEncoding, inputEncodingUTF8, inputEncodingLatin1, inputEncodingWindows1252)
+	cmd.Flag("rules-format", "The format of the generated rules for non Kubernetes specs, `ruler-files` writes a Prometheus rules file per namespace (`<namespace>.yaml`) on the output directory for the central rulers, `cortex` writes a Cortex/Mimir rules file per namespace on the output directory (or a single namespace to the stdout).").Default(rulesFormatPrometheus).EnumVar(&c.rulesFormat, rulesFormatPrometheus, rulesFormatVMAlert, rulesFormatCortex, rulesFormatRulerFiles)
+	cmd.Flag("namespace-from", "The source of the rules namespace: `service`, `name` or `static:<value>` (used with cortex and ruler-files rules formats).").Default(prometheus.CortexNamespaceFromService).StringVar(&c.namespaceFrom)
+	cmd.Flag("k8s-split", "How the Kubernetes specs generated rules are split into PrometheusRule CRs, a single one for the SLO group or one per SLO.").Default(k8sSplitPerGroup).EnumVar(&c.k8sSplit, k8sSplitPerGroup, k8sSplitPerSLO)
+	cmd.Flag("vmalert-debug", "Enables debug mode on the generated vmalert alert rules (used with vmalert rules format).").BoolVar(&c.vmalertDebug)
+	cmd.Flag("vmalert-update-entries-limit", "The number of state updates vmalert stores per alert rule (used with vmalert rules format).").IntVar(&c.vmalertUpdateEntries)
 }
 
+const (
+	rulesFormatPrometheus = "prometheus"
+	rulesFormatVMAlert    = "vmalert"
+	rulesFormatCortex     = "cortex"
+	rulesFormatRulerFiles = "ruler-files"
+
+	k8sSplitPerGroup = "per-group"
+	k8sSplitPerSLO   = "per-slo"
+
+	specHashKey = "sloth.slok.dev/spec-hash"
+)
+
 func (g generateCommand) Name() string { return "generate" }
 func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 	logger := config.Logger.WithValues(log.Kv{"window": g.sloPeriod})
@@ -72,7 +208,30 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 	if err != nil {
 		return err
 	}
-	if inputInfo.IsDir() {
+	if g.mergeInto != "" && (inputInfo.IsDir() || g.rulesFormat != rulesFormatPrometheus) {
+		return fmt.Errorf("--merge-into can only be used with a file input and the Prometheus rules format")
+	}
+	rulerFiles := g.rulesFormat == rulesFormatRulerFiles
+	if rulerFiles && g.slosOut == "-" {
+		return fmt.Errorf("the ruler-files rules format requires an output directory")
+	}
+	// The Cortex namespaces are stored on a file per namespace, unless they are written to the stdout.
+	cortexFiles := g.rulesFormat == rulesFormatCortex && g.slosOut != "-"
+	if g.splitByKind && (g.slosOut == "-" || g.rulesFormat != rulesFormatPrometheus || g.mergeInto != "" || g.exportOpenSLO) {
+		return fmt.Errorf("--split-by-kind requires an output directory and the Prometheus rules format, can't be used with --merge-into or --export-openslo")
+	}
+	// These outputs are stored from all the generated SLOs after the generation.
+	storeGenerated := rulerFiles || cortexFiles || g.splitByKind
+	if g.specHash && (storeGenerated || g.mergeInto != "") {
+		return fmt.Errorf("--spec-hash can't be used with --merge-into, --split-by-kind, the ruler-files rules format or the cortex rules format files")
+	}
+	if g.minBudgetConsumed > 0 && g.omitMetadataRules {
+		return fmt.Errorf("--alerts-min-budget-consumed requires the metadata recording rules, can't be used with --omit-metadata-rules")
+	}
+	if g.serviceBudgetAlert > 0 && g.omitMetadataRules {
+		return fmt.Errorf("--alerts-service-budget-remaining requires the metadata recording rules, can't be used with --omit-metadata-rules")
+	}
+	if inputInfo.IsDir() && !output.IsRemote(g.slosOut) {
 		// If input is a dir, output must be a directory.
 		outInfo, err := os.Stat(g.slosOut)
 		if err != nil {
@@ -101,6 +260,33 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 		g.extraLabels[key] = value
 	}
 
+	for severity := range g.severityMapping {
+		if severity != alert.PageAlertSeverity.String() && severity != alert.TicketAlertSeverity.String() {
+			return fmt.Errorf("invalid %q severity mapping, only page and ticket severities can be mapped", severity)
+		}
+	}
+
+	cortexConfig, err := parseCortexNamespaceFrom(g.namespaceFrom)
+	if err != nil {
+		return fmt.Errorf("invalid namespace source: %w", err)
+	}
+
+	var defaultAnnotations map[string]string
+	if g.defaultAnnotations != "" {
+		defaultAnnotations, err = loadDefaultAnnotations(g.defaultAnnotations)
+		if err != nil {
+			return fmt.Errorf("invalid default annotations: %w", err)
+		}
+	}
+
+	var overrides map[string]generate.SLOOverride
+	if g.overrides != "" {
+		overrides, err = loadSLOOverrides(g.overrides)
+		if err != nil {
+			return fmt.Errorf("invalid SLO overrides: %w", err)
+		}
+	}
+
 	// SLO period.
 	sp, err := prometheusmodel.ParseDuration(g.sloPeriod)
 	if err != nil {
@@ -112,8 +298,35 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 		"out": g.slosOut,
 	})
 
+	// Output writer.
+	uploaderCommands := map[string]string{}
+	for scheme, command := range output.DefaultUploaderCommands {
+		uploaderCommands[scheme] = command
+	}
+	for scheme, command := range g.outUploaders {
+		uploaderCommands[scheme] = command
+	}
+	uploaders := map[string]output.Uploader{}
+	for scheme, command := range uploaderCommands {
+		uploader, err := output.NewCmdUploader(command)
+		if err != nil {
+			return fmt.Errorf("invalid %q output uploader: %w", scheme, err)
+		}
+		uploaders[scheme] = uploader
+	}
+	outWriter, err := output.NewWriter(g.slosOut, uploaders)
+	if err != nil {
+		return fmt.Errorf("could not create output writer: %w", err)
+	}
+	defer outWriter.Close()
+
 	// Load plugins
-	pluginRepo, err := createPluginLoader(ctx, logger, g.sliPluginsPaths)
+	pluginRepo, err := createPluginLoader(ctx, logger, g.sliPluginsPaths, g.sliPluginsTimeout)
+	if err != nil {
+		return err
+	}
+
+	specPolicyPlugins, err := loadSpecPolicyPlugins(ctx, logger, g.specPolicyPluginsPath)
 	if err != nil {
 		return err
 	}
@@ -138,9 +351,9 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 	}
 
 	// Create Spec loaders.
-	promYAMLLoader := prometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod)
+	promYAMLLoader := prometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod).WithWindowPlaceholder(g.sliWindowPlaceholder)
 	kubeYAMLLoader := k8sprometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod)
-	openSLOYAMLLoader := openslo.NewYAMLSpecLoader(sloPeriod)
+	openSLOYAMLLoader := openslo.NewYAMLSpecLoader(sloPeriod).WithExperimentalV2(g.experimentalOpenSLOV2)
 
 	// Get SLO targets.
 	genTargets := []generateTarget{}
@@ -154,28 +367,38 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 		}
 		defer f.Close()
 
-		slxData, err := io.ReadAll(f)
+		slxData, err := readInput(f, g.inputEncoding)
 		if err != nil {
 			return fmt.Errorf("could not read SLOs spec file data: %w", err)
 		}
 
+		frontMatter, err := parseSpecFrontMatter(slxData, g.slosInput)
+		if err != nil {
+			return fmt.Errorf("invalid %q front-matter: %w", g.slosInput, err)
+		}
+
 		// Split YAMLs in case we have multiple yaml files in a single file.
 		splittedSLOsData := splitYAML(slxData)
 
 		// Prepare store output.
 		var out = config.Stdout
-		if g.slosOut != "-" {
-			outFile, err := os.Create(g.slosOut)
+		switch {
+		case g.mergeInto != "", storeGenerated:
+			// The rules will be merged or stored on their files after the generation.
+			out = io.Discard
+		case g.slosOut != "-":
+			outFile, err := outWriter.Create(ctx, "")
 			if err != nil {
-				return fmt.Errorf("could not create out file: %w", err)
+				return err
 			}
-			defer f.Close()
 			out = outFile
 		}
 		for _, s := range splittedSLOsData {
 			genTargets = append(genTargets, generateTarget{
-				SLOData: s,
-				Out:     out,
+				SLOData:     s,
+				Out:         out,
+				FrontMatter: frontMatter,
+				Source:      g.slosInput,
 			})
 		}
 	} else {
@@ -196,8 +419,12 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 			}
 			includeRegex = r
 		}
+		contentFilters, err := parseContentFilters(g.contentIncludes)
+		if err != nil {
+			return err
+		}
 
-		sloPaths, err := discoverSLOManifests(logger, excludeRegex, includeRegex, g.slosInput)
+		sloPaths, err := discoverSLOManifests(logger, excludeRegex, includeRegex, contentFilters, g.followSymlinks, g.slosInput)
 		if err != nil {
 			return fmt.Errorf("could not discover files: %w", err)
 		}
@@ -212,52 +439,128 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 			}
 			defer f.Close()
 
-			slxData, err := io.ReadAll(f)
+			slxData, err := readInput(f, g.inputEncoding)
 			if err != nil {
 				return fmt.Errorf("could not read SLOs spec file data: %w", err)
 			}
 
-			// Infer output path.
-			outputPath := strings.TrimPrefix(path.Clean(sloPath), strings.TrimPrefix(g.slosInput, "./"))
-			outputPath = path.Join(g.slosOut, outputPath)
-
-			// Ensure the file path is ready.
-			err = os.MkdirAll(path.Dir(outputPath), os.ModePerm)
+			frontMatter, err := parseSpecFrontMatter(slxData, sloPath)
 			if err != nil {
-				return err
+				return fmt.Errorf("invalid %q front-matter: %w", sloPath, err)
 			}
 
-			// Create the target file.
-			outFile, err := os.Create(outputPath)
-			if err != nil {
-				return fmt.Errorf("could not create out file: %w", err)
+			// Infer output path.
+			outputPath := strings.TrimPrefix(path.Clean(sloPath), strings.TrimPrefix(g.slosInput, "./"))
+
+			// Create the target file, unless the rules are stored on their files after the generation.
+			var outFile io.Writer = io.Discard
+			if !storeGenerated {
+				outFile, err = outWriter.Create(ctx, outputPath)
+				if err != nil {
+					return err
+				}
 			}
-			defer outFile.Close()
 
 			// Split YAMLs in case we have multiple yaml files in a single file.
 			splittedSLOsData := splitYAML(slxData)
 			for _, s := range splittedSLOsData {
 				genTargets = append(genTargets, generateTarget{
-					SLOData: s,
-					Out:     outFile,
+					SLOData:     s,
+					Out:         outFile,
+					FrontMatter: frontMatter,
+					Source:      sloPath,
 				})
 			}
 		}
 	}
 
+	if g.checkSLIPlugins {
+		err := checkSpecsSLIPlugins(ctx, pluginRepo, genTargets)
+		if err != nil {
+			return err
+		}
+	}
+
+	sloFilter := newSLOIDFilter(g.onlySLOs)
+
 	gen := generator{
 		logger:                logger,
 		windowsRepo:           windowsRepo,
 		disableRecordings:     g.disableRecordings,
+		omitMetadataRules:     g.omitMetadataRules,
 		disableAlerts:         g.disableAlerts,
 		disableOptimizedRules: g.disableOptimizedRules,
+		sliSmoothingWindow:    g.sliSmoothingWindow,
+		sliFreshnessWindow:    g.sliFreshnessWindow,
+		policyRule:            g.policyRule,
+		objectiveDriftRule:    g.objectiveDriftRule,
+		descriptionLabel:      g.descriptionLabel,
+		shareSLIQueries:       g.shareSLIQueries,
+		recordNameTemplate:    g.recordNameTemplate,
+		groupNameTemplate:     g.groupNameTemplate,
+		serviceBudgetAlert:    g.serviceBudgetAlert,
+		alertsLimit:           g.alertsLimit,
 		extraLabels:           g.extraLabels,
 		idLabels:              g.idLabels,
+		serviceNamespaces:     g.serviceNamespaces,
+		overrides:             overrides,
+		specPolicyPlugins:     specPolicyPlugins,
+		objectiveIDLabel:      g.objectiveIDLabel,
+		sliObjectiveLabels:    g.sliObjectiveLabels,
+		sliSuccessRules:       g.sliSuccessRules,
+		rulesFormat:           g.rulesFormat,
+		splitPerSLO:           g.k8sSplit == k8sSplitPerSLO,
+		exportOpenSLO:         g.exportOpenSLO,
+		alertRulesConfig: prometheus.SLOAlertRulesGeneratorConfig{
+			IncludeSLOLabels:      g.alertsWithSLOLabels,
+			PendingRecordingRules: g.alertsPendingRules,
+			ObjectivePrecision:    g.objectivePrecision,
+			SeverityMapping:       g.severityMapping,
+			PageSeverity:          g.pageSeverity,
+			TicketSeverity:        g.ticketSeverity,
+			PageReceiver:          g.pageReceiver,
+			TicketReceiver:        g.ticketReceiver,
+			NoDataAlertSeverity:   g.noDataAlertSeverity,
+			NoDataAlertMinFor:     g.noDataAlertMinFor,
+			PageMinObjective:      g.pageMinObjective,
+			MinBudgetConsumed:     g.minBudgetConsumed,
+			AlertNameFromID:       g.alertNameFromID,
+			AvgOverTimeBurnRates:  g.alertsAvgOverTime,
+			DefaultAnnotations:    defaultAnnotations,
+			Logger:                logger,
+		},
+		vmalertConfig: prometheus.VMAlertConfig{
+			Debug:              g.vmalertDebug,
+			UpdateEntriesLimit: g.vmalertUpdateEntries,
+		},
+		cortexConfig:  cortexConfig,
+		generatedSLOs: &[]prometheus.StorageSLO{},
 	}
 
 	for _, genTarget := range genTargets {
 		dataB := []byte(genTarget.SLOData)
 
+		// Override the defaults with the file front-matter if required.
+		gen, promYAMLLoader, kubeYAMLLoader, openSLOYAMLLoader := gen, promYAMLLoader, kubeYAMLLoader, openSLOYAMLLoader
+		if g.sourceLabel {
+			gen.source = genTarget.Source
+		}
+		if genTarget.FrontMatter != (specFrontMatter{}) {
+			fmSLOPeriod, fmWindowsRepo, err := resolveSpecFrontMatter(ctx, logger, genTarget.FrontMatter, sloPeriod, windowsRepo)
+			if err != nil {
+				return err
+			}
+			gen.windowsRepo = fmWindowsRepo
+			promYAMLLoader = prometheus.NewYAMLSpecLoader(pluginRepo, fmSLOPeriod).WithWindowPlaceholder(g.sliWindowPlaceholder)
+			kubeYAMLLoader = k8sprometheus.NewYAMLSpecLoader(pluginRepo, fmSLOPeriod)
+			openSLOYAMLLoader = openslo.NewYAMLSpecLoader(fmSLOPeriod).WithExperimentalV2(g.experimentalOpenSLOV2)
+		}
+
+		var specHash string
+		if g.specHash {
+			specHash = info.SpecHash(info.Version, dataB)
+		}
+
 		// Match the spec type to know how to generate.
 		switch {
 		case promYAMLLoader.IsSpecType(ctx, dataB):
@@ -265,6 +568,15 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 			if err != nil {
 				return fmt.Errorf("tried loading raw prometheus SLOs spec, it couldn't: %w", err)
 			}
+			slos.SLOs = sloFilter.filter(slos.SLOs)
+			if len(slos.SLOs) == 0 {
+				continue
+			}
+
+			err = writeSpecHashComment(genTarget.Out, specHash)
+			if err != nil {
+				return err
+			}
 
 			err = gen.GeneratePrometheus(ctx, *slos, genTarget.Out)
 			if err != nil {
@@ -272,14 +584,29 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 			}
 
 		case kubeYAMLLoader.IsSpecType(ctx, dataB):
-			sloGroup, err := kubeYAMLLoader.LoadSpec(ctx, dataB)
+			sloGroups, err := kubeYAMLLoader.LoadSpecs(ctx, dataB)
 			if err != nil {
 				return fmt.Errorf("tried loading Kubernetes prometheus SLOs spec, it couldn't: %w", err)
 			}
 
-			err = gen.GenerateKubernetes(ctx, *sloGroup, genTarget.Out)
-			if err != nil {
-				return fmt.Errorf("could not generate Kubernetes format rules: %w", err)
+			for _, sloGroup := range sloGroups {
+				sloGroup.SLOs = sloFilter.filter(sloGroup.SLOs)
+				if len(sloGroup.SLOs) == 0 {
+					continue
+				}
+
+				if specHash != "" {
+					annotations := map[string]string{specHashKey: specHash}
+					for k, v := range sloGroup.K8sMeta.Annotations {
+						annotations[k] = v
+					}
+					sloGroup.K8sMeta.Annotations = annotations
+				}
+
+				err = gen.GenerateKubernetes(ctx, *sloGroup, genTarget.Out)
+				if err != nil {
+					return fmt.Errorf("could not generate Kubernetes format rules: %w", err)
+				}
 			}
 
 		case openSLOYAMLLoader.IsSpecType(ctx, dataB):
@@ -287,6 +614,15 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 			if err != nil {
 				return fmt.Errorf("tried loading OpenSLO SLOs spec, it couldn't: %w", err)
 			}
+			slos.SLOs = sloFilter.filter(slos.SLOs)
+			if len(slos.SLOs) == 0 {
+				continue
+			}
+
+			err = writeSpecHashComment(genTarget.Out, specHash)
+			if err != nil {
+				return err
+			}
 
 			err = gen.GenerateOpenSLO(ctx, *slos, genTarget.Out)
 			if err != nil {
@@ -298,22 +634,310 @@ func (g generateCommand) Run(ctx context.Context, config RootConfig) error {
 		}
 	}
 
+	if unknown := sloFilter.unknownIDs(); len(unknown) > 0 {
+		return fmt.Errorf("unknown SLO IDs: %s", strings.Join(unknown, ", "))
+	}
+
+	if rulerFiles || cortexFiles {
+		err = prometheus.NewNamespacedFilesRulesYAMLRepo(outWriter.Create, cortexConfig, logger).WithCortexNamespace(cortexFiles).StoreSLOs(ctx, *gen.generatedSLOs)
+		if err != nil {
+			return fmt.Errorf("could not store ruler files: %w", err)
+		}
+	}
+
+	if g.splitByKind {
+		err = prometheus.NewKindSplitFilesRulesYAMLRepo(outWriter.Create, logger).StoreSLOs(ctx, *gen.generatedSLOs)
+		if err != nil {
+			return fmt.Errorf("could not store kind split rule files: %w", err)
+		}
+	}
+
+	err = outWriter.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+
+	// Merge the generated rules into the existing rules file.
+	if g.mergeInto != "" {
+		existing, err := os.ReadFile(g.mergeInto)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not read merge into file: %w", err)
+		}
+
+		var b bytes.Buffer
+		err = prometheus.NewIOWriterMergedRulesYAMLRepo(&b, existing, logger).StoreSLOs(ctx, *gen.generatedSLOs)
+		if err != nil {
+			return fmt.Errorf("could not merge rules: %w", err)
+		}
+
+		err = os.WriteFile(g.mergeInto, b.Bytes(), 0644)
+		if err != nil {
+			return fmt.Errorf("could not write merge into file: %w", err)
+		}
+	}
+
+	// Store the redacted helper rules apart from the generated rules.
+	if hasRedactedRules(*gen.generatedSLOs) {
+		if g.redactedRulesOut == "" {
+			logger.Warningf("Redacted query fragments helper rules are not being stored, use --redacted-rules-out")
+		} else {
+			outFile, err := os.Create(g.redactedRulesOut)
+			if err != nil {
+				return fmt.Errorf("could not create redacted rules out file: %w", err)
+			}
+			defer outFile.Close()
+
+			err = prometheus.NewIOWriterRedactedRulesYAMLRepo(outFile, logger).StoreSLOs(ctx, *gen.generatedSLOs)
+			if err != nil {
+				return fmt.Errorf("could not store redacted rules: %w", err)
+			}
+		}
+	}
+
+	// Store the Loki recording rules of the LogQL SLIs apart from the generated rules.
+	if hasLogQLSLOs(*gen.generatedSLOs) {
+		if g.lokiRulesOut == "" {
+			logger.Warningf("LogQL SLIs Loki recording rules are not being stored, use --loki-rules-out")
+		} else {
+			outFile, err := os.Create(g.lokiRulesOut)
+			if err != nil {
+				return fmt.Errorf("could not create Loki rules out file: %w", err)
+			}
+			defer outFile.Close()
+
+			err = prometheus.NewIOWriterLokiRulesYAMLRepo(outFile, logger).StoreSLOs(ctx, *gen.generatedSLOs)
+			if err != nil {
+				return fmt.Errorf("could not store Loki rules: %w", err)
+			}
+		}
+	}
+
+	// Store the dashboards.
+	if g.dashboardsOut != "" && len(*gen.generatedSLOs) > 0 {
+		err := prometheus.NewFSGrafanaDashboardsRepo(g.dashboardsOut, logger).StoreSLOs(ctx, *gen.generatedSLOs)
+		if err != nil {
+			return fmt.Errorf("could not store Grafana dashboards: %w", err)
+		}
+	}
+
+	// Store the generated rules report.
+	if g.reportOut != "" && len(*gen.generatedSLOs) > 0 {
+		outFile, err := os.Create(g.reportOut)
+		if err != nil {
+			return fmt.Errorf("could not create report out file: %w", err)
+		}
+		defer outFile.Close()
+
+		err = prometheus.NewIOWriterRulesReportJSONRepo(outFile, logger).StoreSLOs(ctx, *gen.generatedSLOs)
+		if err != nil {
+			return fmt.Errorf("could not store rules report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sloIDFilter filters the loaded SLOs by their ID, keeping track of the IDs that matched.
+type sloIDFilter struct {
+	ids     map[string]bool
+	matched map[string]bool
+}
+
+// newSLOIDFilter returns a new SLO ID filter, without IDs all the SLOs are kept.
+func newSLOIDFilter(ids []string) sloIDFilter {
+	f := sloIDFilter{ids: map[string]bool{}, matched: map[string]bool{}}
+	for _, v := range ids {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				f.ids[id] = true
+			}
+		}
+	}
+
+	return f
+}
+
+func (f sloIDFilter) filter(slos []prometheus.SLO) []prometheus.SLO {
+	if len(f.ids) == 0 {
+		return slos
+	}
+
+	res := []prometheus.SLO{}
+	for _, slo := range slos {
+		if f.ids[slo.ID] {
+			f.matched[slo.ID] = true
+			res = append(res, slo)
+		}
+	}
+
+	return res
+}
+
+// unknownIDs returns the sorted filter IDs that didn't match any SLO.
+func (f sloIDFilter) unknownIDs() []string {
+	unknown := []string{}
+	for id := range f.ids {
+		if !f.matched[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+func hasRedactedRules(slos []prometheus.StorageSLO) bool {
+	for _, s := range slos {
+		if len(s.Rules.RedactedRecRules) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLogQLSLOs(slos []prometheus.StorageSLO) bool {
+	for _, s := range slos {
+		if s.SLO.SLILogQLBridge != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSpecHashComment writes the spec hash as a YAML comment, if the hash is empty nothing will be written.
+func writeSpecHashComment(out io.Writer, hash string) error {
+	if hash == "" {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(out, "\n# %s: %s", specHashKey, hash)
+	if err != nil {
+		return fmt.Errorf("could not write spec hash: %w", err)
+	}
+
 	return nil
 }
 
+// loadDefaultAnnotations loads the default alert annotations YAML file.
+func loadDefaultAnnotations(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	annotations := map[string]string{}
+	err = yaml.UnmarshalStrict(data, &annotations)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal YAML: %w", err)
+	}
+
+	return annotations, nil
+}
+
+// loadSLOOverrides loads the SLO overrides YAML file keyed by SLO ID.
+func loadSLOOverrides(path string) (map[string]generate.SLOOverride, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	rawOverrides := map[string]struct {
+		Objective          *float64 `yaml:"objective"`
+		DisablePageAlert   *bool    `yaml:"disablePageAlert"`
+		DisableTicketAlert *bool    `yaml:"disableTicketAlert"`
+	}{}
+	err = yaml.UnmarshalStrict(data, &rawOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal YAML: %w", err)
+	}
+
+	overrides := make(map[string]generate.SLOOverride, len(rawOverrides))
+	for id, o := range rawOverrides {
+		overrides[id] = generate.SLOOverride{
+			Objective:          o.Objective,
+			DisablePageAlert:   o.DisablePageAlert,
+			DisableTicketAlert: o.DisableTicketAlert,
+		}
+	}
+
+	return overrides, nil
+}
+
+// parseCortexNamespaceFrom parses the namespace source in `service`, `name` or `static:<value>` form.
+func parseCortexNamespaceFrom(s string) (prometheus.CortexConfig, error) {
+	switch {
+	case s == prometheus.CortexNamespaceFromService, s == prometheus.CortexNamespaceFromName:
+		return prometheus.CortexConfig{NamespaceFrom: s}, nil
+	case strings.HasPrefix(s, prometheus.CortexNamespaceFromStatic+":"):
+		ns := strings.TrimPrefix(s, prometheus.CortexNamespaceFromStatic+":")
+		if ns == "" {
+			return prometheus.CortexConfig{}, fmt.Errorf("static namespace value is required")
+		}
+		return prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromStatic, StaticNamespace: ns}, nil
+	}
+
+	return prometheus.CortexConfig{}, fmt.Errorf("unknown %q namespace source", s)
+}
+
 type generateTarget struct {
-	Out     io.Writer
-	SLOData string
+	Out         io.Writer
+	SLOData     string
+	FrontMatter specFrontMatter
+	Source      string
 }
 
 type generator struct {
 	logger                log.Logger
 	windowsRepo           alert.WindowsRepo
 	disableRecordings     bool
+	omitMetadataRules     bool
 	disableAlerts         bool
 	disableOptimizedRules bool
+	sliSmoothingWindow    time.Duration
+	sliFreshnessWindow    time.Duration
+	policyRule            bool
+	objectiveDriftRule    bool
+	descriptionLabel      bool
+	shareSLIQueries       bool
+	recordNameTemplate    string
+	groupNameTemplate     string
+	serviceBudgetAlert    float64
+	alertsLimit           int
 	extraLabels           map[string]string
 	idLabels              map[string]string
+	serviceNamespaces     map[string]string
+	overrides             map[string]generate.SLOOverride
+	specPolicyPlugins     []prometheus.SpecPolicyPlugin
+	objectiveIDLabel      bool
+	sliObjectiveLabels    bool
+	sliSuccessRules       bool
+	rulesFormat           string
+	splitPerSLO           bool
+	exportOpenSLO         bool
+	alertRulesConfig      prometheus.SLOAlertRulesGeneratorConfig
+	vmalertConfig         prometheus.VMAlertConfig
+	cortexConfig          prometheus.CortexConfig
+	// source is the spec file path of the generated SLOs, empty if unknown.
+	source string
+	// generatedSLOs collects the SLOs of all the generations, used by the outputs apart from the generated rules.
+	generatedSLOs *[]prometheus.StorageSLO
+}
+
+// prometheusSLOStorer knows how to store the SLOs generated from non Kubernetes specs.
+type prometheusSLOStorer interface {
+	StoreSLOs(ctx context.Context, slos []prometheus.StorageSLO) error
+}
+
+// newPrometheusRepo returns the storage repository for the selected rules format.
+func (g generator) newPrometheusRepo(out io.Writer) prometheusSLOStorer {
+	switch g.rulesFormat {
+	case rulesFormatVMAlert:
+		return prometheus.NewIOWriterGroupedRulesVMAlertYAMLRepo(out, g.vmalertConfig, g.logger)
+	case rulesFormatCortex:
+		return prometheus.NewIOWriterGroupedRulesCortexYAMLRepo(out, g.cortexConfig, g.logger)
+	}
+
+	return prometheus.NewIOWriterGroupedRulesYAMLRepo(out, g.logger)
 }
 
 // GeneratePrometheus generates the SLOs based on a raw regular Prometheus spec format input and outs a Prometheus raw yaml.
@@ -323,6 +947,7 @@ func (g generator) GeneratePrometheus(ctx context.Context, slos prometheus.SLOGr
 		Version: info.Version,
 		Mode:    info.ModeCLIGenPrometheus,
 		Spec:    prometheusv1.Version,
+		Source:  g.source,
 	}
 
 	result, err := g.generateRules(ctx, info, slos)
@@ -330,12 +955,17 @@ func (g generator) GeneratePrometheus(ctx context.Context, slos prometheus.SLOGr
 		return err
 	}
 
-	repo := prometheus.NewIOWriterGroupedRulesYAMLRepo(out, g.logger)
+	if g.exportOpenSLO {
+		return g.storeOpenSLO(ctx, result, out)
+	}
+
+	repo := g.newPrometheusRepo(out)
 	storageSLOs := make([]prometheus.StorageSLO, 0, len(result.PrometheusSLOs))
 	for _, s := range result.PrometheusSLOs {
 		storageSLOs = append(storageSLOs, prometheus.StorageSLO{
-			SLO:   s.SLO,
-			Rules: s.SLORules,
+			SLO:    s.SLO,
+			Rules:  s.SLORules,
+			Alerts: s.Alerts,
 		})
 	}
 
@@ -347,21 +977,44 @@ func (g generator) GeneratePrometheus(ctx context.Context, slos prometheus.SLOGr
 	return nil
 }
 
+// storeOpenSLO exports the generated SLOs as OpenSLO manifests.
+func (g generator) storeOpenSLO(ctx context.Context, result *generate.Response, out io.Writer) error {
+	repo := openslo.NewIOWriterOpenSLOYAMLRepo(out, g.logger)
+	storageSLOs := make([]openslo.StorageSLO, 0, len(result.PrometheusSLOs))
+	for _, s := range result.PrometheusSLOs {
+		storageSLOs = append(storageSLOs, openslo.StorageSLO{
+			SLO:    s.SLO,
+			Alerts: s.Alerts,
+		})
+	}
+
+	err := repo.StoreSLOs(ctx, storageSLOs)
+	if err != nil {
+		return fmt.Errorf("could not store SLOS: %w", err)
+	}
+
+	return nil
+}
+
 // generateKubernetes generates the SLOs based on a Kuberentes spec format input and outs a Kubernetes prometheus operator CRD yaml.
 func (g generator) GenerateKubernetes(ctx context.Context, sloGroup k8sprometheus.SLOGroup, out io.Writer) error {
 	g.logger.Infof("Generating from Kubernetes Prometheus spec")
+	if g.exportOpenSLO {
+		return fmt.Errorf("--export-openslo only supports Prometheus specs, Kubernetes specs can't be exported")
+	}
 
 	info := info.Info{
 		Version: info.Version,
 		Mode:    info.ModeCLIGenKubernetes,
 		Spec:    fmt.Sprintf("%s/%s", kubernetesv1.SchemeGroupVersion.Group, kubernetesv1.SchemeGroupVersion.Version),
+		Source:  g.source,
 	}
 	result, err := g.generateRules(ctx, info, sloGroup.SLOGroup)
 	if err != nil {
 		return err
 	}
 
-	repo := k8sprometheus.NewIOWriterPrometheusOperatorYAMLRepo(out, g.logger)
+	repo := k8sprometheus.NewIOWriterPrometheusOperatorYAMLRepo(out, g.logger).WithSplitPerSLO(g.splitPerSLO)
 	storageSLOs := make([]k8sprometheus.StorageSLO, 0, len(result.PrometheusSLOs))
 	for _, s := range result.PrometheusSLOs {
 		storageSLOs = append(storageSLOs, k8sprometheus.StorageSLO{
@@ -381,10 +1034,14 @@ func (g generator) GenerateKubernetes(ctx context.Context, sloGroup k8sprometheu
 // generateOpenSLO generates the SLOs based on a OpenSLO spec format input and outs a Prometheus raw yaml.
 func (g generator) GenerateOpenSLO(ctx context.Context, slos prometheus.SLOGroup, out io.Writer) error {
 	g.logger.Infof("Generating from OpenSLO spec")
+	if g.exportOpenSLO {
+		return fmt.Errorf("--export-openslo only supports Prometheus specs, OpenSLO specs can't be exported")
+	}
 	info := info.Info{
 		Version: info.Version,
 		Mode:    info.ModeCLIGenOpenSLO,
 		Spec:    openslov1alpha.APIVersion,
+		Source:  g.source,
 	}
 
 	result, err := g.generateRules(ctx, info, slos)
@@ -392,12 +1049,13 @@ func (g generator) GenerateOpenSLO(ctx context.Context, slos prometheus.SLOGroup
 		return err
 	}
 
-	repo := prometheus.NewIOWriterGroupedRulesYAMLRepo(out, g.logger)
+	repo := g.newPrometheusRepo(out)
 	storageSLOs := make([]prometheus.StorageSLO, 0, len(result.PrometheusSLOs))
 	for _, s := range result.PrometheusSLOs {
 		storageSLOs = append(storageSLOs, prometheus.StorageSLO{
-			SLO:   s.SLO,
-			Rules: s.SLORules,
+			SLO:    s.SLO,
+			Rules:  s.SLORules,
+			Alerts: s.Alerts,
 		})
 	}
 
@@ -411,22 +1069,35 @@ func (g generator) GenerateOpenSLO(ctx context.Context, slos prometheus.SLOGroup
 
 // generate is the main generator logic that all the spec types and storers share. Mainly has the logic of the generate app service.
 func (g generator) generateRules(ctx context.Context, info info.Info, slos prometheus.SLOGroup) (*generate.Response, error) {
+	// Check and transform the loaded SLOs with the org policies.
+	if len(g.specPolicyPlugins) > 0 {
+		policySLOs, err := prometheus.ApplySpecPolicyPlugins(ctx, g.specPolicyPlugins, slos)
+		if err != nil {
+			return nil, fmt.Errorf("spec policy failed: %w", err)
+		}
+		slos = *policySLOs
+	}
+
 	// Disable recording rules if required.
 	var sliRuleGen generate.SLIRecordingRulesGenerator = generate.NoopSLIRecordingRulesGenerator
 	var metaRuleGen generate.MetadataRecordingRulesGenerator = generate.NoopMetadataRecordingRulesGenerator
 	if !g.disableRecordings {
 		// Disable optimized rules if required.
-		sliRuleGen = prometheus.OptimizedSLIRecordingRulesGenerator
+		sliRuleGen = prometheus.OptimizedSLIRecordingRulesGenerator.WithSmoothingWindow(g.sliSmoothingWindow).WithObjectiveLabels(g.sliObjectiveLabels).WithSuccessRules(g.sliSuccessRules)
 		if g.disableOptimizedRules {
-			sliRuleGen = prometheus.SLIRecordingRulesGenerator
+			sliRuleGen = prometheus.SLIRecordingRulesGenerator.WithSmoothingWindow(g.sliSmoothingWindow).WithObjectiveLabels(g.sliObjectiveLabels).WithSuccessRules(g.sliSuccessRules)
+		}
+		if !g.omitMetadataRules {
+			metaRuleGen = prometheus.MetadataRecordingRulesGenerator.WithFreshnessWindow(g.sliFreshnessWindow).WithPolicyRule(g.policyRule).WithObjectiveDriftRule(g.objectiveDriftRule).WithDescriptionLabel(g.descriptionLabel)
 		}
-		metaRuleGen = prometheus.MetadataRecordingRulesGenerator
 	}
 
 	// Disable alert rules if required.
 	var alertRuleGen generate.SLOAlertRulesGenerator = generate.NoopSLOAlertRulesGenerator
+	serviceBudgetAlert := 0.0
 	if !g.disableAlerts {
-		alertRuleGen = prometheus.SLOAlertRulesGenerator
+		alertRuleGen = prometheus.NewSLOAlertRulesGenerator(g.alertRulesConfig)
+		serviceBudgetAlert = g.serviceBudgetAlert
 	}
 
 	// Generate.
@@ -442,14 +1113,29 @@ func (g generator) generateRules(ctx context.Context, info info.Info, slos prome
 	}
 
 	result, err := controller.Generate(ctx, generate.Request{
-		ExtraLabels: g.extraLabels,
-		IDLabels:    g.idLabels,
-		Info:        info,
-		SLOGroup:    slos,
+		ExtraLabels:                 g.extraLabels,
+		IDLabels:                    g.idLabels,
+		ObjectiveIDLabel:            g.objectiveIDLabel,
+		ServiceNamespaces:           g.serviceNamespaces,
+		Overrides:                   g.overrides,
+		ShareSLIQueries:             g.shareSLIQueries,
+		RecordNameTemplate:          g.recordNameTemplate,
+		GroupNameTemplate:           g.groupNameTemplate,
+		ServiceBudgetAlertThreshold: serviceBudgetAlert,
+		ServiceBudgetAlertConfig:    g.alertRulesConfig,
+		AlertsLimit:                 g.alertsLimit,
+		Info:                        info,
+		SLOGroup:                    slos,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not generate prometheus rules: %w", err)
 	}
 
+	if g.generatedSLOs != nil {
+		for _, s := range result.PrometheusSLOs {
+			*g.generatedSLOs = append(*g.generatedSLOs, prometheus.StorageSLO{SLO: s.SLO, Rules: s.SLORules, Alerts: s.Alerts})
+		}
+	}
+
 	return result, nil
 }
@@ -4,22 +4,133 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
+	prometheusmodel "github.com/prometheus/common/model"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+
+	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/k8sprometheus"
 	"github.com/slok/sloth/internal/log"
+	"github.com/slok/sloth/internal/openslo"
 	"github.com/slok/sloth/internal/prometheus"
 )
 
 var (
-	splitMarkRe  = regexp.MustCompile("(?m)^---")
-	rmCommentsRe = regexp.MustCompile("(?m)^#.*$")
+	splitMarkRe   = regexp.MustCompile("(?m)^---")
+	rmCommentsRe  = regexp.MustCompile("(?m)^#.*$")
+	frontMatterRe = regexp.MustCompile(`^#\s*sloth:(.*)$`)
 )
 
+// specFrontMatter is the optional per file configuration set on the first line of
+// the file using a comment like `# sloth: window=30d catalog=./windows`, the relative catalog
+// paths are relative to the spec file directory.
+type specFrontMatter struct {
+	// Window is the SLO period used by default on the file SLOs.
+	Window string
+	// CatalogPath is the directory path of the SLO period windows catalog used by the file SLOs.
+	CatalogPath string
+}
+
+// parseSpecFrontMatter parses the front-matter of the spec file, if the file doesn't have it, it will return
+// an empty front-matter.
+func parseSpecFrontMatter(data []byte, specFile string) (specFrontMatter, error) {
+	data = bytes.TrimSpace(prometheus.NormalizeSpecData(data))
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	match := frontMatterRe.FindStringSubmatch(strings.TrimSpace(firstLine))
+	if match == nil {
+		return specFrontMatter{}, nil
+	}
+
+	fm := specFrontMatter{}
+	for _, kv := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ' ' || r == ',' }) {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || v == "" {
+			return specFrontMatter{}, fmt.Errorf("invalid %q front-matter option, should be in 'key=value' form", kv)
+		}
+
+		switch k {
+		case "window":
+			fm.Window = v
+		case "catalog":
+			if !filepath.IsAbs(v) {
+				v = filepath.Join(filepath.Dir(specFile), v)
+			}
+			fm.CatalogPath = v
+		default:
+			return specFrontMatter{}, fmt.Errorf("unknown %q front-matter option", k)
+		}
+	}
+
+	return fm, nil
+}
+
+// resolveSpecFrontMatter returns the SLO period and the windows repository that need to be used
+// for the file based on its front-matter, fallbacking to the default ones.
+func resolveSpecFrontMatter(ctx context.Context, logger log.Logger, fm specFrontMatter, sloPeriod time.Duration, windowsRepo alert.WindowsRepo) (time.Duration, alert.WindowsRepo, error) {
+	if fm.Window != "" {
+		sp, err := prometheusmodel.ParseDuration(fm.Window)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid front-matter SLO period duration: %w", err)
+		}
+		sloPeriod = time.Duration(sp)
+	}
+
+	if fm.CatalogPath != "" {
+		wr, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{
+			FS:     os.DirFS(fm.CatalogPath),
+			Logger: logger,
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("could not load front-matter SLO period windows repository: %w", err)
+		}
+		windowsRepo = wr
+	}
+
+	_, err := windowsRepo.GetWindows(ctx, sloPeriod)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid front-matter slo period: %w", err)
+	}
+
+	return sloPeriod, windowsRepo, nil
+}
+
+const (
+	inputEncodingUTF8        = "utf-8"
+	inputEncodingLatin1      = "latin-1"
+	inputEncodingWindows1252 = "windows-1252"
+)
+
+var inputEncodings = map[string]encoding.Encoding{
+	inputEncodingUTF8:        nil,
+	inputEncodingLatin1:      charmap.ISO8859_1,
+	inputEncodingWindows1252: charmap.Windows1252,
+}
+
+// readInput reads all the input data transcoding it from the input encoding to UTF-8.
+func readInput(r io.Reader, inputEncoding string) ([]byte, error) {
+	enc, ok := inputEncodings[inputEncoding]
+	if !ok {
+		return nil, fmt.Errorf("unknown %q input encoding", inputEncoding)
+	}
+	if enc != nil {
+		r = transform.NewReader(r, enc.NewDecoder())
+	}
+
+	return io.ReadAll(r)
+}
+
 func splitYAML(data []byte) []string {
 	// Santize.
+	data = prometheus.NormalizeSpecData(data)
 	data = bytes.TrimSpace(data)
 	data = rmCommentsRe.ReplaceAll(data, []byte(""))
 
@@ -38,10 +149,11 @@ func splitYAML(data []byte) []string {
 	return nonEmptyData
 }
 
-func createPluginLoader(_ context.Context, logger log.Logger, paths []string) (*prometheus.FileSLIPluginRepo, error) {
+func createPluginLoader(_ context.Context, logger log.Logger, paths []string, timeout time.Duration) (*prometheus.FileSLIPluginRepo, error) {
 	config := prometheus.FileSLIPluginRepoConfig{
-		Paths:  paths,
-		Logger: logger,
+		Paths:   paths,
+		Timeout: timeout,
+		Logger:  logger,
 	}
 	sliPluginRepo, err := prometheus.NewFileSLIPluginRepo(config)
 	if err != nil {
@@ -51,44 +163,261 @@ func createPluginLoader(_ context.Context, logger log.Logger, paths []string) (*
 	return sliPluginRepo, nil
 }
 
-func discoverSLOManifests(logger log.Logger, exclude, include *regexp.Regexp, path string) ([]string, error) {
-	logger = logger.WithValues(log.Kv{"svc": "SLODiscovery"})
+// loadSpecPolicyPlugins loads the spec policy plugins of the paths, without paths there are no policies.
+func loadSpecPolicyPlugins(ctx context.Context, logger log.Logger, paths []string) ([]prometheus.SpecPolicyPlugin, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
 
-	paths := []string{}
-	err := filepath.Walk(path, func(path string, info fs.FileInfo, err error) error {
+	repo, err := prometheus.NewFileSpecPolicyPluginRepo(prometheus.FileSpecPolicyPluginRepoConfig{
+		Paths:  paths,
+		Logger: logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create file spec policy plugin repository: %w", err)
+	}
+
+	return repo.ListSpecPolicyPlugins(ctx)
+}
+
+// checkSpecsSLIPlugins checks that the SLI plugins referenced by the Prometheus and Kubernetes specs
+// exist before loading any of them, reporting all the missing plugins at once. The invalid specs are
+// ignored, loading them will report the error.
+func checkSpecsSLIPlugins(ctx context.Context, pluginRepo *prometheus.FileSLIPluginRepo, targets []generateTarget) error {
+	promYAMLLoader := prometheus.NewYAMLSpecLoader(pluginRepo, 0)
+	kubeYAMLLoader := k8sprometheus.NewYAMLSpecLoader(pluginRepo, 0)
+
+	refs := []prometheus.SLIPluginRef{}
+	for _, target := range targets {
+		dataB := []byte(target.SLOData)
+
+		var targetRefs []prometheus.SLIPluginRef
+		var err error
+		switch {
+		case promYAMLLoader.IsSpecType(ctx, dataB):
+			targetRefs, err = promYAMLLoader.SLIPluginRefs(ctx, dataB)
+		case kubeYAMLLoader.IsSpecType(ctx, dataB):
+			targetRefs, err = kubeYAMLLoader.SLIPluginRefs(ctx, dataB)
+		}
 		if err != nil {
-			return err
+			continue
 		}
 
-		if info.IsDir() {
-			return nil
+		for _, ref := range targetRefs {
+			ref.Source = target.Source
+			refs = append(refs, ref)
 		}
+	}
 
-		// Directories and non YAML files don't need to be handled.
-		extension := strings.ToLower(filepath.Ext(path))
-		if info.IsDir() || (extension != ".yml" && extension != ".yaml") {
-			return nil
+	return prometheus.CheckSLIPluginRefs(ctx, pluginRepo, refs)
+}
+
+// contentFilter filters the discovered files by the values of a YAML key on their content.
+type contentFilter struct {
+	raw     string
+	key     *regexp.Regexp
+	value   *regexp.Regexp
+	negated bool
+}
+
+// parseContentFilters parses the content filters in `key=regex` form, or `key!=regex` to
+// negate them (e.g `service=^api-`).
+func parseContentFilters(filters []string) ([]contentFilter, error) {
+	cfs := []contentFilter{}
+	for _, f := range filters {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || k == "" || k == "!" {
+			return nil, fmt.Errorf("invalid %q content filter, should be in 'key=regex' or 'key!=regex' form", f)
 		}
 
-		// Filter by exclude or include (exclude has preference).
-		if exclude != nil && exclude.MatchString(path) {
-			logger.Debugf("Excluding path due to exclude filter %s", path)
-			return nil
+		negated := strings.HasSuffix(k, "!")
+		k = strings.TrimSuffix(k, "!")
+
+		value, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q content filter regex: %w", f, err)
 		}
-		if include != nil && !include.MatchString(path) {
-			logger.Debugf("Excluding path due to include filter %s", path)
-			return nil
+
+		cfs = append(cfs, contentFilter{
+			raw:     f,
+			key:     regexp.MustCompile(`(?m)^[ \t-]*` + regexp.QuoteMeta(k) + `:[ \t]*(.*?)[ \t]*$`),
+			value:   value,
+			negated: negated,
+		})
+	}
+
+	return cfs, nil
+}
+
+// match returns true if any of the key values of the content matches the filter regex (or none
+// of them when negated).
+func (c contentFilter) match(data []byte) bool {
+	matched := false
+	for _, m := range c.key.FindAllSubmatch(data, -1) {
+		value := strings.Trim(string(m[1]), `"'`)
+		if c.value.MatchString(value) {
+			matched = true
+			break
 		}
+	}
 
-		// If we reach here, path discovered.
-		paths = append(paths, path)
+	return matched != c.negated
+}
 
-		return nil
-	})
+// discoverSLOManifests discovers recursively the YAML files of the roots, the symlinked
+// directories are only walked when following symlinks (every directory is walked once, so loops are ignored).
+// The discovered files content needs to match all the content filters.
+func discoverSLOManifests(logger log.Logger, exclude, include *regexp.Regexp, contents []contentFilter, followSymlinks bool, roots ...string) ([]string, error) {
+	logger = logger.WithValues(log.Kv{"svc": "SLODiscovery"})
 
-	if err != nil {
-		return nil, fmt.Errorf("could not find files recursively: %w", err)
+	paths := []string{}
+	discovered := map[string]struct{}{}
+	walkedDirs := map[string]struct{}{}
+	var walk func(root string) error
+	walk = func(root string) error {
+		return filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if followSymlinks && (info.IsDir() || info.Mode()&fs.ModeSymlink != 0) {
+				realPath, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return err
+				}
+				realInfo, err := os.Stat(realPath)
+				if err != nil {
+					return err
+				}
+
+				if realInfo.IsDir() {
+					if _, ok := walkedDirs[realPath]; ok {
+						logger.Debugf("Ignoring already walked directory %s", path)
+						if info.IsDir() {
+							return filepath.SkipDir
+						}
+						return nil
+					}
+
+					// Walk the symlinked directories as regular directories.
+					if !info.IsDir() {
+						return walk(path + string(filepath.Separator))
+					}
+					walkedDirs[realPath] = struct{}{}
+				}
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			// Directories and non YAML files don't need to be handled.
+			extension := strings.ToLower(filepath.Ext(path))
+			if info.IsDir() || (extension != ".yml" && extension != ".yaml") {
+				return nil
+			}
+
+			// Filter by exclude or include (exclude has preference).
+			if exclude != nil && exclude.MatchString(path) {
+				logger.Debugf("Excluding path due to exclude filter %s", path)
+				return nil
+			}
+			if include != nil && !include.MatchString(path) {
+				logger.Debugf("Excluding path due to include filter %s", path)
+				return nil
+			}
+
+			// Filter by the file content.
+			if len(contents) > 0 {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("could not read %q file: %w", path, err)
+				}
+				for _, c := range contents {
+					if !c.match(data) {
+						logger.Debugf("Excluding path due to %q content filter %s", c.raw, path)
+						return nil
+					}
+				}
+			}
+
+			// Roots can overlap, don't discover the same file multiple times.
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			if _, ok := discovered[absPath]; ok {
+				logger.Debugf("Ignoring already discovered path %s", path)
+				return nil
+			}
+			discovered[absPath] = struct{}{}
+
+			// If we reach here, path discovered.
+			paths = append(paths, path)
+
+			return nil
+		})
+	}
+
+	for _, root := range roots {
+		err := walk(root)
+		if err != nil {
+			return nil, fmt.Errorf("could not find files recursively: %w", err)
+		}
 	}
 
 	return paths, nil
 }
+
+// specLoader knows how to load any of the supported spec types into SLO models.
+type specLoader struct {
+	promYAMLLoader    prometheus.YAMLSpecLoader
+	kubeYAMLLoader    k8sprometheus.YAMLSpecLoader
+	openSLOYAMLLoader openslo.YAMLSpecLoader
+}
+
+func newSpecLoader(pluginRepo *prometheus.FileSLIPluginRepo, sloPeriod time.Duration) specLoader {
+	return specLoader{
+		promYAMLLoader:    prometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod),
+		kubeYAMLLoader:    k8sprometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod),
+		openSLOYAMLLoader: openslo.NewYAMLSpecLoader(sloPeriod),
+	}
+}
+
+// LoadSLOs loads the SLOs of all the specs in the data, the data can have multiple YAML specs.
+func (s specLoader) LoadSLOs(ctx context.Context, data []byte) ([]prometheus.SLO, error) {
+	slos := []prometheus.SLO{}
+	for _, spec := range splitYAML(data) {
+		dataB := []byte(spec)
+
+		switch {
+		case s.promYAMLLoader.IsSpecType(ctx, dataB):
+			sloGroup, err := s.promYAMLLoader.LoadSpec(ctx, dataB)
+			if err != nil {
+				return nil, fmt.Errorf("tried loading raw prometheus SLOs spec, it couldn't: %w", err)
+			}
+			slos = append(slos, sloGroup.SLOs...)
+
+		case s.kubeYAMLLoader.IsSpecType(ctx, dataB):
+			sloGroups, err := s.kubeYAMLLoader.LoadSpecs(ctx, dataB)
+			if err != nil {
+				return nil, fmt.Errorf("tried loading Kubernetes prometheus SLOs spec, it couldn't: %w", err)
+			}
+			for _, sloGroup := range sloGroups {
+				slos = append(slos, sloGroup.SLOs...)
+			}
+
+		case s.openSLOYAMLLoader.IsSpecType(ctx, dataB):
+			sloGroup, err := s.openSLOYAMLLoader.LoadSpec(ctx, dataB)
+			if err != nil {
+				return nil, fmt.Errorf("tried loading OpenSLO SLOs spec, it couldn't: %w", err)
+			}
+			slos = append(slos, sloGroup.SLOs...)
+
+		default:
+			return nil, fmt.Errorf("invalid spec, could not load with any of the supported spec types")
+		}
+	}
+
+	return slos, nil
+}
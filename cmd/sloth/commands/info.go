@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	prometheusmodel "github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/log"
+)
+
+type infoThresholdsCommand struct {
+	slosInput            string
+	objective            float64
+	sliPluginsPaths      []string
+	sloPeriodWindowsPath string
+	sloPeriod            string
+}
+
+// NewInfoThresholdsCommand returns the info thresholds command.
+func NewInfoThresholdsCommand(infoCmd *kingpin.CmdClause) Command {
+	c := &infoThresholdsCommand{}
+	cmd := infoCmd.Command("thresholds", "Shows the SLO alert thresholds of the spec objective compared with a different objective.")
+	cmd.Flag("input", "SLO spec input file path.").Short('i').Required().StringVar(&c.slosInput)
+	cmd.Flag("objective", "The objective to compare the spec SLOs thresholds with (e.g 99.95).").Required().Float64Var(&c.objective)
+	cmd.Flag("sli-plugins-path", "The path to SLI plugins (can be repeated), if not set it disable plugins support.").Short('p').StringsVar(&c.sliPluginsPaths)
+	cmd.Flag("slo-period-windows-path", "The directory path to custom SLO period windows catalog (replaces default ones).").StringVar(&c.sloPeriodWindowsPath)
+	cmd.Flag("default-slo-period", "The default SLO period windows to be used for the SLOs.").Default("30d").StringVar(&c.sloPeriod)
+
+	return c
+}
+
+func (i infoThresholdsCommand) Name() string { return "info thresholds" }
+func (i infoThresholdsCommand) Run(ctx context.Context, config RootConfig) error {
+	logger := config.Logger.WithValues(log.Kv{"window": i.sloPeriod})
+
+	if i.objective <= 0 || i.objective > 100 {
+		return fmt.Errorf("objective must be in the (0, 100] range")
+	}
+
+	// SLO period.
+	sp, err := prometheusmodel.ParseDuration(i.sloPeriod)
+	if err != nil {
+		return fmt.Errorf("invalid SLO period duration: %w", err)
+	}
+	sloPeriod := time.Duration(sp)
+
+	// Load plugins.
+	pluginRepo, err := createPluginLoader(ctx, logger, i.sliPluginsPaths, 0)
+	if err != nil {
+		return err
+	}
+
+	// Windows repository.
+	var wfs fs.FS
+	if i.sloPeriodWindowsPath != "" {
+		wfs = os.DirFS(i.sloPeriodWindowsPath)
+	}
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{
+		FS:     wfs,
+		Logger: logger,
+	})
+	if err != nil {
+		return fmt.Errorf("could not load SLO period windows repository: %w", err)
+	}
+
+	// Load SLOs.
+	slxData, err := os.ReadFile(i.slosInput)
+	if err != nil {
+		return fmt.Errorf("could not read SLOs spec file data: %w", err)
+	}
+	slos, err := newSpecLoader(pluginRepo, sloPeriod).LoadSLOs(ctx, slxData)
+	if err != nil {
+		return err
+	}
+
+	// Compute the thresholds of both objectives.
+	alertGen := alert.NewGenerator(windowsRepo)
+	w := tabwriter.NewWriter(config.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLO\tALERT\tSHORT WINDOW\tLONG WINDOW\tOBJECTIVE\tFACTOR\tTHRESHOLD\tNEW OBJECTIVE\tNEW FACTOR\tNEW THRESHOLD")
+	for _, slo := range slos {
+		current, err := alertGen.GenerateMWMBAlerts(ctx, alert.SLO{ID: slo.ID, TimeWindow: slo.TimeWindow, Objective: slo.Objective})
+		if err != nil {
+			return fmt.Errorf("could not generate %q SLO alerts: %w", slo.ID, err)
+		}
+
+		updated, err := alertGen.GenerateMWMBAlerts(ctx, alert.SLO{ID: slo.ID, TimeWindow: slo.TimeWindow, Objective: i.objective})
+		if err != nil {
+			return fmt.Errorf("could not generate %q SLO alerts: %w", slo.ID, err)
+		}
+
+		pairs := [][2]alert.MWMBAlert{
+			{current.PageQuick, updated.PageQuick},
+			{current.PageSlow, updated.PageSlow},
+			{current.TicketQuick, updated.TicketQuick},
+			{current.TicketSlow, updated.TicketSlow},
+		}
+		for _, p := range pairs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%g\t%g\t%.6g\t%g\t%g\t%.6g\n",
+				slo.ID,
+				p[0].ID,
+				prometheusmodel.Duration(p[0].ShortWindow),
+				prometheusmodel.Duration(p[0].LongWindow),
+				slo.Objective,
+				p[0].BurnRateFactor,
+				p[0].ErrorRatioThreshold(),
+				i.objective,
+				p[1].BurnRateFactor,
+				p[1].ErrorRatioThreshold(),
+			)
+		}
+	}
+
+	return w.Flush()
+}
+
+const (
+	inventoryOutputCSV  = "csv"
+	inventoryOutputJSON = "json"
+)
+
+type infoInventoryCommand struct {
+	slosInput        []string
+	slosExcludeRegex string
+	slosIncludeRegex string
+	output           string
+	ownerLabel       string
+	sliPluginsPaths  []string
+	sloPeriod        string
+	followSymlinks   bool
+}
+
+// NewInfoInventoryCommand returns the info inventory command.
+func NewInfoInventoryCommand(infoCmd *kingpin.CmdClause) Command {
+	c := &infoInventoryCommand{}
+	cmd := infoCmd.Command("inventory", "Shows the inventory of all the SLOs (service, name, objective, window and owner) of the discovered specs.")
+	cmd.Flag("input", "SLO spec discovery path, will discover recursively all YAML files (can be repeated).").Short('i').Required().StringsVar(&c.slosInput)
+	cmd.Flag("fs-exclude", "Filter regex to ignore matched discovered SLO file paths.").Short('e').StringVar(&c.slosExcludeRegex)
+	cmd.Flag("fs-include", "Filter regex to include matched discovered SLO file paths, everything else will be ignored. Exclude has preference.").Short('n').StringVar(&c.slosIncludeRegex)
+	cmd.Flag("follow-symlinks", "Follows the symlinked directories while discovering the SLO specs, every directory is discovered once.").BoolVar(&c.followSymlinks)
+	cmd.Flag("output", "The inventory output format.").Short('o').Default(inventoryOutputCSV).EnumVar(&c.output, inventoryOutputCSV, inventoryOutputJSON)
+	cmd.Flag("owner-label", "The SLO label used as the SLO owner.").Default("owner").StringVar(&c.ownerLabel)
+	cmd.Flag("sli-plugins-path", "The path to SLI plugins (can be repeated), if not set it disable plugins support.").Short('p').StringsVar(&c.sliPluginsPaths)
+	cmd.Flag("default-slo-period", "The default SLO period windows to be used for the SLOs.").Default("30d").StringVar(&c.sloPeriod)
+
+	return c
+}
+
+type inventoryRow struct {
+	File      string  `json:"file"`
+	Service   string  `json:"service"`
+	SLO       string  `json:"slo"`
+	ID        string  `json:"id"`
+	Objective float64 `json:"objective"`
+	Window    string  `json:"window"`
+	Owner     string  `json:"owner"`
+}
+
+func (i infoInventoryCommand) Name() string { return "info inventory" }
+func (i infoInventoryCommand) Run(ctx context.Context, config RootConfig) error {
+	logger := config.Logger.WithValues(log.Kv{"window": i.sloPeriod})
+
+	// SLO period.
+	sp, err := prometheusmodel.ParseDuration(i.sloPeriod)
+	if err != nil {
+		return fmt.Errorf("invalid SLO period duration: %w", err)
+	}
+	sloPeriod := time.Duration(sp)
+
+	// Set up files discovery filter regex.
+	var excludeRegex, includeRegex *regexp.Regexp
+	if i.slosExcludeRegex != "" {
+		r, err := regexp.Compile(i.slosExcludeRegex)
+		if err != nil {
+			return fmt.Errorf("invalid exclude regex: %w", err)
+		}
+		excludeRegex = r
+	}
+	if i.slosIncludeRegex != "" {
+		r, err := regexp.Compile(i.slosIncludeRegex)
+		if err != nil {
+			return fmt.Errorf("invalid include regex: %w", err)
+		}
+		includeRegex = r
+	}
+
+	sloPaths, err := discoverSLOManifests(logger, excludeRegex, includeRegex, nil, i.followSymlinks, i.slosInput...)
+	if err != nil {
+		return fmt.Errorf("could not discover files: %w", err)
+	}
+	if len(sloPaths) == 0 {
+		return fmt.Errorf("0 slo specs have been discovered")
+	}
+
+	// Load plugins.
+	pluginRepo, err := createPluginLoader(ctx, logger, i.sliPluginsPaths, 0)
+	if err != nil {
+		return err
+	}
+
+	// Load SLOs.
+	loader := newSpecLoader(pluginRepo, sloPeriod)
+	rows := []inventoryRow{}
+	for _, path := range sloPaths {
+		slxData, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read SLOs spec file data: %w", err)
+		}
+
+		slos, err := loader.LoadSLOs(ctx, slxData)
+		if err != nil {
+			return fmt.Errorf("could not load %q SLOs: %w", path, err)
+		}
+
+		for _, slo := range slos {
+			// SLO windows are normally set in days, show them in days (e.g 7d instead of 1w).
+			window := prometheusmodel.Duration(slo.TimeWindow).String()
+			if slo.TimeWindow%(24*time.Hour) == 0 {
+				window = fmt.Sprintf("%dd", slo.TimeWindow/(24*time.Hour))
+			}
+
+			rows = append(rows, inventoryRow{
+				File:      path,
+				Service:   slo.Service,
+				SLO:       slo.Name,
+				ID:        slo.ID,
+				Objective: slo.Objective,
+				Window:    window,
+				Owner:     slo.Labels[i.ownerLabel],
+			})
+		}
+	}
+
+	if i.output == inventoryOutputJSON {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not format inventory: %w", err)
+		}
+		_, err = config.Stdout.Write(append(data, '\n'))
+		return err
+	}
+
+	w := csv.NewWriter(config.Stdout)
+	_ = w.Write([]string{"file", "service", "slo", "id", "objective", "window", "owner"})
+	for _, r := range rows {
+		_ = w.Write([]string{r.File, r.Service, r.SLO, r.ID, strconv.FormatFloat(r.Objective, 'f', -1, 64), r.Window, r.Owner})
+	}
+	w.Flush()
+
+	return w.Error()
+}
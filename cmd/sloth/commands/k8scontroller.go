@@ -67,9 +67,15 @@ type kubeControllerCommand struct {
 	hotReloadAddr         string
 	metricsListenAddr     string
 	sliPluginsPaths       []string
+	sliPluginsTimeout     time.Duration
 	sloPeriodWindowsPath  string
 	sloPeriod             string
 	disableOptimizedRules bool
+	prune                 bool
+	ensureRetries         int
+	ensureRetryBackoff    time.Duration
+	k8sSplit              string
+	applyConcurrency      int
 }
 
 // NewKubeControllerCommand returns the Kubernetes controller command.
@@ -95,9 +101,15 @@ func NewKubeControllerCommand(app *kingpin.Application) Command {
 	cmd.Flag("extra-labels", "Extra labels that will be added to all the generated Prometheus rules ('key=value' form, can be repeated).").Short('l').StringMapVar(&c.extraLabels)
 	cmd.Flag("id-labels", "Id labels that used as filters for generated recording rules. These will also be added as extra labels ('key=value' form, can be repeated).").Short('d').StringMapVar(&c.idLabels)
 	cmd.Flag("sli-plugins-path", "The path to SLI plugins (can be repeated), if not set it disable plugins support.").Short('p').StringsVar(&c.sliPluginsPaths)
+	cmd.Flag("sli-plugins-timeout", "The max execution time of an SLI plugin, if 0 plugins run without time limit.").Default("0s").DurationVar(&c.sliPluginsTimeout)
 	cmd.Flag("slo-period-windows-path", "The directory path to custom SLO period windows catalog (replaces default ones).").StringVar(&c.sloPeriodWindowsPath)
 	cmd.Flag("default-slo-period", "The default SLO period windows to be used for the SLOs.").Default("30d").StringVar(&c.sloPeriod)
 	cmd.Flag("disable-optimized-rules", "If enabled it will disable optimized generated rules.").BoolVar(&c.disableOptimizedRules)
+	cmd.Flag("prune", "Deletes on every resync the orphaned Prometheus operator rules created by the controller, of removed PrometheusServiceLevels or SLOs (requires namespace and the prometheusrules delete permission).").BoolVar(&c.prune)
+	cmd.Flag("ensure-retries", "The number of retries when ensuring the Prometheus operator rules on Kubernetes fails.").Default("0").IntVar(&c.ensureRetries)
+	cmd.Flag("ensure-retry-backoff", "The initial backoff between ensure retries, it will be doubled on every retry.").Default("500ms").DurationVar(&c.ensureRetryBackoff)
+	cmd.Flag("k8s-split", "How the generated rules are split into Prometheus operator rules, a single one for the PrometheusServiceLevel or one per SLO.").Default(k8sSplitPerGroup).EnumVar(&c.k8sSplit, k8sSplitPerGroup, k8sSplitPerSLO)
+	cmd.Flag("apply-concurrency", "The max number of Prometheus operator rules ensured on Kubernetes at the same time.").Default("1").IntVar(&c.applyConcurrency)
 
 	return c
 }
@@ -118,8 +130,12 @@ func (k kubeControllerCommand) Run(ctx context.Context, config RootConfig) error
 	}
 	sloPeriod := time.Duration(sp)
 
+	if k.prune && k.namespace == "" {
+		return fmt.Errorf("prune mode requires a namespace")
+	}
+
 	// Plugins.
-	pluginRepo, err := createPluginLoader(ctx, logger, k.sliPluginsPaths)
+	pluginRepo, err := createPluginLoader(ctx, logger, k.sliPluginsPaths, k.sliPluginsTimeout)
 	if err != nil {
 		return err
 	}
@@ -324,10 +340,13 @@ func (k kubeControllerCommand) Run(ctx context.Context, config RootConfig) error
 		}
 
 		// Create handler.
+		repo := k8sprometheus.NewPrometheusOperatorCRDRepo(ksvc, logger).WithRetry(k.ensureRetries, k.ensureRetryBackoff).
+			WithSplitPerSLO(k.k8sSplit == k8sSplitPerSLO).
+			WithApplyConcurrency(k.applyConcurrency)
 		config := kubecontroller.HandlerConfig{
 			Generator:        generator,
 			SpecLoader:       k8sprometheus.NewCRSpecLoader(pluginRepo, sloPeriod),
-			Repository:       k8sprometheus.NewPrometheusOperatorCRDRepo(ksvc, logger),
+			Repository:       repo,
 			KubeStatusStorer: ksvc,
 			ExtraLabels:      k.extraLabels,
 			IDLabels:         k.idLabels,
@@ -370,17 +389,71 @@ func (k kubeControllerCommand) Run(ctx context.Context, config RootConfig) error
 				cancel()
 			},
 		)
+
+		// Orphaned rules pruner.
+		if k.prune {
+			g.Add(
+				func() error {
+					logger.Infof("Orphaned rules pruner running")
+					defer logger.Infof("Orphaned rules pruner stopped")
+					for {
+						err := k.pruneOrphanedRules(ctx, ksvc, repo)
+						if err != nil {
+							logger.Errorf("Could not prune orphaned rules: %s", err)
+						}
+
+						select {
+						case <-ctx.Done():
+							return nil
+						case <-time.After(k.resyncInterval):
+						}
+					}
+				},
+				func(_ error) {
+					cancel()
+				},
+			)
+		}
 	}
 
 	return g.Run()
 }
 
+// pruneOrphanedRules deletes the controller created Prometheus operator rules that aren't generated
+// from the PrometheusServiceLevels anymore (removed PrometheusServiceLevels or SLOs).
+func (k kubeControllerCommand) pruneOrphanedRules(ctx context.Context, ksvc kubernetesService, repo k8sprometheus.PrometheusOperatorCRDRepo) error {
+	psls, err := ksvc.ListPrometheusServiceLevels(ctx, k.namespace, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("could not list PrometheusServiceLevels: %w", err)
+	}
+
+	// Generated Prometheus operator rules are annotated with the PrometheusServiceLevel they come from,
+	// when split per SLO, the removed SLOs rules are orphaned too.
+	current := make(map[string][]string, len(psls.Items))
+	for _, psl := range psls.Items {
+		sloNames := make([]string, 0, len(psl.Spec.SLOs))
+		for _, slo := range psl.Spec.SLOs {
+			sloNames = append(sloNames, slo.Name)
+		}
+		current["PrometheusServiceLevel/"+psl.Name] = k8sprometheus.GeneratedPrometheusRuleNames(psl.Name, sloNames, k.k8sSplit == k8sSplitPerSLO)
+	}
+
+	_, err = repo.PruneOrphanedPrometheusRules(ctx, k.namespace, current)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // kubernetesService is an internal interface so we can return all the Kubernetes service specific implemententations from the
 // same function (e.g: regular, dry-run, fake...).
 type kubernetesService interface {
 	ListPrometheusServiceLevels(ctx context.Context, ns string, opts metav1.ListOptions) (*slothv1.PrometheusServiceLevelList, error)
 	WatchPrometheusServiceLevels(ctx context.Context, ns string, opts metav1.ListOptions) (watch.Interface, error)
 	EnsurePrometheusRule(ctx context.Context, pr *monitoringv1.PrometheusRule) error
+	ListPrometheusRules(ctx context.Context, ns string, opts metav1.ListOptions) (*monitoringv1.PrometheusRuleList, error)
+	DeletePrometheusRule(ctx context.Context, ns, name string) error
 	EnsurePrometheusServiceLevelStatus(ctx context.Context, slo *slothv1.PrometheusServiceLevel, err error) error
 }
 
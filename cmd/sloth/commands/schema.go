@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+const schemaFormatJSONSchema = "jsonschema"
+
+type schemaCommand struct {
+	format string
+}
+
+// NewSchemaCommand returns the schema command.
+func NewSchemaCommand(app *kingpin.Application) Command {
+	c := &schemaCommand{}
+	cmd := app.Command("schema", "Shows the machine-readable schema of the native Sloth SLO spec (e.g for IDE autocomplete and external validation).")
+	cmd.Flag("format", "The schema format.").Default(schemaFormatJSONSchema).EnumVar(&c.format, schemaFormatJSONSchema)
+
+	return c
+}
+
+func (schemaCommand) Name() string { return "schema" }
+func (s schemaCommand) Run(_ context.Context, config RootConfig) error {
+	schema, err := prometheus.SpecJSONSchema()
+	if err != nil {
+		return fmt.Errorf("could not generate %s schema: %w", s.format, err)
+	}
+
+	fmt.Fprintln(config.Stdout, string(schema))
+	return nil
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/slok/sloth/internal/app/ruletest"
+)
+
+type testCommand struct {
+	generate *generateCommand
+	dataPath string
+}
+
+// NewTestCommand returns the test command.
+func NewTestCommand(app *kingpin.Application) Command {
+	c := &testCommand{generate: newGenerateCommand()}
+	cmd := app.Command("test", "Generates the Prometheus SLOs and evaluates the rules against synthetic samples, reporting the alerts that fire (accepts the same flags as generate).")
+	c.generate.registerFlags(cmd)
+	cmd.Flag("data", "Synthetic samples YAML file path, uses the promtool unit tests `interval` and `input_series` format (optionally `eval_duration`).").Required().StringVar(&c.dataPath)
+
+	return c
+}
+
+func (t testCommand) Name() string { return "test" }
+func (t testCommand) Run(ctx context.Context, config RootConfig) error {
+	rawData, err := os.ReadFile(t.dataPath)
+	if err != nil {
+		return fmt.Errorf("could not read data file: %w", err)
+	}
+	data, err := ruletest.LoadData(rawData)
+	if err != nil {
+		return fmt.Errorf("invalid data file: %w", err)
+	}
+
+	inputInfo, err := os.Stat(t.generate.slosInput)
+	if err != nil {
+		return err
+	}
+
+	// Generate the rules on a temporary location.
+	tmpDir, err := os.MkdirTemp("", "sloth-test-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.generate.slosOut = filepath.Join(tmpDir, "rules.yaml")
+	if inputInfo.IsDir() {
+		t.generate.slosOut = tmpDir
+	}
+	err = t.generate.Run(ctx, config)
+	if err != nil {
+		return fmt.Errorf("could not generate rules: %w", err)
+	}
+
+	ruleFiles := []string{}
+	err = filepath.WalkDir(tmpDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			ruleFiles = append(ruleFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not get generated rule files: %w", err)
+	}
+
+	// Evaluate the rules.
+	svc, err := ruletest.NewService(ruletest.ServiceConfig{Logger: config.Logger})
+	if err != nil {
+		return fmt.Errorf("could not create rules test service: %w", err)
+	}
+	res, err := svc.Evaluate(ctx, ruletest.Request{
+		RuleFiles: ruleFiles,
+		Data:      *data,
+	})
+	if err != nil {
+		return fmt.Errorf("could not evaluate rules: %w", err)
+	}
+
+	if len(res.FiredAlerts) == 0 {
+		fmt.Fprintln(config.Stdout, "No alerts fired.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(config.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALERT\tFIRST FIRED\tLAST FIRED\tLABELS")
+	for _, a := range res.FiredAlerts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Name, a.FirstFiredAt, a.LastFiredAt, labels.FromMap(a.Labels))
+	}
+
+	return w.Flush()
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -9,6 +10,8 @@ import (
 	"regexp"
 	"time"
 
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	prometheusmodel "github.com/prometheus/common/model"
 	"gopkg.in/alecthomas/kingpin.v2"
 
@@ -20,28 +23,50 @@ import (
 )
 
 type validateCommand struct {
-	slosInput            string
-	slosExcludeRegex     string
-	slosIncludeRegex     string
-	extraLabels          map[string]string
-	idLabels             map[string]string
-	sliPluginsPaths      []string
-	sloPeriodWindowsPath string
-	sloPeriod            string
+	slosInput             []string
+	slosExcludeRegex      string
+	slosIncludeRegex      string
+	contentIncludes       []string
+	followSymlinks        bool
+	extraLabels           map[string]string
+	idLabels              map[string]string
+	sliPluginsPaths       []string
+	sliPluginsTimeout     time.Duration
+	checkSLIPlugins       bool
+	sliWindowPlaceholder  string
+	sloPeriodWindowsPath  string
+	sloPeriod             string
+	experimentalOpenSLOV2 bool
+	strictOpenSLO         bool
+	maxSeries             int
+	checkFeasibility      bool
+	prometheusURL         string
+	inputEncoding         string
 }
 
 // NewValidateCommand returns the validate command.
 func NewValidateCommand(app *kingpin.Application) Command {
 	c := &validateCommand{extraLabels: map[string]string{}, idLabels: map[string]string{}}
 	cmd := app.Command("validate", "Validates the SLO manifests and generation of Prometheus SLOs.")
-	cmd.Flag("input", "SLO spec discovery path, will discover recursively all YAML files.").Short('i').Required().StringVar(&c.slosInput)
+	cmd.Flag("input", "SLO spec discovery path, will discover recursively all YAML files (can be repeated).").Short('i').Required().StringsVar(&c.slosInput)
 	cmd.Flag("fs-exclude", "Filter regex to ignore matched discovered SLO file paths.").Short('e').StringVar(&c.slosExcludeRegex)
 	cmd.Flag("fs-include", "Filter regex to include matched discovered SLO file paths, everything else will be ignored. Exclude has preference.").Short('n').StringVar(&c.slosIncludeRegex)
+	cmd.Flag("content-include", "Filter to include only the discovered SLO files with a YAML key value on their content matching the regex, in 'key=regex' form or 'key!=regex' to negate it (e.g 'service=^api-', can be repeated, all need to match).").StringsVar(&c.contentIncludes)
+	cmd.Flag("follow-symlinks", "Follows the symlinked directories while discovering the SLO specs, every directory is discovered once.").BoolVar(&c.followSymlinks)
 	cmd.Flag("extra-labels", "Extra labels that will be added to all the generated Prometheus rules ('key=value' form, can be repeated).").Short('l').StringMapVar(&c.extraLabels)
 	cmd.Flag("id-labels", "Id labels that used as filters for generated recording rules. These will also be added as extra labels ('key=value' form, can be repeated).").Short('d').StringMapVar(&c.idLabels)
 	cmd.Flag("sli-plugins-path", "The path to SLI plugins (can be repeated), if not set it disable plugins support.").Short('p').StringsVar(&c.sliPluginsPaths)
+	cmd.Flag("sli-plugins-timeout", "The max execution time of an SLI plugin, if 0 plugins run without time limit.").Default("0s").DurationVar(&c.sliPluginsTimeout)
+	cmd.Flag("check-sli-plugins", "Checks that all the SLI plugins referenced by the specs exist before validating, failing with all the missing plugins and the SLOs using them.").BoolVar(&c.checkSLIPlugins)
+	cmd.Flag("sli-window-placeholder", "A custom SLI queries window placeholder token (e.g `$__range`) that will be used as the `{{.window}}` template variable on Prometheus specs.").StringVar(&c.sliWindowPlaceholder)
 	cmd.Flag("slo-period-windows-path", "The directory path to custom SLO period windows catalog (replaces default ones).").StringVar(&c.sloPeriodWindowsPath)
 	cmd.Flag("default-slo-period", "The default SLO period windows to be used for the SLOs.").Default("30d").StringVar(&c.sloPeriod)
+	cmd.Flag("experimental-openslo-v2", "Enables the experimental OpenSLO v2alpha specs support (only a subset of the spec is supported).").BoolVar(&c.experimentalOpenSLOV2)
+	cmd.Flag("strict-openslo", "Fails validating the OpenSLO specs with unknown fields instead of ignoring them.").BoolVar(&c.strictOpenSLO)
+	cmd.Flag("max-series", "The max number of series an SLO SLI can have, checked against a live Prometheus (requires --prometheus-url), if 0 it will not be checked.").IntVar(&c.maxSeries)
+	cmd.Flag("check-feasibility", "Warns about the SLOs whose objective has not been met by the SLI on the SLO time window, checked against a live Prometheus (requires --prometheus-url).").BoolVar(&c.checkFeasibility)
+	cmd.Flag("prometheus-url", "The Prometheus URL used to check the SLI series cardinality and the SLO feasibility.").StringVar(&c.prometheusURL)
+	cmd.Flag("input-encoding", "The encoding of the SLO spec files, transcoded to UTF-8 before loading them.").Default(inputEncodingUTF8).EnumVar(&c.inputEncoding, inputEncodingUTF8, inputEncodingLatin1, inputEncodingWindows1252)
 
 	return c
 }
@@ -79,9 +104,13 @@ func (v validateCommand) Run(ctx context.Context, config RootConfig) error {
 		}
 		includeRegex = r
 	}
+	contentFilters, err := parseContentFilters(v.contentIncludes)
+	if err != nil {
+		return err
+	}
 
 	// Discover SLOs.
-	sloPaths, err := discoverSLOManifests(logger, excludeRegex, includeRegex, v.slosInput)
+	sloPaths, err := discoverSLOManifests(logger, excludeRegex, includeRegex, contentFilters, v.followSymlinks, v.slosInput...)
 	if err != nil {
 		return fmt.Errorf("could not discover files: %w", err)
 	}
@@ -90,11 +119,35 @@ func (v validateCommand) Run(ctx context.Context, config RootConfig) error {
 	}
 
 	// Load plugins.
-	pluginRepo, err := createPluginLoader(ctx, logger, v.sliPluginsPaths)
+	pluginRepo, err := createPluginLoader(ctx, logger, v.sliPluginsPaths, v.sliPluginsTimeout)
 	if err != nil {
 		return err
 	}
 
+	if v.checkSLIPlugins {
+		targets := []generateTarget{}
+		for _, input := range sloPaths {
+			f, err := os.Open(input)
+			if err != nil {
+				return fmt.Errorf("could not open SLOs spec file: %w", err)
+			}
+			slxData, err := readInput(f, v.inputEncoding)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("could not read SLOs spec file data: %w", err)
+			}
+
+			for _, data := range splitYAML(slxData) {
+				targets = append(targets, generateTarget{SLOData: data, Source: input})
+			}
+		}
+
+		err := checkSpecsSLIPlugins(ctx, pluginRepo, targets)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Windows repository.
 	var wfs fs.FS
 	if v.sloPeriodWindowsPath != "" {
@@ -114,17 +167,76 @@ func (v validateCommand) Run(ctx context.Context, config RootConfig) error {
 		return fmt.Errorf("invalid default slo period: %w", err)
 	}
 
+	// Prepare the live Prometheus checks if required.
+	var promAPI promv1.API
+	if v.maxSeries > 0 || v.checkFeasibility {
+		if v.prometheusURL == "" {
+			return fmt.Errorf("--prometheus-url is required to check the max series or the SLO feasibility")
+		}
+
+		client, err := promapi.NewClient(promapi.Config{Address: v.prometheusURL})
+		if err != nil {
+			return fmt.Errorf("could not create Prometheus client: %w", err)
+		}
+		promAPI = promv1.NewAPI(client)
+	}
+
+	checkCardinality := func(context.Context, []prometheus.SLO) error { return nil }
+	if v.maxSeries > 0 {
+		seriesCounter := prometheus.NewPrometheusAPISeriesCounter(promAPI)
+		checkCardinality = func(ctx context.Context, slos []prometheus.SLO) error {
+			for _, slo := range slos {
+				err := prometheus.ValidateSLICardinality(ctx, seriesCounter, slo, v.maxSeries)
+				if err != nil {
+					return fmt.Errorf("invalid %q SLO cardinality: %w", slo.ID, err)
+				}
+			}
+			return nil
+		}
+	}
+
+	// The unmet objectives are warned to start a target discussion, they don't fail the validation.
+	checkFeasibility := func(context.Context, []prometheus.SLO) error { return nil }
+	if v.checkFeasibility {
+		valueQuerier := prometheus.NewPrometheusAPIValueQuerier(promAPI)
+		checkFeasibility = func(ctx context.Context, slos []prometheus.SLO) error {
+			for _, slo := range slos {
+				err := prometheus.ValidateSLOFeasibility(ctx, valueQuerier, slo)
+				switch {
+				case errors.Is(err, prometheus.ErrSLOObjectiveUnmet):
+					logger.WithValues(log.Kv{"slo": slo.ID}).Warningf("%q SLO is not feasible: %s", slo.ID, err)
+				case err != nil:
+					return fmt.Errorf("could not check %q SLO feasibility: %w", slo.ID, err)
+				}
+			}
+			return nil
+		}
+	}
+
+	checkLivePrometheus := func(ctx context.Context, slos []prometheus.SLO) error {
+		err := checkCardinality(ctx, slos)
+		if err != nil {
+			return err
+		}
+		return checkFeasibility(ctx, slos)
+	}
+
 	// Create Spec loaders.
-	promYAMLLoader := prometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod)
+	promYAMLLoader := prometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod).WithWindowPlaceholder(v.sliWindowPlaceholder)
 	kubeYAMLLoader := k8sprometheus.NewYAMLSpecLoader(pluginRepo, sloPeriod)
-	openSLOYAMLLoader := openslo.NewYAMLSpecLoader(sloPeriod)
+	openSLOYAMLLoader := openslo.NewYAMLSpecLoader(sloPeriod).WithExperimentalV2(v.experimentalOpenSLOV2).WithStrict(v.strictOpenSLO)
 
 	// For every file load the data and start the validation process:
 	validations := []*fileValidation{}
 	totalValidations := 0
 	for _, input := range sloPaths {
 		// Get SLO spec data.
-		slxData, err := os.ReadFile(input)
+		f, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("could not open SLOs spec file: %w", err)
+		}
+		slxData, err := readInput(f, v.inputEncoding)
+		f.Close()
 		if err != nil {
 			return fmt.Errorf("could not read SLOs spec file data: %w", err)
 		}
@@ -143,6 +255,23 @@ func (v validateCommand) Run(ctx context.Context, config RootConfig) error {
 		// TODO(slok): Add service meta to validation.
 		validation := &fileValidation{File: input}
 		validations = append(validations, validation)
+
+		// Override the defaults with the file front-matter if required.
+		promYAMLLoader, kubeYAMLLoader, openSLOYAMLLoader := promYAMLLoader, kubeYAMLLoader, openSLOYAMLLoader
+		frontMatter, err := parseSpecFrontMatter(slxData, input)
+		if err == nil && frontMatter != (specFrontMatter{}) {
+			var fmSLOPeriod time.Duration
+			fmSLOPeriod, gen.windowsRepo, err = resolveSpecFrontMatter(ctx, logger, frontMatter, sloPeriod, windowsRepo)
+			promYAMLLoader = prometheus.NewYAMLSpecLoader(pluginRepo, fmSLOPeriod).WithWindowPlaceholder(v.sliWindowPlaceholder)
+			kubeYAMLLoader = k8sprometheus.NewYAMLSpecLoader(pluginRepo, fmSLOPeriod)
+			openSLOYAMLLoader = openslo.NewYAMLSpecLoader(fmSLOPeriod).WithExperimentalV2(v.experimentalOpenSLOV2).WithStrict(v.strictOpenSLO)
+		}
+		if err != nil {
+			totalValidations++
+			validation.Errs = []error{fmt.Errorf("invalid front-matter: %w", err)}
+			splittedSLOsData = nil
+		}
+
 		for _, data := range splittedSLOsData {
 			totalValidations++
 
@@ -155,6 +284,11 @@ func (v validateCommand) Run(ctx context.Context, config RootConfig) error {
 					err := gen.GeneratePrometheus(ctx, *slos, io.Discard)
 					if err != nil {
 						validation.Errs = []error{fmt.Errorf("Could not generate Prometheus format rules: %w", err)}
+						continue
+					}
+					err = checkLivePrometheus(ctx, slos.SLOs)
+					if err != nil {
+						validation.Errs = []error{err}
 					}
 					continue
 				}
@@ -162,11 +296,19 @@ func (v validateCommand) Run(ctx context.Context, config RootConfig) error {
 				validation.Errs = []error{fmt.Errorf("Tried loading raw prometheus SLOs spec, it couldn't: %w", promErr)}
 
 			case kubeYAMLLoader.IsSpecType(ctx, dataB):
-				sloGroup, k8sErr := kubeYAMLLoader.LoadSpec(ctx, dataB)
+				sloGroups, k8sErr := kubeYAMLLoader.LoadSpecs(ctx, dataB)
 				if k8sErr == nil {
-					err := gen.GenerateKubernetes(ctx, *sloGroup, io.Discard)
-					if err != nil {
-						validation.Errs = []error{fmt.Errorf("could not generate Kubernetes format rules: %w", err)}
+					for _, sloGroup := range sloGroups {
+						err := gen.GenerateKubernetes(ctx, *sloGroup, io.Discard)
+						if err != nil {
+							validation.Errs = []error{fmt.Errorf("could not generate Kubernetes format rules: %w", err)}
+							break
+						}
+						err = checkLivePrometheus(ctx, sloGroup.SLOGroup.SLOs)
+						if err != nil {
+							validation.Errs = []error{err}
+							break
+						}
 					}
 					continue
 				}
@@ -179,6 +321,11 @@ func (v validateCommand) Run(ctx context.Context, config RootConfig) error {
 					err := gen.GenerateOpenSLO(ctx, *slos, io.Discard)
 					if err != nil {
 						validation.Errs = []error{fmt.Errorf("Could not generate OpenSLO format rules: %w", err)}
+						continue
+					}
+					err = checkLivePrometheus(ctx, slos.SLOs)
+					if err != nil {
+						validation.Errs = []error{err}
 					}
 					continue
 				}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/slok/sloth/internal/app/watch"
+	"github.com/slok/sloth/internal/log"
+)
+
+type watchCommand struct {
+	generate *generateCommand
+	debounce time.Duration
+}
+
+// NewWatchCommand returns the watch command.
+func NewWatchCommand(app *kingpin.Application) Command {
+	c := &watchCommand{generate: newGenerateCommand()}
+	cmd := app.Command("watch", "Watches the SLO specs and regenerates the Prometheus SLOs on every change (accepts the same flags as generate).")
+	c.generate.registerFlags(cmd)
+	cmd.Flag("debounce", "The time without spec changes that needs to pass before regenerating.").Default("500ms").DurationVar(&c.debounce)
+
+	return c
+}
+
+func (w watchCommand) Name() string { return "watch" }
+func (w watchCommand) Run(ctx context.Context, config RootConfig) error {
+	logger := config.Logger.WithValues(log.Kv{"input": w.generate.slosInput})
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	inputInfo, err := os.Stat(w.generate.slosInput)
+	if err != nil {
+		return err
+	}
+
+	input, err := filepath.Abs(w.generate.slosInput)
+	if err != nil {
+		return err
+	}
+	out, err := filepath.Abs(w.generate.slosOut)
+	if err != nil {
+		return err
+	}
+
+	// Editors replace the files on save, on file inputs watch the parent directory and
+	// notify only the input changes. Never notify the output changes or we would loop.
+	watchPath := input
+	filter := func(path string) bool {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return false
+		}
+		if path == out || strings.HasPrefix(path, out+string(filepath.Separator)) {
+			return false
+		}
+		extension := strings.ToLower(filepath.Ext(path))
+		return extension == ".yml" || extension == ".yaml"
+	}
+	if !inputInfo.IsDir() {
+		watchPath = filepath.Dir(input)
+		filter = func(path string) bool {
+			path, err := filepath.Abs(path)
+			return err == nil && path == input
+		}
+	}
+
+	notifier, err := watch.NewFSNotifier(watch.FSNotifierConfig{
+		Paths:  []string{watchPath},
+		Filter: filter,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create file system notifier: %w", err)
+	}
+	defer notifier.Close()
+
+	regenerator := watch.RegeneratorFunc(func(ctx context.Context) error {
+		return w.generate.Run(ctx, config)
+	})
+
+	svc, err := watch.NewService(watch.ServiceConfig{
+		Notifier:    notifier,
+		Regenerator: regenerator,
+		Debounce:    w.debounce,
+		Logger:      logger,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create watch service: %w", err)
+	}
+
+	// Initial generation, failures don't stop watching so they can be fixed on the specs.
+	err = regenerator.Regenerate(ctx)
+	if err != nil {
+		logger.Errorf("Could not generate SLOs: %s", err)
+	}
+
+	logger.Infof("Watching SLO spec changes")
+	return svc.Run(ctx)
+}
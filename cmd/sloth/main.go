@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -25,13 +26,26 @@ func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 	generateCmd := commands.NewGenerateCommand(app)
 	kubeCtrlCmd := commands.NewKubeControllerCommand(app)
 	validateCmd := commands.NewValidateCommand(app)
+	doctorCmd := commands.NewDoctorCommand(app)
+	watchCmd := commands.NewWatchCommand(app)
 	versionCmd := commands.NewVersionCommand(app)
+	schemaCmd := commands.NewSchemaCommand(app)
+	testCmd := commands.NewTestCommand(app)
+	infoCmd := app.Command("info", "Shows information about the SLOs.")
+	infoThresholdsCmd := commands.NewInfoThresholdsCommand(infoCmd)
+	infoInventoryCmd := commands.NewInfoInventoryCommand(infoCmd)
 
 	cmds := map[string]commands.Command{
-		generateCmd.Name(): generateCmd,
-		kubeCtrlCmd.Name(): kubeCtrlCmd,
-		validateCmd.Name(): validateCmd,
-		versionCmd.Name():  versionCmd,
+		generateCmd.Name():       generateCmd,
+		kubeCtrlCmd.Name():       kubeCtrlCmd,
+		validateCmd.Name():       validateCmd,
+		doctorCmd.Name():         doctorCmd,
+		watchCmd.Name():          watchCmd,
+		versionCmd.Name():        versionCmd,
+		schemaCmd.Name():         schemaCmd,
+		testCmd.Name():           testCmd,
+		infoThresholdsCmd.Name(): infoThresholdsCmd,
+		infoInventoryCmd.Name():  infoInventoryCmd,
 	}
 
 	// Parse commandline.
@@ -47,6 +61,10 @@ func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 	config.Logger = getLogger(*config)
 
 	// Execute command.
+	if config.Timeout > 0 {
+		return runWithTimeout(ctx, cmdName, cmds[cmdName], *config)
+	}
+
 	err = cmds[cmdName].Run(ctx, *config)
 	if err != nil {
 		return fmt.Errorf("%q command failed: %w", cmdName, err)
@@ -55,6 +73,31 @@ func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 	return nil
 }
 
+// runWithTimeout runs the command with the timeout deadline on the context, the command is not
+// waited after the deadline, so steps that ignore the context can't extend the run time.
+func runWithTimeout(ctx context.Context, cmdName string, cmd commands.Command, config commands.RootConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- cmd.Run(ctx, config)
+	}()
+
+	select {
+	case err := <-errC:
+		if err == nil {
+			return nil
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%q command timed out after %s: %w", cmdName, config.Timeout, err)
+		}
+		return fmt.Errorf("%q command failed: %w", cmdName, err)
+	case <-ctx.Done():
+		return fmt.Errorf("%q command timed out after %s: %w", cmdName, config.Timeout, ctx.Err())
+	}
+}
+
 // getLogger returns the application logger.
 func getLogger(config commands.RootConfig) log.Logger {
 	if config.NoLog {
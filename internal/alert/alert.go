@@ -36,6 +36,18 @@ type MWMBAlert struct {
 	Severity       Severity
 }
 
+// ErrorRatioThreshold returns the SLI error ratio that needs to be exceeded on the alert
+// windows to trigger the alert.
+func (m MWMBAlert) ErrorRatioThreshold() float64 {
+	return m.BurnRateFactor * m.ErrorBudget / 100
+}
+
+// Attainable returns false when the SLI error ratio threshold of the alert is not below 1
+// (e.g low objectives with high burn rate factors), the alert will never trigger.
+func (m MWMBAlert) Attainable() bool {
+	return m.ErrorRatioThreshold() < 1
+}
+
 // MWMBAlertGroup what represents all the alerts of an SLO.
 // ITs divided into two groups that are made of 2 alerts:
 // - Page & quick: Critical alerts that trigger in high rate burn in short term.
@@ -232,3 +232,52 @@ spec:
 		})
 	}
 }
+
+func TestMWMBAlertErrorRatioThreshold(t *testing.T) {
+	tests := map[string]struct {
+		objective    float64
+		expThreshold map[string]float64
+	}{
+		"A 99.9 objective should have the default thresholds.": {
+			objective: 99.9,
+			expThreshold: map[string]float64{
+				"page-quick":   0.0144,
+				"page-slow":    0.006,
+				"ticket-quick": 0.003,
+				"ticket-slow":  0.001,
+			},
+		},
+
+		"A 99.95 objective should have half of the 99.9 objective thresholds.": {
+			objective: 99.95,
+			expThreshold: map[string]float64{
+				"page-quick":   0.0072,
+				"page-slow":    0.003,
+				"ticket-quick": 0.0015,
+				"ticket-slow":  0.0005,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+			require.NoError(err)
+			generator := alert.NewGenerator(windowsRepo)
+			gotAlerts, err := generator.GenerateMWMBAlerts(context.TODO(), alert.SLO{
+				ID:         "test",
+				TimeWindow: 30 * 24 * time.Hour,
+				Objective:  test.objective,
+			})
+			require.NoError(err)
+
+			assert.InDelta(test.expThreshold["page-quick"], gotAlerts.PageQuick.ErrorRatioThreshold(), 1e-9)
+			assert.InDelta(test.expThreshold["page-slow"], gotAlerts.PageSlow.ErrorRatioThreshold(), 1e-9)
+			assert.InDelta(test.expThreshold["ticket-quick"], gotAlerts.TicketQuick.ErrorRatioThreshold(), 1e-9)
+			assert.InDelta(test.expThreshold["ticket-slow"], gotAlerts.TicketSlow.ErrorRatioThreshold(), 1e-9)
+		})
+	}
+}
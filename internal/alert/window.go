@@ -7,8 +7,11 @@ import (
 	"io/fs"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
+	prommodel "github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
 	"github.com/slok/sloth/internal/log"
@@ -61,7 +64,9 @@ func (w Window) Validate() error {
 // - Alert severity: ["page", "ticket"].
 // - Measuring period: ["long", "short"].
 type Windows struct {
-	SLOPeriod   time.Duration
+	SLOPeriod time.Duration
+	// Scalable windows can be used on other SLO periods by scaling them (check Scale).
+	Scalable    bool
 	PageQuick   Window
 	PageSlow    Window
 	TicketQuick Window
@@ -93,9 +98,70 @@ func (w Windows) Validate() error {
 		return fmt.Errorf("invalid ticket slow: %w", err)
 	}
 
+	// Page alerts need to be triggered faster than ticket alerts, so all the page
+	// windows must be tighter than the ticket windows.
+	pageShort := maxDuration(w.PageQuick.ShortWindow, w.PageSlow.ShortWindow)
+	ticketShort := minDuration(w.TicketQuick.ShortWindow, w.TicketSlow.ShortWindow)
+	if pageShort >= ticketShort {
+		return fmt.Errorf("page short windows (%s) must be tighter than ticket short windows (%s)", pageShort, ticketShort)
+	}
+
+	pageLong := maxDuration(w.PageQuick.LongWindow, w.PageSlow.LongWindow)
+	ticketLong := minDuration(w.TicketQuick.LongWindow, w.TicketSlow.LongWindow)
+	if pageLong >= ticketLong {
+		return fmt.Errorf("page long windows (%s) must be tighter than ticket long windows (%s)", pageLong, ticketLong)
+	}
+
+	// Windows designed for a shorter SLO period can't be used on a longer one.
+	longest := maxDuration(w.TicketQuick.LongWindow, w.TicketSlow.LongWindow)
+	if longest >= w.SLOPeriod {
+		return fmt.Errorf("ticket long windows (%s) must be shorter than the slo period (%s)", longest, w.SLOPeriod)
+	}
+
 	return nil
 }
 
+// Scale returns the windows scaled proportionally to a different SLO period, the error budget
+// percents are kept so the burn rate factors are the same as the original windows ones.
+func (w Windows) Scale(period time.Duration) Windows {
+	ratio := float64(period) / float64(w.SLOPeriod)
+	scale := func(d time.Duration) time.Duration {
+		d = time.Duration(float64(d) * ratio).Round(time.Minute)
+		if d < time.Minute {
+			return time.Minute
+		}
+		return d
+	}
+	scaleWindow := func(win Window) Window {
+		win.ShortWindow = scale(win.ShortWindow)
+		win.LongWindow = scale(win.LongWindow)
+		return win
+	}
+
+	return Windows{
+		SLOPeriod:   period,
+		Scalable:    w.Scalable,
+		PageQuick:   scaleWindow(w.PageQuick),
+		PageSlow:    scaleWindow(w.PageSlow),
+		TicketQuick: scaleWindow(w.TicketQuick),
+		TicketSlow:  scaleWindow(w.TicketSlow),
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 // Error budget speeds based on a full time window, however once we have the factor (speed)
 // the value can be used with any time window.
 func (w Windows) GetSpeedPageQuick() float64 {
@@ -140,8 +206,10 @@ func (c *FSWindowsRepoConfig) defaults() error {
 
 type FSWindowsRepo struct {
 	windows map[time.Duration]Windows
-	loader  windowLoader
-	logger  log.Logger
+	// scalable are the windows used for the periods without windows.
+	scalable *Windows
+	loader   windowLoader
+	logger   log.Logger
 }
 
 func NewFSWindowsRepo(config FSWindowsRepoConfig) (*FSWindowsRepo, error) {
@@ -211,6 +279,13 @@ func (f *FSWindowsRepo) load(ctx context.Context, windowsFS fs.FS) error {
 		}
 		f.windows[windows.SLOPeriod] = *windows
 
+		if windows.Scalable {
+			if f.scalable != nil {
+				return fmt.Errorf("%q and %q slo periods are scalable, only one scalable slo period is supported", periodString(f.scalable.SLOPeriod), periodString(windows.SLOPeriod))
+			}
+			f.scalable = windows
+		}
+
 		return nil
 	})
 
@@ -223,13 +298,34 @@ func (f *FSWindowsRepo) load(ctx context.Context, windowsFS fs.FS) error {
 
 func (f *FSWindowsRepo) GetWindows(_ context.Context, period time.Duration) (*Windows, error) {
 	w, ok := f.windows[period]
+	if !ok && f.scalable != nil {
+		w = f.scalable.Scale(period)
+		err := w.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("could not scale %s scalable windows to %s window period: %w", periodString(f.scalable.SLOPeriod), periodString(period), err)
+		}
+		return &w, nil
+	}
 	if !ok {
-		return nil, fmt.Errorf("window period %s missing", period)
+		periods := make([]string, 0, len(f.windows))
+		for p := range f.windows {
+			periods = append(periods, periodString(p))
+		}
+		sort.Strings(periods)
+		return nil, fmt.Errorf("window period %s missing, the windows catalog has the %s periods", periodString(period), strings.Join(periods, ", "))
 	}
 
 	return &w, nil
 }
 
+// periodString formats the SLO periods in days like the catalogs do (e.g `30d`).
+func periodString(period time.Duration) string {
+	if period%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", period/(24*time.Hour))
+	}
+	return prommodel.Duration(period).String()
+}
+
 type windowLoader struct{}
 
 func (l windowLoader) LoadWindow(_ context.Context, data []byte) (*Windows, error) {
@@ -251,6 +347,7 @@ func (l windowLoader) LoadWindow(_ context.Context, data []byte) (*Windows, erro
 	// Map to model.
 	w := &Windows{
 		SLOPeriod: time.Duration(s.Spec.SLOPeriod),
+		Scalable:  s.Spec.Scalable,
 		PageQuick: Window{
 			ErrorBudgetPercent: s.Spec.Page.Quick.ErrorBudgetPercent,
 			ShortWindow:        time.Duration(s.Spec.Page.Quick.ShortWindow),
@@ -0,0 +1,303 @@
+package alert_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/alert"
+)
+
+func TestFSWindowsRepo(t *testing.T) {
+	tests := map[string]struct {
+		windows string
+		expErr  bool
+	}{
+		"A catalog with page windows tighter than the ticket windows should be loaded.": {
+			windows: `
+apiVersion: sloth.slok.dev/v1
+kind: AlertWindows
+spec:
+  sloPeriod: 7d
+  page:
+    quick:
+      errorBudgetPercent: 8
+      shortWindow: 5m
+      longWindow: 1h
+    slow:
+      errorBudgetPercent: 12.5
+      shortWindow: 30m
+      longWindow: 6h
+  ticket:
+    quick:
+      errorBudgetPercent: 20
+      shortWindow: 2h
+      longWindow: 1d
+    slow:
+      errorBudgetPercent: 42
+      shortWindow: 6h
+      longWindow: 3d
+`,
+		},
+
+		"A catalog with ticket short windows tighter than the page short windows should fail.": {
+			windows: `
+apiVersion: sloth.slok.dev/v1
+kind: AlertWindows
+spec:
+  sloPeriod: 7d
+  page:
+    quick:
+      errorBudgetPercent: 8
+      shortWindow: 5m
+      longWindow: 1h
+    slow:
+      errorBudgetPercent: 12.5
+      shortWindow: 2h
+      longWindow: 6h
+  ticket:
+    quick:
+      errorBudgetPercent: 20
+      shortWindow: 30m
+      longWindow: 1d
+    slow:
+      errorBudgetPercent: 42
+      shortWindow: 6h
+      longWindow: 3d
+`,
+			expErr: true,
+		},
+
+		"A catalog with inverted page and ticket windows should fail.": {
+			windows: `
+apiVersion: sloth.slok.dev/v1
+kind: AlertWindows
+spec:
+  sloPeriod: 7d
+  page:
+    quick:
+      errorBudgetPercent: 20
+      shortWindow: 2h
+      longWindow: 1d
+    slow:
+      errorBudgetPercent: 42
+      shortWindow: 6h
+      longWindow: 3d
+  ticket:
+    quick:
+      errorBudgetPercent: 8
+      shortWindow: 5m
+      longWindow: 1h
+    slow:
+      errorBudgetPercent: 12.5
+      shortWindow: 30m
+      longWindow: 6h
+`,
+			expErr: true,
+		},
+
+		"A catalog with windows longer than the slo period should fail.": {
+			windows: `
+apiVersion: sloth.slok.dev/v1
+kind: AlertWindows
+spec:
+  sloPeriod: 2d
+  page:
+    quick:
+      errorBudgetPercent: 8
+      shortWindow: 5m
+      longWindow: 1h
+    slow:
+      errorBudgetPercent: 12.5
+      shortWindow: 30m
+      longWindow: 6h
+  ticket:
+    quick:
+      errorBudgetPercent: 20
+      shortWindow: 2h
+      longWindow: 1d
+    slow:
+      errorBudgetPercent: 42
+      shortWindow: 6h
+      longWindow: 3d
+`,
+			expErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			repo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{
+				FS: fstest.MapFS{"7d.yaml": &fstest.MapFile{Data: []byte(test.windows)}},
+			})
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				windows, err := repo.GetWindows(context.TODO(), 7*24*time.Hour)
+				require.NoError(err)
+				assert.Equal(7*24*time.Hour, windows.SLOPeriod)
+			}
+		})
+	}
+}
+
+func TestFSWindowsRepoGetWindows(t *testing.T) {
+	const windows7d = `
+apiVersion: sloth.slok.dev/v1
+kind: AlertWindows
+spec:
+  sloPeriod: 7d
+  page:
+    quick:
+      errorBudgetPercent: 8
+      shortWindow: 5m
+      longWindow: 1h
+    slow:
+      errorBudgetPercent: 12.5
+      shortWindow: 30m
+      longWindow: 6h
+  ticket:
+    quick:
+      errorBudgetPercent: 20
+      shortWindow: 2h
+      longWindow: 1d
+    slow:
+      errorBudgetPercent: 42
+      shortWindow: 6h
+      longWindow: 3d
+`
+
+	tests := map[string]struct {
+		period    time.Duration
+		expErrMsg string
+	}{
+		"Getting the windows of the catalog period should return the windows.": {
+			period: 7 * 24 * time.Hour,
+		},
+
+		"Getting the windows of a different period than the catalog one should fail.": {
+			period:    30 * 24 * time.Hour,
+			expErrMsg: "window period 30d missing, the windows catalog has the 7d periods",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			repo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{
+				FS: fstest.MapFS{"7d.yaml": &fstest.MapFile{Data: []byte(windows7d)}},
+			})
+			require.NoError(err)
+
+			windows, err := repo.GetWindows(context.TODO(), test.period)
+
+			if test.expErrMsg != "" {
+				assert.EqualError(err, test.expErrMsg)
+			} else if assert.NoError(err) {
+				assert.Equal(test.period, windows.SLOPeriod)
+			}
+		})
+	}
+}
+
+func TestFSWindowsRepoGetWindowsScalable(t *testing.T) {
+	const windows30d = `
+apiVersion: sloth.slok.dev/v1
+kind: AlertWindows
+spec:
+  sloPeriod: 30d
+  scalable: true
+  page:
+    quick:
+      errorBudgetPercent: 2
+      shortWindow: 5m
+      longWindow: 1h
+    slow:
+      errorBudgetPercent: 5
+      shortWindow: 30m
+      longWindow: 6h
+  ticket:
+    quick:
+      errorBudgetPercent: 10
+      shortWindow: 2h
+      longWindow: 1d
+    slow:
+      errorBudgetPercent: 10
+      shortWindow: 6h
+      longWindow: 3d
+`
+
+	tests := map[string]struct {
+		windows    fstest.MapFS
+		period     time.Duration
+		expWindows *alert.Windows
+		expErr     bool
+	}{
+		"Getting the windows of the scalable catalog period should return the catalog windows.": {
+			windows: fstest.MapFS{"30d.yaml": &fstest.MapFile{Data: []byte(windows30d)}},
+			period:  30 * 24 * time.Hour,
+			expWindows: &alert.Windows{
+				SLOPeriod:   30 * 24 * time.Hour,
+				Scalable:    true,
+				PageQuick:   alert.Window{ErrorBudgetPercent: 2, ShortWindow: 5 * time.Minute, LongWindow: time.Hour},
+				PageSlow:    alert.Window{ErrorBudgetPercent: 5, ShortWindow: 30 * time.Minute, LongWindow: 6 * time.Hour},
+				TicketQuick: alert.Window{ErrorBudgetPercent: 10, ShortWindow: 2 * time.Hour, LongWindow: 24 * time.Hour},
+				TicketSlow:  alert.Window{ErrorBudgetPercent: 10, ShortWindow: 6 * time.Hour, LongWindow: 72 * time.Hour},
+			},
+		},
+
+		"Getting the windows of a period without windows should scale the scalable catalog windows.": {
+			windows: fstest.MapFS{"30d.yaml": &fstest.MapFile{Data: []byte(windows30d)}},
+			period:  7 * 24 * time.Hour,
+			expWindows: &alert.Windows{
+				SLOPeriod:   7 * 24 * time.Hour,
+				Scalable:    true,
+				PageQuick:   alert.Window{ErrorBudgetPercent: 2, ShortWindow: time.Minute, LongWindow: 14 * time.Minute},
+				PageSlow:    alert.Window{ErrorBudgetPercent: 5, ShortWindow: 7 * time.Minute, LongWindow: 84 * time.Minute},
+				TicketQuick: alert.Window{ErrorBudgetPercent: 10, ShortWindow: 28 * time.Minute, LongWindow: 336 * time.Minute},
+				TicketSlow:  alert.Window{ErrorBudgetPercent: 10, ShortWindow: 84 * time.Minute, LongWindow: 1008 * time.Minute},
+			},
+		},
+
+		"Having multiple scalable catalogs should fail.": {
+			windows: fstest.MapFS{
+				"30d.yaml": &fstest.MapFile{Data: []byte(windows30d)},
+				"60d.yaml": &fstest.MapFile{Data: []byte(strings.Replace(windows30d, "sloPeriod: 30d", "sloPeriod: 60d", 1))},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			repo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{FS: test.windows})
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			windows, err := repo.GetWindows(context.TODO(), test.period)
+			require.NoError(err)
+			assert.Equal(test.expWindows, windows)
+
+			// The scaled windows burn rates should be the same as the catalog ones.
+			catalogWindows, err := repo.GetWindows(context.TODO(), 30*24*time.Hour)
+			require.NoError(err)
+			assert.InDelta(catalogWindows.GetSpeedPageQuick(), windows.GetSpeedPageQuick(), 0.01)
+		})
+	}
+}
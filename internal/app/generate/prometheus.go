@@ -3,6 +3,8 @@ package generate
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/prometheus/prometheus/model/rulefmt"
 
@@ -98,10 +100,63 @@ type Request struct {
 	ExtraLabels map[string]string
 	// IDLabels are the extra labels added to the SLOs recording rules on execution time.
 	IDLabels map[string]string
+	// ObjectiveIDLabel adds the SLO objective as an ID label on the SLO recording rules and alert selectors.
+	ObjectiveIDLabel bool
+	// ServiceNamespaces maps the SLO services to the namespace that will be stamped as the
+	// `namespace` label on all the SLO rules (e.g multi-tenant Prometheus).
+	ServiceNamespaces map[string]string
+	// ShareSLIQueries records the event SLI queries used by multiple SLOs of the group once, as
+	// shared recording rules referenced by the SLOs SLI recording rules.
+	ShareSLIQueries bool
+	// ServiceBudgetAlertThreshold when set, generates an alert for every service that fires when the
+	// worst SLO of the service has less than this percent (e.g 10) of its period error budget remaining.
+	ServiceBudgetAlertThreshold float64
+	// ServiceBudgetAlertConfig is the alert rules configuration used by the service alerts, so they
+	// have the same severity labels, receivers and default annotations as the SLO ticket alerts.
+	ServiceBudgetAlertConfig prometheus.SLOAlertRulesGeneratorConfig
+	// AlertsLimit is the max number of alerts the SLOs alert rule groups can produce (the
+	// Prometheus rule group `limit`), 0 is unlimited.
+	AlertsLimit int
+	// RecordNameTemplate is the Go template used to name the SLOs SLI recording rules, if empty
+	// the default names will be used.
+	RecordNameTemplate string
+	// GroupNameTemplate is the Go template used to name the SLOs rule groups, if empty
+	// the default names will be used.
+	GroupNameTemplate string
+	// Overrides patch the fields of the SLOs by SLO ID before the generation (e.g objectives on canary
+	// pipelines), the SLO IDs without SLOs are ignored.
+	Overrides map[string]SLOOverride
 	// SLOGroup are the SLOs group that will be used to generate the SLO results and Prom rules.
 	SLOGroup prometheus.SLOGroup
 }
 
+// SLOOverride are the SLO fields that can be overridden, the unset ones are not modified.
+type SLOOverride struct {
+	Objective          *float64
+	DisablePageAlert   *bool
+	DisableTicketAlert *bool
+}
+
+func (o SLOOverride) apply(slo prometheus.SLO) prometheus.SLO {
+	if o.Objective != nil {
+		slo.Objective = *o.Objective
+	}
+	if o.DisablePageAlert != nil {
+		slo.PageAlertMeta.Disable = *o.DisablePageAlert
+	}
+	if o.DisableTicketAlert != nil {
+		slo.TicketAlertMeta.Disable = *o.DisableTicketAlert
+	}
+
+	return slo
+}
+
+const (
+	namespaceLabelName = "namespace"
+	// reservedLabelPrefix is the prefix of the labels generated by Sloth.
+	reservedLabelPrefix = "sloth_"
+)
+
 type SLOResult struct {
 	SLO      prometheus.SLO
 	Alerts   alert.MWMBAlertGroup
@@ -113,7 +168,47 @@ type Response struct {
 }
 
 func (s Service) Generate(ctx context.Context, r Request) (*Response, error) {
-	err := r.SLOGroup.Validate()
+	// The execution labels would fight with the labels generated by Sloth.
+	err := checkReservedLabels(r.ExtraLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extra labels: %w", err)
+	}
+	err = checkReservedLabels(r.IDLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID labels: %w", err)
+	}
+
+	// Redact the marked SLI query fragments before validating, the markup is not valid PromQL.
+	slos := make([]prometheus.SLO, 0, len(r.SLOGroup.SLOs))
+	redactedRules := map[string][]rulefmt.Rule{}
+	for _, slo := range r.SLOGroup.SLOs {
+		slo, rules, err := prometheus.RedactSLO(slo)
+		if err != nil {
+			return nil, fmt.Errorf("could not redact %q slo: %w", slo.ID, err)
+		}
+		redactedRules[slo.ID] = rules
+		slos = append(slos, slo)
+	}
+	r.SLOGroup.SLOs = slos
+
+	for i, slo := range r.SLOGroup.SLOs {
+		if o, ok := r.Overrides[slo.ID]; ok {
+			r.SLOGroup.SLOs[i] = o.apply(slo)
+			s.logger.WithCtxValues(ctx).WithValues(log.Kv{"slo": slo.ID}).Infof("SLO overrides applied")
+		}
+	}
+
+	for i := range r.SLOGroup.SLOs {
+		r.SLOGroup.SLOs[i].RecordNameTemplate = r.RecordNameTemplate
+		r.SLOGroup.SLOs[i].GroupNameTemplate = r.GroupNameTemplate
+	}
+
+	var sharedQueries prometheus.SharedSLIQueries
+	if r.ShareSLIQueries {
+		r.SLOGroup.SLOs, sharedQueries = prometheus.ShareSLIQueries(r.SLOGroup.SLOs)
+	}
+
+	err = r.SLOGroup.Validate()
 	if err != nil {
 		return nil, fmt.Errorf("invalid SLO group: %w", err)
 	}
@@ -124,16 +219,83 @@ func (s Service) Generate(ctx context.Context, r Request) (*Response, error) {
 		// Add extra labels.
 		slo.Labels = mergeLabels(slo.Labels, r.ExtraLabels)
 		slo.IDLabels = r.IDLabels
+		slo.ObjectiveIDLabel = r.ObjectiveIDLabel
 
 		// Generate SLO result.
 		result, err := s.generateSLO(ctx, r.Info, slo)
 		if err != nil {
 			return nil, fmt.Errorf("could not generate %q slo: %w", slo.ID, err)
 		}
+		result.SLORules.AlertsLimit = r.AlertsLimit
+
+		// Stamp the service namespace on all the SLO rules.
+		if ns, ok := r.ServiceNamespaces[slo.Service]; ok {
+			nsLabels := map[string]string{namespaceLabelName: ns}
+			for _, rules := range [][]rulefmt.Rule{
+				result.SLORules.SLIErrorRecRules,
+				result.SLORules.MetadataRecRules,
+				result.SLORules.AlertRules,
+			} {
+				for i := range rules {
+					rules[i].Labels = mergeLabels(rules[i].Labels, nsLabels)
+				}
+			}
+		}
 
 		results = append(results, *result)
 	}
 
+	// Every SLO has the shared rules it uses, the storages store them once on their own group.
+	if len(sharedQueries) > 0 {
+		for i, res := range results {
+			sharedRules, err := sharedQueries.RecordingRules(res.SLORules.SLIErrorRecRules)
+			if err != nil {
+				return nil, fmt.Errorf("could not generate %q slo shared SLI recording rules: %w", res.SLO.ID, err)
+			}
+
+			if ns, ok := r.ServiceNamespaces[res.SLO.Service]; ok {
+				for i := range sharedRules {
+					sharedRules[i].Labels = mergeLabels(sharedRules[i].Labels, map[string]string{namespaceLabelName: ns})
+				}
+			}
+			results[i].SLORules.SharedRecRules = sharedRules
+		}
+	}
+
+	// The redacted fragments helper rules are rendered for the SLI windows used by the SLO rules.
+	for i, res := range results {
+		redacted, err := prometheus.RenderRedactedRules(redactedRules[res.SLO.ID], res.SLO, res.Alerts, res.SLORules)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate %q slo redacted recording rules: %w", res.SLO.ID, err)
+		}
+
+		if ns, ok := r.ServiceNamespaces[res.SLO.Service]; ok {
+			for i := range redacted {
+				redacted[i].Labels = mergeLabels(redacted[i].Labels, map[string]string{namespaceLabelName: ns})
+			}
+		}
+		results[i].SLORules.RedactedRecRules = redacted
+	}
+
+	results = groupDependentSLIRules(results)
+
+	// The service alerts are only generated once, along with the first SLO of every service alert rules.
+	if r.ServiceBudgetAlertThreshold > 0 {
+		services := map[string]bool{}
+		for i, res := range results {
+			if services[res.SLO.Service] {
+				continue
+			}
+			services[res.SLO.Service] = true
+
+			rule := prometheus.ServiceBudgetAlertRule(r.ServiceBudgetAlertConfig, res.SLO.Service, r.IDLabels, r.ServiceBudgetAlertThreshold)
+			if ns, ok := r.ServiceNamespaces[res.SLO.Service]; ok {
+				rule.Labels = mergeLabels(rule.Labels, map[string]string{namespaceLabelName: ns})
+			}
+			results[i].SLORules.AlertRules = append(results[i].SLORules.AlertRules, rule)
+		}
+	}
+
 	return &Response{
 		PrometheusSLOs: results,
 	}, nil
@@ -154,6 +316,16 @@ func (s Service) generateSLO(ctx context.Context, info info.Info, slo prometheus
 	}
 	logger.Infof("Multiwindow-multiburn alerts generated")
 
+	// Best effort lint, the fast burn alert of low objectives can't trigger.
+	if !slo.PageAlertMeta.Disable && !as.PageQuick.Attainable() {
+		logger.Warningf("%q SLO fast burn page alert will never trigger, it needs an error ratio above %.2f (%g burn rate factor with a %g%% objective)", slo.ID, as.PageQuick.ErrorRatioThreshold(), as.PageQuick.BurnRateFactor, slo.Objective)
+	}
+
+	// Best effort lint, the raw SLI error ratio query needs to return a ratio.
+	if slo.SLI.Raw != nil && prometheus.IsCountShapedQuery(slo.SLI.Raw.ErrorRatioQuery) {
+		logger.Warningf("%q SLO SLI error ratio query looks like it returns counts instead of a 0-1 ratio", slo.ID)
+	}
+
 	// Generate SLI recording rules.
 	sliRecordingRules, err := s.sliRecordRuleGen.GenerateSLIRecordingRules(ctx, slo, *as)
 	if err != nil {
@@ -175,17 +347,87 @@ func (s Service) generateSLO(ctx context.Context, info info.Info, slo prometheus
 	}
 	logger.WithValues(log.Kv{"rules": len(alertRules)}).Infof("SLO alert rules generated")
 
+	// The alert rules generator can return recording rules (e.g the alert firing conditions),
+	// these are stored with the metadata recordings so they don't end on the alerts group.
+	var onlyAlertRules []rulefmt.Rule
+	for _, r := range alertRules {
+		if r.Record != "" {
+			metaRecordingRules = append(metaRecordingRules, r)
+			continue
+		}
+		onlyAlertRules = append(onlyAlertRules, r)
+	}
+	alertRules = onlyAlertRules
+
 	return &SLOResult{
 		SLO:    slo,
 		Alerts: *as,
+		// The rules are stored by kind on SLI recordings, metadata recordings and alerts groups
+		// (in this order), sort them so every group rule is evaluated after its dependencies.
 		SLORules: prometheus.SLORules{
-			SLIErrorRecRules: sliRecordingRules,
-			MetadataRecRules: metaRecordingRules,
-			AlertRules:       alertRules,
+			SLIErrorRecRules: prometheus.SortRulesByDependency(sliRecordingRules),
+			MetadataRecRules: prometheus.SortRulesByDependency(metaRecordingRules),
+			AlertRules:       prometheus.SortRulesByDependency(alertRules),
 		},
 	}, nil
 }
 
+// groupDependentSLIRules moves the SLI recording rules of the SLOs that depend on other SLO SLI
+// to the SLI recording rules of the dependency chain root (after its rules). The rule groups are
+// evaluated concurrently, only the rules of the same group have a guaranteed evaluation order.
+func groupDependentSLIRules(results []SLOResult) []SLOResult {
+	index := map[string]int{}
+	for i, res := range results {
+		index[res.SLO.ID] = i
+	}
+
+	// Get the root and depth of every dependent SLO, the dependencies are already validated.
+	type dependent struct {
+		idx   int
+		root  int
+		depth int
+	}
+	dependents := []dependent{}
+	for i, res := range results {
+		if res.SLO.SLIDependsOn == "" {
+			continue
+		}
+
+		root, depth := i, 0
+		for results[root].SLO.SLIDependsOn != "" {
+			root = index[results[root].SLO.SLIDependsOn]
+			depth++
+		}
+		dependents = append(dependents, dependent{idx: i, root: root, depth: depth})
+	}
+
+	// Move the closest dependents first, so every SLI is after its dependency.
+	sort.SliceStable(dependents, func(i, j int) bool { return dependents[i].depth < dependents[j].depth })
+	for _, d := range dependents {
+		root := &results[d.root].SLORules
+		root.SLIErrorRecRules = append(root.SLIErrorRecRules, results[d.idx].SLORules.SLIErrorRecRules...)
+		results[d.idx].SLORules.SLIErrorRecRules = nil
+	}
+
+	return results
+}
+
+// checkReservedLabels returns an error if any of the labels collides with the Sloth reserved labels.
+func checkReservedLabels(labels map[string]string) error {
+	reserved := []string{}
+	for k := range labels {
+		if strings.HasPrefix(k, reservedLabelPrefix) {
+			reserved = append(reserved, k)
+		}
+	}
+	if len(reserved) == 0 {
+		return nil
+	}
+	sort.Strings(reserved)
+
+	return fmt.Errorf("label names can't use the %q prefix reserved by Sloth: %s", reservedLabelPrefix, strings.Join(reserved, ", "))
+}
+
 func mergeLabels(ms ...map[string]string) map[string]string {
 	res := map[string]string{}
 	for _, m := range ms {
@@ -2,6 +2,8 @@ package generate_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/slok/sloth/internal/alert"
 	"github.com/slok/sloth/internal/app/generate"
 	"github.com/slok/sloth/internal/info"
+	"github.com/slok/sloth/internal/log"
 	"github.com/slok/sloth/internal/prometheus"
 )
 
@@ -26,6 +29,59 @@ func TestIntegrationAppServiceGenerate(t *testing.T) {
 			expErr: true,
 		},
 
+		"Having extra labels colliding with the Sloth reserved labels it should error.": {
+			req: generate.Request{
+				ExtraLabels: map[string]string{
+					"extra_k1":      "extra_v1",
+					"sloth_service": "foo",
+				},
+				SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+					{
+						ID:      "test-id",
+						Name:    "test-name",
+						Service: "test-svc",
+						SLI: prometheus.SLI{
+							Events: &prometheus.SLIEvents{
+								ErrorQuery: `rate(my_metric{error="true"}[{{.window}}])`,
+								TotalQuery: `rate(my_metric[{{.window}}])`,
+							},
+						},
+						TimeWindow:      30 * 24 * time.Hour,
+						Objective:       99.9,
+						PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+						TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+					},
+				}},
+			},
+			expErr: true,
+		},
+
+		"Having ID labels colliding with the Sloth reserved labels it should error.": {
+			req: generate.Request{
+				IDLabels: map[string]string{
+					"sloth_id": "foo",
+				},
+				SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+					{
+						ID:      "test-id",
+						Name:    "test-name",
+						Service: "test-svc",
+						SLI: prometheus.SLI{
+							Events: &prometheus.SLIEvents{
+								ErrorQuery: `rate(my_metric{error="true"}[{{.window}}])`,
+								TotalQuery: `rate(my_metric[{{.window}}])`,
+							},
+						},
+						TimeWindow:      30 * 24 * time.Hour,
+						Objective:       99.9,
+						PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+						TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+					},
+				}},
+			},
+			expErr: true,
+		},
+
 		"Having SLOs it should generate Prometheus recording and alert rules.": {
 			req: generate.Request{
 				ExtraLabels: map[string]string{
@@ -416,3 +472,520 @@ or
 		})
 	}
 }
+
+func TestIntegrationAppServiceGenerateRedacted(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+	require.NoError(err)
+
+	svc, err := generate.NewService(generate.ServiceConfig{
+		AlertGenerator: alert.NewGenerator(windowsRepo),
+	})
+	require.NoError(err)
+
+	gotResp, err := svc.Generate(context.TODO(), generate.Request{
+		SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+			{
+				ID:      "test-id",
+				Name:    "test-name",
+				Service: "test-svc",
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorRatioQuery: `sum(rate(my_errors[{{.window}}])) / <<redact:count(up{token="s3cr3t"})>>`,
+					},
+				},
+				TimeWindow:      30 * 24 * time.Hour,
+				Objective:       99.9,
+				PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+				TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+			},
+		}},
+	})
+	require.NoError(err)
+	require.Len(gotResp.PrometheusSLOs, 1)
+
+	// The fragment should be replaced by the helper rule on the generated rules.
+	res := gotResp.PrometheusSLOs[0]
+	assert.Equal(`sum(rate(my_errors[{{.window}}])) / slo:redacted:b943442a974c`, res.SLO.SLI.Raw.ErrorRatioQuery)
+	for _, r := range res.SLORules.SLIErrorRecRules {
+		assert.NotContains(r.Expr, "s3cr3t")
+	}
+	assert.Contains(res.SLORules.SLIErrorRecRules[0].Expr, "slo:redacted:b943442a974c")
+	assert.Equal([]rulefmt.Rule{{Record: "slo:redacted:b943442a974c", Expr: `count(up{token="s3cr3t"})`}}, res.SLORules.RedactedRecRules)
+}
+
+func TestIntegrationAppServiceGenerateServiceNamespaces(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+	require.NoError(err)
+
+	svc, err := generate.NewService(generate.ServiceConfig{
+		AlertGenerator: alert.NewGenerator(windowsRepo),
+	})
+	require.NoError(err)
+
+	newSLO := func(service string) prometheus.SLO {
+		return prometheus.SLO{
+			ID:      service + "-test-name",
+			Name:    "test-name",
+			Service: service,
+			SLI: prometheus.SLI{
+				Events: &prometheus.SLIEvents{
+					ErrorQuery: `rate(my_metric{error="true"}[{{.window}}])`,
+					TotalQuery: `rate(my_metric[{{.window}}])`,
+				},
+			},
+			TimeWindow:      30 * 24 * time.Hour,
+			Objective:       99.9,
+			PageAlertMeta:   prometheus.AlertMeta{Name: "p_alert_test_name"},
+			TicketAlertMeta: prometheus.AlertMeta{Name: "t_alert_test_name"},
+		}
+	}
+
+	gotResp, err := svc.Generate(context.TODO(), generate.Request{
+		ServiceNamespaces: map[string]string{
+			"svc-a": "team-a",
+			"svc-b": "team-b",
+		},
+		SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+			newSLO("svc-a"),
+			newSLO("svc-b"),
+			newSLO("svc-c"),
+		}},
+	})
+	require.NoError(err)
+	require.Len(gotResp.PrometheusSLOs, 3)
+
+	// Every rule of the mapped services should have the namespace, the unmapped ones none.
+	expNamespaces := map[string]string{"svc-a": "team-a", "svc-b": "team-b", "svc-c": ""}
+	for _, res := range gotResp.PrometheusSLOs {
+		rules := append(append(res.SLORules.SLIErrorRecRules, res.SLORules.MetadataRecRules...), res.SLORules.AlertRules...)
+		require.NotEmpty(rules)
+		for _, r := range rules {
+			assert.Equal(expNamespaces[res.SLO.Service], r.Labels["namespace"], "%s service %s%s rule", res.SLO.Service, r.Record, r.Alert)
+		}
+	}
+}
+
+func TestIntegrationAppServiceGenerateOverrides(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+	require.NoError(err)
+
+	svc, err := generate.NewService(generate.ServiceConfig{
+		AlertGenerator: alert.NewGenerator(windowsRepo),
+	})
+	require.NoError(err)
+
+	newSLO := func(name string) prometheus.SLO {
+		return prometheus.SLO{
+			ID:      "test-svc-" + name,
+			Name:    name,
+			Service: "test-svc",
+			SLI: prometheus.SLI{
+				Events: &prometheus.SLIEvents{
+					ErrorQuery: `rate(my_metric{error="true"}[{{.window}}])`,
+					TotalQuery: `rate(my_metric[{{.window}}])`,
+				},
+			},
+			TimeWindow:      30 * 24 * time.Hour,
+			Objective:       99.9,
+			PageAlertMeta:   prometheus.AlertMeta{Name: "p_alert_test_name"},
+			TicketAlertMeta: prometheus.AlertMeta{Name: "t_alert_test_name"},
+		}
+	}
+
+	objective := 95.0
+	disable := true
+	gotResp, err := svc.Generate(context.TODO(), generate.Request{
+		Overrides: map[string]generate.SLOOverride{
+			"test-svc-slo-a":   {Objective: &objective, DisablePageAlert: &disable},
+			"test-svc-missing": {Objective: &objective},
+		},
+		SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+			newSLO("slo-a"),
+			newSLO("slo-b"),
+		}},
+	})
+	require.NoError(err)
+	require.Len(gotResp.PrometheusSLOs, 2)
+
+	// Only the overridden SLO ID should have been patched.
+	gotSLOA, gotSLOB := gotResp.PrometheusSLOs[0], gotResp.PrometheusSLOs[1]
+	assert.Equal(95.0, gotSLOA.SLO.Objective)
+	assert.InDelta(5.0, gotSLOA.Alerts.PageQuick.ErrorBudget, 0.0001)
+	assert.True(gotSLOA.SLO.PageAlertMeta.Disable)
+	assert.False(gotSLOA.SLO.TicketAlertMeta.Disable)
+	assert.Equal(99.9, gotSLOB.SLO.Objective)
+	assert.False(gotSLOB.SLO.PageAlertMeta.Disable)
+}
+
+func TestIntegrationAppServiceGenerateShareSLIQueries(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+	require.NoError(err)
+
+	svc, err := generate.NewService(generate.ServiceConfig{
+		AlertGenerator: alert.NewGenerator(windowsRepo),
+	})
+	require.NoError(err)
+
+	newSLO := func(name, errorQuery string) prometheus.SLO {
+		return prometheus.SLO{
+			ID:      "test-svc-" + name,
+			Name:    name,
+			Service: "test-svc",
+			SLI: prometheus.SLI{
+				Events: &prometheus.SLIEvents{
+					ErrorQuery: errorQuery,
+					TotalQuery: `rate(my_metric[{{.window}}])`,
+				},
+			},
+			TimeWindow:      30 * 24 * time.Hour,
+			Objective:       99.9,
+			PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+			TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+		}
+	}
+
+	gotResp, err := svc.Generate(context.TODO(), generate.Request{
+		ShareSLIQueries: true,
+		SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+			newSLO("availability", `rate(my_metric{code=~"5.."}[{{.window}}])`),
+			newSLO("throttling", `rate(my_metric{code="429"}[{{.window}}])`),
+		}},
+	})
+	require.NoError(err)
+	require.Len(gotResp.PrometheusSLOs, 2)
+
+	// Every SLO should have the shared rules it uses, apart from its SLI rules.
+	for _, res := range gotResp.PrometheusSLOs {
+		for _, r := range res.SLORules.SLIErrorRecRules {
+			assert.False(strings.HasPrefix(r.Record, "slo:shared:"), "%s %s rule", res.SLO.Name, r.Record)
+			if strings.HasPrefix(r.Record, "slo:sli_error:") {
+				assert.NotContains(r.Expr, "rate(my_metric[", "%s %s rule", res.SLO.Name, r.Record)
+			}
+		}
+
+		// A shared rule for every SLI window (the SLO period uses the optimized SLI rule).
+		assert.Len(res.SLORules.SharedRecRules, 7, res.SLO.Name)
+		for _, r := range res.SLORules.SharedRecRules {
+			assert.True(strings.HasPrefix(r.Record, "slo:shared:"), r.Record)
+			assert.Contains(r.Expr, "rate(my_metric[")
+			assert.Equal(map[string]string{"sloth_shared": "true"}, r.Labels)
+		}
+	}
+	assert.Equal(gotResp.PrometheusSLOs[0].SLORules.SharedRecRules, gotResp.PrometheusSLOs[1].SLORules.SharedRecRules)
+}
+
+func TestIntegrationAppServiceGenerateServiceBudgetAlert(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+	require.NoError(err)
+
+	svc, err := generate.NewService(generate.ServiceConfig{
+		AlertGenerator: alert.NewGenerator(windowsRepo),
+	})
+	require.NoError(err)
+
+	newSLO := func(service, name string) prometheus.SLO {
+		return prometheus.SLO{
+			ID:      service + "-" + name,
+			Name:    name,
+			Service: service,
+			SLI: prometheus.SLI{
+				Events: &prometheus.SLIEvents{
+					ErrorQuery: `rate(my_metric{error="true"}[{{.window}}])`,
+					TotalQuery: `rate(my_metric[{{.window}}])`,
+				},
+			},
+			TimeWindow:      30 * 24 * time.Hour,
+			Objective:       99.9,
+			PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+			TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+		}
+	}
+
+	gotResp, err := svc.Generate(context.TODO(), generate.Request{
+		ServiceBudgetAlertThreshold: 10,
+		ServiceNamespaces:           map[string]string{"svc-b": "team-b"},
+		SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+			newSLO("svc-a", "availability"),
+			newSLO("svc-a", "latency"),
+			newSLO("svc-b", "availability"),
+		}},
+	})
+	require.NoError(err)
+	require.Len(gotResp.PrometheusSLOs, 3)
+
+	// Every service should have one aggregate alert, on its first SLO.
+	expAlertRules := [][]rulefmt.Rule{
+		{
+			{
+				Alert: "SlothServiceErrorBudgetLow",
+				Expr:  "min by(sloth_service) (slo:period_error_budget_remaining:ratio{sloth_service=\"svc-a\"}) < 0.1\n",
+				Labels: map[string]string{
+					"sloth_severity": "ticket",
+				},
+				Annotations: map[string]string{
+					"title":   "(ticket) {{$labels.sloth_service}} service SLOs error budget is running out.",
+					"summary": "{{$labels.sloth_service}} service worst SLO has {{ $value | humanizePercentage }} of its period error budget remaining.",
+				},
+			},
+		},
+		{},
+		{
+			{
+				Alert: "SlothServiceErrorBudgetLow",
+				Expr:  "min by(sloth_service) (slo:period_error_budget_remaining:ratio{sloth_service=\"svc-b\"}) < 0.1\n",
+				Labels: map[string]string{
+					"sloth_severity": "ticket",
+					"namespace":      "team-b",
+				},
+				Annotations: map[string]string{
+					"title":   "(ticket) {{$labels.sloth_service}} service SLOs error budget is running out.",
+					"summary": "{{$labels.sloth_service}} service worst SLO has {{ $value | humanizePercentage }} of its period error budget remaining.",
+				},
+			},
+		},
+	}
+	for i, res := range gotResp.PrometheusSLOs {
+		assert.Equal(expAlertRules[i], res.SLORules.AlertRules, res.SLO.ID)
+	}
+}
+
+func TestIntegrationAppServiceGenerateAlertsPendingRecordingRules(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+	require.NoError(err)
+
+	svc, err := generate.NewService(generate.ServiceConfig{
+		AlertGenerator:         alert.NewGenerator(windowsRepo),
+		SLOAlertRulesGenerator: prometheus.NewSLOAlertRulesGenerator(prometheus.SLOAlertRulesGeneratorConfig{PendingRecordingRules: true}),
+	})
+	require.NoError(err)
+
+	gotResp, err := svc.Generate(context.TODO(), generate.Request{
+		SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+			{
+				ID:      "svc-availability",
+				Name:    "availability",
+				Service: "svc",
+				SLI: prometheus.SLI{
+					Events: &prometheus.SLIEvents{
+						ErrorQuery: `rate(my_metric{error="true"}[{{.window}}])`,
+						TotalQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+				TimeWindow:      30 * 24 * time.Hour,
+				Objective:       99.9,
+				PageAlertMeta:   prometheus.AlertMeta{Name: "PageAlert"},
+				TicketAlertMeta: prometheus.AlertMeta{Name: "TicketAlert"},
+			},
+		}},
+	})
+	require.NoError(err)
+	require.Len(gotResp.PrometheusSLOs, 1)
+
+	// The firing condition recording rules should be on the recordings, not with the alerts.
+	rules := gotResp.PrometheusSLOs[0].SLORules
+	gotAlerts := []string{}
+	for _, r := range rules.AlertRules {
+		gotAlerts = append(gotAlerts, r.Alert)
+	}
+	assert.Equal([]string{"PageAlert", "TicketAlert"}, gotAlerts)
+
+	gotConditions := []string{}
+	for _, r := range rules.MetadataRecRules {
+		if r.Record == "slo:alert_firing_condition:bool" {
+			gotConditions = append(gotConditions, r.Labels["sloth_alert"])
+		}
+	}
+	assert.Equal([]string{"PageAlert", "TicketAlert"}, gotConditions)
+}
+
+func TestIntegrationAppServiceGenerateSLIDependsOn(t *testing.T) {
+	windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+	require.NoError(t, err)
+
+	svc, err := generate.NewService(generate.ServiceConfig{
+		AlertGenerator: alert.NewGenerator(windowsRepo),
+	})
+	require.NoError(t, err)
+
+	newSLO := func(name, dependsOn string) prometheus.SLO {
+		return prometheus.SLO{
+			ID:      "svc-" + name,
+			Name:    name,
+			Service: "svc",
+			SLI: prometheus.SLI{
+				Raw: &prometheus.SLIRaw{
+					ErrorRatioQuery: `max_over_time(slo:sli_error:ratio_rate5m{sloth_service="svc"}[{{.window}}])`,
+				},
+			},
+			TimeWindow:      30 * 24 * time.Hour,
+			Objective:       99.9,
+			PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+			TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+			SLIDependsOn:    dependsOn,
+		}
+	}
+
+	tests := map[string]struct {
+		slos         []prometheus.SLO
+		expRuleSLOs  [][]string
+		expErrSubstr string
+	}{
+		"Dependent SLIs should be generated on their dependency group after its rules.": {
+			slos: []prometheus.SLO{
+				newSLO("slo-c", "svc-slo-b"),
+				newSLO("slo-a", ""),
+				newSLO("slo-b", "svc-slo-a"),
+				newSLO("slo-d", ""),
+			},
+			expRuleSLOs: [][]string{
+				{},
+				{"slo-a", "slo-b", "slo-c"},
+				{},
+				{"slo-d"},
+			},
+		},
+
+		"A missing dependency should fail.": {
+			slos: []prometheus.SLO{
+				newSLO("slo-a", "svc-missing"),
+			},
+			expErrSubstr: "sli_dependency_missing",
+		},
+
+		"A dependency cycle should fail.": {
+			slos: []prometheus.SLO{
+				newSLO("slo-a", "svc-slo-b"),
+				newSLO("slo-b", "svc-slo-a"),
+			},
+			expErrSubstr: "sli_dependency_cycle",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			gotResp, err := svc.Generate(context.TODO(), generate.Request{
+				SLOGroup: prometheus.SLOGroup{SLOs: test.slos},
+			})
+			if test.expErrSubstr != "" {
+				require.Error(err)
+				assert.Contains(err.Error(), test.expErrSubstr)
+				return
+			}
+			require.NoError(err)
+
+			// Get the SLO names of every SLI recording rules group in order.
+			gotRuleSLOs := [][]string{}
+			for _, res := range gotResp.PrometheusSLOs {
+				names := []string{}
+				for _, rule := range res.SLORules.SLIErrorRecRules {
+					if len(names) == 0 || names[len(names)-1] != rule.Labels["sloth_slo"] {
+						names = append(names, rule.Labels["sloth_slo"])
+					}
+				}
+				gotRuleSLOs = append(gotRuleSLOs, names)
+			}
+			assert.Equal(test.expRuleSLOs, gotRuleSLOs)
+		})
+	}
+}
+
+type warningsLogger struct {
+	log.Logger
+	warnings *[]string
+}
+
+func (w warningsLogger) Warningf(format string, args ...interface{}) {
+	*w.warnings = append(*w.warnings, fmt.Sprintf(format, args...))
+}
+func (w warningsLogger) WithValues(map[string]interface{}) log.Logger { return w }
+func (w warningsLogger) WithCtxValues(context.Context) log.Logger     { return w }
+
+func TestIntegrationAppServiceGenerateUnattainableAlertsLint(t *testing.T) {
+	tests := map[string]struct {
+		objective  float64
+		query      string
+		expWarning string
+	}{
+		"A 50% objective should warn about the unattainable fast burn alert.": {
+			objective:  50,
+			query:      `sum(rate(x{code=~"5.."}[{{.window}}])) / sum(rate(x[{{.window}}]))`,
+			expWarning: `"test-id" SLO fast burn page alert will never trigger`,
+		},
+
+		"A 99.9% objective should not warn.": {
+			objective: 99.9,
+			query:     `sum(rate(x{code=~"5.."}[{{.window}}])) / sum(rate(x[{{.window}}]))`,
+		},
+
+		"A count-shaped raw SLI query should warn about the query not being a ratio.": {
+			objective:  99.9,
+			query:      `sum(rate(x{code=~"5.."}[{{.window}}]))`,
+			expWarning: `"test-id" SLO SLI error ratio query looks like it returns counts instead of a 0-1 ratio`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			windowsRepo, err := alert.NewFSWindowsRepo(alert.FSWindowsRepoConfig{})
+			require.NoError(err)
+
+			warnings := []string{}
+			svc, err := generate.NewService(generate.ServiceConfig{
+				AlertGenerator: alert.NewGenerator(windowsRepo),
+				Logger:         warningsLogger{Logger: log.Noop, warnings: &warnings},
+			})
+			require.NoError(err)
+
+			_, err = svc.Generate(context.TODO(), generate.Request{
+				SLOGroup: prometheus.SLOGroup{SLOs: []prometheus.SLO{
+					{
+						ID:      "test-id",
+						Name:    "test-name",
+						Service: "test-svc",
+						SLI: prometheus.SLI{
+							Raw: &prometheus.SLIRaw{
+								ErrorRatioQuery: test.query,
+							},
+						},
+						TimeWindow:      30 * 24 * time.Hour,
+						Objective:       test.objective,
+						PageAlertMeta:   prometheus.AlertMeta{Name: "TestAlert"},
+						TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+					},
+				}},
+			})
+			require.NoError(err)
+
+			if test.expWarning != "" {
+				require.Len(warnings, 1)
+				assert.Contains(warnings[0], test.expWarning)
+			} else {
+				assert.Empty(warnings)
+			}
+		})
+	}
+}
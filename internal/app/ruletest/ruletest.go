@@ -0,0 +1,255 @@
+package ruletest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	promqlparser "github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	"gopkg.in/yaml.v2"
+
+	"github.com/slok/sloth/internal/log"
+)
+
+// Series is a synthetic metric series, it uses the promtool rules unit tests series format
+// (e.g `series: 'http_requests_total{code="500"}'` and `values: '0+10x60'`).
+type Series struct {
+	Series string `yaml:"series"`
+	Values string `yaml:"values"`
+}
+
+// Data are the synthetic metric samples the rules are evaluated against.
+type Data struct {
+	// Interval is the time between the series samples, by default 1m.
+	Interval prommodel.Duration `yaml:"interval"`
+	// EvalDuration is the time the rules will be evaluated, by default the longest series time.
+	EvalDuration prommodel.Duration `yaml:"eval_duration"`
+	InputSeries  []Series           `yaml:"input_series"`
+}
+
+func (d *Data) defaults() error {
+	if len(d.InputSeries) == 0 {
+		return fmt.Errorf("at least one input series is required")
+	}
+
+	if d.Interval == 0 {
+		d.Interval = prommodel.Duration(time.Minute)
+	}
+
+	if d.EvalDuration == 0 {
+		for _, s := range d.InputSeries {
+			_, values, err := promqlparser.ParseSeriesDesc(fmt.Sprintf("%s %s", s.Series, s.Values))
+			if err != nil {
+				return fmt.Errorf("invalid %q series: %w", s.Series, err)
+			}
+			if seriesDuration := prommodel.Duration(len(values)) * d.Interval; seriesDuration > d.EvalDuration {
+				d.EvalDuration = seriesDuration
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadData loads the synthetic samples data from YAML.
+func LoadData(data []byte) (*Data, error) {
+	d := &Data{}
+	err := yaml.UnmarshalStrict(data, d)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal YAML data: %w", err)
+	}
+
+	return d, nil
+}
+
+// ServiceConfig is the application service configuration.
+type ServiceConfig struct {
+	Logger log.Logger
+}
+
+func (c *ServiceConfig) defaults() error {
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "ruletest.Service"})
+
+	return nil
+}
+
+// Service is the application service that evaluates Prometheus rules against synthetic data.
+type Service struct {
+	logger log.Logger
+}
+
+// NewService returns a new rules test application service.
+func NewService(config ServiceConfig) (*Service, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid service configuration: %w", err)
+	}
+
+	return &Service{
+		logger: config.Logger,
+	}, nil
+}
+
+// Request is the rules evaluation request.
+type Request struct {
+	// RuleFiles are the Prometheus rule files that will be evaluated.
+	RuleFiles []string
+	// Data are the synthetic samples used by the rules.
+	Data Data
+}
+
+// FiredAlert is an alert that has been firing during the evaluation.
+type FiredAlert struct {
+	Name   string
+	Labels map[string]string
+	// FirstFiredAt and LastFiredAt are the times since the evaluation start the alert has been firing.
+	FirstFiredAt time.Duration
+	LastFiredAt  time.Duration
+}
+
+// Response is the rules evaluation response.
+type Response struct {
+	// FiredAlerts are the alerts that have been firing, sorted by the first time they fired.
+	FiredAlerts []FiredAlert
+}
+
+// Evaluate loads the data samples and evaluates the rules on every data interval, like promtool
+// rules unit tests, returning the alerts that fire.
+func (s Service) Evaluate(ctx context.Context, r Request) (*Response, error) {
+	if len(r.RuleFiles) == 0 {
+		return nil, fmt.Errorf("at least one rule file is required")
+	}
+
+	err := r.Data.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+	interval := time.Duration(r.Data.Interval)
+
+	var load strings.Builder
+	fmt.Fprintf(&load, "load %s\n", r.Data.Interval)
+	for _, s := range r.Data.InputSeries {
+		fmt.Fprintf(&load, "  %s %s\n", s.Series, s.Values)
+	}
+
+	suite, err := promql.NewLazyLoader(nil, load.String(), promql.LazyLoaderOpts{EnableAtModifier: true, EnableNegativeOffset: true})
+	if err != nil {
+		return nil, fmt.Errorf("could not load data: %w", err)
+	}
+	defer suite.Close()
+	suite.SubqueryInterval = interval
+
+	manager := rules.NewManager(&rules.ManagerOptions{
+		QueryFunc:  rules.EngineQueryFunc(suite.QueryEngine(), suite.Storage()),
+		Appendable: suite.Storage(),
+		Context:    ctx,
+		NotifyFunc: func(ctx context.Context, expr string, alerts ...*rules.Alert) {},
+		Logger:     gokitlog.NewNopLogger(),
+	})
+	groupsByName, errs := manager.LoadGroups(interval, nil, "", nil, r.RuleFiles...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("could not load rules: %w", errs[0])
+	}
+
+	// Evaluate the groups in the files order, so the alerts use the same evaluation recording rules.
+	groupOrder := map[string]int{}
+	for _, file := range r.RuleFiles {
+		ruleGroups, errs := rulefmt.ParseFile(file)
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("could not load rules: %w", errs[0])
+		}
+		for _, g := range ruleGroups.Groups {
+			groupOrder[rules.GroupKey(file, g.Name)] = len(groupOrder)
+		}
+	}
+	groups := make([]*rules.Group, 0, len(groupsByName))
+	for _, g := range groupsByName {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groupOrder[rules.GroupKey(groups[i].File(), groups[i].Name())] < groupOrder[rules.GroupKey(groups[j].File(), groups[j].Name())]
+	})
+	for _, g := range groups {
+		for _, rule := range g.Rules() {
+			// Mark the alerts as restored so they don't wait for the restoration to fire.
+			if ar, ok := rule.(*rules.AlertingRule); ok {
+				ar.SetRestored(true)
+			}
+		}
+	}
+
+	firedAlerts := map[string]*FiredAlert{}
+	start := time.Unix(0, 0).UTC()
+	end := start.Add(time.Duration(r.Data.EvalDuration))
+	for ts := start; !ts.After(end); ts = ts.Add(interval) {
+		var evalErr error
+		suite.WithSamplesTill(ts, func(err error) {
+			if err != nil {
+				evalErr = fmt.Errorf("could not load samples: %w", err)
+				return
+			}
+
+			for _, g := range groups {
+				g.Eval(suite.Context(), ts)
+				for _, rule := range g.Rules() {
+					if rule.LastError() != nil {
+						evalErr = fmt.Errorf("could not evaluate %q rule at %s: %w", rule.Name(), ts.Sub(start), rule.LastError())
+						return
+					}
+				}
+			}
+		})
+		if evalErr != nil {
+			return nil, evalErr
+		}
+
+		for _, g := range groups {
+			for _, rule := range g.Rules() {
+				ar, ok := rule.(*rules.AlertingRule)
+				if !ok {
+					continue
+				}
+
+				for _, a := range ar.ActiveAlerts() {
+					if a.State != rules.StateFiring {
+						continue
+					}
+
+					key := a.Labels.String()
+					fa, ok := firedAlerts[key]
+					if !ok {
+						fa = &FiredAlert{Name: ar.Name(), Labels: a.Labels.Map(), FirstFiredAt: ts.Sub(start)}
+						firedAlerts[key] = fa
+					}
+					fa.LastFiredAt = ts.Sub(start)
+				}
+			}
+		}
+	}
+
+	res := &Response{FiredAlerts: []FiredAlert{}}
+	for _, fa := range firedAlerts {
+		res.FiredAlerts = append(res.FiredAlerts, *fa)
+	}
+	sort.Slice(res.FiredAlerts, func(i, j int) bool {
+		a, b := res.FiredAlerts[i], res.FiredAlerts[j]
+		if a.FirstFiredAt != b.FirstFiredAt {
+			return a.FirstFiredAt < b.FirstFiredAt
+		}
+		return fmt.Sprint(a.Labels) < fmt.Sprint(b.Labels)
+	})
+
+	s.logger.WithValues(log.Kv{"alerts": len(res.FiredAlerts)}).Debugf("Rules evaluated")
+
+	return res, nil
+}
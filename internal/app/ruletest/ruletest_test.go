@@ -0,0 +1,130 @@
+package ruletest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/app/ruletest"
+)
+
+const testRules = `
+groups:
+  - name: sloth-slo-sli-recordings-svc-slo1
+    rules:
+      - record: slo:sli_error:ratio_rate5m
+        expr: sum(rate(http_requests_total{code="500"}[5m])) / sum(rate(http_requests_total[5m]))
+        labels:
+          sloth_id: svc-slo1
+  - name: sloth-slo-alerts-svc-slo1
+    rules:
+      - alert: FastBurn
+        expr: slo:sli_error:ratio_rate5m{sloth_id="svc-slo1"} > (14.4 * 0.001)
+        for: 2m
+        labels:
+          sloth_severity: page
+`
+
+func TestServiceEvaluate(t *testing.T) {
+	tests := map[string]struct {
+		data           string
+		expFiredAlerts []ruletest.FiredAlert
+		expErr         bool
+	}{
+		"Samples burning the error budget fast should fire the alert.": {
+			data: `
+interval: 1m
+input_series:
+  - series: 'http_requests_total{code="200"}'
+    values: '0+90x10'
+  - series: 'http_requests_total{code="500"}'
+    values: '0+10x10'
+`,
+			expFiredAlerts: []ruletest.FiredAlert{
+				{
+					Name: "FastBurn",
+					Labels: map[string]string{
+						"alertname":      "FastBurn",
+						"sloth_id":       "svc-slo1",
+						"sloth_severity": "page",
+					},
+					FirstFiredAt: 3 * time.Minute,
+					LastFiredAt:  11 * time.Minute,
+				},
+			},
+		},
+
+		"Samples without errors should not fire the alert.": {
+			data: `
+interval: 1m
+input_series:
+  - series: 'http_requests_total{code="200"}'
+    values: '0+100x10'
+  - series: 'http_requests_total{code="500"}'
+    values: '0+0x10'
+`,
+			expFiredAlerts: []ruletest.FiredAlert{},
+		},
+
+		"Samples that stop burning the error budget should resolve the alert.": {
+			data: `
+interval: 1m
+eval_duration: 30m
+input_series:
+  - series: 'http_requests_total{code="200"}'
+    values: '0+90x10 900+100x20'
+  - series: 'http_requests_total{code="500"}'
+    values: '0+10x10 100+0x20'
+`,
+			expFiredAlerts: []ruletest.FiredAlert{
+				{
+					Name: "FastBurn",
+					Labels: map[string]string{
+						"alertname":      "FastBurn",
+						"sloth_id":       "svc-slo1",
+						"sloth_severity": "page",
+					},
+					FirstFiredAt: 3 * time.Minute,
+					LastFiredAt:  14 * time.Minute,
+				},
+			},
+		},
+
+		"Data without series should fail.": {
+			data:   `interval: 1m`,
+			expErr: true,
+		},
+	}
+
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(testRules), 0644))
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			data, err := ruletest.LoadData([]byte(test.data))
+			require.NoError(err)
+
+			svc, err := ruletest.NewService(ruletest.ServiceConfig{})
+			require.NoError(err)
+
+			gotResp, err := svc.Evaluate(context.TODO(), ruletest.Request{
+				RuleFiles: []string{rulesFile},
+				Data:      *data,
+			})
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expFiredAlerts, gotResp.FiredAlerts)
+			}
+		})
+	}
+}
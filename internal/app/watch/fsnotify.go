@@ -0,0 +1,154 @@
+package watch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FSNotifierConfig is the configuration of FSNotifier.
+type FSNotifierConfig struct {
+	// Paths are the watched paths, directories are watched recursively.
+	Paths []string
+	// Filter is an optional filter that returns true for the file paths that need to be notified.
+	Filter func(path string) bool
+}
+
+func (c *FSNotifierConfig) defaults() error {
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("at least one path is required")
+	}
+
+	if c.Filter == nil {
+		c.Filter = func(string) bool { return true }
+	}
+
+	return nil
+}
+
+// FSNotifier is a Notifier that uses the OS file system events to notify changes
+// on the watched paths.
+type FSNotifier struct {
+	watcher *fsnotify.Watcher
+	filter  func(path string) bool
+	events  chan string
+	errors  chan error
+	done    chan struct{}
+}
+
+// NewFSNotifier returns a new FSNotifier, the new directories created while watching
+// will be watched too.
+func NewFSNotifier(config FSNotifierConfig) (*FSNotifier, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("could not create file system watcher: %w", err)
+	}
+
+	n := &FSNotifier{
+		watcher: watcher,
+		filter:  config.Filter,
+		events:  make(chan string),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+	}
+
+	for _, path := range config.Paths {
+		err := n.addRecursive(path)
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+
+	go n.run()
+
+	return n, nil
+}
+
+// Events satisfies Notifier interface.
+func (n *FSNotifier) Events() <-chan string { return n.events }
+
+// Errors satisfies Notifier interface.
+func (n *FSNotifier) Errors() <-chan error { return n.errors }
+
+// Close stops watching the paths.
+func (n *FSNotifier) Close() error {
+	close(n.done)
+	return n.watcher.Close()
+}
+
+func (n *FSNotifier) run() {
+	defer close(n.events)
+	defer close(n.errors)
+
+	for {
+		select {
+		case ev, ok := <-n.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+
+			// Watch the new directories.
+			if info, err := os.Stat(ev.Name); ev.Op.Has(fsnotify.Create) && err == nil && info.IsDir() {
+				err := n.addRecursive(ev.Name)
+				if err != nil {
+					n.sendErr(err)
+				}
+			}
+
+			if !n.filter(ev.Name) {
+				continue
+			}
+			select {
+			case n.events <- ev.Name:
+			case <-n.done:
+				return
+			}
+
+		case err, ok := <-n.watcher.Errors:
+			if !ok {
+				return
+			}
+			n.sendErr(err)
+		}
+	}
+}
+
+func (n *FSNotifier) sendErr(err error) {
+	select {
+	case n.errors <- err:
+	case <-n.done:
+	}
+}
+
+func (n *FSNotifier) addRecursive(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("could not walk %q: %w", path, err)
+		}
+
+		// Watching the directories is enough to notify the changes of their files, the root
+		// is always watched so single files inputs are supported.
+		if !d.IsDir() && path != root {
+			return nil
+		}
+
+		err = n.watcher.Add(path)
+		if err != nil {
+			return fmt.Errorf("could not watch %q: %w", path, err)
+		}
+
+		return nil
+	})
+}
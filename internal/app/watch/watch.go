@@ -0,0 +1,126 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slok/sloth/internal/log"
+)
+
+// Notifier knows how to notify file changes.
+type Notifier interface {
+	// Events returns the channel where the changed file paths are sent.
+	Events() <-chan string
+	// Errors returns the channel where the watching errors are sent.
+	Errors() <-chan error
+}
+
+// Regenerator knows how to regenerate the SLOs.
+type Regenerator interface {
+	Regenerate(ctx context.Context) error
+}
+
+// RegeneratorFunc is a helper to use functions as Regenerator.
+type RegeneratorFunc func(ctx context.Context) error
+
+func (r RegeneratorFunc) Regenerate(ctx context.Context) error { return r(ctx) }
+
+// ServiceConfig is the application service configuration.
+type ServiceConfig struct {
+	Notifier    Notifier
+	Regenerator Regenerator
+	// Debounce is the time without changes that needs to pass before regenerating, so
+	// a burst of changes (e.g editor saves) only triggers one regeneration.
+	Debounce time.Duration
+	Logger   log.Logger
+}
+
+func (c *ServiceConfig) defaults() error {
+	if c.Notifier == nil {
+		return fmt.Errorf("notifier is required")
+	}
+
+	if c.Regenerator == nil {
+		return fmt.Errorf("regenerator is required")
+	}
+
+	if c.Debounce <= 0 {
+		c.Debounce = 500 * time.Millisecond
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "watch.Service"})
+
+	return nil
+}
+
+// Service is the application service that regenerates the SLOs on file changes.
+type Service struct {
+	notifier    Notifier
+	regenerator Regenerator
+	debounce    time.Duration
+	logger      log.Logger
+}
+
+// NewService returns a new watch application service.
+func NewService(config ServiceConfig) (*Service, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Service{
+		notifier:    config.Notifier,
+		regenerator: config.Regenerator,
+		debounce:    config.Debounce,
+		logger:      config.Logger,
+	}, nil
+}
+
+// Run will regenerate the SLOs on every debounced batch of file changes until the
+// context is cancelled. Regeneration errors are logged and don't stop the watching.
+func (s Service) Run(ctx context.Context) error {
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+
+		case path, ok := <-s.notifier.Events():
+			if !ok {
+				return fmt.Errorf("notifier events channel closed")
+			}
+			s.logger.Debugf("%q file changed", path)
+
+			// Restart the debounce period on every change.
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(s.debounce)
+			timerC = timer.C
+
+		case err, ok := <-s.notifier.Errors():
+			if !ok {
+				return fmt.Errorf("notifier errors channel closed")
+			}
+			s.logger.Errorf("Watching error: %s", err)
+
+		case <-timerC:
+			timer, timerC = nil, nil
+			err := s.regenerator.Regenerate(ctx)
+			if err != nil {
+				s.logger.Errorf("Could not regenerate SLOs: %s", err)
+				continue
+			}
+			s.logger.Infof("SLOs regenerated")
+		}
+	}
+}
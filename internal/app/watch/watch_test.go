@@ -0,0 +1,110 @@
+package watch_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/app/watch"
+)
+
+type testNotifier struct {
+	events chan string
+	errors chan error
+}
+
+func (t testNotifier) Events() <-chan string { return t.events }
+func (t testNotifier) Errors() <-chan error  { return t.errors }
+
+func TestServiceRun(t *testing.T) {
+	const debounce = 20 * time.Millisecond
+
+	tests := map[string]struct {
+		// changes are the batches of changed files, every batch is notified at once and
+		// the next one is notified after the batch regeneration.
+		changes          [][]string
+		regenerateErr    error
+		expRegenerations int
+	}{
+		"Without changes it shouldn't regenerate.": {
+			changes:          [][]string{},
+			expRegenerations: 0,
+		},
+
+		"A single change should regenerate once.": {
+			changes:          [][]string{{"slos/a.yaml"}},
+			expRegenerations: 1,
+		},
+
+		"A burst of changes should be debounced and regenerate once.": {
+			changes:          [][]string{{"slos/a.yaml", "slos/a.yaml", "slos/b.yaml"}},
+			expRegenerations: 1,
+		},
+
+		"Multiple changes separated in time should regenerate on every one.": {
+			changes:          [][]string{{"slos/a.yaml"}, {"slos/b.yaml", "slos/c.yaml"}, {"slos/a.yaml"}},
+			expRegenerations: 3,
+		},
+
+		"Failing regenerations shouldn't stop the watching.": {
+			changes:          [][]string{{"slos/a.yaml"}, {"slos/b.yaml"}},
+			regenerateErr:    fmt.Errorf("something"),
+			expRegenerations: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			notifier := testNotifier{events: make(chan string), errors: make(chan error)}
+
+			var mu sync.Mutex
+			regenerations := 0
+			regenerated := make(chan struct{}, len(test.changes))
+			svc, err := watch.NewService(watch.ServiceConfig{
+				Notifier: notifier,
+				Regenerator: watch.RegeneratorFunc(func(_ context.Context) error {
+					mu.Lock()
+					regenerations++
+					mu.Unlock()
+					regenerated <- struct{}{}
+					return test.regenerateErr
+				}),
+				Debounce: debounce,
+			})
+			require.NoError(err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			runErr := make(chan error)
+			go func() { runErr <- svc.Run(ctx) }()
+
+			// Notify the changes waiting for every batch regeneration.
+			for _, batch := range test.changes {
+				for _, path := range batch {
+					notifier.events <- path
+				}
+				select {
+				case <-regenerated:
+				case <-time.After(time.Second):
+					require.FailNow("regeneration timeout")
+				}
+			}
+
+			// Give time to extra regenerations.
+			time.Sleep(3 * debounce)
+			cancel()
+			require.NoError(<-runErr)
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expRegenerations, regenerations)
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package info
 
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
 var (
 	// Version is the version app.
 	Version = "dev"
@@ -20,4 +25,17 @@ type Info struct {
 	Version string
 	Mode    Mode
 	Spec    string
+	// Source is the optional path of the spec file the SLOs come from.
+	Source string
+}
+
+// SpecHash returns a deterministic hash of the source spec and the Sloth version that generated
+// it, this way the generated rules can be checked for drift against the source without regenerating.
+func SpecHash(version string, spec []byte) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(version))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(spec)
+
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
 }
@@ -0,0 +1,61 @@
+package info_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/sloth/internal/info"
+)
+
+func TestSpecHash(t *testing.T) {
+	tests := map[string]struct {
+		versionA string
+		specA    string
+		versionB string
+		specB    string
+		expEqual bool
+	}{
+		"Identical spec and version should have the same hash.": {
+			versionA: "v1.0.0",
+			specA:    "version: prometheus/v1\nservice: svc\n",
+			versionB: "v1.0.0",
+			specB:    "version: prometheus/v1\nservice: svc\n",
+			expEqual: true,
+		},
+
+		"Different specs should have different hash.": {
+			versionA: "v1.0.0",
+			specA:    "version: prometheus/v1\nservice: svc\n",
+			versionB: "v1.0.0",
+			specB:    "version: prometheus/v1\nservice: svc2\n",
+			expEqual: false,
+		},
+
+		"Different versions should have different hash.": {
+			versionA: "v1.0.0",
+			specA:    "version: prometheus/v1\nservice: svc\n",
+			versionB: "v1.1.0",
+			specB:    "version: prometheus/v1\nservice: svc\n",
+			expEqual: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotA := info.SpecHash(test.versionA, []byte(test.specA))
+			gotB := info.SpecHash(test.versionB, []byte(test.specB))
+
+			assert.Equal(test.expEqual, gotA == gotB)
+			assert.Regexp(`^sha256:[0-9a-f]{64}$`, gotA)
+		})
+	}
+}
+
+func TestSpecHashStable(t *testing.T) {
+	got := info.SpecHash("v1.0.0", []byte("version: prometheus/v1\n"))
+	assert.Equal(t, got, info.SpecHash("v1.0.0", []byte("version: prometheus/v1\n")))
+	assert.Equal(t, "sha256:05b5306897ac6060cae8f5f21fc282313b2c96cc981b3e01f871c3aa5c59b9b6", got)
+}
@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	v1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 )
 
@@ -15,6 +17,20 @@ type PrometheusRulesEnsurer struct {
 	mock.Mock
 }
 
+// DeletePrometheusRule provides a mock function with given fields: ctx, ns, name
+func (_m *PrometheusRulesEnsurer) DeletePrometheusRule(ctx context.Context, ns string, name string) error {
+	ret := _m.Called(ctx, ns, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, ns, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // EnsurePrometheusRule provides a mock function with given fields: ctx, pr
 func (_m *PrometheusRulesEnsurer) EnsurePrometheusRule(ctx context.Context, pr *v1.PrometheusRule) error {
 	ret := _m.Called(ctx, pr)
@@ -29,6 +45,29 @@ func (_m *PrometheusRulesEnsurer) EnsurePrometheusRule(ctx context.Context, pr *
 	return r0
 }
 
+// ListPrometheusRules provides a mock function with given fields: ctx, ns, opts
+func (_m *PrometheusRulesEnsurer) ListPrometheusRules(ctx context.Context, ns string, opts metav1.ListOptions) (*v1.PrometheusRuleList, error) {
+	ret := _m.Called(ctx, ns, opts)
+
+	var r0 *v1.PrometheusRuleList
+	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions) *v1.PrometheusRuleList); ok {
+		r0 = rf(ctx, ns, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.PrometheusRuleList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, metav1.ListOptions) error); ok {
+		r1 = rf(ctx, ns, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewPrometheusRulesEnsurer interface {
 	mock.TestingT
 	Cleanup(func())
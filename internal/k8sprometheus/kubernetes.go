@@ -69,6 +69,19 @@ func (k KubernetesService) EnsurePrometheusRule(ctx context.Context, pr *monitor
 	return nil
 }
 
+func (k KubernetesService) ListPrometheusRules(ctx context.Context, ns string, opts metav1.ListOptions) (*monitoringv1.PrometheusRuleList, error) {
+	return k.monitoringCli.MonitoringV1().PrometheusRules(ns).List(ctx, opts)
+}
+
+func (k KubernetesService) DeletePrometheusRule(ctx context.Context, ns, name string) error {
+	err := k.monitoringCli.MonitoringV1().PrometheusRules(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !kubeerrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
 // EnsurePrometheusServiceLevelStatus updates the status of a PrometheusServiceLeve, be aware that updating
 // an status will trigger a watch update event on a controller.
 // In case of no error we will update "last correct Prometheus operation rules generated" TS so we can be in
@@ -117,6 +130,15 @@ func (d DryRunKubernetesService) EnsurePrometheusRule(_ context.Context, _ *moni
 	return nil
 }
 
+func (d DryRunKubernetesService) ListPrometheusRules(ctx context.Context, ns string, opts metav1.ListOptions) (*monitoringv1.PrometheusRuleList, error) {
+	return d.svc.ListPrometheusRules(ctx, ns, opts)
+}
+
+func (d DryRunKubernetesService) DeletePrometheusRule(_ context.Context, _, _ string) error {
+	d.logger.Infof("Dry run DeletePrometheusRule")
+	return nil
+}
+
 func (d DryRunKubernetesService) EnsurePrometheusServiceLevelStatus(_ context.Context, _ *slothv1.PrometheusServiceLevel, _ error) error {
 	d.logger.Infof("Dry run EnsurePrometheusServiceLevelStatus")
 	return nil
@@ -149,6 +171,14 @@ func (f FakeKubernetesService) EnsurePrometheusRule(ctx context.Context, pr *mon
 	return f.ksvc.EnsurePrometheusRule(ctx, pr)
 }
 
+func (f FakeKubernetesService) ListPrometheusRules(ctx context.Context, ns string, opts metav1.ListOptions) (*monitoringv1.PrometheusRuleList, error) {
+	return f.ksvc.ListPrometheusRules(ctx, ns, opts)
+}
+
+func (f FakeKubernetesService) DeletePrometheusRule(ctx context.Context, ns, name string) error {
+	return f.ksvc.DeletePrometheusRule(ctx, ns, name)
+}
+
 func (f FakeKubernetesService) EnsurePrometheusServiceLevelStatus(ctx context.Context, slo *slothv1.PrometheusServiceLevel, err error) error {
 	return f.ksvc.EnsurePrometheusServiceLevelStatus(ctx, slo, err)
 }
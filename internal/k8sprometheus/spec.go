@@ -1,12 +1,15 @@
 package k8sprometheus
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"regexp"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/slok/sloth/internal/prometheus"
 	k8sprometheusv1 "github.com/slok/sloth/pkg/kubernetes/api/sloth/v1"
@@ -22,6 +25,7 @@ type SLIPluginRepo interface {
 type YAMLSpecLoader struct {
 	windowPeriod time.Duration
 	pluginsRepo  SLIPluginRepo
+	idGenerator  prometheus.IDGenerator
 	decoder      runtime.Decoder
 }
 
@@ -30,20 +34,116 @@ func NewYAMLSpecLoader(pluginsRepo SLIPluginRepo, windowPeriod time.Duration) YA
 	return YAMLSpecLoader{
 		windowPeriod: windowPeriod,
 		pluginsRepo:  pluginsRepo,
+		idGenerator:  prometheus.DefaultIDGenerator,
 		decoder:      scheme.Codecs.UniversalDeserializer(),
 	}
 }
 
+// WithIDGenerator returns a copy of the loader that will use a custom SLO ID generator.
+func (y YAMLSpecLoader) WithIDGenerator(idGenerator prometheus.IDGenerator) YAMLSpecLoader {
+	y.idGenerator = idGenerator
+	return y
+}
+
 var (
 	specTypeV1RegexKind       = regexp.MustCompile(`(?m)^kind: +['"]?PrometheusServiceLevel['"]? *$`)
 	specTypeV1RegexAPIVersion = regexp.MustCompile(`(?m)^apiVersion: +['"]?sloth.slok.dev\/v1['"]? *$`)
+
+	// Kubernetes lists (e.g: `kubectl get -o yaml`) have the CRs indented as items.
+	specTypeListRegexKind         = regexp.MustCompile(`(?m)^kind: +['"]?List['"]? *$`)
+	specTypeListV1RegexKind       = regexp.MustCompile(`(?m)^[ \-]+kind: +['"]?PrometheusServiceLevel['"]? *$`)
+	specTypeListV1RegexAPIVersion = regexp.MustCompile(`(?m)^[ \-]+apiVersion: +['"]?sloth.slok.dev\/v1['"]? *$`)
 )
 
 func (y YAMLSpecLoader) IsSpecType(_ context.Context, data []byte) bool {
+	data = prometheus.NormalizeSpecData(data)
+	if isSpecList(data) {
+		return specTypeListV1RegexKind.Match(data) && specTypeListV1RegexAPIVersion.Match(data)
+	}
+
 	return specTypeV1RegexKind.Match(data) && specTypeV1RegexAPIVersion.Match(data)
 }
 
+func isSpecList(data []byte) bool {
+	return specTypeListRegexKind.Match(data)
+}
+
+// LoadSpecs loads the spec like LoadSpec, but it also supports Kubernetes `List` specs, returning
+// an SLO group for every PrometheusServiceLevel item (the items of other kinds are ignored).
+func (y YAMLSpecLoader) LoadSpecs(ctx context.Context, data []byte) ([]*SLOGroup, error) {
+	data = prometheus.NormalizeSpecData(data)
+	if !isSpecList(data) {
+		sloGroup, err := y.LoadSpec(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		return []*SLOGroup{sloGroup}, nil
+	}
+
+	kslos, err := y.decodeSpecList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sloGroups := []*SLOGroup{}
+	for _, kslo := range kslos {
+		sloGroup, err := y.loadKubernetesSpec(ctx, kslo)
+		if err != nil {
+			return nil, fmt.Errorf("could not load %q list item: %w", kslo.Name, err)
+		}
+		sloGroups = append(sloGroups, sloGroup)
+	}
+
+	return sloGroups, nil
+}
+
 func (y YAMLSpecLoader) LoadSpec(ctx context.Context, data []byte) (*SLOGroup, error) {
+	kslo, err := y.decodeSpec(prometheus.NormalizeSpecData(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return y.loadKubernetesSpec(ctx, kslo)
+}
+
+// SLIPluginRefs returns the SLI plugins referenced by the spec SLOs (including `List` specs)
+// without executing them, so the plugins can be checked before loading the specs.
+func (y YAMLSpecLoader) SLIPluginRefs(ctx context.Context, data []byte) ([]prometheus.SLIPluginRef, error) {
+	data = prometheus.NormalizeSpecData(data)
+	var kslos []*k8sprometheusv1.PrometheusServiceLevel
+	if isSpecList(data) {
+		l, err := y.decodeSpecList(data)
+		if err != nil {
+			return nil, err
+		}
+		kslos = l
+	} else {
+		kslo, err := y.decodeSpec(data)
+		if err != nil {
+			return nil, err
+		}
+		kslos = append(kslos, kslo)
+	}
+
+	refs := []prometheus.SLIPluginRef{}
+	for _, kslo := range kslos {
+		for _, specSLO := range kslo.Spec.SLOs {
+			if specSLO.SLI.Plugin == nil {
+				continue
+			}
+
+			id, err := y.idGenerator.GenerateSLOID(ctx, kslo.Spec.Service, specSLO.Name)
+			if err != nil {
+				return nil, fmt.Errorf("could not generate %q SLO ID: %w", specSLO.Name, err)
+			}
+			refs = append(refs, prometheus.SLIPluginRef{PluginID: specSLO.SLI.Plugin.ID, SLOID: id})
+		}
+	}
+
+	return refs, nil
+}
+
+func (y YAMLSpecLoader) decodeSpec(data []byte) (*k8sprometheusv1.PrometheusServiceLevel, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("spec is required")
 	}
@@ -58,12 +158,49 @@ func (y YAMLSpecLoader) LoadSpec(ctx context.Context, data []byte) (*SLOGroup, e
 		return nil, fmt.Errorf("can't type assert runtime.Object to v1.PrometheusServiceLeve")
 	}
 
+	return kslo, nil
+}
+
+// decodeSpecList decodes the PrometheusServiceLevel items of a Kubernetes `List` spec.
+func (y YAMLSpecLoader) decodeSpecList(data []byte) ([]*k8sprometheusv1.PrometheusServiceLevel, error) {
+	list := metav1.List{}
+	err := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), len(data)).Decode(&list)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode kubernetes list %w", err)
+	}
+
+	kslos := []*k8sprometheusv1.PrometheusServiceLevel{}
+	for i, item := range list.Items {
+		obj, _, err := y.decoder.Decode(item.Raw, nil, nil)
+		if err != nil {
+			// Other kinds of the list are not registered on our decoder.
+			if runtime.IsNotRegisteredError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("could not decode kubernetes list item %d: %w", i, err)
+		}
+
+		kslo, ok := obj.(*k8sprometheusv1.PrometheusServiceLevel)
+		if !ok {
+			continue
+		}
+		kslos = append(kslos, kslo)
+	}
+
+	if len(kslos) == 0 {
+		return nil, fmt.Errorf("at least one PrometheusServiceLevel is required on the list")
+	}
+
+	return kslos, nil
+}
+
+func (y YAMLSpecLoader) loadKubernetesSpec(ctx context.Context, kslo *k8sprometheusv1.PrometheusServiceLevel) (*SLOGroup, error) {
 	// Check at least we have one SLO.
 	if len(kslo.Spec.SLOs) == 0 {
 		return nil, fmt.Errorf("at least one SLO is required")
 	}
 
-	m, err := mapSpecToModel(ctx, y.windowPeriod, y.pluginsRepo, kslo)
+	m, err := mapSpecToModel(ctx, y.windowPeriod, y.pluginsRepo, y.idGenerator, kslo)
 	if err != nil {
 		return nil, fmt.Errorf("could not map to model: %w", err)
 	}
@@ -74,6 +211,7 @@ func (y YAMLSpecLoader) LoadSpec(ctx context.Context, data []byte) (*SLOGroup, e
 type CRSpecLoader struct {
 	windowPeriod time.Duration
 	pluginsRepo  SLIPluginRepo
+	idGenerator  prometheus.IDGenerator
 }
 
 // CRSpecLoader knows how to load Kubernetes CRD specs and converts them to a model.
@@ -82,19 +220,31 @@ func NewCRSpecLoader(pluginsRepo SLIPluginRepo, windowPeriod time.Duration) CRSp
 	return CRSpecLoader{
 		windowPeriod: windowPeriod,
 		pluginsRepo:  pluginsRepo,
+		idGenerator:  prometheus.DefaultIDGenerator,
 	}
 }
 
+// WithIDGenerator returns a copy of the loader that will use a custom SLO ID generator.
+func (c CRSpecLoader) WithIDGenerator(idGenerator prometheus.IDGenerator) CRSpecLoader {
+	c.idGenerator = idGenerator
+	return c
+}
+
 func (c CRSpecLoader) LoadSpec(ctx context.Context, spec *k8sprometheusv1.PrometheusServiceLevel) (*SLOGroup, error) {
-	return mapSpecToModel(ctx, c.windowPeriod, c.pluginsRepo, spec)
+	return mapSpecToModel(ctx, c.windowPeriod, c.pluginsRepo, c.idGenerator, spec)
 }
 
-func mapSpecToModel(ctx context.Context, defaultWindowPeriod time.Duration, pluginsRepo SLIPluginRepo, kspec *k8sprometheusv1.PrometheusServiceLevel) (*SLOGroup, error) {
+func mapSpecToModel(ctx context.Context, defaultWindowPeriod time.Duration, pluginsRepo SLIPluginRepo, idGenerator prometheus.IDGenerator, kspec *k8sprometheusv1.PrometheusServiceLevel) (*SLOGroup, error) {
 	slos := make([]prometheus.SLO, 0, len(kspec.Spec.SLOs))
 	spec := kspec.Spec
 	for _, specSLO := range kspec.Spec.SLOs {
+		id, err := idGenerator.GenerateSLOID(ctx, spec.Service, specSLO.Name)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate %q SLO ID: %w", specSLO.Name, err)
+		}
+
 		slo := prometheus.SLO{
-			ID:              fmt.Sprintf("%s-%s", spec.Service, specSLO.Name),
+			ID:              id,
 			Name:            specSLO.Name,
 			Description:     specSLO.Description,
 			Service:         spec.Service,
@@ -144,6 +294,12 @@ func mapSpecToModel(ctx context.Context, defaultWindowPeriod time.Duration, plug
 				return nil, fmt.Errorf("plugin %q execution error: %w", specSLO.SLI.Plugin.ID, err)
 			}
 
+			// Fail early with the plugin context, invalid queries would fail later on the rules generation.
+			err = prometheus.ValidatePromExpr(rawQuery)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %q returned an invalid %q SLO SLI query: %w", specSLO.SLI.Plugin.ID, specSLO.Name, err)
+			}
+
 			slo.SLI.Raw = &prometheus.SLIRaw{
 				ErrorRatioQuery: rawQuery,
 			}
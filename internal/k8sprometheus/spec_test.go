@@ -113,6 +113,38 @@ spec:
 			expErr: true,
 		},
 
+		"Spec with SLI plugin that returns an invalid PromQL query should fail.": {
+			plugins: map[string]prometheus.SLIPlugin{
+				"test_plugin": {
+					ID: "test_plugin",
+					Func: func(_ context.Context, _ map[string]string, _ map[string]string, _ map[string]string) (string, error) {
+						return `sum(rate(http_requests_total{code=~"5.."}[{{.window}}])`, nil
+					},
+				},
+			},
+			specYaml: `
+apiVersion: sloth.slok.dev/v1
+kind: PrometheusServiceLevel
+metadata:
+  name: k8s-test-svc
+  namespace: test-ns
+spec:
+  service: test-svc
+  slos:
+    - name: "slo"
+      objective: 99
+      sli:
+        plugin:
+          id: test_plugin
+      alerting:
+        pageAlert:
+          disable: true
+        ticketAlert:
+          disable: true
+`,
+			expErr: true,
+		},
+
 		"Spec with SLI plugin should use the plugin correctly.": {
 			plugins: map[string]prometheus.SLIPlugin{
 				"test_plugin": {
@@ -366,6 +398,348 @@ spec:
 	}
 }
 
+func TestYAMLoadSpecAlertsDisable(t *testing.T) {
+	tests := map[string]struct {
+		pageDisable   bool
+		ticketDisable bool
+		expPage       prometheus.AlertMeta
+		expTicket     prometheus.AlertMeta
+	}{
+		"Without disabled alerts both alerts should be enabled.": {
+			pageDisable:   false,
+			ticketDisable: false,
+			expPage:       prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "pageteam"}, Annotations: map[string]string{}},
+			expTicket:     prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "ticketteam"}, Annotations: map[string]string{}},
+		},
+
+		"Disabling the page alert should only disable the page alert.": {
+			pageDisable:   true,
+			ticketDisable: false,
+			expPage:       prometheus.AlertMeta{Disable: true},
+			expTicket:     prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "ticketteam"}, Annotations: map[string]string{}},
+		},
+
+		"Disabling the ticket alert should only disable the ticket alert.": {
+			pageDisable:   false,
+			ticketDisable: true,
+			expPage:       prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "pageteam"}, Annotations: map[string]string{}},
+			expTicket:     prometheus.AlertMeta{Disable: true},
+		},
+
+		"Disabling both alerts should disable both alerts.": {
+			pageDisable:   true,
+			ticketDisable: true,
+			expPage:       prometheus.AlertMeta{Disable: true},
+			expTicket:     prometheus.AlertMeta{Disable: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			specYaml := fmt.Sprintf(`
+apiVersion: sloth.slok.dev/v1
+kind: PrometheusServiceLevel
+metadata:
+  name: k8s-test-svc
+  namespace: test-ns
+spec:
+  service: test-svc
+  slos:
+    - name: "slo1"
+      objective: 99
+      sli:
+        raw:
+          errorRatioQuery: test_expr_ratio
+      alerting:
+        name: MyAlert
+        pageAlert:
+          disable: %t
+          labels:
+            severity: pageteam
+        ticketAlert:
+          disable: %t
+          labels:
+            severity: ticketteam
+`, test.pageDisable, test.ticketDisable)
+
+			loader := k8sprometheus.NewYAMLSpecLoader(testMemPluginsRepo(nil), 30*24*time.Hour)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+
+			if assert.NoError(err) && assert.Len(gotModel.SLOs, 1) {
+				assert.Equal(test.expPage, gotModel.SLOs[0].PageAlertMeta)
+				assert.Equal(test.expTicket, gotModel.SLOs[0].TicketAlertMeta)
+			}
+		})
+	}
+}
+
+func TestYAMLoadSpecs(t *testing.T) {
+	tests := map[string]struct {
+		specYaml  string
+		expGroups map[string][]string
+		expErr    bool
+	}{
+		"A single CR should load a single SLO group.": {
+			specYaml: `
+apiVersion: sloth.slok.dev/v1
+kind: PrometheusServiceLevel
+metadata:
+  name: k8s-test-svc
+  namespace: test-ns
+spec:
+  service: test-svc
+  slos:
+    - name: "slo1"
+      objective: 99
+      sli:
+        raw:
+          errorRatioQuery: test_expr_ratio
+      alerting:
+        pageAlert:
+          disable: true
+        ticketAlert:
+          disable: true
+`,
+			expGroups: map[string][]string{"k8s-test-svc": {"test-svc-slo1"}},
+		},
+
+		"A list with multiple CRs should load an SLO group for every CR.": {
+			specYaml: `
+apiVersion: v1
+kind: List
+metadata:
+  resourceVersion: ""
+items:
+- apiVersion: sloth.slok.dev/v1
+  kind: PrometheusServiceLevel
+  metadata:
+    name: k8s-test-svc1
+    namespace: test-ns
+  spec:
+    service: test-svc1
+    slos:
+    - name: slo1
+      objective: 99
+      sli:
+        raw:
+          errorRatioQuery: test_expr_ratio
+      alerting:
+        pageAlert:
+          disable: true
+        ticketAlert:
+          disable: true
+- apiVersion: sloth.slok.dev/v1
+  kind: PrometheusServiceLevel
+  metadata:
+    name: k8s-test-svc2
+    namespace: test-ns
+  spec:
+    service: test-svc2
+    slos:
+    - name: slo1
+      objective: 99
+      sli:
+        raw:
+          errorRatioQuery: test_expr_ratio
+      alerting:
+        pageAlert:
+          disable: true
+        ticketAlert:
+          disable: true
+    - name: slo2
+      objective: 99.9
+      sli:
+        raw:
+          errorRatioQuery: test_expr_ratio
+      alerting:
+        pageAlert:
+          disable: true
+        ticketAlert:
+          disable: true
+`,
+			expGroups: map[string][]string{
+				"k8s-test-svc1": {"test-svc1-slo1"},
+				"k8s-test-svc2": {"test-svc2-slo1", "test-svc2-slo2"},
+			},
+		},
+
+		"A list with other kinds should ignore them.": {
+			specYaml: `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: test
+- apiVersion: sloth.slok.dev/v1
+  kind: PrometheusServiceLevel
+  metadata:
+    name: k8s-test-svc
+    namespace: test-ns
+  spec:
+    service: test-svc
+    slos:
+    - name: slo1
+      objective: 99
+      sli:
+        raw:
+          errorRatioQuery: test_expr_ratio
+      alerting:
+        pageAlert:
+          disable: true
+        ticketAlert:
+          disable: true
+`,
+			expGroups: map[string][]string{"k8s-test-svc": {"test-svc-slo1"}},
+		},
+
+		"A list without CRs should fail.": {
+			specYaml: `
+apiVersion: v1
+kind: List
+items: []
+`,
+			expErr: true,
+		},
+
+		"A list with an invalid CR should fail.": {
+			specYaml: `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: sloth.slok.dev/v1
+  kind: PrometheusServiceLevel
+  metadata:
+    name: k8s-test-svc
+    namespace: test-ns
+  spec:
+    service: test-svc
+    slos: []
+`,
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := k8sprometheus.NewYAMLSpecLoader(testMemPluginsRepo(nil), 30*24*time.Hour)
+			gotModels, err := loader.LoadSpecs(context.TODO(), []byte(test.specYaml))
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				gotGroups := map[string][]string{}
+				for _, m := range gotModels {
+					for _, slo := range m.SLOs {
+						gotGroups[m.K8sMeta.Name] = append(gotGroups[m.K8sMeta.Name], slo.ID)
+					}
+				}
+				assert.Equal(test.expGroups, gotGroups)
+			}
+		})
+	}
+}
+
+func TestYAMLSLIPluginRefs(t *testing.T) {
+	tests := map[string]struct {
+		specYaml string
+		expRefs  []prometheus.SLIPluginRef
+		expErr   bool
+	}{
+		"A single CR should return the SLI plugin references.": {
+			specYaml: `
+apiVersion: sloth.slok.dev/v1
+kind: PrometheusServiceLevel
+metadata:
+  name: k8s-test-svc
+spec:
+  service: test-svc
+  slos:
+    - name: "slo1"
+      objective: 99
+      sli:
+        plugin:
+          id: test_plugin
+      alerting:
+        name: Alert1
+    - name: "slo2"
+      objective: 99
+      sli:
+        raw:
+          errorRatioQuery: test_expr_ratio
+      alerting:
+        name: Alert2
+`,
+			expRefs: []prometheus.SLIPluginRef{{PluginID: "test_plugin", SLOID: "test-svc-slo1"}},
+		},
+
+		"A list with multiple CRs should return the SLI plugin references of every CR.": {
+			specYaml: `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: sloth.slok.dev/v1
+  kind: PrometheusServiceLevel
+  metadata:
+    name: k8s-test-svc1
+  spec:
+    service: test-svc1
+    slos:
+    - name: slo1
+      objective: 99
+      sli:
+        plugin:
+          id: test_plugin1
+      alerting:
+        name: Alert1
+- apiVersion: sloth.slok.dev/v1
+  kind: PrometheusServiceLevel
+  metadata:
+    name: k8s-test-svc2
+  spec:
+    service: test-svc2
+    slos:
+    - name: slo1
+      objective: 99
+      sli:
+        plugin:
+          id: test_plugin2
+      alerting:
+        name: Alert1
+`,
+			expRefs: []prometheus.SLIPluginRef{
+				{PluginID: "test_plugin1", SLOID: "test-svc1-slo1"},
+				{PluginID: "test_plugin2", SLOID: "test-svc2-slo1"},
+			},
+		},
+
+		"An invalid spec should fail.": {
+			specYaml: `{`,
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := k8sprometheus.NewYAMLSpecLoader(testMemPluginsRepo(nil), 30*24*time.Hour)
+			gotRefs, err := loader.SLIPluginRefs(context.TODO(), []byte(test.specYaml))
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expRefs, gotRefs)
+			}
+		})
+	}
+}
+
 func TestYAMLIsSpecType(t *testing.T) {
 	tests := map[string]struct {
 		specYaml string
@@ -428,6 +802,28 @@ kind:               PrometheusServiceLevel
 `,
 			exp: true,
 		},
+
+		"A list with CRs should match": {
+			specYaml: `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: sloth.slok.dev/v1
+  kind: PrometheusServiceLevel
+`,
+			exp: true,
+		},
+
+		"A list without CRs shouldn't match": {
+			specYaml: `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+`,
+			exp: false,
+		},
 	}
 
 	for name, test := range tests {
@@ -3,8 +3,16 @@ package k8sprometheus
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/prometheus/prometheus/model/rulefmt"
@@ -13,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/slok/sloth/internal/info"
 	"github.com/slok/sloth/internal/log"
@@ -36,9 +45,18 @@ func NewIOWriterPrometheusOperatorYAMLRepo(writer io.Writer, logger log.Logger)
 // IOWriterPrometheusOperatorYAMLRepo knows to store all the SLO rules (recordings and alerts)
 // grouped in an IOWriter in Kubernetes prometheus operator YAML format.
 type IOWriterPrometheusOperatorYAMLRepo struct {
-	writer  io.Writer
-	encoder runtime.Encoder
-	logger  log.Logger
+	writer      io.Writer
+	encoder     runtime.Encoder
+	splitPerSLO bool
+	logger      log.Logger
+}
+
+// WithSplitPerSLO returns a copy of the repository that will store a PrometheusRule per SLO
+// instead of a single one for all the SLO group, the CRs will be named `<name>-<slo name>`.
+// This is useful for big SLO groups that would exceed the Kubernetes object size limits.
+func (i IOWriterPrometheusOperatorYAMLRepo) WithSplitPerSLO(split bool) IOWriterPrometheusOperatorYAMLRepo {
+	i.splitPerSLO = split
+	return i
 }
 
 type StorageSLO struct {
@@ -47,15 +65,21 @@ type StorageSLO struct {
 }
 
 func (i IOWriterPrometheusOperatorYAMLRepo) StoreSLOs(ctx context.Context, kmeta K8sMeta, slos []StorageSLO) error {
-	rule, err := mapModelToPrometheusOperator(ctx, kmeta, slos)
+	rules, err := mapModelToPrometheusOperatorRules(ctx, kmeta, slos, i.splitPerSLO)
 	if err != nil {
-		return fmt.Errorf("could not map model to Prometheus operator CR: %w", err)
+		return err
 	}
 
 	var b bytes.Buffer
-	err = i.encoder.Encode(rule, &b)
-	if err != nil {
-		return fmt.Errorf("could encode prometheus operator object: %w", err)
+	for idx, rule := range rules {
+		if idx > 0 {
+			b.WriteString("---\n")
+		}
+
+		err := i.encoder.Encode(rule, &b)
+		if err != nil {
+			return fmt.Errorf("could encode prometheus operator object: %w", err)
+		}
 	}
 
 	rulesYaml := writeTopDisclaimer(b.Bytes())
@@ -67,11 +91,104 @@ func (i IOWriterPrometheusOperatorYAMLRepo) StoreSLOs(ctx context.Context, kmeta
 	return nil
 }
 
+// mapModelToPrometheusOperatorRules maps the SLOs to a single Prometheus operator CR, or a CR
+// per SLO named `<name>-<slo name>` if split. When split, the shared SLI recordings used by the
+// SLOs are on their own `<name>-shared-recordings` CR so they are evaluated only once.
+func mapModelToPrometheusOperatorRules(ctx context.Context, kmeta K8sMeta, slos []StorageSLO, splitPerSLO bool) ([]*monitoringv1.PrometheusRule, error) {
+	if !splitPerSLO {
+		rule, err := mapModelToPrometheusOperator(ctx, kmeta, slos)
+		if err != nil {
+			return nil, fmt.Errorf("could not map model to Prometheus operator CR: %w", err)
+		}
+		return []*monitoringv1.PrometheusRule{rule}, nil
+	}
+
+	if len(slos) == 0 {
+		return nil, fmt.Errorf("slo rules required")
+	}
+
+	rules := []*monitoringv1.PrometheusRule{}
+	sharedRules := make([][]rulefmt.Rule, 0, len(slos))
+	for _, slo := range slos {
+		sharedRules = append(sharedRules, slo.Rules.SharedRecRules)
+		slo.Rules.SharedRecRules = nil
+
+		sloKmeta := kmeta
+		sloKmeta.Name = perSLOPrometheusRuleName(kmeta.Name, slo.SLO.Name)
+		rule, err := mapModelToPrometheusOperator(ctx, sloKmeta, []StorageSLO{slo})
+		if err != nil {
+			// SLOs without rules are ignored, we only fail if all the SLOs don't have rules.
+			if errors.Is(err, ErrNoSLORules) {
+				continue
+			}
+			return nil, fmt.Errorf("could not map model to Prometheus operator CR: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if shared := prometheus.MergeSharedRecRules(sharedRules...); len(shared) > 0 {
+		sharedKmeta := kmeta
+		sharedKmeta.Name = sharedPrometheusRuleName(kmeta.Name)
+		rule, err := mapModelToPrometheusOperator(ctx, sharedKmeta, []StorageSLO{{Rules: prometheus.SLORules{SharedRecRules: shared}}})
+		if err != nil {
+			return nil, fmt.Errorf("could not map model to Prometheus operator CR: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 0 {
+		return nil, ErrNoSLORules
+	}
+
+	return rules, nil
+}
+
+// GeneratedPrometheusRuleNames returns the names of the Prometheus operator rule CRs that can be
+// generated for the SLOs of a spec, the CRs of the SLOs without rules are not generated.
+func GeneratedPrometheusRuleNames(name string, sloNames []string, splitPerSLO bool) []string {
+	if !splitPerSLO {
+		return []string{name}
+	}
+
+	names := make([]string, 0, len(sloNames)+1)
+	for _, sloName := range sloNames {
+		names = append(names, perSLOPrometheusRuleName(name, sloName))
+	}
+	names = append(names, sharedPrometheusRuleName(name))
+
+	return names
+}
+
+// perSLOPrometheusRuleName returns the SLO Prometheus operator rule CR name, the SLO names can have
+// characters and lengths that are not valid on Kubernetes names, so these are sanitized to a DNS-1123
+// subdomain with a hash suffix of the original name to avoid collisions.
+func perSLOPrometheusRuleName(name, sloName string) string {
+	ruleName := fmt.Sprintf("%s-%s", name, sloName)
+	if len(k8svalidation.IsDNS1123Subdomain(ruleName)) == 0 {
+		return ruleName
+	}
+
+	sanitized := strings.Trim(invalidK8sNameCharsRegexp.ReplaceAllString(strings.ToLower(ruleName), "-"), "-")
+	hash := sha256.Sum256([]byte(ruleName))
+	suffix := "-" + hex.EncodeToString(hash[:])[:8]
+	if maxLen := k8svalidation.DNS1123SubdomainMaxLength - len(suffix); len(sanitized) > maxLen {
+		sanitized = strings.TrimRight(sanitized[:maxLen], "-")
+	}
+
+	return sanitized + suffix
+}
+
+var invalidK8sNameCharsRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+
+func sharedPrometheusRuleName(name string) string {
+	return fmt.Sprintf("%s-shared-recordings", name)
+}
+
 func mapModelToPrometheusOperator(_ context.Context, kmeta K8sMeta, slos []StorageSLO) (*monitoringv1.PrometheusRule, error) {
 	// Add extra labels.
 	labels := map[string]string{
-		"app.kubernetes.io/component":  "SLO",
-		"app.kubernetes.io/managed-by": "sloth",
+		"app.kubernetes.io/component": "SLO",
+		managedByLabelName:            managedByLabelValue,
 	}
 	for k, v := range kmeta.Labels {
 		labels[k] = v
@@ -94,24 +211,35 @@ func mapModelToPrometheusOperator(_ context.Context, kmeta K8sMeta, slos []Stora
 		return nil, fmt.Errorf("slo rules required")
 	}
 
+	sharedRules := make([][]rulefmt.Rule, 0, len(slos))
+	for _, slo := range slos {
+		sharedRules = append(sharedRules, slo.Rules.SharedRecRules)
+	}
+	if shared := prometheus.MergeSharedRecRules(sharedRules...); len(shared) > 0 {
+		rule.Spec.Groups = append(rule.Spec.Groups, monitoringv1.RuleGroup{
+			Name:  prometheus.SharedRecordingsGroupName,
+			Rules: promRulesToKubeRules(shared),
+		})
+	}
+
 	for _, slo := range slos {
 		if len(slo.Rules.SLIErrorRecRules) > 0 {
 			rule.Spec.Groups = append(rule.Spec.Groups, monitoringv1.RuleGroup{
-				Name:  fmt.Sprintf("sloth-slo-sli-recordings-%s", slo.SLO.ID),
+				Name:  slo.SLO.GetRuleGroupName(prometheus.GroupKindSLIRecordings),
 				Rules: promRulesToKubeRules(slo.Rules.SLIErrorRecRules),
 			})
 		}
 
 		if len(slo.Rules.MetadataRecRules) > 0 {
 			rule.Spec.Groups = append(rule.Spec.Groups, monitoringv1.RuleGroup{
-				Name:  fmt.Sprintf("sloth-slo-meta-recordings-%s", slo.SLO.ID),
+				Name:  slo.SLO.GetRuleGroupName(prometheus.GroupKindMetaRecordings),
 				Rules: promRulesToKubeRules(slo.Rules.MetadataRecRules),
 			})
 		}
 
 		if len(slo.Rules.AlertRules) > 0 {
 			rule.Spec.Groups = append(rule.Spec.Groups, monitoringv1.RuleGroup{
-				Name:  fmt.Sprintf("sloth-slo-alerts-%s", slo.SLO.ID),
+				Name:  slo.SLO.GetRuleGroupName(prometheus.GroupKindAlerts),
 				Rules: promRulesToKubeRules(slo.Rules.AlertRules),
 			})
 		}
@@ -167,36 +295,181 @@ func NewPrometheusOperatorCRDRepo(ensurer PrometheusRulesEnsurer, logger log.Log
 // PrometheusOperatorCRDRepo knows to store all the SLO rules (recordings and alerts)
 // grouped as a Kubernetes prometheus operator CR using Kubernetes API server.
 type PrometheusOperatorCRDRepo struct {
-	logger  log.Logger
-	ensurer PrometheusRulesEnsurer
+	logger           log.Logger
+	ensurer          PrometheusRulesEnsurer
+	retries          int
+	retryBackoff     time.Duration
+	splitPerSLO      bool
+	applyConcurrency int
+}
+
+// WithRetry returns a copy of the repository that will retry the Prometheus operator rule CR ensure
+// operations the number of times, waiting between retries with an exponential backoff starting at
+// initialBackoff.
+func (p PrometheusOperatorCRDRepo) WithRetry(retries int, initialBackoff time.Duration) PrometheusOperatorCRDRepo {
+	p.retries = retries
+	p.retryBackoff = initialBackoff
+	return p
+}
+
+// WithSplitPerSLO returns a copy of the repository that will ensure a PrometheusRule per SLO
+// instead of a single one for all the SLO group, the CRs will be named `<name>-<slo name>`.
+func (p PrometheusOperatorCRDRepo) WithSplitPerSLO(split bool) PrometheusOperatorCRDRepo {
+	p.splitPerSLO = split
+	return p
+}
+
+// WithApplyConcurrency returns a copy of the repository that will ensure up to concurrency
+// Prometheus operator rule CRs at the same time, by default they are ensured one by one.
+func (p PrometheusOperatorCRDRepo) WithApplyConcurrency(concurrency int) PrometheusOperatorCRDRepo {
+	p.applyConcurrency = concurrency
+	return p
 }
 
 type PrometheusRulesEnsurer interface {
 	EnsurePrometheusRule(ctx context.Context, pr *monitoringv1.PrometheusRule) error
+	ListPrometheusRules(ctx context.Context, ns string, opts metav1.ListOptions) (*monitoringv1.PrometheusRuleList, error)
+	DeletePrometheusRule(ctx context.Context, ns, name string) error
 }
 
 //go:generate mockery --case underscore --output k8sprometheusmock --outpkg k8sprometheusmock --name PrometheusRulesEnsurer
 
 func (p PrometheusOperatorCRDRepo) StoreSLOs(ctx context.Context, kmeta K8sMeta, slos []StorageSLO) error {
 	// Map to the Prometheus operator CRD.
-	rule, err := mapModelToPrometheusOperator(ctx, kmeta, slos)
+	rules, err := mapModelToPrometheusOperatorRules(ctx, kmeta, slos, p.splitPerSLO)
 	if err != nil {
-		return fmt.Errorf("could not map model to Prometheus operator CR: %w", err)
+		return err
+	}
+
+	for _, rule := range rules {
+		// Add object reference.
+		rule.ObjectMeta.OwnerReferences = append(rule.ObjectMeta.OwnerReferences, metav1.OwnerReference{
+			Kind:       kmeta.Kind,
+			APIVersion: kmeta.APIVersion,
+			Name:       kmeta.Name,
+			UID:        types.UID(kmeta.UID),
+		})
+
+		// Stamp the ownership so Sloth managed rules can be found.
+		annotations := map[string]string{}
+		for k, v := range rule.ObjectMeta.Annotations {
+			annotations[k] = v
+		}
+		annotations[specSourceAnnotationName] = fmt.Sprintf("%s/%s", kmeta.Kind, kmeta.Name)
+		rule.ObjectMeta.Annotations = annotations
+		rule.ObjectMeta.Labels[managedByLabelName] = managedByLabelValue
+	}
+
+	// Create on API server, with bounded concurrency.
+	concurrency := p.applyConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+	for _, rule := range rules {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(rule *monitoringv1.PrometheusRule) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := p.ensurePrometheusRule(ctx, rule)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("could not ensure %q Prometheus operator rule CR: %w", rule.Name, err))
+				mu.Unlock()
+			}
+		}(rule)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// ensurePrometheusRule will ensure the rule retrying with exponential backoff on errors.
+func (p PrometheusOperatorCRDRepo) ensurePrometheusRule(ctx context.Context, rule *monitoringv1.PrometheusRule) error {
+	backoff := p.retryBackoff
+	for i := 0; ; i++ {
+		err := p.ensurer.EnsurePrometheusRule(ctx, rule)
+		if err == nil || i >= p.retries {
+			return err
+		}
+
+		p.logger.WithValues(log.Kv{"retry": i + 1, "backoff": backoff}).Warningf("Could not ensure Prometheus operator rule CR, retrying: %s", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
+}
+
+const (
+	managedByLabelName       = "app.kubernetes.io/managed-by"
+	managedByLabelValue      = "sloth"
+	specSourceAnnotationName = "sloth.slok.dev/spec-source"
+)
 
-	// Add object reference.
-	rule.ObjectMeta.OwnerReferences = append(rule.ObjectMeta.OwnerReferences, metav1.OwnerReference{
-		Kind:       kmeta.Kind,
-		APIVersion: kmeta.APIVersion,
-		Name:       kmeta.Name,
-		UID:        types.UID(kmeta.UID),
+// ListOrphanedPrometheusRules lists the Prometheus operator rule CRs on the namespace that have been
+// created by the Sloth controller (using the spec source annotation) and are not part of the current
+// generated rules, these are indexed by spec source (e.g: `PrometheusServiceLevel/my-slos`) and
+// have the generated rule names of the source.
+//
+// Rules only having the managed-by label (e.g: applied from `sloth generate` output) are ignored.
+func (p PrometheusOperatorCRDRepo) ListOrphanedPrometheusRules(ctx context.Context, ns string, current map[string][]string) ([]string, error) {
+	currentNames := map[string]map[string]struct{}{}
+	for source, names := range current {
+		currentNames[source] = map[string]struct{}{}
+		for _, name := range names {
+			currentNames[source][name] = struct{}{}
+		}
+	}
+
+	rules, err := p.ensurer.ListPrometheusRules(ctx, ns, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", managedByLabelName, managedByLabelValue),
 	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list Prometheus operator rule CRs: %w", err)
+	}
 
-	// Create on API server.
-	err = p.ensurer.EnsurePrometheusRule(ctx, rule)
+	orphaned := []string{}
+	for _, rule := range rules.Items {
+		source, ok := rule.Annotations[specSourceAnnotationName]
+		if !ok {
+			continue
+		}
+		if _, ok := currentNames[source][rule.Name]; ok {
+			continue
+		}
+		orphaned = append(orphaned, rule.Name)
+	}
+	sort.Strings(orphaned)
+
+	return orphaned, nil
+}
+
+// PruneOrphanedPrometheusRules deletes the Prometheus operator rule CRs on the namespace that have been
+// created by the Sloth controller and are not part of the current generated rules of their spec source.
+// Returns the deleted rule names.
+func (p PrometheusOperatorCRDRepo) PruneOrphanedPrometheusRules(ctx context.Context, ns string, current map[string][]string) ([]string, error) {
+	orphaned, err := p.ListOrphanedPrometheusRules(ctx, ns, current)
 	if err != nil {
-		return fmt.Errorf("could not ensure Prometheus operator rule CR: %w", err)
+		return nil, err
 	}
 
-	return nil
+	for _, name := range orphaned {
+		err := p.ensurer.DeletePrometheusRule(ctx, ns, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not delete %q Prometheus operator rule CR: %w", name, err)
+		}
+		p.logger.WithValues(log.Kv{"namespace": ns, "name": name}).Infof("Orphaned Prometheus operator rule CR pruned")
+	}
+
+	return orphaned, nil
 }
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/prometheus/prometheus/model/rulefmt"
@@ -13,6 +16,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/slok/sloth/internal/k8sprometheus"
 	"github.com/slok/sloth/internal/k8sprometheus/k8sprometheusmock"
@@ -502,7 +506,10 @@ func TestPrometheusOperatorCRDRepo(t *testing.T) {
 							"app.kubernetes.io/component":  "SLO",
 							"app.kubernetes.io/managed-by": "sloth",
 						},
-						Annotations: map[string]string{"ak1": "av1"},
+						Annotations: map[string]string{
+							"ak1":                        "av1",
+							"sloth.slok.dev/spec-source": "test-kind/test-name",
+						},
 						OwnerReferences: []metav1.OwnerReference{
 							{
 								Kind:       "test-kind",
@@ -598,6 +605,32 @@ func TestPrometheusOperatorCRDRepo(t *testing.T) {
 				m.On("EnsurePrometheusRule", mock.Anything, exp).Once().Return(nil)
 			},
 		},
+
+		"Having custom ownership labels should be stamped with Sloth ownership labels and annotations.": {
+			k8sMeta: k8sprometheus.K8sMeta{
+				Name:       "test-name",
+				Namespace:  "test-ns",
+				Labels:     map[string]string{"app.kubernetes.io/managed-by": "someone"},
+				Kind:       "PrometheusServiceLevel",
+				APIVersion: "sloth.slok.dev/v1",
+			},
+			slos: []k8sprometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "testa"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{
+							{Record: "test:record-a1", Expr: "test-expr-a1"},
+						},
+					},
+				},
+			},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("EnsurePrometheusRule", mock.Anything, mock.MatchedBy(func(pr *monitoringv1.PrometheusRule) bool {
+					return pr.Labels["app.kubernetes.io/managed-by"] == "sloth" &&
+						pr.Annotations["sloth.slok.dev/spec-source"] == "PrometheusServiceLevel/test-name"
+				})).Once().Return(nil)
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -620,3 +653,413 @@ func TestPrometheusOperatorCRDRepo(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusOperatorCRDRepoPruneOrphaned(t *testing.T) {
+	listOpts := metav1.ListOptions{LabelSelector: "app.kubernetes.io/managed-by=sloth"}
+	newRule := func(name, source string) *monitoringv1.PrometheusRule {
+		r := &monitoringv1.PrometheusRule{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"}}
+		if source != "" {
+			r.ObjectMeta.Annotations = map[string]string{"sloth.slok.dev/spec-source": source}
+		}
+		return r
+	}
+	newRuleList := func(rules ...*monitoringv1.PrometheusRule) *monitoringv1.PrometheusRuleList {
+		return &monitoringv1.PrometheusRuleList{Items: rules}
+	}
+
+	tests := map[string]struct {
+		current     map[string][]string
+		mock        func(m *k8sprometheusmock.PrometheusRulesEnsurer)
+		expOrphaned []string
+		expErr      bool
+	}{
+		"Having an error while listing the rules should fail.": {
+			current: map[string][]string{"PrometheusServiceLevel/test-1": {"test-1"}},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("ListPrometheusRules", mock.Anything, "test-ns", listOpts).Once().Return(nil, fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+
+		"Not having orphaned rules shouldn't delete anything.": {
+			current: map[string][]string{"PrometheusServiceLevel/test-1": {"test-1"}, "PrometheusServiceLevel/test-2": {"test-2-slo1", "test-2-shared-recordings"}},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("ListPrometheusRules", mock.Anything, "test-ns", listOpts).Once().Return(newRuleList(
+					newRule("test-1", "PrometheusServiceLevel/test-1"),
+					newRule("test-2-slo1", "PrometheusServiceLevel/test-2"),
+				), nil)
+			},
+			expOrphaned: []string{},
+		},
+
+		"Having orphaned rules should delete the orphaned ones.": {
+			current: map[string][]string{"PrometheusServiceLevel/test-2": {"test-2"}},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("ListPrometheusRules", mock.Anything, "test-ns", listOpts).Once().Return(newRuleList(
+					newRule("test-3", "PrometheusServiceLevel/test-3"),
+					newRule("test-2", "PrometheusServiceLevel/test-2"),
+					newRule("test-1", "PrometheusServiceLevel/test-1"),
+				), nil)
+				m.On("DeletePrometheusRule", mock.Anything, "test-ns", "test-1").Once().Return(nil)
+				m.On("DeletePrometheusRule", mock.Anything, "test-ns", "test-3").Once().Return(nil)
+			},
+			expOrphaned: []string{"test-1", "test-3"},
+		},
+
+		"Having managed-by labeled rules without the spec source annotation shouldn't delete them.": {
+			current: map[string][]string{"PrometheusServiceLevel/test-2": {"test-2"}},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("ListPrometheusRules", mock.Anything, "test-ns", listOpts).Once().Return(newRuleList(
+					newRule("test-1", ""),
+					newRule("test-2", "PrometheusServiceLevel/test-2"),
+					newRule("test-3", "PrometheusServiceLevel/test-3"),
+				), nil)
+				m.On("DeletePrometheusRule", mock.Anything, "test-ns", "test-3").Once().Return(nil)
+			},
+			expOrphaned: []string{"test-3"},
+		},
+
+		"Having rules with the same name as a current source but different source should delete them.": {
+			current: map[string][]string{"PrometheusServiceLevel/test-1": {"test-1"}},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("ListPrometheusRules", mock.Anything, "test-ns", listOpts).Once().Return(newRuleList(
+					newRule("test-1", "PrometheusServiceLevel/test-2"),
+				), nil)
+				m.On("DeletePrometheusRule", mock.Anything, "test-ns", "test-1").Once().Return(nil)
+			},
+			expOrphaned: []string{"test-1"},
+		},
+
+		"Having per SLO rules of removed SLOs from a current source should delete them.": {
+			current: map[string][]string{"PrometheusServiceLevel/test-1": {"test-1-slo1", "test-1-shared-recordings"}},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("ListPrometheusRules", mock.Anything, "test-ns", listOpts).Once().Return(newRuleList(
+					newRule("test-1-slo1", "PrometheusServiceLevel/test-1"),
+					newRule("test-1-slo2", "PrometheusServiceLevel/test-1"),
+				), nil)
+				m.On("DeletePrometheusRule", mock.Anything, "test-ns", "test-1-slo2").Once().Return(nil)
+			},
+			expOrphaned: []string{"test-1-slo2"},
+		},
+
+		"Having an error while deleting orphaned rules should fail.": {
+			current: map[string][]string{"PrometheusServiceLevel/test-2": {"test-2"}},
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("ListPrometheusRules", mock.Anything, "test-ns", listOpts).Once().Return(newRuleList(
+					newRule("test-1", "PrometheusServiceLevel/test-1"),
+					newRule("test-2", "PrometheusServiceLevel/test-2"),
+				), nil)
+				m.On("DeletePrometheusRule", mock.Anything, "test-ns", "test-1").Once().Return(fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mpre := &k8sprometheusmock.PrometheusRulesEnsurer{}
+			test.mock(mpre)
+
+			repo := k8sprometheus.NewPrometheusOperatorCRDRepo(mpre, log.Noop)
+			gotOrphaned, err := repo.PruneOrphanedPrometheusRules(context.TODO(), "test-ns", test.current)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expOrphaned, gotOrphaned)
+			}
+			mpre.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPrometheusOperatorCRDRepoRetry(t *testing.T) {
+	slos := []k8sprometheus.StorageSLO{
+		{
+			SLO: prometheus.SLO{ID: "testa"},
+			Rules: prometheus.SLORules{
+				SLIErrorRecRules: []rulefmt.Rule{
+					{Record: "test:record-a1", Expr: "test-expr-a1"},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		retries int
+		mock    func(m *k8sprometheusmock.PrometheusRulesEnsurer)
+		expErr  bool
+	}{
+		"Not having retries and an error while ensuring should fail.": {
+			retries: 0,
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("EnsurePrometheusRule", mock.Anything, mock.Anything).Once().Return(fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+
+		"Having transient errors while ensuring should retry until success.": {
+			retries: 3,
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("EnsurePrometheusRule", mock.Anything, mock.Anything).Twice().Return(fmt.Errorf("something"))
+				m.On("EnsurePrometheusRule", mock.Anything, mock.Anything).Once().Return(nil)
+			},
+		},
+
+		"Having errors while ensuring after exhausting the retries should fail.": {
+			retries: 2,
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("EnsurePrometheusRule", mock.Anything, mock.Anything).Times(3).Return(fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mpre := &k8sprometheusmock.PrometheusRulesEnsurer{}
+			test.mock(mpre)
+
+			repo := k8sprometheus.NewPrometheusOperatorCRDRepo(mpre, log.Noop).WithRetry(test.retries, time.Millisecond)
+			err := repo.StoreSLOs(context.TODO(), k8sprometheus.K8sMeta{Name: "test-name", Kind: "test-kind"}, slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mpre.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPrometheusOperatorCRDRepoApplyConcurrency(t *testing.T) {
+	slos := []k8sprometheus.StorageSLO{}
+	for _, name := range []string{"slo-a", "slo-b", "slo-c", "slo-d", "slo-e"} {
+		slos = append(slos, k8sprometheus.StorageSLO{
+			SLO: prometheus.SLO{ID: name, Name: name},
+			Rules: prometheus.SLORules{
+				SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+			},
+		})
+	}
+	ruleNamed := func(name string) interface{} {
+		return mock.MatchedBy(func(pr *monitoringv1.PrometheusRule) bool { return pr.Name == name })
+	}
+
+	tests := map[string]struct {
+		concurrency int
+		mock        func(m *k8sprometheusmock.PrometheusRulesEnsurer)
+		expErr      []string
+	}{
+		"Without concurrency all the CRs should be ensured.": {
+			concurrency: 0,
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				for _, slo := range slos {
+					m.On("EnsurePrometheusRule", mock.Anything, ruleNamed("test-name-"+slo.SLO.Name)).Once().Return(nil)
+				}
+			},
+		},
+
+		"With concurrency all the CRs should be ensured.": {
+			concurrency: 3,
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				for _, slo := range slos {
+					m.On("EnsurePrometheusRule", mock.Anything, ruleNamed("test-name-"+slo.SLO.Name)).Once().Return(nil)
+				}
+			},
+		},
+
+		"With concurrency and errors while ensuring, all the CRs should be ensured and the errors aggregated.": {
+			concurrency: 3,
+			mock: func(m *k8sprometheusmock.PrometheusRulesEnsurer) {
+				m.On("EnsurePrometheusRule", mock.Anything, ruleNamed("test-name-slo-a")).Once().Return(nil)
+				m.On("EnsurePrometheusRule", mock.Anything, ruleNamed("test-name-slo-b")).Once().Return(fmt.Errorf("something"))
+				m.On("EnsurePrometheusRule", mock.Anything, ruleNamed("test-name-slo-c")).Once().Return(nil)
+				m.On("EnsurePrometheusRule", mock.Anything, ruleNamed("test-name-slo-d")).Once().Return(fmt.Errorf("something"))
+				m.On("EnsurePrometheusRule", mock.Anything, ruleNamed("test-name-slo-e")).Once().Return(nil)
+			},
+			expErr: []string{`"test-name-slo-b"`, `"test-name-slo-d"`},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks, track the concurrent ensures.
+			var mu sync.Mutex
+			running, maxRunning := 0, 0
+			mpre := &k8sprometheusmock.PrometheusRulesEnsurer{}
+			test.mock(mpre)
+			for _, c := range mpre.ExpectedCalls {
+				c.Run(func(_ mock.Arguments) {
+					mu.Lock()
+					running++
+					if running > maxRunning {
+						maxRunning = running
+					}
+					mu.Unlock()
+					time.Sleep(5 * time.Millisecond)
+					mu.Lock()
+					running--
+					mu.Unlock()
+				})
+			}
+
+			repo := k8sprometheus.NewPrometheusOperatorCRDRepo(mpre, log.Noop).
+				WithSplitPerSLO(true).
+				WithApplyConcurrency(test.concurrency)
+			err := repo.StoreSLOs(context.TODO(), k8sprometheus.K8sMeta{Name: "test-name", Kind: "test-kind"}, slos)
+
+			if len(test.expErr) > 0 {
+				if assert.Error(err) {
+					for _, exp := range test.expErr {
+						assert.Contains(err.Error(), exp)
+					}
+				}
+			} else {
+				assert.NoError(err)
+			}
+			mpre.AssertExpectations(t)
+			assert.LessOrEqual(maxRunning, max(test.concurrency, 1))
+		})
+	}
+}
+
+func TestIOWriterPrometheusOperatorYAMLRepoSplit(t *testing.T) {
+	slos := []k8sprometheus.StorageSLO{
+		{
+			SLO: prometheus.SLO{ID: "testa", Name: "slo-a"},
+			Rules: prometheus.SLORules{
+				SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record-a", Expr: "test-expr-a"}},
+			},
+		},
+		{
+			SLO: prometheus.SLO{ID: "testb", Name: "slo-b"},
+			Rules: prometheus.SLORules{
+				AlertRules: []rulefmt.Rule{{Alert: "testAlertB", Expr: "test-expr-b"}},
+			},
+		},
+		{
+			SLO: prometheus.SLO{ID: "testc", Name: "slo-c"},
+		},
+	}
+
+	tests := map[string]struct {
+		splitPerSLO bool
+		slos        []k8sprometheus.StorageSLO
+		expNames    []string
+		expShared   int
+		expErr      bool
+	}{
+		"Having the per group mode, should render a single CR.": {
+			splitPerSLO: false,
+			slos:        slos,
+			expNames:    []string{"test-name"},
+		},
+
+		"Having the per SLO mode, should render a CR per SLO with rules.": {
+			splitPerSLO: true,
+			slos:        slos,
+			expNames:    []string{"test-name-slo-a", "test-name-slo-b"},
+		},
+
+		"Having the per SLO mode with shared SLI rules, should render the shared rules on their own CR once.": {
+			splitPerSLO: true,
+			slos: []k8sprometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "testa", Name: "slo-a"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record-a", Expr: "test-expr-a"}},
+						SharedRecRules:   []rulefmt.Rule{{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: "test-shared-expr"}},
+					},
+				},
+				{
+					SLO: prometheus.SLO{ID: "testb", Name: "slo-b"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record-b", Expr: "test-expr-b"}},
+						SharedRecRules:   []rulefmt.Rule{{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: "test-shared-expr"}},
+					},
+				},
+			},
+			expNames:  []string{"test-name-slo-a", "test-name-slo-b", "test-name-shared-recordings"},
+			expShared: 1,
+		},
+
+		"Having the per SLO mode without SLO rules, should fail.": {
+			splitPerSLO: true,
+			slos:        []k8sprometheus.StorageSLO{{SLO: prometheus.SLO{ID: "testc", Name: "slo-c"}}},
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotYAML bytes.Buffer
+			repo := k8sprometheus.NewIOWriterPrometheusOperatorYAMLRepo(&gotYAML, log.Noop).WithSplitPerSLO(test.splitPerSLO)
+			err := repo.StoreSLOs(context.TODO(), k8sprometheus.K8sMeta{Name: "test-name", Namespace: "test-ns"}, test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				got := gotYAML.String()
+				assert.Equal(len(test.expNames), strings.Count(got, "kind: PrometheusRule"))
+				for _, n := range test.expNames {
+					assert.Contains(got, fmt.Sprintf("  name: %s\n", n))
+				}
+				assert.Equal(test.expShared, strings.Count(got, "record: slo:shared:"))
+				assert.Equal(test.expShared, strings.Count(got, "name: sloth-slo-shared-recordings\n"))
+			}
+		})
+	}
+}
+
+func TestGeneratedPrometheusRuleNames(t *testing.T) {
+	tests := map[string]struct {
+		sloNames    []string
+		splitPerSLO bool
+		expNames    []string
+	}{
+		"Not split should have a single rule.": {
+			sloNames: []string{"slo1", "slo2"},
+			expNames: []string{"test"},
+		},
+
+		"Split per SLO should have a rule per SLO and the shared rule.": {
+			sloNames:    []string{"slo1", "slo2"},
+			splitPerSLO: true,
+			expNames:    []string{"test-slo1", "test-slo2", "test-shared-recordings"},
+		},
+
+		"Split per SLO with SLO names that are not valid Kubernetes names should sanitize them.": {
+			sloNames:    []string{"Requests_Availability", "requests.latency_p99"},
+			splitPerSLO: true,
+			expNames:    []string{"test-requests-availability-3a09adbb", "test-requests-latency-p99-211acb9d", "test-shared-recordings"},
+		},
+
+		"Split per SLO with long SLO names should truncate them.": {
+			sloNames:    []string{strings.Repeat("a", 300)},
+			splitPerSLO: true,
+			expNames:    []string{"test-" + strings.Repeat("a", 239) + "-9c15a5bc", "test-shared-recordings"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotNames := k8sprometheus.GeneratedPrometheusRuleNames("test", test.sloNames, test.splitPerSLO)
+			assert.Equal(t, test.expNames, gotNames)
+			for _, n := range gotNames {
+				assert.Empty(t, k8svalidation.IsDNS1123Subdomain(n))
+			}
+		})
+	}
+}
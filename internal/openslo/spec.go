@@ -10,38 +10,80 @@ import (
 	"time"
 
 	openslov1alpha "github.com/OpenSLO/oslo/pkg/manifest/v1alpha"
+	prommodel "github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
+	"github.com/slok/sloth/internal/alert"
 	"github.com/slok/sloth/internal/prometheus"
 )
 
 type YAMLSpecLoader struct {
-	windowPeriod time.Duration
+	windowPeriod   time.Duration
+	idGenerator    prometheus.IDGenerator
+	experimentalV2 bool
+	strict         bool
 }
 
 // YAMLSpecLoader knows how to load YAML specs and converts them to a model.
 func NewYAMLSpecLoader(windowPeriod time.Duration) YAMLSpecLoader {
 	return YAMLSpecLoader{
 		windowPeriod: windowPeriod,
+		idGenerator:  prometheus.DefaultIDGenerator,
 	}
 }
 
+// WithIDGenerator returns a copy of the loader that will use a custom SLO ID generator.
+func (y YAMLSpecLoader) WithIDGenerator(idGenerator prometheus.IDGenerator) YAMLSpecLoader {
+	y.idGenerator = idGenerator
+	return y
+}
+
+// WithExperimentalV2 returns a copy of the loader that will also load the experimental OpenSLO v2alpha specs.
+// Only a subset of the spec is supported, unsupported fields will make the spec load fail.
+func (y YAMLSpecLoader) WithExperimentalV2(enabled bool) YAMLSpecLoader {
+	y.experimentalV2 = enabled
+	return y
+}
+
+// WithStrict returns a copy of the loader that will fail loading the specs with unknown fields
+// instead of ignoring them (e.g: typos on the field names).
+func (y YAMLSpecLoader) WithStrict(enabled bool) YAMLSpecLoader {
+	y.strict = enabled
+	return y
+}
+
 var (
 	specTypeV1AlphaRegexKind       = regexp.MustCompile(`(?m)^kind: +['"]?SLO['"]? *$`)
 	specTypeV1AlphaRegexAPIVersion = regexp.MustCompile(`(?m)^apiVersion: +['"]?openslo\/v1alpha['"]? *$`)
+	specTypeV2AlphaRegexAPIVersion = regexp.MustCompile(`(?m)^apiVersion: +['"]?openslo\.com\/v2alpha['"]? *$`)
 )
 
 func (y YAMLSpecLoader) IsSpecType(_ context.Context, data []byte) bool {
-	return specTypeV1AlphaRegexKind.Match(data) && specTypeV1AlphaRegexAPIVersion.Match(data)
+	data = prometheus.NormalizeSpecData(data)
+	if !specTypeV1AlphaRegexKind.Match(data) {
+		return false
+	}
+
+	return specTypeV1AlphaRegexAPIVersion.Match(data) || (y.experimentalV2 && specTypeV2AlphaRegexAPIVersion.Match(data))
 }
 
-func (y YAMLSpecLoader) LoadSpec(_ context.Context, data []byte) (*prometheus.SLOGroup, error) {
+func (y YAMLSpecLoader) LoadSpec(ctx context.Context, data []byte) (*prometheus.SLOGroup, error) {
+	data = prometheus.NormalizeSpecData(data)
 	if len(data) == 0 {
 		return nil, fmt.Errorf("spec is required")
 	}
 
+	if y.experimentalV2 && specTypeV2AlphaRegexAPIVersion.Match(data) {
+		return y.loadSpecV2Alpha(ctx, data)
+	}
+
+	unmarshal := yaml.Unmarshal
+	if y.strict {
+		unmarshal = yaml.UnmarshalStrict
+	}
+
 	s := openslov1alpha.SLO{}
-	err := yaml.Unmarshal(data, &s)
+	err := unmarshal(data, &s)
 	if err != nil {
 		return nil, fmt.Errorf("could not unmarshall YAML spec correctly: %w", err)
 	}
@@ -62,7 +104,15 @@ func (y YAMLSpecLoader) LoadSpec(_ context.Context, data []byte) (*prometheus.SL
 		return nil, fmt.Errorf("invalid SLO time windows: %w", err)
 	}
 
-	m, err := y.mapSpecToModel(s)
+	// The OpenSLO types can't tell an explicit `counter: false` from a missing counter,
+	// so we get them apart.
+	counters := ratioCountersV1Alpha{}
+	err = yaml.Unmarshal(data, &counters)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshall YAML spec correctly: %w", err)
+	}
+
+	m, err := y.mapSpecToModel(ctx, s, counters)
 	if err != nil {
 		return nil, fmt.Errorf("could not map to model: %w", err)
 	}
@@ -70,8 +120,34 @@ func (y YAMLSpecLoader) LoadSpec(_ context.Context, data []byte) (*prometheus.SL
 	return m, nil
 }
 
-func (y YAMLSpecLoader) mapSpecToModel(spec openslov1alpha.SLO) (*prometheus.SLOGroup, error) {
-	slos, err := y.getSLOs(spec)
+// ratioCountersV1Alpha are the explicitly set ratio metrics counters of the v1alpha spec objectives.
+type ratioCountersV1Alpha struct {
+	Spec struct {
+		Objectives []struct {
+			RatioMetrics *struct {
+				Counter *bool `yaml:"counter"`
+			} `yaml:"ratioMetrics"`
+		} `yaml:"objectives"`
+	} `yaml:"spec"`
+}
+
+// isGauge returns true if the objective ratio metrics have been explicitly set as non counters.
+func (r ratioCountersV1Alpha) isGauge(idx int) bool {
+	if idx >= len(r.Spec.Objectives) || r.Spec.Objectives[idx].RatioMetrics == nil {
+		return false
+	}
+
+	return isGaugeRatio(r.Spec.Objectives[idx].RatioMetrics.Counter)
+}
+
+// isGaugeRatio returns true when the ratio metric counter is explicitly disabled, a missing
+// counter is handled as a counter so the queries are used as they are (e.g with `rate`).
+func isGaugeRatio(counter *bool) bool {
+	return counter != nil && !*counter
+}
+
+func (y YAMLSpecLoader) mapSpecToModel(ctx context.Context, spec openslov1alpha.SLO, counters ratioCountersV1Alpha) (*prometheus.SLOGroup, error) {
+	slos, err := y.getSLOs(ctx, spec, counters)
 	if err != nil {
 		return nil, fmt.Errorf("could not map SLOs correctly: %w", err)
 	}
@@ -79,8 +155,17 @@ func (y YAMLSpecLoader) mapSpecToModel(spec openslov1alpha.SLO) (*prometheus.SLO
 	return &prometheus.SLOGroup{SLOs: slos}, nil
 }
 
-// validateTimeWindow will validate that Sloth only supports 30 day based time windows
-// we need this because time windows are a required by OpenSLO.
+// supportedTimeWindows returns the SLO time windows Sloth has alert windows for on
+// its default catalog.
+func supportedTimeWindows() []time.Duration {
+	return []time.Duration{
+		28 * 24 * time.Hour,
+		30 * 24 * time.Hour,
+	}
+}
+
+// validateTimeWindow will validate that Sloth only supports day based time windows of the
+// supported windows set, we need this because time windows are a required by OpenSLO.
 func (YAMLSpecLoader) validateTimeWindow(spec openslov1alpha.SLO) error {
 	if len(spec.Spec.TimeWindows) == 0 {
 		return nil
@@ -95,7 +180,16 @@ func (YAMLSpecLoader) validateTimeWindow(spec openslov1alpha.SLO) error {
 		return fmt.Errorf("only days based time windows are supported")
 	}
 
-	return nil
+	window := time.Duration(t.Count) * 24 * time.Hour
+	supported := []string{}
+	for _, w := range supportedTimeWindows() {
+		if w == window {
+			return nil
+		}
+		supported = append(supported, fmt.Sprintf("%dd", w/(24*time.Hour)))
+	}
+
+	return fmt.Errorf("%dd time window is not supported, supported time windows are: %s", t.Count, strings.Join(supported, ", "))
 }
 
 var errorRatioRawQueryTpl = template.Must(template.New("").Parse(`
@@ -110,11 +204,25 @@ var errorRatioRawQueryTpl = template.Must(template.New("").Parse(`
   )
 `))
 
+// errorRatioGaugeRawQueryTpl is the same as errorRatioRawQueryTpl for non counter metrics, these
+// metrics are not rate based, so the good and total values are averaged over the window.
+var errorRatioGaugeRawQueryTpl = template.Must(template.New("").Parse(`
+  1 - (
+    (
+      avg_over_time(({{ .good }})[{{"{{.window}}"}}:])
+    )
+    /
+    (
+      avg_over_time(({{ .total }})[{{"{{.window}}"}}:])
+    )
+  )
+`))
+
 // getSLI gets the SLI from the OpenSLO slo objective, we only support ratio based openSLO objectives,
 // however we will convert to a raw based sloth SLI because the ratio queries that we have differ from
 // Sloth. Sloth uses bad/total events, OpenSLO uses good/total events. We get the ratio using good events
-// and then rest to 1, to get a raw error ratio query.
-func (y YAMLSpecLoader) getSLI(_ openslov1alpha.SLOSpec, slo openslov1alpha.Objective) (*prometheus.SLI, error) {
+// and then rest to 1, to get a raw error ratio query. Non counter (gauge) metrics are averaged over the window.
+func (y YAMLSpecLoader) getSLI(_ openslov1alpha.SLOSpec, slo openslov1alpha.Objective, gauge bool) (*prometheus.SLI, error) {
 	if slo.RatioMetrics == nil {
 		return nil, fmt.Errorf("missing ratioMetrics")
 	}
@@ -139,8 +247,12 @@ func (y YAMLSpecLoader) getSLI(_ openslov1alpha.SLOSpec, slo openslov1alpha.Obje
 	}
 
 	// Map as good and total events as a raw query.
+	tpl := errorRatioRawQueryTpl
+	if gauge {
+		tpl = errorRatioGaugeRawQueryTpl
+	}
 	var b bytes.Buffer
-	err := errorRatioRawQueryTpl.Execute(&b, map[string]string{"good": good.Query, "total": total.Query})
+	err := tpl.Execute(&b, map[string]string{"good": good.Query, "total": total.Query})
 	if err != nil {
 		return nil, fmt.Errorf("could not execute mapping SLI template: %w", err)
 	}
@@ -153,11 +265,11 @@ func (y YAMLSpecLoader) getSLI(_ openslov1alpha.SLOSpec, slo openslov1alpha.Obje
 // getSLOs will try getting all the objectives as individual SLOs, this way we can map
 // to what Sloth understands as an SLO, that OpenSLO understands as a list of objectives
 // for the same SLO.
-func (y YAMLSpecLoader) getSLOs(spec openslov1alpha.SLO) ([]prometheus.SLO, error) {
+func (y YAMLSpecLoader) getSLOs(ctx context.Context, spec openslov1alpha.SLO, counters ratioCountersV1Alpha) ([]prometheus.SLO, error) {
 	res := []prometheus.SLO{}
 
 	for idx, slo := range spec.Spec.Objectives {
-		sli, err := y.getSLI(spec.Spec, slo)
+		sli, err := y.getSLI(spec.Spec, slo, counters.isGauge(idx))
 		if err != nil {
 			return nil, fmt.Errorf("could not map SLI: %w", err)
 		}
@@ -168,9 +280,15 @@ func (y YAMLSpecLoader) getSLOs(spec openslov1alpha.SLO) ([]prometheus.SLO, erro
 		}
 
 		// TODO(slok): Think about using `slo.Value` insted of idx (`slo.Value` is not mandatory).
+		name := fmt.Sprintf("%s-%d", spec.Metadata.Name, idx)
+		id, err := y.idGenerator.GenerateSLOID(ctx, spec.Spec.Service, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate %q SLO ID: %w", name, err)
+		}
+
 		res = append(res, prometheus.SLO{
-			ID:              fmt.Sprintf("%s-%s-%d", spec.Spec.Service, spec.Metadata.Name, idx),
-			Name:            fmt.Sprintf("%s-%d", spec.Metadata.Name, idx),
+			ID:              id,
+			Name:            name,
 			Service:         spec.Spec.Service,
 			Description:     spec.Spec.Description,
 			TimeWindow:      timeWindow,
@@ -183,3 +301,253 @@ func (y YAMLSpecLoader) getSLOs(spec openslov1alpha.SLO) ([]prometheus.SLO, erro
 
 	return res, nil
 }
+
+const specV2AlphaAPIVersion = "openslo.com/v2alpha"
+
+// These types are the subset of the OpenSLO v2alpha SLO spec that Sloth supports, the spec is
+// loaded in strict mode so any unsupported field will fail instead of being ignored. The same
+// types are used to export the SLOs as OpenSLO, so the exported specs can be loaded again.
+type sloV2Alpha struct {
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Metadata   metadataV2Alpha `yaml:"metadata"`
+	Spec       sloSpecV2Alpha  `yaml:"spec"`
+}
+
+type metadataV2Alpha struct {
+	Name        string            `yaml:"name,omitempty"`
+	DisplayName string            `yaml:"displayName,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+}
+
+type sloSpecV2Alpha struct {
+	Description     string               `yaml:"description,omitempty"`
+	Service         string               `yaml:"service"`
+	BudgetingMethod string               `yaml:"budgetingMethod,omitempty"`
+	Indicator       indicatorV2Alpha     `yaml:"indicator"`
+	TimeWindow      []timeWindowV2Alpha  `yaml:"timeWindow,omitempty"`
+	Objectives      []objectiveV2Alpha   `yaml:"objectives"`
+	AlertPolicies   []alertPolicyV2Alpha `yaml:"alertPolicies,omitempty"`
+}
+
+type indicatorV2Alpha struct {
+	Metadata metadataV2Alpha `yaml:"metadata,omitempty"`
+	Spec     struct {
+		RatioMetric *ratioMetricV2Alpha `yaml:"ratioMetric"`
+	} `yaml:"spec"`
+}
+
+type ratioMetricV2Alpha struct {
+	Counter *bool                      `yaml:"counter,omitempty"`
+	Good    *metricSourceHolderV2Alpha `yaml:"good,omitempty"`
+	Bad     *metricSourceHolderV2Alpha `yaml:"bad,omitempty"`
+	Total   *metricSourceHolderV2Alpha `yaml:"total"`
+}
+
+type timeWindowV2Alpha struct {
+	Duration  string `yaml:"duration"`
+	IsRolling bool   `yaml:"isRolling"`
+}
+
+type objectiveV2Alpha struct {
+	DisplayName string  `yaml:"displayName,omitempty"`
+	Target      float64 `yaml:"target"`
+}
+
+type alertPolicyV2Alpha struct {
+	Kind     string          `yaml:"kind"`
+	Metadata metadataV2Alpha `yaml:"metadata"`
+	Spec     struct {
+		Description        string                  `yaml:"description,omitempty"`
+		AlertWhenBreaching bool                    `yaml:"alertWhenBreaching"`
+		Conditions         []alertConditionV2Alpha `yaml:"conditions"`
+	} `yaml:"spec"`
+}
+
+type alertConditionV2Alpha struct {
+	Kind     string          `yaml:"kind"`
+	Metadata metadataV2Alpha `yaml:"metadata"`
+	Spec     struct {
+		Severity  string `yaml:"severity"`
+		Condition struct {
+			Kind           string  `yaml:"kind"`
+			Op             string  `yaml:"op"`
+			Threshold      float64 `yaml:"threshold"`
+			LookbackWindow string  `yaml:"lookbackWindow"`
+			AlertAfter     string  `yaml:"alertAfter"`
+		} `yaml:"condition"`
+	} `yaml:"spec"`
+}
+
+type metricSourceHolderV2Alpha struct {
+	MetricSource struct {
+		Type string `yaml:"type"`
+		Spec struct {
+			Query string `yaml:"query"`
+		} `yaml:"spec"`
+	} `yaml:"metricSource"`
+}
+
+func (m *metricSourceHolderV2Alpha) query(name string) (string, error) {
+	if m.MetricSource.Type != "prometheus" {
+		return "", fmt.Errorf("prometheus %q metric source type is required", name)
+	}
+
+	if m.MetricSource.Spec.Query == "" {
+		return "", fmt.Errorf("%q metric source query is required", name)
+	}
+
+	return m.MetricSource.Spec.Query, nil
+}
+
+// loadSpecV2Alpha loads the experimental OpenSLO v2alpha specs.
+func (y YAMLSpecLoader) loadSpecV2Alpha(ctx context.Context, data []byte) (*prometheus.SLOGroup, error) {
+	s := sloV2Alpha{}
+	err := yaml.UnmarshalStrict(data, &s)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshall YAML spec correctly (only a subset of v2alpha is supported): %w", err)
+	}
+
+	if s.APIVersion != specV2AlphaAPIVersion {
+		return nil, fmt.Errorf("invalid spec version, should be %q", specV2AlphaAPIVersion)
+	}
+
+	if len(s.Spec.Objectives) == 0 {
+		return nil, fmt.Errorf("at least one SLO is required")
+	}
+
+	if s.Spec.BudgetingMethod != "" && s.Spec.BudgetingMethod != "Occurrences" {
+		return nil, fmt.Errorf("unsupported %q budgeting method, only Occurrences is supported", s.Spec.BudgetingMethod)
+	}
+
+	// Time window.
+	timeWindow := y.windowPeriod
+	if len(s.Spec.TimeWindow) > 1 {
+		return nil, fmt.Errorf("invalid SLO time windows: only 1 time window is supported")
+	}
+	if len(s.Spec.TimeWindow) == 1 {
+		tw := s.Spec.TimeWindow[0]
+		if !tw.IsRolling {
+			return nil, fmt.Errorf("invalid SLO time windows: only rolling time windows are supported")
+		}
+		d, err := prommodel.ParseDuration(tw.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLO time windows: %w", err)
+		}
+		timeWindow = time.Duration(d)
+	}
+
+	// SLI.
+	ratio := s.Spec.Indicator.Spec.RatioMetric
+	if ratio == nil {
+		return nil, fmt.Errorf("could not map SLI: missing ratioMetric")
+	}
+	if ratio.Total == nil {
+		return nil, fmt.Errorf("could not map SLI: missing 'total' metric")
+	}
+	total, err := ratio.Total.query("total")
+	if err != nil {
+		return nil, fmt.Errorf("could not map SLI: %w", err)
+	}
+
+	gauge := isGaugeRatio(ratio.Counter)
+	var sli prometheus.SLI
+	switch {
+	case ratio.Good != nil && ratio.Bad != nil:
+		return nil, fmt.Errorf("could not map SLI: 'good' and 'bad' metrics can't be used at the same time")
+	case ratio.Bad != nil:
+		bad, err := ratio.Bad.query("bad")
+		if err != nil {
+			return nil, fmt.Errorf("could not map SLI: %w", err)
+		}
+		sli.Events = &prometheus.SLIEvents{ErrorQuery: bad, TotalQuery: total}
+		if gauge {
+			sli.Events.Mode = prometheus.SLIEventsModeGauge
+		}
+	case ratio.Good != nil:
+		good, err := ratio.Good.query("good")
+		if err != nil {
+			return nil, fmt.Errorf("could not map SLI: %w", err)
+		}
+		tpl := errorRatioRawQueryTpl
+		if gauge {
+			tpl = errorRatioGaugeRawQueryTpl
+		}
+		var b bytes.Buffer
+		err = tpl.Execute(&b, map[string]string{"good": good, "total": total})
+		if err != nil {
+			return nil, fmt.Errorf("could not execute mapping SLI template: %w", err)
+		}
+		sli.Raw = &prometheus.SLIRaw{ErrorRatioQuery: b.String()}
+	default:
+		return nil, fmt.Errorf("could not map SLI: 'good' or 'bad' metric is required")
+	}
+
+	pageAlert, ticketAlert, err := getAlertsV2Alpha(s.Spec.AlertPolicies)
+	if err != nil {
+		return nil, fmt.Errorf("could not map alert policies: %w", err)
+	}
+
+	slos := []prometheus.SLO{}
+	for idx, obj := range s.Spec.Objectives {
+		name := fmt.Sprintf("%s-%d", s.Metadata.Name, idx)
+		id, err := y.idGenerator.GenerateSLOID(ctx, s.Spec.Service, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate %q SLO ID: %w", name, err)
+		}
+
+		slos = append(slos, prometheus.SLO{
+			ID:              id,
+			Name:            name,
+			Service:         s.Spec.Service,
+			Description:     s.Spec.Description,
+			TimeWindow:      timeWindow,
+			SLI:             sli,
+			Objective:       obj.Target * 100, // OpenSLO uses ratios, we use percents.
+			Labels:          s.Metadata.Labels,
+			PageAlertMeta:   pageAlert,
+			TicketAlertMeta: ticketAlert,
+		})
+	}
+
+	return &prometheus.SLOGroup{SLOs: slos}, nil
+}
+
+const alertConditionBurnRateKindV2Alpha = "burnrate"
+
+// getAlertsV2Alpha maps the burn rate alert policies to the SLO page and ticket alerts (by the conditions
+// severity), the alert is named with the policy display name. The alert windows and burn rates are the
+// Sloth ones of the SLO time window, not the conditions ones.
+func getAlertsV2Alpha(policies []alertPolicyV2Alpha) (page, ticket prometheus.AlertMeta, err error) {
+	page = prometheus.AlertMeta{Disable: true}
+	ticket = prometheus.AlertMeta{Disable: true}
+	for _, p := range policies {
+		name := p.Metadata.DisplayName
+		if name == "" {
+			name = p.Metadata.Name
+		}
+
+		for _, c := range p.Spec.Conditions {
+			if c.Spec.Condition.Kind != alertConditionBurnRateKindV2Alpha {
+				return page, ticket, fmt.Errorf("%q alert policy: unsupported %q condition kind, only %q is supported", p.Metadata.Name, c.Spec.Condition.Kind, alertConditionBurnRateKindV2Alpha)
+			}
+
+			var alertMeta *prometheus.AlertMeta
+			switch c.Spec.Severity {
+			case alert.PageAlertSeverity.String():
+				alertMeta = &page
+			case alert.TicketAlertSeverity.String():
+				alertMeta = &ticket
+			default:
+				return page, ticket, fmt.Errorf("%q alert policy: unsupported %q severity, only %q and %q are supported", p.Metadata.Name, c.Spec.Severity, alert.PageAlertSeverity, alert.TicketAlertSeverity)
+			}
+
+			// The quick and slow conditions of the same severity are the same Sloth alert.
+			if alertMeta.Disable {
+				*alertMeta = prometheus.AlertMeta{Name: name}
+			}
+		}
+	}
+
+	return page, ticket, nil
+}
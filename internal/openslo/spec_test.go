@@ -2,6 +2,8 @@ package openslo_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"testing"
 	"time"
 
@@ -271,6 +273,169 @@ spec:
 				},
 			}},
 		},
+
+		"Spec with counter and non counter ratio metrics should average the non counter metrics over the window.": {
+			specYaml: `
+apiVersion: openslo/v1alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  objectives:
+  - ratioMetrics:
+      counter: true
+      good:
+        source: prometheus
+        queryType: promql
+        query: sum(rate(http_requests_total{code!~"5.."}[{{.window}}]))
+      total:
+        source: prometheus
+        queryType: promql
+        query: sum(rate(http_requests_total[{{.window}}]))
+    target: 0.99
+  - ratioMetrics:
+      counter: false
+      good:
+        source: prometheus
+        queryType: promql
+        query: sum(up{job="my-job"})
+      total:
+        source: prometheus
+        queryType: promql
+        query: count(up{job="my-job"})
+    target: 0.99
+  service: my-test-service
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "my-test-service-ratio-0",
+					Name:       "ratio-0",
+					Service:    "my-test-service",
+					TimeWindow: 30 * 24 * time.Hour,
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{
+							ErrorRatioQuery: `
+  1 - (
+    (
+      sum(rate(http_requests_total{code!~"5.."}[{{.window}}]))
+    )
+    /
+    (
+      sum(rate(http_requests_total[{{.window}}]))
+    )
+  )
+`,
+						},
+					},
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+				{
+					ID:         "my-test-service-ratio-1",
+					Name:       "ratio-1",
+					Service:    "my-test-service",
+					TimeWindow: 30 * 24 * time.Hour,
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{
+							ErrorRatioQuery: `
+  1 - (
+    (
+      avg_over_time((sum(up{job="my-job"}))[{{.window}}:])
+    )
+    /
+    (
+      avg_over_time((count(up{job="my-job"}))[{{.window}}:])
+    )
+  )
+`,
+						},
+					},
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with a supported 30 days time window should load correctly.": {
+			specYaml: `
+apiVersion: openslo/v1alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  objectives:
+  - ratioMetrics:
+      good:
+        source: prometheus
+        queryType: promql
+        query: sum(rate(http_requests_total{code!~"5.."}[{{.window}}]))
+      total:
+        source: prometheus
+        queryType: promql
+        query: sum(rate(http_requests_total[{{.window}}]))
+    target: 0.99
+  service: my-test-service
+  timeWindows:
+  - count: 30
+    isRolling: true
+    unit: Day
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "my-test-service-ratio-0",
+					Name:       "ratio-0",
+					Service:    "my-test-service",
+					TimeWindow: 30 * 24 * time.Hour,
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{
+							ErrorRatioQuery: `
+  1 - (
+    (
+      sum(rate(http_requests_total{code!~"5.."}[{{.window}}]))
+    )
+    /
+    (
+      sum(rate(http_requests_total[{{.window}}]))
+    )
+  )
+`,
+						},
+					},
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with an unsupported 13 days time window should fail.": {
+			specYaml: `
+apiVersion: openslo/v1alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  objectives:
+  - ratioMetrics:
+      good:
+        source: prometheus
+        queryType: promql
+        query: sum(rate(http_requests_total{code!~"5.."}[{{.window}}]))
+      total:
+        source: prometheus
+        queryType: promql
+        query: sum(rate(http_requests_total[{{.window}}]))
+    target: 0.99
+  service: my-test-service
+  timeWindows:
+  - count: 13
+    isRolling: true
+    unit: Day
+`,
+			expErr: true,
+		},
 	}
 
 	for name, test := range tests {
@@ -289,6 +454,154 @@ spec:
 	}
 }
 
+func TestYAMLoadSpecIDGenerator(t *testing.T) {
+	specYaml := `
+apiVersion: openslo/v1alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  objectives:
+  - ratioMetrics:
+      good:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="GOOD"}
+      total:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="ALL"}
+    target: 0.98
+  service: my-test-service
+  timeWindows:
+  - count: 28
+    isRolling: true
+    unit: Day
+`
+
+	assert := assert.New(t)
+
+	hashedIDGen := prometheus.IDGeneratorFunc(func(_ context.Context, service, name string) (string, error) {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(service+"/"+name)))[:16], nil
+	})
+	loader := openslo.NewYAMLSpecLoader(30 * 24 * time.Hour).WithIDGenerator(hashedIDGen)
+	gotModel, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+	if assert.NoError(err) && assert.Len(gotModel.SLOs, 1) {
+		assert.Equal(fmt.Sprintf("%x", sha256.Sum256([]byte("my-test-service/ratio-0")))[:16], gotModel.SLOs[0].ID)
+		assert.Equal("ratio-0", gotModel.SLOs[0].Name)
+	}
+}
+
+func TestYAMLoadSpecStrict(t *testing.T) {
+	tests := map[string]struct {
+		specYaml string
+		strict   bool
+		expErr   bool
+	}{
+		"A spec with an unknown field without strict mode should load correctly.": {
+			specYaml: `
+apiVersion: openslo/v1alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  objectives:
+  - ratioMetrics:
+      good:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="GOOD"}
+      total:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="ALL"}
+    target: 0.98
+  descripton: A ratio SLO.
+  service: my-test-service
+  timeWindows:
+  - count: 28
+    isRolling: true
+    unit: Day
+`,
+			strict: false,
+		},
+
+		"A spec with an unknown field with strict mode should fail.": {
+			specYaml: `
+apiVersion: openslo/v1alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  objectives:
+  - ratioMetrics:
+      good:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="GOOD"}
+      total:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="ALL"}
+    target: 0.98
+  descripton: A ratio SLO.
+  service: my-test-service
+  timeWindows:
+  - count: 28
+    isRolling: true
+    unit: Day
+`,
+			strict: true,
+			expErr: true,
+		},
+
+		"A spec without unknown fields with strict mode should load correctly.": {
+			specYaml: `
+apiVersion: openslo/v1alpha
+kind: SLO
+metadata:
+  name: ratio
+  displayName: Ratio
+spec:
+  description: A ratio SLO.
+  budgetingMethod: Occurrences
+  objectives:
+  - ratioMetrics:
+      counter: true
+      good:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="GOOD"}
+      total:
+        source: prometheus
+        queryType: promql
+        query: latency_west_c7{code="ALL"}
+    target: 0.98
+  service: my-test-service
+  timeWindows:
+  - count: 28
+    isRolling: true
+    unit: Day
+`,
+			strict: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := openslo.NewYAMLSpecLoader(30 * 24 * time.Hour).WithStrict(test.strict)
+			_, err := loader.LoadSpec(context.TODO(), []byte(test.specYaml))
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
 func TestYAMLIsSpecType(t *testing.T) {
 	tests := map[string]struct {
 		specYaml string
@@ -364,3 +677,304 @@ kind:              SLO
 		})
 	}
 }
+
+func TestYAMLoadSpecExperimentalV2(t *testing.T) {
+	specYaml := `
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: ratio
+  labels:
+    team: a-team
+spec:
+  description: This is a v2 SLO.
+  service: my-test-service
+  budgetingMethod: Occurrences
+  indicator:
+    metadata:
+      name: errors
+    spec:
+      ratioMetric:
+        counter: true
+        bad:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(rate(http_requests_total{code=~"5.."}[{{.window}}]))
+        total:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(rate(http_requests_total[{{.window}}]))
+  timeWindow:
+  - duration: 28d
+    isRolling: true
+  objectives:
+  - target: 0.99
+`
+
+	tests := map[string]struct {
+		experimentalV2 bool
+		specYaml       string
+		expIsSpecType  bool
+		expModel       *prometheus.SLOGroup
+		expErr         bool
+	}{
+		"A v2 spec without the experimental v2 support should not be loaded.": {
+			experimentalV2: false,
+			specYaml:       specYaml,
+			expIsSpecType:  false,
+			expErr:         true,
+		},
+
+		"A v2 spec with the experimental v2 support should be loaded.": {
+			experimentalV2: true,
+			specYaml:       specYaml,
+			expIsSpecType:  true,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:          "my-test-service-ratio-0",
+					Name:        "ratio-0",
+					Service:     "my-test-service",
+					Description: "This is a v2 SLO.",
+					TimeWindow:  28 * 24 * time.Hour,
+					SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+						ErrorQuery: `sum(rate(http_requests_total{code=~"5.."}[{{.window}}]))`,
+						TotalQuery: `sum(rate(http_requests_total[{{.window}}]))`,
+					}},
+					Objective:       99,
+					Labels:          map[string]string{"team": "a-team"},
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"A v2 spec with a non counter ratio metric should load the events SLI in gauge mode.": {
+			experimentalV2: true,
+			specYaml: `
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  service: my-test-service
+  indicator:
+    spec:
+      ratioMetric:
+        counter: false
+        bad:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(queue_jobs_failed)
+        total:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(queue_jobs)
+  objectives:
+  - target: 0.99
+`,
+			expIsSpecType: true,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "my-test-service-ratio-0",
+					Name:       "ratio-0",
+					Service:    "my-test-service",
+					TimeWindow: 30 * 24 * time.Hour,
+					SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+						ErrorQuery: `sum(queue_jobs_failed)`,
+						TotalQuery: `sum(queue_jobs)`,
+						Mode:       prometheus.SLIEventsModeGauge,
+					}},
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"A v2 spec with burn rate alert policies should enable the alerts of the policies severity.": {
+			experimentalV2: true,
+			specYaml: `
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  service: my-test-service
+  indicator:
+    spec:
+      ratioMetric:
+        counter: false
+        bad:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(queue_jobs_failed)
+        total:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(queue_jobs)
+  objectives:
+  - target: 0.99
+  alertPolicies:
+  - kind: AlertPolicy
+    metadata:
+      name: ratio-page-quick
+      displayName: QueueJobsFailing
+    spec:
+      alertWhenBreaching: true
+      conditions:
+      - kind: AlertCondition
+        metadata:
+          name: ratio-page-quick
+        spec:
+          severity: page
+          condition:
+            kind: burnrate
+            op: gt
+            threshold: 14.4
+            lookbackWindow: 1h
+            alertAfter: 0s
+`,
+			expIsSpecType: true,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "my-test-service-ratio-0",
+					Name:       "ratio-0",
+					Service:    "my-test-service",
+					TimeWindow: 30 * 24 * time.Hour,
+					SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+						ErrorQuery: `sum(queue_jobs_failed)`,
+						TotalQuery: `sum(queue_jobs)`,
+						Mode:       prometheus.SLIEventsModeGauge,
+					}},
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Name: "QueueJobsFailing"},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"A v2 spec with alert policies of an unsupported severity should fail.": {
+			experimentalV2: true,
+			specYaml: `
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  service: my-test-service
+  indicator:
+    spec:
+      ratioMetric:
+        good:
+          metricSource:
+            type: prometheus
+            spec:
+              query: good
+        total:
+          metricSource:
+            type: prometheus
+            spec:
+              query: total
+  objectives:
+  - target: 0.99
+  alertPolicies:
+  - kind: AlertPolicy
+    metadata:
+      name: ratio-critical
+    spec:
+      alertWhenBreaching: true
+      conditions:
+      - kind: AlertCondition
+        metadata:
+          name: ratio-critical
+        spec:
+          severity: critical
+          condition:
+            kind: burnrate
+            op: gt
+            threshold: 14.4
+            lookbackWindow: 1h
+            alertAfter: 0s
+`,
+			expIsSpecType: true,
+			expErr:        true,
+		},
+
+		"A v2 spec with unsupported fields should fail.": {
+			experimentalV2: true,
+			specYaml: `
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  service: my-test-service
+  indicator:
+    spec:
+      thresholdMetric:
+        metricSource:
+          type: prometheus
+          spec:
+            query: something
+  objectives:
+  - target: 0.99
+`,
+			expIsSpecType: true,
+			expErr:        true,
+		},
+
+		"A v2 spec with calendar time windows should fail.": {
+			experimentalV2: true,
+			specYaml: `
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: ratio
+spec:
+  service: my-test-service
+  indicator:
+    spec:
+      ratioMetric:
+        good:
+          metricSource:
+            type: prometheus
+            spec:
+              query: good
+        total:
+          metricSource:
+            type: prometheus
+            spec:
+              query: total
+  timeWindow:
+  - duration: 1M
+    isRolling: false
+  objectives:
+  - target: 0.99
+`,
+			expIsSpecType: true,
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := openslo.NewYAMLSpecLoader(30 * 24 * time.Hour).WithExperimentalV2(test.experimentalV2)
+			assert.Equal(test.expIsSpecType, loader.IsSpecType(context.TODO(), []byte(test.specYaml)))
+
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(test.specYaml))
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expModel, gotModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package openslo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/info"
+	"github.com/slok/sloth/internal/log"
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+// StorageSLO is the SLO that will be exported as OpenSLO, the alerts are required
+// to export the alert policies.
+type StorageSLO struct {
+	SLO    prometheus.SLO
+	Alerts alert.MWMBAlertGroup
+}
+
+// NewIOWriterOpenSLOYAMLRepo returns a new IOWriterOpenSLOYAMLRepo.
+func NewIOWriterOpenSLOYAMLRepo(writer io.Writer, logger log.Logger) IOWriterOpenSLOYAMLRepo {
+	return IOWriterOpenSLOYAMLRepo{
+		writer: writer,
+		logger: logger.WithValues(log.Kv{"svc": "storage.IOWriter", "format": "openslo"}),
+	}
+}
+
+// IOWriterOpenSLOYAMLRepo knows to export SLOs as OpenSLO v2alpha YAML manifests, using the subset of
+// the spec that Sloth loads (with the OpenSLO v2alpha support enabled). The SLO alerts will be exported
+// as inline OpenSLO alert policies using burn rate conditions.
+//
+// OpenSLO queries are not templated, so the SLIs using the `{{.window}}` template can't be exported.
+type IOWriterOpenSLOYAMLRepo struct {
+	writer io.Writer
+	logger log.Logger
+}
+
+func (i IOWriterOpenSLOYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slos are required")
+	}
+
+	var b bytes.Buffer
+	for _, slo := range slos {
+		manifest, err := mapModelToOpenSLOV2Alpha(slo)
+		if err != nil {
+			return fmt.Errorf("could not map %q SLO to OpenSLO: %w", slo.SLO.ID, err)
+		}
+
+		b.WriteString("---\n")
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		err = enc.Encode(manifest)
+		if err != nil {
+			return fmt.Errorf("could not format OpenSLO manifest: %w", err)
+		}
+	}
+
+	_, err := i.writer.Write(append([]byte(disclaimer), b.Bytes()...))
+	if err != nil {
+		return fmt.Errorf("could not write OpenSLO manifests: %w", err)
+	}
+
+	i.logger.WithCtxValues(ctx).WithValues(log.Kv{"slos": len(slos)}).Infof("OpenSLO manifests written")
+
+	return nil
+}
+
+var disclaimer = fmt.Sprintf(`
+# Code generated by Sloth (%s): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+`, info.Version)
+
+// mapModelToOpenSLOV2Alpha maps an SLO to an OpenSLO v2alpha manifest, with an alert policy for each of
+// the enabled alerts, quick and slow MWMB alerts are exported as independent alert policies of the same
+// severity because OpenSLO alert policies only accept one condition.
+func mapModelToOpenSLOV2Alpha(s StorageSLO) (*sloV2Alpha, error) {
+	slo := s.SLO
+	ratio, err := mapSLIToOpenSLOV2Alpha(slo)
+	if err != nil {
+		return nil, err
+	}
+
+	type alertPolicy struct {
+		name  string
+		speed string
+		alert alert.MWMBAlert
+	}
+	alertPolicies := []alertPolicy{}
+	if !slo.PageAlertMeta.Disable {
+		alertPolicies = append(alertPolicies,
+			alertPolicy{name: slo.PageAlertMeta.Name, speed: "quick", alert: s.Alerts.PageQuick},
+			alertPolicy{name: slo.PageAlertMeta.Name, speed: "slow", alert: s.Alerts.PageSlow},
+		)
+	}
+	if !slo.TicketAlertMeta.Disable {
+		alertPolicies = append(alertPolicies,
+			alertPolicy{name: slo.TicketAlertMeta.Name, speed: "quick", alert: s.Alerts.TicketQuick},
+			alertPolicy{name: slo.TicketAlertMeta.Name, speed: "slow", alert: s.Alerts.TicketSlow},
+		)
+	}
+
+	// Sloth MWMB alerts fire as soon as the burn rate of both windows is over the threshold, and OpenSLO
+	// burn rate conditions only have one window, so we use the long window as the lookback window.
+	policies := []alertPolicyV2Alpha{}
+	for _, ap := range alertPolicies {
+		severity := ap.alert.Severity.String()
+		name := fmt.Sprintf("%s-%s-%s", slo.Name, severity, ap.speed)
+
+		policy := alertPolicyV2Alpha{
+			Kind:     openSLOAlertPolicyKind,
+			Metadata: metadataV2Alpha{Name: name, DisplayName: ap.name},
+		}
+		policy.Spec.Description = fmt.Sprintf("%s %s burn rate alert policy of %s SLO.", ap.speed, severity, slo.Name)
+		policy.Spec.AlertWhenBreaching = true
+
+		condition := alertConditionV2Alpha{
+			Kind:     openSLOAlertConditionKind,
+			Metadata: metadataV2Alpha{Name: name},
+		}
+		condition.Spec.Severity = severity
+		condition.Spec.Condition.Kind = alertConditionBurnRateKindV2Alpha
+		condition.Spec.Condition.Op = "gt"
+		condition.Spec.Condition.Threshold = ap.alert.BurnRateFactor
+		condition.Spec.Condition.LookbackWindow = durationToOpenSLOStr(ap.alert.LongWindow)
+		condition.Spec.Condition.AlertAfter = durationToOpenSLOStr(0)
+		policy.Spec.Conditions = []alertConditionV2Alpha{condition}
+
+		policies = append(policies, policy)
+	}
+
+	// Format the objective ratio without the float division noise (e.g 0.9990000000000001).
+	target, err := strconv.ParseFloat(strconv.FormatFloat(slo.Objective/100, 'g', 12, 64), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid objective: %w", err)
+	}
+
+	manifest := &sloV2Alpha{
+		APIVersion: specV2AlphaAPIVersion,
+		Kind:       openSLOSLOKind,
+		Metadata:   metadataV2Alpha{Name: slo.Name, Labels: slo.Labels},
+		Spec: sloSpecV2Alpha{
+			Description:     slo.Description,
+			Service:         slo.Service,
+			BudgetingMethod: "Occurrences",
+			TimeWindow: []timeWindowV2Alpha{
+				{Duration: durationToOpenSLOStr(slo.TimeWindow), IsRolling: true},
+			},
+			Objectives:    []objectiveV2Alpha{{Target: target}},
+			AlertPolicies: policies,
+		},
+	}
+	manifest.Spec.Indicator.Metadata.Name = slo.Name
+	manifest.Spec.Indicator.Spec.RatioMetric = ratio
+
+	return manifest, nil
+}
+
+const (
+	openSLOSLOKind              = "SLO"
+	openSLOAlertPolicyKind      = "AlertPolicy"
+	openSLOAlertConditionKind   = "AlertCondition"
+	openSLOPrometheusSourceType = "prometheus"
+)
+
+func mapSLIToOpenSLOV2Alpha(slo prometheus.SLO) (*ratioMetricV2Alpha, error) {
+	var err error
+	newSource := func(name, query string) *metricSourceHolderV2Alpha {
+		if err != nil {
+			return nil
+		}
+		if strings.Contains(query, "{{") {
+			err = fmt.Errorf("%q SLI query is templated (e.g `{{.window}}`), OpenSLO queries can't be templated", name)
+			return nil
+		}
+
+		m := &metricSourceHolderV2Alpha{}
+		m.MetricSource.Type = openSLOPrometheusSourceType
+		m.MetricSource.Spec.Query = query
+		return m
+	}
+	counter := func(c bool) *bool { return &c }
+
+	var ratio *ratioMetricV2Alpha
+	switch {
+	case slo.SLI.Raw != nil && slo.SLI.Raw.ErrorRatioQuery != "":
+		return nil, fmt.Errorf("error ratio query SLIs can't be exported, OpenSLO ratio metrics require the bad and total queries")
+	case slo.SLI.Raw != nil:
+		ratio = &ratioMetricV2Alpha{
+			Counter: counter(true),
+			Bad:     newSource("error", slo.SLI.Raw.ErrorQuery),
+			Total:   newSource("total", slo.SLI.Raw.TotalQuery),
+		}
+	case slo.SLI.Events != nil:
+		ratio = &ratioMetricV2Alpha{
+			Counter: counter(slo.SLI.Events.Mode != prometheus.SLIEventsModeGauge),
+			Bad:     newSource("error", slo.SLI.Events.ErrorQuery),
+			Total:   newSource("total", slo.SLI.Events.TotalQuery),
+		}
+	case slo.SLI.DenominatorCorrected != nil:
+		ratio = &ratioMetricV2Alpha{
+			Counter: counter(true),
+			Total:   newSource("total", slo.SLI.DenominatorCorrected.TotalQuery),
+		}
+		switch {
+		case slo.SLI.DenominatorCorrected.ErrorQuery != nil:
+			ratio.Bad = newSource("error", *slo.SLI.DenominatorCorrected.ErrorQuery)
+		case slo.SLI.DenominatorCorrected.SuccessQuery != nil:
+			ratio.Good = newSource("success", *slo.SLI.DenominatorCorrected.SuccessQuery)
+		}
+	case slo.SLI.Precomputed != nil:
+		// The precomputed metrics are rates, these are averaged over the window like the non counter metrics.
+		ratio = &ratioMetricV2Alpha{
+			Counter: counter(false),
+			Bad:     newSource("error", slo.SLI.Precomputed.ErrorMetric),
+			Total:   newSource("total", slo.SLI.Precomputed.TotalMetric),
+		}
+	default:
+		return nil, fmt.Errorf("unknown SLI type")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ratio, nil
+}
+
+func durationToOpenSLOStr(d time.Duration) string {
+	return prommodel.Duration(d).String()
+}
@@ -0,0 +1,299 @@
+package openslo_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/log"
+	"github.com/slok/sloth/internal/openslo"
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+func getAlertGroup() alert.MWMBAlertGroup {
+	return alert.MWMBAlertGroup{
+		PageQuick:   alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour, BurnRateFactor: 14.4, Severity: alert.PageAlertSeverity},
+		PageSlow:    alert.MWMBAlert{ShortWindow: 30 * time.Minute, LongWindow: 6 * time.Hour, BurnRateFactor: 6, Severity: alert.PageAlertSeverity},
+		TicketQuick: alert.MWMBAlert{ShortWindow: 2 * time.Hour, LongWindow: 1 * 24 * time.Hour, BurnRateFactor: 3, Severity: alert.TicketAlertSeverity},
+		TicketSlow:  alert.MWMBAlert{ShortWindow: 6 * time.Hour, LongWindow: 3 * 24 * time.Hour, BurnRateFactor: 1, Severity: alert.TicketAlertSeverity},
+	}
+}
+
+func TestIOWriterOpenSLOYAMLRepo(t *testing.T) {
+	tests := map[string]struct {
+		slos    []openslo.StorageSLO
+		expYAML string
+		expErr  bool
+	}{
+		"Having 0 SLOs should fail.": {
+			slos:   []openslo.StorageSLO{},
+			expErr: true,
+		},
+
+		"Having an SLO with disabled alerts should export the SLO without alert policies.": {
+			slos: []openslo.StorageSLO{
+				{
+					SLO: prometheus.SLO{
+						ID:         "test-svc-test",
+						Name:       "test",
+						Service:    "test-svc",
+						TimeWindow: 30 * 24 * time.Hour,
+						Objective:  99.9,
+						SLI: prometheus.SLI{Precomputed: &prometheus.SLIPrecomputed{
+							ErrorMetric: `my_errors:rate5m{job="test"}`,
+							TotalMetric: `my_total:rate5m{job="test"}`,
+						}},
+						PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+						TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+					},
+					Alerts: getAlertGroup(),
+				},
+			},
+			expYAML: `
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+---
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: test
+spec:
+  service: test-svc
+  budgetingMethod: Occurrences
+  indicator:
+    metadata:
+      name: test
+    spec:
+      ratioMetric:
+        counter: false
+        bad:
+          metricSource:
+            type: prometheus
+            spec:
+              query: my_errors:rate5m{job="test"}
+        total:
+          metricSource:
+            type: prometheus
+            spec:
+              query: my_total:rate5m{job="test"}
+  timeWindow:
+    - duration: 30d
+      isRolling: true
+  objectives:
+    - target: 0.999
+`,
+		},
+
+		"Having an SLO with page alerts should export the SLO with burn rate alert policies.": {
+			slos: []openslo.StorageSLO{
+				{
+					SLO: prometheus.SLO{
+						ID:          "test-svc-test",
+						Name:        "test",
+						Service:     "test-svc",
+						Description: "test description",
+						TimeWindow:  30 * 24 * time.Hour,
+						Objective:   99,
+						Labels:      map[string]string{"owner": "team-a"},
+						SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+							ErrorQuery: `sum(my_inflight_errors)`,
+							TotalQuery: `sum(my_inflight)`,
+							Mode:       prometheus.SLIEventsModeGauge,
+						}},
+						PageAlertMeta:   prometheus.AlertMeta{Name: "testAlert"},
+						TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+					},
+					Alerts: getAlertGroup(),
+				},
+			},
+			expYAML: `
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+---
+apiVersion: openslo.com/v2alpha
+kind: SLO
+metadata:
+  name: test
+  labels:
+    owner: team-a
+spec:
+  description: test description
+  service: test-svc
+  budgetingMethod: Occurrences
+  indicator:
+    metadata:
+      name: test
+    spec:
+      ratioMetric:
+        counter: false
+        bad:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(my_inflight_errors)
+        total:
+          metricSource:
+            type: prometheus
+            spec:
+              query: sum(my_inflight)
+  timeWindow:
+    - duration: 30d
+      isRolling: true
+  objectives:
+    - target: 0.99
+  alertPolicies:
+    - kind: AlertPolicy
+      metadata:
+        name: test-page-quick
+        displayName: testAlert
+      spec:
+        description: quick page burn rate alert policy of test SLO.
+        alertWhenBreaching: true
+        conditions:
+          - kind: AlertCondition
+            metadata:
+              name: test-page-quick
+            spec:
+              severity: page
+              condition:
+                kind: burnrate
+                op: gt
+                threshold: 14.4
+                lookbackWindow: 1h
+                alertAfter: 0s
+    - kind: AlertPolicy
+      metadata:
+        name: test-page-slow
+        displayName: testAlert
+      spec:
+        description: slow page burn rate alert policy of test SLO.
+        alertWhenBreaching: true
+        conditions:
+          - kind: AlertCondition
+            metadata:
+              name: test-page-slow
+            spec:
+              severity: page
+              condition:
+                kind: burnrate
+                op: gt
+                threshold: 6
+                lookbackWindow: 6h
+                alertAfter: 0s
+`,
+		},
+
+		"Having an SLO with window templated queries should fail.": {
+			slos: []openslo.StorageSLO{
+				{
+					SLO: prometheus.SLO{
+						ID:         "test-svc-test",
+						Name:       "test",
+						Service:    "test-svc",
+						TimeWindow: 30 * 24 * time.Hour,
+						Objective:  99,
+						SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+							ErrorQuery: `sum(rate(my_errors[{{.window}}]))`,
+							TotalQuery: `sum(rate(my_total[{{.window}}]))`,
+						}},
+						PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+						TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+					},
+					Alerts: getAlertGroup(),
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an SLO with an error ratio query SLI should fail.": {
+			slos: []openslo.StorageSLO{
+				{
+					SLO: prometheus.SLO{
+						ID:              "test-svc-test",
+						Name:            "test",
+						Service:         "test-svc",
+						TimeWindow:      30 * 24 * time.Hour,
+						Objective:       99,
+						SLI:             prometheus.SLI{Raw: &prometheus.SLIRaw{ErrorRatioQuery: `avg(my_error_ratio)`}},
+						PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+						TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+					},
+					Alerts: getAlertGroup(),
+				},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotYAML bytes.Buffer
+			repo := openslo.NewIOWriterOpenSLOYAMLRepo(&gotYAML, log.Noop)
+			err := repo.StoreSLOs(context.TODO(), test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expYAML, gotYAML.String())
+			}
+		})
+	}
+}
+
+func TestIOWriterOpenSLOYAMLRepoRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	slo := prometheus.SLO{
+		ID:          "test-svc-test",
+		Name:        "test",
+		Service:     "test-svc",
+		Description: "test description",
+		TimeWindow:  30 * 24 * time.Hour,
+		Objective:   99.9,
+		Labels:      map[string]string{"owner": "team-a"},
+		SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+			ErrorQuery: `sum(my_inflight_errors)`,
+			TotalQuery: `sum(my_inflight)`,
+			Mode:       prometheus.SLIEventsModeGauge,
+		}},
+		PageAlertMeta:   prometheus.AlertMeta{Name: "testPageAlert"},
+		TicketAlertMeta: prometheus.AlertMeta{Name: "testTicketAlert"},
+	}
+
+	// Export.
+	var gotYAML bytes.Buffer
+	repo := openslo.NewIOWriterOpenSLOYAMLRepo(&gotYAML, log.Noop)
+	err := repo.StoreSLOs(context.TODO(), []openslo.StorageSLO{{SLO: slo, Alerts: getAlertGroup()}})
+	require.NoError(err)
+
+	// Load the exported spec again.
+	loader := openslo.NewYAMLSpecLoader(28 * 24 * time.Hour).WithExperimentalV2(true)
+	require.True(loader.IsSpecType(context.TODO(), gotYAML.Bytes()))
+	gotSLOs, err := loader.LoadSpec(context.TODO(), gotYAML.Bytes())
+	require.NoError(err)
+	require.Len(gotSLOs.SLOs, 1)
+
+	expSLO := prometheus.SLO{
+		ID:              "test-svc-test-0",
+		Name:            "test-0",
+		Service:         slo.Service,
+		Description:     slo.Description,
+		TimeWindow:      slo.TimeWindow,
+		Objective:       slo.Objective,
+		Labels:          slo.Labels,
+		SLI:             slo.SLI,
+		PageAlertMeta:   slo.PageAlertMeta,
+		TicketAlertMeta: slo.TicketAlertMeta,
+	}
+	assert.Equal(expSLO, gotSLOs.SLOs[0])
+}
@@ -0,0 +1,178 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Writer creates the outputs where the generated rules are written.
+type Writer interface {
+	// Create returns a new output for the path relative to the writer destination, an empty
+	// path is the destination itself.
+	Create(ctx context.Context, path string) (io.Writer, error)
+	// Commit stores all the created outputs, needs to be called once all of them have been written.
+	Commit(ctx context.Context) error
+	// Close releases the outputs, the ones not committed are discarded.
+	Close() error
+}
+
+// Uploader uploads data to a remote destination URL (e.g `s3://bucket/key`).
+type Uploader interface {
+	Upload(ctx context.Context, url string, data []byte) error
+}
+
+// UploaderFunc is a helper to create uploaders from functions.
+type UploaderFunc func(ctx context.Context, url string, data []byte) error
+
+// Upload satisfies Uploader interface.
+func (u UploaderFunc) Upload(ctx context.Context, url string, data []byte) error {
+	return u(ctx, url, data)
+}
+
+// DefaultUploaderCommands are the uploader commands used by default for the remote destinations.
+var DefaultUploaderCommands = map[string]string{
+	"s3": "aws s3 cp -",
+	"gs": "gsutil cp -",
+}
+
+// NewCmdUploader returns an Uploader that runs the command with the destination URL as the last
+// argument and the data on the standard input.
+func NewCmdUploader(command string) (Uploader, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("uploader command is required")
+	}
+
+	return UploaderFunc(func(ctx context.Context, url string, data []byte) error {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], append(args[1:], url)...)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if err != nil {
+			return fmt.Errorf("uploader command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}), nil
+}
+
+var remoteRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// IsRemote returns true if the output is a remote destination URL.
+func IsRemote(out string) bool {
+	return remoteRegexp.MatchString(out)
+}
+
+// NewWriter returns the Writer for the output, remote destination URLs use the uploader of their
+// scheme, the rest are file system paths.
+func NewWriter(out string, uploaders map[string]Uploader) (Writer, error) {
+	if !IsRemote(out) {
+		return &fsWriter{root: out}, nil
+	}
+
+	u, err := url.Parse(out)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output URL: %w", err)
+	}
+
+	uploader, ok := uploaders[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("there is no uploader for %q output scheme", u.Scheme)
+	}
+
+	return &uploaderWriter{root: u, uploader: uploader}, nil
+}
+
+type fsWriter struct {
+	root  string
+	files []*os.File
+}
+
+func (f *fsWriter) Create(_ context.Context, path string) (io.Writer, error) {
+	path = filepath.Join(f.root, path)
+
+	// Ensure the file path is ready.
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create out file: %w", err)
+	}
+	f.files = append(f.files, file)
+
+	return file, nil
+}
+
+func (f *fsWriter) Commit(_ context.Context) error {
+	files := f.files
+	f.files = nil
+	for _, file := range files {
+		err := file.Close()
+		if err != nil {
+			return fmt.Errorf("could not close %q out file: %w", file.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (f *fsWriter) Close() error {
+	for _, file := range f.files {
+		_ = file.Close()
+	}
+	f.files = nil
+
+	return nil
+}
+
+type upload struct {
+	url  string
+	data *bytes.Buffer
+}
+
+type uploaderWriter struct {
+	root     *url.URL
+	uploader Uploader
+	uploads  []upload
+}
+
+func (u *uploaderWriter) Create(_ context.Context, path string) (io.Writer, error) {
+	dst := u.root.String()
+	if path != "" {
+		dst = u.root.JoinPath(filepath.ToSlash(path)).String()
+	}
+
+	b := &bytes.Buffer{}
+	u.uploads = append(u.uploads, upload{url: dst, data: b})
+
+	return b, nil
+}
+
+func (u *uploaderWriter) Commit(ctx context.Context) error {
+	uploads := u.uploads
+	u.uploads = nil
+	for _, up := range uploads {
+		err := u.uploader.Upload(ctx, up.url, up.data.Bytes())
+		if err != nil {
+			return fmt.Errorf("could not upload %q: %w", up.url, err)
+		}
+	}
+
+	return nil
+}
+
+func (u *uploaderWriter) Close() error {
+	u.uploads = nil
+	return nil
+}
@@ -0,0 +1,152 @@
+package output_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/output"
+)
+
+type memUploader struct {
+	uploads map[string]string
+	err     error
+}
+
+func (m *memUploader) Upload(_ context.Context, url string, data []byte) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.uploads[url] = string(data)
+	return nil
+}
+
+func TestUploaderWriter(t *testing.T) {
+	tests := map[string]struct {
+		out          string
+		outputs      map[string]string
+		commit       bool
+		uploadErr    error
+		expUploads   map[string]string
+		expErr       bool
+		expCommitErr bool
+	}{
+		"A remote file output should be uploaded to the URL.": {
+			out:        "mem://bucket/rules.yaml",
+			outputs:    map[string]string{"": "rules"},
+			commit:     true,
+			expUploads: map[string]string{"mem://bucket/rules.yaml": "rules"},
+		},
+
+		"A remote directory output should upload every output relative to the URL.": {
+			out: "mem://bucket/prefix",
+			outputs: map[string]string{
+				"a.yaml":        "rules-a",
+				"nested/b.yaml": "rules-b",
+			},
+			commit: true,
+			expUploads: map[string]string{
+				"mem://bucket/prefix/a.yaml":        "rules-a",
+				"mem://bucket/prefix/nested/b.yaml": "rules-b",
+			},
+		},
+
+		"Not committed outputs should not be uploaded.": {
+			out:        "mem://bucket/rules.yaml",
+			outputs:    map[string]string{"": "rules"},
+			commit:     false,
+			expUploads: map[string]string{},
+		},
+
+		"An output without uploader for the scheme should fail.": {
+			out:    "unknown://bucket/rules.yaml",
+			expErr: true,
+		},
+
+		"A failed upload should fail the commit.": {
+			out:          "mem://bucket/rules.yaml",
+			outputs:      map[string]string{"": "rules"},
+			commit:       true,
+			uploadErr:    fmt.Errorf("something"),
+			expUploads:   map[string]string{},
+			expCommitErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			uploader := &memUploader{uploads: map[string]string{}, err: test.uploadErr}
+			w, err := output.NewWriter(test.out, map[string]output.Uploader{"mem": uploader})
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			defer w.Close()
+
+			for path, data := range test.outputs {
+				out, err := w.Create(context.TODO(), path)
+				require.NoError(err)
+				_, err = out.Write([]byte(data))
+				require.NoError(err)
+			}
+
+			if test.commit {
+				err = w.Commit(context.TODO())
+				if test.expCommitErr {
+					assert.Error(err)
+				} else {
+					assert.NoError(err)
+				}
+			}
+			assert.Equal(test.expUploads, uploader.uploads)
+		})
+	}
+}
+
+func TestFSWriter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir := t.TempDir()
+	w, err := output.NewWriter(dir, nil)
+	require.NoError(err)
+	defer w.Close()
+
+	out, err := w.Create(context.TODO(), "nested/a.yaml")
+	require.NoError(err)
+	_, err = out.Write([]byte("rules-a"))
+	require.NoError(err)
+	require.NoError(w.Commit(context.TODO()))
+
+	data, err := os.ReadFile(filepath.Join(dir, "nested", "a.yaml"))
+	require.NoError(err)
+	assert.Equal("rules-a", string(data))
+}
+
+func TestIsRemote(t *testing.T) {
+	tests := map[string]struct {
+		out       string
+		expRemote bool
+	}{
+		"A S3 URL should be remote.":        {out: "s3://bucket/key", expRemote: true},
+		"A GCS URL should be remote.":       {out: "gs://bucket/key", expRemote: true},
+		"A relative path should be local.":  {out: "./out/rules.yaml", expRemote: false},
+		"An absolute path should be local.": {out: "/tmp/rules.yaml", expRemote: false},
+		"The stdout should be local.":       {out: "-", expRemote: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expRemote, output.IsRemote(test.out))
+		})
+	}
+}
@@ -4,86 +4,310 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
+	prommodel "github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/rulefmt"
 
 	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/log"
 )
 
 // genFunc knows how to generate an SLI recording rule for a specific time window.
-type alertGenFunc func(slo SLO, sloAlert AlertMeta, quick, slow alert.MWMBAlert) (*rulefmt.Rule, error)
+type alertGenFunc func(config SLOAlertRulesGeneratorConfig, slo SLO, sloAlert AlertMeta, quick, slow alert.MWMBAlert) (*rulefmt.Rule, error)
 
-type sloAlertRulesGenerator struct {
+// SLOAlertRulesGeneratorConfig is the configuration of the SLO alert rules generator.
+type SLOAlertRulesGeneratorConfig struct {
+	// IncludeSLOLabels will add the SLO labels to the alert rules labels, the alert
+	// specific labels have precedence over the SLO labels.
+	IncludeSLOLabels bool
+	// PendingRecordingRules will generate a companion recording rule for every alert with
+	// the alert firing condition as `0` or `1` (by SLO, alert name and severity), the burn rate
+	// conditions don't include the business hours and min budget consumed gates.
+	PendingRecordingRules bool
+	// ObjectivePrecision is the number of significant digits used to format the error budget and
+	// burn rate factors of the alert thresholds, 0 disables the rounding.
+	ObjectivePrecision int
+	// SeverityMapping maps the Sloth alert severities (`page` and `ticket`) to custom `severity`
+	// label values, the Sloth severity label is not mapped. PageSeverity and TicketSeverity have precedence.
+	SeverityMapping map[string]string
+	// PageSeverity and TicketSeverity are the `severity` label values set on the page and ticket
+	// alerts (e.g `critical` and `warning`), the alert spec labels have precedence, empty doesn't set it.
+	PageSeverity   string
+	TicketSeverity string
+	// PageReceiver and TicketReceiver are the `receiver` label values set on the page and ticket
+	// alerts to route them (e.g `pagerduty` and `jira`), the alert spec labels have precedence, empty doesn't set it.
+	PageReceiver   string
+	TicketReceiver string
+	// NoDataAlertSeverity will generate an `absent` based alert for every SLO that fires when the
+	// SLI stops reporting, using this value as the severity label, empty disables the alert.
+	NoDataAlertSeverity string
+	// NoDataAlertMinFor is the minimum `for` of the no data alert (e.g the scrape interval), the only
+	// generated alert with a `for`, shorter `for` durations will be clamped to this minimum, 0 disables the clamping.
+	NoDataAlertMinFor time.Duration
+	// PageMinObjective disables the page alerts of the SLOs with an objective below it (e.g 99),
+	// the ticket alerts are kept, 0 disables the filter.
+	PageMinObjective float64
+	// MinBudgetConsumed is the percent of the SLO period error budget (e.g 10) that needs to be
+	// consumed for the burn rate alerts to fire, 0 disables the condition.
+	MinBudgetConsumed float64
+	// AlertNameFromID uses the SLO ID as the burn rate alerts name instead of the spec alert name,
+	// this way the alert names (and their silences) are stable when the SLO is renamed keeping its ID.
+	AlertNameFromID bool
+	// DefaultAnnotations are the annotations set on all the SLO alerts, the SLO alert annotations
+	// have precedence.
+	DefaultAnnotations map[string]string
+	// AvgOverTimeBurnRates makes the burn rate alerts read the SLI recording rule of the alert shortest
+	// window with `avg_over_time` over every alert window, instead of the SLI recording rule of every
+	// window. This reduces the query cost at the expense of an unweighted average of the SLI ratios.
+	AvgOverTimeBurnRates bool
+	// Logger is used to warn about the generation corrections (e.g clamped alerts `for`).
+	Logger log.Logger
+}
+
+// AlertRulesGenerator knows how to generate the SLO prometheus alert rules.
+type AlertRulesGenerator struct {
 	alertGenFunc alertGenFunc
+	config       SLOAlertRulesGeneratorConfig
+}
+
+// NewSLOAlertRulesGenerator returns a new SLO alert rules generator with custom settings.
+func NewSLOAlertRulesGenerator(config SLOAlertRulesGeneratorConfig) AlertRulesGenerator {
+	if config.Logger == nil {
+		config.Logger = log.Noop
+	}
+
+	return AlertRulesGenerator{
+		alertGenFunc: defaultSLOAlertGenerator,
+		config:       config,
+	}
 }
 
 // SLOAlertRulesGenerator knows how to generate the SLO prometheus alert rules
 // from an SLO.
-var SLOAlertRulesGenerator = sloAlertRulesGenerator{alertGenFunc: defaultSLOAlertGenerator}
+var SLOAlertRulesGenerator = NewSLOAlertRulesGenerator(SLOAlertRulesGeneratorConfig{})
 
-func (s sloAlertRulesGenerator) GenerateSLOAlertRules(_ context.Context, slo SLO, alerts alert.MWMBAlertGroup) ([]rulefmt.Rule, error) {
+func (s AlertRulesGenerator) GenerateSLOAlertRules(_ context.Context, slo SLO, alerts alert.MWMBAlertGroup) ([]rulefmt.Rule, error) {
 	rules := []rulefmt.Rule{}
 
 	// Generate Page alerts.
-	if !slo.PageAlertMeta.Disable {
-		rule, err := s.alertGenFunc(slo, slo.PageAlertMeta, alerts.PageQuick, alerts.PageSlow)
+	if !slo.PageAlertMeta.Disable && slo.Objective >= s.config.PageMinObjective {
+		rule, err := s.alertGenFunc(s.config, slo, slo.PageAlertMeta, alerts.PageQuick, alerts.PageSlow)
 		if err != nil {
 			return nil, fmt.Errorf("could not create page alert: %w", err)
 		}
+		condition, err := burnRateConditionExpr(s.config, slo, alerts.PageQuick, alerts.PageSlow)
+		if err != nil {
+			return nil, fmt.Errorf("could not create page alert condition: %w", err)
+		}
 
-		rules = append(rules, *rule)
+		rules = append(rules, s.withPendingRules(slo, *rule, condition)...)
 	}
 
 	// Generate Ticket alerts.
 	if !slo.TicketAlertMeta.Disable {
-		rule, err := s.alertGenFunc(slo, slo.TicketAlertMeta, alerts.TicketQuick, alerts.TicketSlow)
+		rule, err := s.alertGenFunc(s.config, slo, slo.TicketAlertMeta, alerts.TicketQuick, alerts.TicketSlow)
 		if err != nil {
 			return nil, fmt.Errorf("could not create ticket alert: %w", err)
 		}
+		condition, err := burnRateConditionExpr(s.config, slo, alerts.TicketQuick, alerts.TicketSlow)
+		if err != nil {
+			return nil, fmt.Errorf("could not create ticket alert condition: %w", err)
+		}
+
+		rules = append(rules, s.withPendingRules(slo, *rule, condition)...)
+	}
+
+	// Generate SLI no data alert.
+	if s.config.NoDataAlertSeverity != "" {
+		rule, err := noDataSLOAlertRule(s.config, slo, alerts.PageQuick.ShortWindow)
+		if err != nil {
+			return nil, fmt.Errorf("could not create no data alert: %w", err)
+		}
+
+		// The no data alert `for` is the SLI shortest window, shorter than the scrape interval will not fire reliably.
+		if minFor := prommodel.Duration(s.config.NoDataAlertMinFor); minFor > 0 && rule.For < minFor {
+			s.config.Logger.WithValues(log.Kv{"slo": slo.ID, "alert": rule.Alert, "for": rule.For, "min-for": minFor}).Warningf("No data alert for is shorter than the min alert for, clamping")
+			rule.For = minFor
+		}
 
-		rules = append(rules, *rule)
+		condition := fmt.Sprintf("(\n%s\n) or on() vector(0)\n", strings.TrimSuffix(rule.Expr, "\n"))
+		rules = append(rules, s.withPendingRules(slo, rule, condition)...)
 	}
 
 	return rules, nil
 }
 
-func defaultSLOAlertGenerator(slo SLO, sloAlert AlertMeta, quick, slow alert.MWMBAlert) (*rulefmt.Rule, error) {
+// noDataSLOAlertRule returns an alert that fires when the SLI total events of the window have no data, without
+// data the burn rate alerts can't fire, so we need to know when we are blind. The total events are used because
+// the error events have no data when there are no errors, the SLIs without a total events query (e.g error
+// ratio queries) use the SLI error ratio of the window.
+func noDataSLOAlertRule(config SLOAlertRulesGeneratorConfig, slo SLO, window time.Duration) (rulefmt.Rule, error) {
+	var sloLabels map[string]string
+	if config.IncludeSLOLabels {
+		sloLabels = slo.Labels
+	}
+
+	query := slo.GetSLIErrorMetric(window) + labelsToPromFilter(slo.GetSLOIDPromLabels())
+	if totalQuery := sliTotalQuery(slo); totalQuery != "" {
+		tpl, err := template.New("sliTotalExpr").Option("missingkey=error").Parse(totalQuery)
+		if err != nil {
+			return rulefmt.Rule{}, fmt.Errorf("could not create SLI total expression template data: %w", err)
+		}
+		var b bytes.Buffer
+		err = tpl.Execute(&b, map[string]string{tplKeyWindow: timeDurationToPromStr(window)})
+		if err != nil {
+			return rulefmt.Rule{}, fmt.Errorf("could not render SLI total expression template: %w", err)
+		}
+		query = strings.TrimSpace(b.String())
+	}
+
+	return rulefmt.Rule{
+		Alert: sloSLINoDataAlertName,
+		Expr:  fmt.Sprintf("absent(%s)\n", query),
+		For:   prommodel.Duration(window),
+		Annotations: mergeLabels(map[string]string{
+			"title":   fmt.Sprintf("(%s) {{$labels.%s}} {{$labels.%s}} SLO SLI has no data.", config.NoDataAlertSeverity, sloServiceLabelName, sloNameLabelName),
+			"summary": fmt.Sprintf("{{$labels.%s}} {{$labels.%s}} SLO SLI is not reporting, the SLO burn rate alerts can't fire.", sloServiceLabelName, sloNameLabelName),
+		}, config.DefaultAnnotations),
+		// The absent series only has the labels of the query selectors, so we set the SLO ID labels.
+		Labels: mergeLabels(sloLabels, map[string]string{sloSeverityLabelName: config.NoDataAlertSeverity}, slo.GetSLOIDPromLabels()),
+	}, nil
+}
+
+// sliTotalQuery returns the SLI total events query, empty if the SLI doesn't have one.
+func sliTotalQuery(slo SLO) string {
+	switch {
+	case slo.SLI.Events != nil:
+		return slo.SLI.Events.TotalQuery
+	case slo.SLI.Raw != nil:
+		return slo.SLI.Raw.TotalQuery
+	case slo.SLI.DenominatorCorrected != nil:
+		return slo.SLI.DenominatorCorrected.TotalQuery
+	case slo.SLI.Precomputed != nil:
+		return slo.SLI.Precomputed.TotalMetric
+	}
+
+	return ""
+}
+
+// ServiceBudgetAlertRule returns an alert that fires when the worst SLO of the service has less than
+// the min budget remaining percent (e.g 10) of its period error budget, aggregating the SLOs period
+// error budget remaining metadata recording rules of the service. It's a ticket alert, so it has
+// the same severity labels, receiver and default annotations as the SLO ticket alerts.
+func ServiceBudgetAlertRule(config SLOAlertRulesGeneratorConfig, service string, idLabels map[string]string, minBudgetRemaining float64) rulefmt.Rule {
+	filter := labelsToPromFilter(mergeLabels(map[string]string{sloServiceLabelName: service}, idLabels))
+	return rulefmt.Rule{
+		Alert: sloServiceBudgetLowAlertName,
+		Expr: fmt.Sprintf("min by(%s) (%s%s) < %s\n",
+			sloServiceLabelName,
+			metricSLOPeriodErrorBudgetRemainingRatio,
+			filter,
+			strconv.FormatFloat(minBudgetRemaining/100, 'f', -1, 64),
+		),
+		Annotations: mergeLabels(map[string]string{
+			"title":   fmt.Sprintf("(%s) {{$labels.%s}} service SLOs error budget is running out.", alert.TicketAlertSeverity, sloServiceLabelName),
+			"summary": fmt.Sprintf("{{$labels.%s}} service worst SLO has {{ $value | humanizePercentage }} of its period error budget remaining.", sloServiceLabelName),
+		}, config.DefaultAnnotations),
+		Labels: mergeLabels(alertSeverityLabels(config, alert.TicketAlertSeverity), idLabels),
+	}
+}
+
+// alertSeverityLabels returns the severity labels of the alerts with the Sloth severity, the Sloth
+// severity label and the alert severity (mapped if required) and receiver labels, if configured.
+func alertSeverityLabels(config SLOAlertRulesGeneratorConfig, severity alert.Severity) map[string]string {
+	labels := map[string]string{
+		sloSeverityLabelName: severity.String(),
+	}
+
+	alertSeverity, alertReceiver := config.TicketSeverity, config.TicketReceiver
+	if severity == alert.PageAlertSeverity {
+		alertSeverity, alertReceiver = config.PageSeverity, config.PageReceiver
+	}
+	if v, ok := config.SeverityMapping[severity.String()]; ok && alertSeverity == "" {
+		alertSeverity = v
+	}
+	if alertSeverity != "" {
+		labels[alertSeverityLabelName] = alertSeverity
+	}
+	if alertReceiver != "" {
+		labels[alertReceiverLabelName] = alertReceiver
+	}
+
+	return labels
+}
+
+// withPendingRules will return the alert rule along with its firing condition recording rule, if
+// enabled. The condition is recorded as `0` or `1` (by SLO, alert name and severity) so the pending
+// state of the alerts can be tracked, the alerts `for` is kept.
+func (s AlertRulesGenerator) withPendingRules(slo SLO, rule rulefmt.Rule, conditionExpr string) []rulefmt.Rule {
+	if !s.config.PendingRecordingRules {
+		return []rulefmt.Rule{rule}
+	}
+
+	// The alert name is required so the conditions of the SLO alerts with the same severity
+	// (e.g burn rate and no data alerts) don't collide.
+	conditionRule := rulefmt.Rule{
+		Record: sloAlertConditionMetric,
+		Expr:   conditionExpr,
+		Labels: mergeLabels(slo.GetSLOIDPromLabels(), map[string]string{
+			sloAlertLabelName:    rule.Alert,
+			sloSeverityLabelName: rule.Labels[sloSeverityLabelName],
+		}),
+	}
+
+	return []rulefmt.Rule{conditionRule, rule}
+}
+
+func defaultSLOAlertGenerator(config SLOAlertRulesGeneratorConfig, slo SLO, sloAlert AlertMeta, quick, slow alert.MWMBAlert) (*rulefmt.Rule, error) {
 	// Generate the filter labels based on the SLO ids.
 	metricFilter := labelsToPromFilter(slo.GetSLOIDPromLabels())
 
 	// Render the alert template.
-	tplData := struct {
-		MetricFilter         string
-		ErrorBudgetRatio     float64
-		QuickShortMetric     string
-		QuickShortBurnFactor float64
-		QuickLongMetric      string
-		QuickLongBurnFactor  float64
-		SlowShortMetric      string
-		SlowShortBurnFactor  float64
-		SlowQuickMetric      string
-		SlowQuickBurnFactor  float64
-		WindowLabel          string
-	}{
-		MetricFilter:         metricFilter,
-		ErrorBudgetRatio:     quick.ErrorBudget / 100, // Any(quick or slow) should work because are the same.
-		QuickShortMetric:     slo.GetSLIErrorMetric(quick.ShortWindow),
-		QuickShortBurnFactor: quick.BurnRateFactor,
-		QuickLongMetric:      slo.GetSLIErrorMetric(quick.LongWindow),
-		QuickLongBurnFactor:  quick.BurnRateFactor,
-		SlowShortMetric:      slo.GetSLIErrorMetric(slow.ShortWindow),
-		SlowShortBurnFactor:  slow.BurnRateFactor,
-		SlowQuickMetric:      slo.GetSLIErrorMetric(slow.LongWindow),
-		SlowQuickBurnFactor:  slow.BurnRateFactor,
-		WindowLabel:          sloWindowLabelName,
-	}
+	tplData := newMWMBAlertTplData(config, slo, quick, slow)
 	var expr bytes.Buffer
 	err := mwmbAlertTpl.Execute(&expr, tplData)
 	if err != nil {
 		return nil, fmt.Errorf("could not render alert expression: %w", err)
 	}
 
+	// Only fire when the burn rate has already consumed part of the period error budget.
+	if config.MinBudgetConsumed > 0 {
+		exprBody := expr.String()
+		expr.Reset()
+		err := budgetConsumedAlertTpl.Execute(&expr, map[string]interface{}{
+			"AlertExpr":             strings.TrimSuffix(exprBody, "\n"),
+			"MetricFilter":          metricFilter,
+			"BudgetRemainingMetric": metricSLOPeriodErrorBudgetRemainingRatio,
+			"BudgetConsumedRatio":   config.MinBudgetConsumed / 100,
+			"SLOIDName":             sloIDLabelName,
+			"SLOLabelName":          sloNameLabelName,
+			"SLOServiceName":        sloServiceLabelName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not render alert budget consumed expression: %w", err)
+		}
+	}
+
+	// Only fire on the business hours.
+	if sloAlert.BusinessHours != nil && sloAlert.BusinessHours.TimeGate {
+		exprBody := expr.String()
+		expr.Reset()
+		hours, days := businessHoursConditions(*sloAlert.BusinessHours)
+		err := businessHoursAlertTpl.Execute(&expr, map[string]interface{}{
+			"AlertExpr":      strings.TrimSuffix(exprBody, "\n"),
+			"HoursCondition": hours,
+			"DaysCondition":  days,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not render alert business hours expression: %w", err)
+		}
+	}
+
 	// Add specific annotations.
 	severity := quick.Severity.String() // Any(quick or slow) should work because are the same.
 	extraAnnotations := map[string]string{
@@ -91,30 +315,220 @@ func defaultSLOAlertGenerator(slo SLO, sloAlert AlertMeta, quick, slow alert.MWM
 		"summary": fmt.Sprintf("{{$labels.%s}} {{$labels.%s}} SLO error budget burn rate is over expected.", sloServiceLabelName, sloNameLabelName),
 	}
 
-	// Add specific labels. We don't add the labels from the rules because we will
+	// Add specific labels. By default we don't add the labels from the rules because we will
 	// inherit on the alerts, this way we avoid warnings of overrided labels.
-	extraLabels := map[string]string{
-		sloSeverityLabelName: severity,
+	extraLabels := alertSeverityLabels(config, quick.Severity)
+	if sloAlert.BusinessHours != nil {
+		extraLabels[alertBusinessHoursOnlyLabelName] = "true"
+	}
+
+	var sloLabels map[string]string
+	if config.IncludeSLOLabels {
+		sloLabels = slo.Labels
+	}
+
+	annotations, err := renderAnnotationHelpers(mergeLabels(extraAnnotations, config.DefaultAnnotations, sloAlert.Annotations), slo, quick, slow)
+	if err != nil {
+		return nil, fmt.Errorf("could not render alert annotations: %w", err)
+	}
+
+	alertName := sloAlert.Name
+	if config.AlertNameFromID {
+		alertName = slo.ID
 	}
 
 	return &rulefmt.Rule{
-		Alert:       sloAlert.Name,
+		Alert:       alertName,
 		Expr:        expr.String(),
-		Annotations: mergeLabels(extraAnnotations, sloAlert.Annotations),
-		Labels:      mergeLabels(extraLabels, sloAlert.Labels, slo.IDLabels),
+		Annotations: annotations,
+		Labels:      mergeLabels(sloLabels, extraLabels, sloAlert.Labels, slo.IDLabels),
 	}, nil
 }
 
+// mwmbAlertTplData is the data of the multiburn multiwindow alert templates.
+type mwmbAlertTplData struct {
+	ErrorBudgetRatio     float64
+	QuickShortQuery      string
+	QuickShortBurnFactor float64
+	QuickLongQuery       string
+	QuickLongBurnFactor  float64
+	SlowShortQuery       string
+	SlowShortBurnFactor  float64
+	SlowQuickQuery       string
+	SlowQuickBurnFactor  float64
+	WindowLabel          string
+}
+
+func newMWMBAlertTplData(config SLOAlertRulesGeneratorConfig, slo SLO, quick, slow alert.MWMBAlert) mwmbAlertTplData {
+	metricFilter := labelsToPromFilter(slo.GetSLOIDPromLabels())
+
+	// Get the SLI error ratio query of every alert window.
+	sliQuery := func(window time.Duration) string {
+		return slo.GetSLIErrorMetric(window) + metricFilter
+	}
+	if config.AvgOverTimeBurnRates {
+		baseWindow := quick.ShortWindow
+		if slow.ShortWindow < baseWindow {
+			baseWindow = slow.ShortWindow
+		}
+		sliQuery = func(window time.Duration) string {
+			baseQuery := slo.GetSLIErrorMetric(baseWindow) + metricFilter
+			if window == baseWindow {
+				return baseQuery
+			}
+			return fmt.Sprintf("avg_over_time(%s[%s])", baseQuery, timeDurationToPromStr(window))
+		}
+	}
+
+	return mwmbAlertTplData{
+		ErrorBudgetRatio:     roundFloat(quick.ErrorBudget/100, config.ObjectivePrecision), // Any(quick or slow) should work because are the same.
+		QuickShortQuery:      sliQuery(quick.ShortWindow),
+		QuickShortBurnFactor: roundFloat(quick.BurnRateFactor, config.ObjectivePrecision),
+		QuickLongQuery:       sliQuery(quick.LongWindow),
+		QuickLongBurnFactor:  roundFloat(quick.BurnRateFactor, config.ObjectivePrecision),
+		SlowShortQuery:       sliQuery(slow.ShortWindow),
+		SlowShortBurnFactor:  roundFloat(slow.BurnRateFactor, config.ObjectivePrecision),
+		SlowQuickQuery:       sliQuery(slow.LongWindow),
+		SlowQuickBurnFactor:  roundFloat(slow.BurnRateFactor, config.ObjectivePrecision),
+		WindowLabel:          sloWindowLabelName,
+	}
+}
+
+// burnRateConditionExpr returns the multiburn multiwindow burn rate condition of the alert as `0` or `1`,
+// instead of filtering the series like the alert expression does.
+func burnRateConditionExpr(config SLOAlertRulesGeneratorConfig, slo SLO, quick, slow alert.MWMBAlert) (string, error) {
+	var expr bytes.Buffer
+	err := mwmbConditionTpl.Execute(&expr, newMWMBAlertTplData(config, slo, quick, slow))
+	if err != nil {
+		return "", fmt.Errorf("could not render alert condition expression: %w", err)
+	}
+
+	return expr.String(), nil
+}
+
 // Multiburn multiwindow alert template.
 var mwmbAlertTpl = template.Must(template.New("mwmbAlertTpl").Option("missingkey=error").Parse(`(
-    max({{ .QuickShortMetric }}{{ .MetricFilter}} > ({{ .QuickShortBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+    max({{ .QuickShortQuery }} > ({{ .QuickShortBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
     and
-    max({{ .QuickLongMetric }}{{ .MetricFilter}} > ({{ .QuickLongBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+    max({{ .QuickLongQuery }} > ({{ .QuickLongBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
 )
 or
 (
-    max({{ .SlowShortMetric }}{{ .MetricFilter }} > ({{ .SlowShortBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+    max({{ .SlowShortQuery }} > ({{ .SlowShortBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
     and
-    max({{ .SlowQuickMetric }}{{ .MetricFilter }} > ({{ .SlowQuickBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+    max({{ .SlowQuickQuery }} > ({{ .SlowQuickBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
 )
 `))
+
+// Multiburn multiwindow alert condition template, `1` when any of the windows burn rates are over
+// the threshold and `0` otherwise.
+var mwmbConditionTpl = template.Must(template.New("mwmbConditionTpl").Option("missingkey=error").Parse(`(
+    max({{ .QuickShortQuery }} > bool ({{ .QuickShortBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+    *
+    max({{ .QuickLongQuery }} > bool ({{ .QuickLongBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+)
++
+(
+    max({{ .SlowShortQuery }} > bool ({{ .SlowShortBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+    *
+    max({{ .SlowQuickQuery }} > bool ({{ .SlowQuickBurnFactor }} * {{ .ErrorBudgetRatio }})) without ({{ .WindowLabel }})
+)
+> bool 0
+`))
+
+// Error budget consumed condition template, wraps the multiburn multiwindow alert expression.
+var budgetConsumedAlertTpl = template.Must(template.New("budgetConsumedAlertTpl").Option("missingkey=error").Parse(`(
+{{ .AlertExpr }}
+)
+and on({{ .SLOIDName }}, {{ .SLOLabelName }}, {{ .SLOServiceName }})
+(
+    {{ .BudgetRemainingMetric }}{{ .MetricFilter }} <= (1 - {{ .BudgetConsumedRatio }})
+)
+`))
+
+// Business hours condition template, wraps the alert expression with the UTC hours and days of the week.
+var businessHoursAlertTpl = template.Must(template.New("businessHoursAlertTpl").Option("missingkey=error").Parse(`(
+{{ .AlertExpr }}
+)
+and on()
+(
+    {{ .HoursCondition }}
+    and on()
+    {{ .DaysCondition }}
+)
+`))
+
+// businessHoursConditions returns the UTC hours and days PromQL conditions of the business hours,
+// the ranges that wrap around (e.g overnight hours) are the union of both range ends.
+func businessHoursConditions(bh AlertBusinessHours) (hours, days string) {
+	hours = fmt.Sprintf("hour() >= %d < %d", bh.StartHour, bh.EndHour)
+	if bh.StartHour > bh.EndHour {
+		hours = fmt.Sprintf("(hour() >= %d or hour() < %d)", bh.StartHour, bh.EndHour)
+	}
+
+	days = fmt.Sprintf("day_of_week() >= %d <= %d", bh.StartDay, bh.EndDay)
+	if bh.StartDay > bh.EndDay {
+		days = fmt.Sprintf("(day_of_week() >= %d or day_of_week() <= %d)", bh.StartDay, bh.EndDay)
+	}
+
+	return hours, days
+}
+
+// annotationHelperRegexp matches the Sloth helpers on the alert annotations, e.g: `<<sloth:budget_burned_minutes>>`.
+var annotationHelperRegexp = regexp.MustCompile(`<<sloth:([a-z_]+)>>`)
+
+const (
+	// annotationHelperBudgetBurnedMinutes is replaced by a Prometheus template that gets the error budget
+	// minutes burned in the alert window (the SLI error ratio of the window scaled to minutes).
+	annotationHelperBudgetBurnedMinutes = "budget_burned_minutes"
+	// annotationHelperAlertWindow is replaced by the alert window (e.g: `1h`).
+	annotationHelperAlertWindow = "alert_window"
+	// annotationHelperQuickShortWindow is replaced by the short window of the quick alert (e.g: `5m`).
+	annotationHelperQuickShortWindow = "quick_short_window"
+	// annotationHelperQuickLongWindow is replaced by the long window of the quick alert (e.g: `1h`).
+	annotationHelperQuickLongWindow = "quick_long_window"
+	// annotationHelperSlowShortWindow is replaced by the short window of the slow alert (e.g: `30m`).
+	annotationHelperSlowShortWindow = "slow_short_window"
+	// annotationHelperSlowLongWindow is replaced by the long window of the slow alert (e.g: `6h`).
+	annotationHelperSlowLongWindow = "slow_long_window"
+)
+
+// renderAnnotationHelpers replaces the Sloth helpers of the alert annotations, the alert window
+// used is the long window of the quick alert, the one that triggers first.
+func renderAnnotationHelpers(annotations map[string]string, slo SLO, quick, slow alert.MWMBAlert) (map[string]string, error) {
+	window := quick.LongWindow
+	budgetBurnedMinutesTpl := fmt.Sprintf("{{ with query `%s%s * %s` }}{{ . | first | value | printf \"%%.0f\" }}{{ end }}",
+		slo.GetSLIErrorMetric(window),
+		labelsToPromFilter(slo.GetSLOIDPromLabels()),
+		strconv.FormatFloat(window.Minutes(), 'f', -1, 64),
+	)
+
+	res := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		var err error
+		res[k] = annotationHelperRegexp.ReplaceAllStringFunc(v, func(m string) string {
+			switch helper := annotationHelperRegexp.FindStringSubmatch(m)[1]; helper {
+			case annotationHelperBudgetBurnedMinutes:
+				return budgetBurnedMinutesTpl
+			case annotationHelperAlertWindow:
+				return timeDurationToPromStr(window)
+			case annotationHelperQuickShortWindow:
+				return timeDurationToPromStr(quick.ShortWindow)
+			case annotationHelperQuickLongWindow:
+				return timeDurationToPromStr(quick.LongWindow)
+			case annotationHelperSlowShortWindow:
+				return timeDurationToPromStr(slow.ShortWindow)
+			case annotationHelperSlowLongWindow:
+				return timeDurationToPromStr(slow.LongWindow)
+			default:
+				err = fmt.Errorf("unknown %q annotation helper", helper)
+				return m
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q annotation: %w", k, err)
+		}
+	}
+
+	return res, nil
+}
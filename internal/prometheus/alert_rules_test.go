@@ -2,11 +2,14 @@ package prometheus_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	prommodel "github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/slok/sloth/internal/alert"
 	"github.com/slok/sloth/internal/prometheus"
@@ -49,8 +52,23 @@ func getSLOAlertGroup() alert.MWMBAlertGroup {
 	}
 }
 
+// testPageAlertExpr is the page alert expression of the `test-svc-test` SLO using `getSLOAlertGroup` alerts.
+const testPageAlertExpr = `(
+    max(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (13 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate12m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (13 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate21m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate22m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.01)) without (sloth_window)
+)
+`
+
 func TestGenerateSLOAlertRules(t *testing.T) {
 	tests := map[string]struct {
+		config     prometheus.SLOAlertRulesGeneratorConfig
 		slo        prometheus.SLO
 		alertGroup func() alert.MWMBAlertGroup
 		expRules   []rulefmt.Rule
@@ -209,19 +227,1374 @@ or
 				},
 			},
 		},
-	}
 
-	for name, test := range tests {
-		t.Run(name, func(t *testing.T) {
-			assert := assert.New(t)
+		"Having an SLO with labels and the SLO labels option disabled, shouldn't add the SLO labels on the alerts.": {
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				Labels:  map[string]string{"team": "team-a", "custom-label": "slo"},
+				PageAlertMeta: prometheus.AlertMeta{
+					Name:   "something1",
+					Labels: map[string]string{"custom-label": "test1"},
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"custom-label":   "test1",
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
 
-			gotRules, err := prometheus.SLOAlertRulesGenerator.GenerateSLOAlertRules(context.TODO(), test.slo, test.alertGroup())
+		"Having an SLO with labels and the SLO labels option enabled, should add the SLO labels on the alerts with lower precedence.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{IncludeSLOLabels: true},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				Labels:  map[string]string{"team": "team-a", "custom-label": "slo", "sloth_severity": "slo"},
+				PageAlertMeta: prometheus.AlertMeta{
+					Name:   "something1",
+					Labels: map[string]string{"custom-label": "test1"},
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"team":           "team-a",
+						"custom-label":   "test1",
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
 
-			if test.expErr {
-				assert.Error(err)
-			} else if assert.NoError(err) {
-				assert.Equal(test.expRules, gotRules)
-			}
+		"Having an SLO with the pending recording rules option enabled, should add the alerts firing condition recording rules keeping the alerts for.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{PendingRecordingRules: true, NoDataAlertSeverity: "warning"},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:alert_firing_condition:bool",
+					Expr: `(
+    max(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > bool (13 * 0.01)) without (sloth_window)
+    *
+    max(slo:sli_error:ratio_rate12m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > bool (13 * 0.01)) without (sloth_window)
+)
++
+(
+    max(slo:sli_error:ratio_rate21m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > bool (23 * 0.01)) without (sloth_window)
+    *
+    max(slo:sli_error:ratio_rate22m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > bool (23 * 0.01)) without (sloth_window)
+)
+> bool 0
+`,
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"sloth_alert":    "something1",
+						"sloth_severity": "page",
+					},
+				},
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Record: "slo:alert_firing_condition:bool",
+					Expr:   "(\nabsent(slo:sli_error:ratio_rate11m{sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"})\n) or on() vector(0)\n",
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"sloth_alert":    "SlothSLINoData",
+						"sloth_severity": "warning",
+					},
+				},
+				{
+					Alert: "SlothSLINoData",
+					Expr:  "absent(slo:sli_error:ratio_rate11m{sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"})\n",
+					For:   prommodel.Duration(11 * time.Minute),
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"sloth_severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI is not reporting, the SLO burn rate alerts can't fire.",
+						"title":   "(warning) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI has no data.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a severity mapping, should use the custom severity values on each alert tier.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{
+				SeverityMapping: map[string]string{"page": "sev1", "ticket": "sev2"},
+			},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name: "something2",
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+						"severity":       "sev1",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "something2",
+					Expr: `(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+						"severity":       "sev2",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with the objective ID label, should select the SLI metrics by the objective label.": {
+			slo: prometheus.SLO{
+				ID:               "test-svc-test",
+				Name:             "test",
+				Service:          "test-svc",
+				Objective:        99.9,
+				ObjectiveIDLabel: true,
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr: `(
+    max(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_objective="99.9", sloth_service="test-svc", sloth_slo="test"} > (13 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate12m{sloth_id="test-svc-test", sloth_objective="99.9", sloth_service="test-svc", sloth_slo="test"} > (13 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate21m{sloth_id="test-svc-test", sloth_objective="99.9", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate22m{sloth_id="test-svc-test", sloth_objective="99.9", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with alert annotation helpers, should render the budget burned minutes and window of the alert.": {
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+					Annotations: map[string]string{
+						"description": "Burned <<sloth:budget_burned_minutes>> minutes of error budget in the last <<sloth:alert_window>>.",
+					},
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"description": "Burned {{ with query `slo:sli_error:ratio_rate12m{sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"} * 12` }}{{ . | first | value | printf \"%.0f\" }}{{ end }} minutes of error budget in the last 12m.",
+						"summary":     "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":       "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with an unknown alert annotation helper, should fail.": {
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+					Annotations: map[string]string{
+						"description": "Burned <<sloth:budget_burned_hours>> hours.",
+					},
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expErr:     true,
+		},
+
+		"Having an SLO with the alert window annotation helpers, should render the windows of every alert tier.": {
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name:        "something1",
+					Annotations: map[string]string{"windows": "<<sloth:quick_short_window>>/<<sloth:quick_long_window>> <<sloth:slow_short_window>>/<<sloth:slow_long_window>>"},
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name:        "something2",
+					Annotations: map[string]string{"windows": "<<sloth:quick_short_window>>/<<sloth:quick_long_window>> <<sloth:slow_short_window>>/<<sloth:slow_long_window>>"},
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"windows": "11m/12m 21m/22m",
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "something2",
+					Expr: `(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+					},
+					Annotations: map[string]string{
+						"windows": "31m/32m 41m/42m",
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a record name template, should use the templated SLI metrics on the alerts.": {
+			slo: prometheus.SLO{
+				ID:                 "test-svc-test",
+				Name:               "test",
+				Service:            "test-svc",
+				RecordNameTemplate: "team:{{ .Role }}:ratio_rate{{ .Window }}",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name:        "something1",
+					Annotations: map[string]string{"description": "<<sloth:budget_burned_minutes>>"},
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  strings.ReplaceAll(testPageAlertExpr, "slo:sli_error:", "team:sli_error:"),
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"description": "{{ with query `team:sli_error:ratio_rate12m{sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"} * 12` }}{{ . | first | value | printf \"%.0f\" }}{{ end }}",
+						"summary":     "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":       "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with the no data alert option enabled, should add an absent based alert on the SLI series.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{NoDataAlertSeverity: "warning"},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "SlothSLINoData",
+					Expr:  "absent(slo:sli_error:ratio_rate11m{sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"})\n",
+					For:   prommodel.Duration(11 * time.Minute),
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"sloth_severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI is not reporting, the SLO burn rate alerts can't fire.",
+						"title":   "(warning) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI has no data.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with an events SLI and the no data alert option enabled, should check the total events so having zero errors doesn't fire.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{NoDataAlertSeverity: "warning"},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+					ErrorQuery: `sum(rate(http_requests_total{code=~"5.."}[{{.window}}]))`,
+					TotalQuery: `sum(rate(http_requests_total[{{.window}}]))`,
+				}},
+				PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+				TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "SlothSLINoData",
+					Expr:  "absent(sum(rate(http_requests_total[11m])))\n",
+					For:   prommodel.Duration(11 * time.Minute),
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"sloth_severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI is not reporting, the SLO burn rate alerts can't fire.",
+						"title":   "(warning) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI has no data.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with the no data alert option enabled and alerts disabled, should only add the no data alert.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{NoDataAlertSeverity: "warning"},
+			slo: prometheus.SLO{
+				ID:              "test-svc-test",
+				Name:            "test",
+				Service:         "test-svc",
+				IDLabels:        map[string]string{"env": "prod"},
+				PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+				TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "SlothSLINoData",
+					Expr:  "absent(slo:sli_error:ratio_rate11m{env=\"prod\", sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"})\n",
+					For:   prommodel.Duration(11 * time.Minute),
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"env":            "prod",
+						"sloth_severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI is not reporting, the SLO burn rate alerts can't fire.",
+						"title":   "(warning) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI has no data.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a no data alert for shorter than the min alert for, should clamp the alert for to the min.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{NoDataAlertSeverity: "warning", NoDataAlertMinFor: 30 * time.Second},
+			slo: prometheus.SLO{
+				ID:              "test-svc-test",
+				Name:            "test",
+				Service:         "test-svc",
+				PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+				TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+			},
+			alertGroup: func() alert.MWMBAlertGroup {
+				g := getSLOAlertGroup()
+				g.PageQuick.ShortWindow = 10 * time.Second
+				return g
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "SlothSLINoData",
+					Expr:  "absent(slo:sli_error:ratio_rate10s{sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"})\n",
+					For:   prommodel.Duration(30 * time.Second),
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"sloth_severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI is not reporting, the SLO burn rate alerts can't fire.",
+						"title":   "(warning) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI has no data.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a no data alert for longer than the min alert for, should not clamp the alert for.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{NoDataAlertSeverity: "warning", NoDataAlertMinFor: 30 * time.Second},
+			slo: prometheus.SLO{
+				ID:              "test-svc-test",
+				Name:            "test",
+				Service:         "test-svc",
+				PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+				TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "SlothSLINoData",
+					Expr:  "absent(slo:sli_error:ratio_rate11m{sloth_id=\"test-svc-test\", sloth_service=\"test-svc\", sloth_slo=\"test\"})\n",
+					For:   prommodel.Duration(11 * time.Minute),
+					Labels: map[string]string{
+						"sloth_id":       "test-svc-test",
+						"sloth_service":  "test-svc",
+						"sloth_slo":      "test",
+						"sloth_severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI is not reporting, the SLO burn rate alerts can't fire.",
+						"title":   "(warning) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO SLI has no data.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with an objective below the page min objective, should only create ticket alert rules.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{PageMinObjective: 99},
+			slo: prometheus.SLO{
+				ID:        "test-svc-test",
+				Name:      "test",
+				Service:   "test-svc",
+				Objective: 98.5,
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name: "something2",
+				},
+			},
+			alertGroup: func() alert.MWMBAlertGroup {
+				g := getSLOAlertGroup()
+				g.TicketQuick = g.PageQuick
+				g.TicketSlow = g.PageSlow
+				g.TicketQuick.Severity = alert.TicketAlertSeverity
+				return g
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something2",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with an objective above the page min objective, should create page and ticket alert rules.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{PageMinObjective: 99},
+			slo: prometheus.SLO{
+				ID:        "test-svc-test",
+				Name:      "test",
+				Service:   "test-svc",
+				Objective: 99.9,
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name: "something2",
+				},
+			},
+			alertGroup: func() alert.MWMBAlertGroup {
+				g := getSLOAlertGroup()
+				g.TicketQuick = g.PageQuick
+				g.TicketSlow = g.PageSlow
+				g.TicketQuick.Severity = alert.TicketAlertSeverity
+				return g
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "something2",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having page and ticket severities, should set the severity label on the alerts.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{
+				PageSeverity:   "critical",
+				TicketSeverity: "warning",
+			},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name: "something2",
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+						"severity":       "critical",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "something2",
+					Expr: `(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+						"severity":       "warning",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having page and ticket receivers, should set a different receiver label on every alert tier.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{
+				PageReceiver:   "pagerduty",
+				TicketReceiver: "jira",
+			},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name: "something2",
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+						"receiver":       "pagerduty",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "something2",
+					Expr: `(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+						"receiver":       "jira",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having page and ticket severities and alert severity labels, the alert labels should have precedence.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{
+				PageSeverity:   "critical",
+				TicketSeverity: "warning",
+			},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name:   "something2",
+					Labels: map[string]string{"severity": "info"},
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+						"severity":       "critical",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "something2",
+					Expr: `(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+						"severity":       "info",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having only the page severity, should set the severity label only on the page alerts.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{
+				PageSeverity: "critical",
+			},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name: "something2",
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr:  testPageAlertExpr,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+						"severity":       "critical",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+				{
+					Alert: "something2",
+					Expr: `(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "ticket",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having a min budget consumed, should add the budget consumed condition to the alerts.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{MinBudgetConsumed: 10},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr: `(
+(
+    max(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (13 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate12m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (13 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate21m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate22m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.01)) without (sloth_window)
+)
+)
+and on(sloth_id, sloth_slo, sloth_service)
+(
+    slo:period_error_budget_remaining:ratio{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} <= (1 - 0.1)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having a business hours alert with time gate, should add the business hours label and the time gate condition to the alert.": {
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name:          "something2",
+					BusinessHours: &prometheus.AlertBusinessHours{StartHour: 9, EndHour: 17, StartDay: 1, EndDay: 5, TimeGate: true},
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something2",
+					Expr: `(
+(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+)
+and on()
+(
+    hour() >= 9 < 17
+    and on()
+    day_of_week() >= 1 <= 5
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity":      "ticket",
+						"business_hours_only": "true",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having a business hours alert with wrapping ranges time gate, should add the union of both range ends to the time gate condition.": {
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name:          "something2",
+					BusinessHours: &prometheus.AlertBusinessHours{StartHour: 22, EndHour: 6, StartDay: 5, EndDay: 1, TimeGate: true},
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something2",
+					Expr: `(
+(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+)
+and on()
+(
+    (hour() >= 22 or hour() < 6)
+    and on()
+    (day_of_week() >= 5 or day_of_week() <= 1)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity":      "ticket",
+						"business_hours_only": "true",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having a business hours alert without time gate, should only add the business hours label to the alert.": {
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Name:          "something2",
+					BusinessHours: &prometheus.AlertBusinessHours{StartHour: 9, EndHour: 17, StartDay: 1, EndDay: 5},
+				},
+			},
+			alertGroup: getSLOAlertGroup,
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something2",
+					Expr: `(
+    max(slo:sli_error:ratio_rate31m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate32m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (33 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate41m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate42m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (43 * 0.01)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity":      "ticket",
+						"business_hours_only": "true",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(ticket) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a high objective and a low precision, shouldn't round the error budget to 0.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{ObjectivePrecision: 3},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: func() alert.MWMBAlertGroup {
+				g := getSLOAlertGroup()
+				g.PageQuick.ErrorBudget = 100 - 99.99
+				g.PageQuick.BurnRateFactor = 0.1 * 3 * 48
+				g.PageSlow.ErrorBudget = 100 - 99.99
+				return g
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr: `(
+    max(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (14.4 * 0.0001)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate12m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (14.4 * 0.0001)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate21m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.0001)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate22m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.0001)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a floating point objective and a precision, should format the thresholds with the precision.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{ObjectivePrecision: 6},
+			slo: prometheus.SLO{
+				ID:      "test-svc-test",
+				Name:    "test",
+				Service: "test-svc",
+				PageAlertMeta: prometheus.AlertMeta{
+					Name: "something1",
+				},
+				TicketAlertMeta: prometheus.AlertMeta{
+					Disable: true,
+				},
+			},
+			alertGroup: func() alert.MWMBAlertGroup {
+				g := getSLOAlertGroup()
+				g.PageQuick.ErrorBudget = 100 - 99.95
+				g.PageQuick.BurnRateFactor = 0.1 * 3 * 48
+				g.PageSlow.ErrorBudget = 100 - 99.95
+				return g
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Alert: "something1",
+					Expr: `(
+    max(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (14.4 * 0.0005)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate12m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (14.4 * 0.0005)) without (sloth_window)
+)
+or
+(
+    max(slo:sli_error:ratio_rate21m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.0005)) without (sloth_window)
+    and
+    max(slo:sli_error:ratio_rate22m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (23 * 0.0005)) without (sloth_window)
+)
+`,
+					Labels: map[string]string{
+						"sloth_severity": "page",
+					},
+					Annotations: map[string]string{
+						"summary": "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+						"title":   "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotRules, err := prometheus.NewSLOAlertRulesGenerator(test.config).GenerateSLOAlertRules(context.TODO(), test.slo, test.alertGroup())
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expRules, gotRules)
+			}
+		})
+	}
+}
+
+func TestGenerateSLOAlertRulesAlertNameFromID(t *testing.T) {
+	newSLO := func(name, alertName string) prometheus.SLO {
+		return prometheus.SLO{
+			ID:              "test-svc-stable",
+			Name:            name,
+			Service:         "test-svc",
+			PageAlertMeta:   prometheus.AlertMeta{Name: alertName},
+			TicketAlertMeta: prometheus.AlertMeta{Name: alertName},
+		}
+	}
+
+	tests := map[string]struct {
+		config               prometheus.SLOAlertRulesGeneratorConfig
+		expAlertNames        []string
+		expRenamedAlertNames []string
+	}{
+		"Without the alert name from ID, renaming the SLO alerts should change the alert names.": {
+			config:               prometheus.SLOAlertRulesGeneratorConfig{},
+			expAlertNames:        []string{"OldAlert", "OldAlert"},
+			expRenamedAlertNames: []string{"NewAlert", "NewAlert"},
+		},
+
+		"With the alert name from ID, renaming the SLO and its alerts should keep the alert names.": {
+			config:               prometheus.SLOAlertRulesGeneratorConfig{AlertNameFromID: true},
+			expAlertNames:        []string{"test-svc-stable", "test-svc-stable"},
+			expRenamedAlertNames: []string{"test-svc-stable", "test-svc-stable"},
+		},
+	}
+
+	alertNames := func(rules []rulefmt.Rule) []string {
+		names := []string{}
+		for _, r := range rules {
+			names = append(names, r.Alert)
+		}
+		return names
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			gen := prometheus.NewSLOAlertRulesGenerator(test.config)
+
+			gotRules, err := gen.GenerateSLOAlertRules(context.TODO(), newSLO("old-name", "OldAlert"), getSLOAlertGroup())
+			require.NoError(err)
+			gotRenamedRules, err := gen.GenerateSLOAlertRules(context.TODO(), newSLO("new-name", "NewAlert"), getSLOAlertGroup())
+			require.NoError(err)
+
+			assert.Equal(test.expAlertNames, alertNames(gotRules))
+			assert.Equal(test.expRenamedAlertNames, alertNames(gotRenamedRules))
+		})
+	}
+}
+
+func TestGenerateSLOAlertRulesAvgOverTimeBurnRates(t *testing.T) {
+	slo := prometheus.SLO{
+		ID:              "test-svc-test",
+		Name:            "test",
+		Service:         "test-svc",
+		PageAlertMeta:   prometheus.AlertMeta{Name: "testAlert"},
+		TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+	}
+
+	tests := map[string]struct {
+		config  prometheus.SLOAlertRulesGeneratorConfig
+		expExpr string
+	}{
+		"Without avg over time burn rates, the alerts should use the SLI recording rule of every window.": {
+			config:  prometheus.SLOAlertRulesGeneratorConfig{},
+			expExpr: testPageAlertExpr,
+		},
+
+		"With avg over time burn rates, the alerts should use the shortest window SLI recording rule averaged over every window.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{AvgOverTimeBurnRates: true},
+			expExpr: `(
+    max(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"} > (13 * 0.01)) without (sloth_window)
+    and
+    max(avg_over_time(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"}[12m]) > (13 * 0.01)) without (sloth_window)
+)
+or
+(
+    max(avg_over_time(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"}[21m]) > (23 * 0.01)) without (sloth_window)
+    and
+    max(avg_over_time(slo:sli_error:ratio_rate11m{sloth_id="test-svc-test", sloth_service="test-svc", sloth_slo="test"}[22m]) > (23 * 0.01)) without (sloth_window)
+)
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			gen := prometheus.NewSLOAlertRulesGenerator(test.config)
+			gotRules, err := gen.GenerateSLOAlertRules(context.TODO(), slo, getSLOAlertGroup())
+			require.NoError(err)
+			require.Len(gotRules, 1)
+
+			assert.Equal(test.expExpr, gotRules[0].Expr)
+		})
+	}
+}
+
+func TestGenerateSLOAlertRulesDefaultAnnotations(t *testing.T) {
+	tests := map[string]struct {
+		sloAnnotations map[string]string
+		expAnnotations map[string]string
+	}{
+		"The default annotations should be set on the alerts.": {
+			expAnnotations: map[string]string{
+				"title":      "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+				"summary":    "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+				"channel":    "#team",
+				"escalation": "team-policy",
+			},
+		},
+
+		"The SLO alert annotations should override the default annotations.": {
+			sloAnnotations: map[string]string{"channel": "#slo-team"},
+			expAnnotations: map[string]string{
+				"title":      "(page) {{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is too fast.",
+				"summary":    "{{$labels.sloth_service}} {{$labels.sloth_slo}} SLO error budget burn rate is over expected.",
+				"channel":    "#slo-team",
+				"escalation": "team-policy",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			slo := prometheus.SLO{
+				ID:              "test-svc-test",
+				Name:            "test",
+				Service:         "test-svc",
+				PageAlertMeta:   prometheus.AlertMeta{Name: "testAlert", Annotations: test.sloAnnotations},
+				TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+			}
+			gen := prometheus.NewSLOAlertRulesGenerator(prometheus.SLOAlertRulesGeneratorConfig{
+				DefaultAnnotations: map[string]string{"channel": "#team", "escalation": "team-policy"},
+			})
+			gotRules, err := gen.GenerateSLOAlertRules(context.TODO(), slo, getSLOAlertGroup())
+			require.NoError(err)
+			require.Len(gotRules, 1)
+
+			assert.Equal(test.expAnnotations, gotRules[0].Annotations)
+		})
+	}
+}
+
+func TestServiceBudgetAlertRule(t *testing.T) {
+	tests := map[string]struct {
+		config  prometheus.SLOAlertRulesGeneratorConfig
+		expRule rulefmt.Rule
+	}{
+		"Having the default config, the alert should be a ticket alert.": {
+			expRule: rulefmt.Rule{
+				Alert: "SlothServiceErrorBudgetLow",
+				Expr:  "min by(sloth_service) (slo:period_error_budget_remaining:ratio{owner=\"team-a\", sloth_service=\"test-svc\"}) < 0.1\n",
+				Labels: map[string]string{
+					"sloth_severity": "ticket",
+					"owner":          "team-a",
+				},
+				Annotations: map[string]string{
+					"title":   "(ticket) {{$labels.sloth_service}} service SLOs error budget is running out.",
+					"summary": "{{$labels.sloth_service}} service worst SLO has {{ $value | humanizePercentage }} of its period error budget remaining.",
+				},
+			},
+		},
+
+		"Having the severity mapping, the alert should have the mapped ticket severity label.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{
+				SeverityMapping: map[string]string{"page": "critical", "ticket": "warning"},
+			},
+			expRule: rulefmt.Rule{
+				Alert: "SlothServiceErrorBudgetLow",
+				Expr:  "min by(sloth_service) (slo:period_error_budget_remaining:ratio{owner=\"team-a\", sloth_service=\"test-svc\"}) < 0.1\n",
+				Labels: map[string]string{
+					"sloth_severity": "ticket",
+					"severity":       "warning",
+					"owner":          "team-a",
+				},
+				Annotations: map[string]string{
+					"title":   "(ticket) {{$labels.sloth_service}} service SLOs error budget is running out.",
+					"summary": "{{$labels.sloth_service}} service worst SLO has {{ $value | humanizePercentage }} of its period error budget remaining.",
+				},
+			},
+		},
+
+		"Having the severity mapping, receivers and default annotations, the alert should have the ticket ones.": {
+			config: prometheus.SLOAlertRulesGeneratorConfig{
+				SeverityMapping:    map[string]string{"page": "critical", "ticket": "warning"},
+				PageSeverity:       "sev1",
+				TicketSeverity:     "sev3",
+				PageReceiver:       "pagerduty",
+				TicketReceiver:     "slack",
+				DefaultAnnotations: map[string]string{"channel": "#team"},
+			},
+			expRule: rulefmt.Rule{
+				Alert: "SlothServiceErrorBudgetLow",
+				Expr:  "min by(sloth_service) (slo:period_error_budget_remaining:ratio{owner=\"team-a\", sloth_service=\"test-svc\"}) < 0.1\n",
+				Labels: map[string]string{
+					"sloth_severity": "ticket",
+					"severity":       "sev3",
+					"receiver":       "slack",
+					"owner":          "team-a",
+				},
+				Annotations: map[string]string{
+					"title":   "(ticket) {{$labels.sloth_service}} service SLOs error budget is running out.",
+					"summary": "{{$labels.sloth_service}} service worst SLO has {{ $value | humanizePercentage }} of its period error budget remaining.",
+					"channel": "#team",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotRule := prometheus.ServiceBudgetAlertRule(test.config, "test-svc", map[string]string{"owner": "team-a"}, 10)
+			assert.Equal(t, test.expRule, gotRule)
 		})
 	}
 }
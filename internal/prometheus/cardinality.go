@@ -0,0 +1,75 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+
+	"github.com/slok/sloth/internal/alert"
+)
+
+// SeriesCounter knows how to count the series returned by a Prometheus query.
+//
+//go:generate mockery --case underscore --output prometheusmock --outpkg prometheusmock --name SeriesCounter
+type SeriesCounter interface {
+	CountSeries(ctx context.Context, query string) (int, error)
+}
+
+// NewPrometheusAPISeriesCounter returns a SeriesCounter that counts the series using
+// a live Prometheus API.
+func NewPrometheusAPISeriesCounter(api promv1.API) SeriesCounter {
+	return prometheusAPISeriesCounter{api: api}
+}
+
+type prometheusAPISeriesCounter struct {
+	api promv1.API
+}
+
+func (p prometheusAPISeriesCounter) CountSeries(ctx context.Context, query string) (int, error) {
+	res, _, err := p.api.Query(ctx, fmt.Sprintf("count(%s)", query), time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("could not query Prometheus: %w", err)
+	}
+
+	vector, ok := res.(prommodel.Vector)
+	if !ok {
+		return 0, fmt.Errorf("unexpected Prometheus query result type %q", res.Type())
+	}
+
+	// No series, count returns an empty result.
+	if len(vector) == 0 {
+		return 0, nil
+	}
+
+	return int(vector[0].Value), nil
+}
+
+// sliCardinalityCheckWindow is the window used to render the SLI query when checking
+// its cardinality, the number of series doesn't depend on the window, so we use a short one.
+const sliCardinalityCheckWindow = 5 * time.Minute
+
+// ValidateSLICardinality will check that the series of the SLO SLI recording rules don't exceed
+// the max series, using a live Prometheus to get the series the SLI query label grouping returns.
+func ValidateSLICardinality(ctx context.Context, counter SeriesCounter, slo SLO, maxSeries int) error {
+	rule, err := factorySLIRecordGenerator(slo, sliCardinalityCheckWindow, alert.MWMBAlertGroup{})
+	if err != nil {
+		return fmt.Errorf("could not render SLI query: %w", err)
+	}
+
+	// The redacted fragments are not recorded on the live Prometheus, use the original fragments.
+	query := redactMarkupRegexp.ReplaceAllString(rule.Expr, "$1")
+
+	series, err := counter.CountSeries(ctx, query)
+	if err != nil {
+		return fmt.Errorf("could not count SLI series: %w", err)
+	}
+
+	if series > maxSeries {
+		return fmt.Errorf("SLI query returns %d series, exceeds the max %d series", series, maxSeries)
+	}
+
+	return nil
+}
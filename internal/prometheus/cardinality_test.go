@@ -0,0 +1,100 @@
+package prometheus_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/sloth/internal/prometheus"
+	"github.com/slok/sloth/internal/prometheus/prometheusmock"
+)
+
+func TestValidateSLICardinality(t *testing.T) {
+	tests := map[string]struct {
+		slo       prometheus.SLO
+		maxSeries int
+		mock      func(m *prometheusmock.SeriesCounter)
+		expErr    bool
+	}{
+		"SLI series under the max series should not fail.": {
+			slo: prometheus.SLO{
+				ID:         "test",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+					ErrorQuery: `sum(rate(my_metric{code=~"5.."}[{{.window}}])) by (route)`,
+					TotalQuery: `sum(rate(my_metric[{{.window}}])) by (route)`,
+				}},
+			},
+			maxSeries: 10,
+			mock: func(m *prometheusmock.SeriesCounter) {
+				expQuery := "(sum(rate(my_metric{code=~\"5..\"}[5m])) by (route))\n/\n(sum(rate(my_metric[5m])) by (route))\n"
+				m.On("CountSeries", mock.Anything, expQuery).Once().Return(10, nil)
+			},
+		},
+
+		"SLI series over the max series should fail.": {
+			slo: prometheus.SLO{
+				ID:         "test",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+					ErrorRatioQuery: `sum(rate(my_metric[{{.window}}])) by (route, pod)`,
+				}},
+			},
+			maxSeries: 10,
+			mock: func(m *prometheusmock.SeriesCounter) {
+				m.On("CountSeries", mock.Anything, "(sum(rate(my_metric[5m])) by (route, pod))").Once().Return(11, nil)
+			},
+			expErr: true,
+		},
+
+		"SLI with redacted fragments should check the original fragments.": {
+			slo: prometheus.SLO{
+				ID:         "test",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+					ErrorRatioQuery: `sum(<<redact:my_metric{token="secret"}>>)`,
+				}},
+			},
+			maxSeries: 1,
+			mock: func(m *prometheusmock.SeriesCounter) {
+				m.On("CountSeries", mock.Anything, `(sum(my_metric{token="secret"}))`).Once().Return(1, nil)
+			},
+		},
+
+		"Failing counting the series should fail.": {
+			slo: prometheus.SLO{
+				ID:         "test",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+					ErrorRatioQuery: `rate(my_metric[{{.window}}])`,
+				}},
+			},
+			maxSeries: 10,
+			mock: func(m *prometheusmock.SeriesCounter) {
+				m.On("CountSeries", mock.Anything, mock.Anything).Once().Return(0, fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := prometheusmock.NewSeriesCounter(t)
+			test.mock(m)
+
+			err := prometheus.ValidateSLICardinality(context.TODO(), m, test.slo, test.maxSeries)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
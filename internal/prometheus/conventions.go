@@ -2,16 +2,39 @@ package prometheus
 
 const (
 	// Metrics.
-	sliErrorMetricFmt = "slo:sli_error:ratio_rate%s"
+	sliErrorMetricFmt         = "slo:sli_error:ratio_rate%s"
+	sliErrorSmoothedMetricFmt = "slo:sli_error:ratio_rate%s:smoothed%s"
+	sliSuccessMetricFmt       = "slo:sli_success:ratio_rate%s"
+	sloAlertConditionMetric   = "slo:alert_firing_condition:bool"
+	sloRedactedMetricPrefix   = "slo:redacted:"
+	sloSharedMetricPrefix     = "slo:shared:"
+	sliLogQLErrorEventsMetric = "slo:sli_logql_error_events:count1m"
+	sliLogQLTotalEventsMetric = "slo:sli_logql_total_events:count1m"
+
+	// Alerts.
+	sloSLINoDataAlertName        = "SlothSLINoData"
+	sloServiceBudgetLowAlertName = "SlothServiceErrorBudgetLow"
+
+	// Queries.
+	defaultSLIMaintenanceGateQuery = `ALERTS{alertname="Maintenance"}`
 
 	// Labels.
-	sloNameLabelName      = "sloth_slo"
-	sloIDLabelName        = "sloth_id"
-	sloServiceLabelName   = "sloth_service"
-	sloWindowLabelName    = "sloth_window"
-	sloSeverityLabelName  = "sloth_severity"
-	sloVersionLabelName   = "sloth_version"
-	sloModeLabelName      = "sloth_mode"
-	sloSpecLabelName      = "sloth_spec"
-	sloObjectiveLabelName = "sloth_objective"
+	sloNameLabelName        = "sloth_slo"
+	sloIDLabelName          = "sloth_id"
+	sloServiceLabelName     = "sloth_service"
+	sloWindowLabelName      = "sloth_window"
+	sloSeverityLabelName    = "sloth_severity"
+	sloVersionLabelName     = "sloth_version"
+	sloModeLabelName        = "sloth_mode"
+	sloSpecLabelName        = "sloth_spec"
+	sloObjectiveLabelName   = "sloth_objective"
+	sloErrorBudgetLabelName = "sloth_error_budget"
+	sloDescriptionLabelName = "sloth_description"
+	sloSourceLabelName      = "sloth_source"
+	sloSharedLabelName      = "sloth_shared"
+	sloAlertLabelName       = "sloth_alert"
+	alertSeverityLabelName  = "severity"
+	alertReceiverLabelName  = "receiver"
+
+	alertBusinessHoursOnlyLabelName = "business_hours_only"
 )
@@ -0,0 +1,247 @@
+package prometheus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+
+	"github.com/slok/sloth/internal/log"
+)
+
+// NewFSGrafanaDashboardsRepo returns a new FSGrafanaDashboardsRepo.
+func NewFSGrafanaDashboardsRepo(dir string, logger log.Logger) FSGrafanaDashboardsRepo {
+	return FSGrafanaDashboardsRepo{
+		dir:    dir,
+		logger: logger.WithValues(log.Kv{"svc": "storage.FS", "format": "grafana-dashboard"}),
+	}
+}
+
+// FSGrafanaDashboardsRepo knows how to store a Grafana dashboard JSON per SLO in a directory.
+// The dashboards are based on the generated SLO recording rules (error budget, burn rate and SLIs),
+// the panels of the not generated recording rules are omitted.
+type FSGrafanaDashboardsRepo struct {
+	dir    string
+	logger log.Logger
+}
+
+// StoreSLOs will store a `<slo-id>.json` Grafana dashboard file for every SLO.
+func (f FSGrafanaDashboardsRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slos are required")
+	}
+
+	err := os.MkdirAll(f.dir, 0755)
+	if err != nil {
+		return fmt.Errorf("could not create dashboards directory: %w", err)
+	}
+
+	logger := f.logger.WithCtxValues(ctx)
+	for _, slo := range slos {
+		dashboard := mapSLOToGrafanaDashboard(slo, sloRecordedMetrics(slo.SLO, slos))
+		if len(dashboard.Panels) == 0 {
+			logger.WithValues(log.Kv{"slo": slo.SLO.ID}).Warningf("Dashboard without panels, the SLO recording rules are not generated")
+		}
+
+		data, err := json.MarshalIndent(dashboard, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not format %q SLO dashboard: %w", slo.SLO.ID, err)
+		}
+
+		err = os.WriteFile(filepath.Join(f.dir, slo.SLO.ID+".json"), append(data, '\n'), 0644)
+		if err != nil {
+			return fmt.Errorf("could not write %q SLO dashboard: %w", slo.SLO.ID, err)
+		}
+	}
+
+	logger.WithValues(log.Kv{"dashboards": len(slos), "dir": f.dir}).Infof("Grafana dashboards written")
+
+	return nil
+}
+
+// sloRecordedMetrics returns the metrics recorded for the SLO. The SLO recording rules can be on
+// other SLO rules (e.g the dependent SLO SLI rules are moved to their dependency chain root), so
+// these are matched by the SLO ID labels on all the SLOs rules.
+func sloRecordedMetrics(slo SLO, slos []StorageSLO) map[string]bool {
+	idLabels := slo.GetSLOIDPromLabels()
+	recorded := map[string]bool{}
+	for _, s := range slos {
+		for _, rules := range [][]rulefmt.Rule{s.Rules.SLIErrorRecRules, s.Rules.MetadataRecRules} {
+			for _, r := range rules {
+				if r.Record != "" && labelsContain(r.Labels, idLabels) {
+					recorded[r.Record] = true
+				}
+			}
+		}
+	}
+
+	return recorded
+}
+
+// labelsContain returns true if all the want labels are on the labels.
+func labelsContain(labels, want map[string]string) bool {
+	for k, v := range want {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+type grafanaDashboard struct {
+	UID           string         `json:"uid"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description,omitempty"`
+	Tags          []string       `json:"tags"`
+	SchemaVersion int            `json:"schemaVersion"`
+	Time          grafanaTime    `json:"time"`
+	Panels        []grafanaPanel `json:"panels"`
+}
+
+type grafanaTime struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaPanel struct {
+	ID          int                `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	GridPos     grafanaGridPos     `json:"gridPos"`
+	FieldConfig grafanaFieldConfig `json:"fieldConfig"`
+	Targets     []grafanaTarget    `json:"targets"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaFieldConfig struct {
+	Defaults grafanaFieldDefaults `json:"defaults"`
+}
+
+type grafanaFieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+type grafanaTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+const grafanaDashboardSchemaVersion = 39
+
+// mapSLOToGrafanaDashboard maps an SLO to a Grafana dashboard with the error budget,
+// burn rate and SLI error ratio panels, only with the targets of the recorded metrics.
+func mapSLOToGrafanaDashboard(slo StorageSLO, recorded map[string]bool) grafanaDashboard {
+	filter := labelsToPromFilter(slo.SLO.GetSLOIDPromLabels())
+	period := timeDurationToPromStr(slo.SLO.TimeWindow)
+
+	// Grafana UIDs have a max length, so we use a hash of the SLO ID.
+	uid := sha256.Sum256([]byte(slo.SLO.ID))
+
+	// One target for every SLI error window, the total time window is also recorded as a helper.
+	windows := getAlertGroupWindows(slo.Alerts)
+	if len(windows) == 0 || windows[len(windows)-1] != slo.SLO.TimeWindow {
+		windows = append(windows, slo.SLO.TimeWindow)
+	}
+	sliMetrics := make([]dashboardMetric, 0, len(windows))
+	for _, w := range windows {
+		metric := slo.SLO.GetSLIErrorMetric(w)
+		sliMetrics = append(sliMetrics, dashboardMetric{name: metric, legend: metric})
+	}
+
+	panels := []grafanaPanel{
+		{
+			ID:          1,
+			Type:        "stat",
+			Title:       fmt.Sprintf("Remaining error budget (%s)", period),
+			GridPos:     grafanaGridPos{H: 6, W: 12, X: 0, Y: 0},
+			FieldConfig: grafanaFieldConfig{Defaults: grafanaFieldDefaults{Unit: "percentunit"}},
+			Targets:     dashboardTargets(recorded, filter, dashboardMetric{name: metricSLOPeriodErrorBudgetRemainingRatio}),
+		},
+		{
+			ID:          2,
+			Type:        "stat",
+			Title:       "Objective",
+			GridPos:     grafanaGridPos{H: 6, W: 12, X: 12, Y: 0},
+			FieldConfig: grafanaFieldConfig{Defaults: grafanaFieldDefaults{Unit: "percentunit"}},
+			Targets:     dashboardTargets(recorded, filter, dashboardMetric{name: metricSLOObjectiveRatio}),
+		},
+		{
+			ID:      3,
+			Type:    "timeseries",
+			Title:   "Burn rate",
+			GridPos: grafanaGridPos{H: 9, W: 24, X: 0, Y: 6},
+			Targets: dashboardTargets(recorded, filter,
+				dashboardMetric{name: metricSLOCurrentBurnRateRatio, legend: "current"},
+				dashboardMetric{name: metricSLOPeriodBurnRateRatio, legend: period},
+			),
+		},
+		{
+			ID:          4,
+			Type:        "timeseries",
+			Title:       "SLI error ratio",
+			GridPos:     grafanaGridPos{H: 9, W: 24, X: 0, Y: 15},
+			FieldConfig: grafanaFieldConfig{Defaults: grafanaFieldDefaults{Unit: "percentunit"}},
+			Targets:     dashboardTargets(recorded, filter, sliMetrics...),
+		},
+	}
+
+	// Skip the panels of the not recorded metrics (e.g disabled metadata recording rules).
+	recordedPanels := []grafanaPanel{}
+	for _, p := range panels {
+		if len(p.Targets) > 0 {
+			recordedPanels = append(recordedPanels, p)
+		}
+	}
+
+	return grafanaDashboard{
+		UID:           "sloth-" + hex.EncodeToString(uid[:])[:16],
+		Title:         fmt.Sprintf("SLO / %s / %s", slo.SLO.Service, slo.SLO.Name),
+		Description:   slo.SLO.Description,
+		Tags:          []string{"sloth", "slo"},
+		SchemaVersion: grafanaDashboardSchemaVersion,
+		Time:          grafanaTime{From: "now-" + period, To: "now"},
+		Panels:        recordedPanels,
+	}
+}
+
+type dashboardMetric struct {
+	name   string
+	legend string
+}
+
+// dashboardTargets returns the panel targets of the recorded metrics.
+func dashboardTargets(recorded map[string]bool, filter string, metrics ...dashboardMetric) []grafanaTarget {
+	targets := []grafanaTarget{}
+	for _, m := range metrics {
+		if !recorded[m.name] {
+			continue
+		}
+		targets = append(targets, grafanaTarget{
+			RefID:        refID(len(targets)),
+			Expr:         m.name + filter,
+			LegendFormat: m.legend,
+		})
+	}
+
+	return targets
+}
+
+// refID returns the Grafana query reference ID for the index (A, B... Z, AA, AB...).
+func refID(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return refID(i/26-1) + string(rune('A'+i%26))
+}
@@ -0,0 +1,197 @@
+package prometheus_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/log"
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+func TestFSGrafanaDashboardsRepoStore(t *testing.T) {
+	type panel struct {
+		Title   string `json:"title"`
+		Targets []struct {
+			Expr string `json:"expr"`
+		} `json:"targets"`
+	}
+
+	alerts := alert.MWMBAlertGroup{
+		PageQuick:   alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+		PageSlow:    alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+		TicketQuick: alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+		TicketSlow:  alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+	}
+	slo1 := prometheus.SLO{ID: "test1", Name: "test-name", Service: "test-svc", TimeWindow: 30 * 24 * time.Hour}
+	slo2 := prometheus.SLO{ID: "test2", Name: "test-name2", Service: "test-svc", TimeWindow: 28 * 24 * time.Hour}
+	slo3 := prometheus.SLO{ID: "test3", Name: "test-name3", Service: "test-svc", TimeWindow: 30 * 24 * time.Hour, SLIDependsOn: "test1"}
+	slo1Labels := slo1.GetSLOIDPromLabels()
+	slo2Labels := slo2.GetSLOIDPromLabels()
+	slo3Labels := slo3.GetSLOIDPromLabels()
+	metaRules := func(labels map[string]string) []rulefmt.Rule {
+		return []rulefmt.Rule{
+			{Record: "slo:objective:ratio", Labels: labels},
+			{Record: "slo:current_burn_rate:ratio", Labels: labels},
+			{Record: "slo:period_burn_rate:ratio", Labels: labels},
+			{Record: "slo:period_error_budget_remaining:ratio", Labels: labels},
+		}
+	}
+
+	tests := map[string]struct {
+		slos      []prometheus.StorageSLO
+		expPanels map[string]map[string][]string
+		expErr    bool
+	}{
+		"Having 0 SLOs should fail.": {
+			slos:   []prometheus.StorageSLO{},
+			expErr: true,
+		},
+
+		"Having SLOs should store a dashboard per SLO referencing the SLO recording rules.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO:    slo1,
+					Alerts: alerts,
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{
+							{Record: "slo:sli_error:ratio_rate5m", Labels: slo1Labels},
+							{Record: "slo:sli_error:ratio_rate1h", Labels: slo1Labels},
+							{Record: "slo:sli_error:ratio_rate30d", Labels: slo1Labels},
+							{Record: "slo:sli_error:ratio_rate5m:smoothed1h", Labels: slo1Labels},
+							{Record: "slo:sli_success:ratio_rate5m", Labels: slo1Labels},
+						},
+						MetadataRecRules: metaRules(slo1Labels),
+					},
+				},
+				{
+					SLO:    slo2,
+					Alerts: alerts,
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{
+							{Record: "slo:sli_error:ratio_rate5m", Labels: slo2Labels},
+							{Record: "slo:sli_error:ratio_rate1h", Labels: slo2Labels},
+							{Record: "slo:sli_error:ratio_rate4w", Labels: slo2Labels},
+						},
+						MetadataRecRules: metaRules(slo2Labels),
+					},
+				},
+			},
+			expPanels: map[string]map[string][]string{
+				"test1.json": {
+					"Remaining error budget (30d)": {`slo:period_error_budget_remaining:ratio{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`},
+					"Objective":                    {`slo:objective:ratio{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`},
+					"Burn rate": {
+						`slo:current_burn_rate:ratio{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`,
+						`slo:period_burn_rate:ratio{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`,
+					},
+					"SLI error ratio": {
+						`slo:sli_error:ratio_rate5m{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`,
+						`slo:sli_error:ratio_rate1h{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`,
+						`slo:sli_error:ratio_rate30d{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`,
+					},
+				},
+				"test2.json": {
+					"Remaining error budget (4w)": {`slo:period_error_budget_remaining:ratio{sloth_id="test2", sloth_service="test-svc", sloth_slo="test-name2"}`},
+					"Objective":                   {`slo:objective:ratio{sloth_id="test2", sloth_service="test-svc", sloth_slo="test-name2"}`},
+					"Burn rate": {
+						`slo:current_burn_rate:ratio{sloth_id="test2", sloth_service="test-svc", sloth_slo="test-name2"}`,
+						`slo:period_burn_rate:ratio{sloth_id="test2", sloth_service="test-svc", sloth_slo="test-name2"}`,
+					},
+					"SLI error ratio": {
+						`slo:sli_error:ratio_rate5m{sloth_id="test2", sloth_service="test-svc", sloth_slo="test-name2"}`,
+						`slo:sli_error:ratio_rate1h{sloth_id="test2", sloth_service="test-svc", sloth_slo="test-name2"}`,
+						`slo:sli_error:ratio_rate4w{sloth_id="test2", sloth_service="test-svc", sloth_slo="test-name2"}`,
+					},
+				},
+			},
+		},
+
+		"Having dependent SLOs, the SLI rules on the dependency root should be on the dashboard of every SLO.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO:    slo1,
+					Alerts: alerts,
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{
+							{Record: "slo:sli_error:ratio_rate5m", Labels: slo1Labels},
+							{Record: "slo:sli_error:ratio_rate30d", Labels: slo1Labels},
+							{Record: "slo:sli_error:ratio_rate5m", Labels: slo3Labels},
+							{Record: "slo:sli_error:ratio_rate30d", Labels: slo3Labels},
+						},
+					},
+				},
+				{
+					SLO:    slo3,
+					Alerts: alerts,
+				},
+			},
+			expPanels: map[string]map[string][]string{
+				"test1.json": {
+					"SLI error ratio": {
+						`slo:sli_error:ratio_rate5m{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`,
+						`slo:sli_error:ratio_rate30d{sloth_id="test1", sloth_service="test-svc", sloth_slo="test-name"}`,
+					},
+				},
+				"test3.json": {
+					"SLI error ratio": {
+						`slo:sli_error:ratio_rate5m{sloth_id="test3", sloth_service="test-svc", sloth_slo="test-name3"}`,
+						`slo:sli_error:ratio_rate30d{sloth_id="test3", sloth_service="test-svc", sloth_slo="test-name3"}`,
+					},
+				},
+			},
+		},
+
+		"Having SLOs without recording rules should store dashboards without panels.": {
+			slos: []prometheus.StorageSLO{
+				{SLO: slo1, Alerts: alerts},
+			},
+			expPanels: map[string]map[string][]string{
+				"test1.json": {},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			dir := t.TempDir()
+			repo := prometheus.NewFSGrafanaDashboardsRepo(dir, log.Noop)
+			err := repo.StoreSLOs(context.TODO(), test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			for file, expPanels := range test.expPanels {
+				data, err := os.ReadFile(filepath.Join(dir, file))
+				require.NoError(err)
+
+				dashboard := struct {
+					Panels []panel `json:"panels"`
+				}{}
+				require.NoError(json.Unmarshal(data, &dashboard))
+
+				gotPanels := map[string][]string{}
+				for _, p := range dashboard.Panels {
+					for _, t := range p.Targets {
+						gotPanels[p.Title] = append(gotPanels[p.Title], t.Expr)
+					}
+				}
+				assert.Equal(expPanels, gotPanels)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+
+	"github.com/slok/sloth/internal/alert"
+)
+
+// ErrSLOObjectiveUnmet will be used when the historical SLI doesn't meet the SLO objective, the
+// SLO error budget has already been consumed.
+var ErrSLOObjectiveUnmet = fmt.Errorf("SLO objective unmet")
+
+// ValueQuerier knows how to get the value of a Prometheus query returning a single series.
+//
+//go:generate mockery --case underscore --output prometheusmock --outpkg prometheusmock --name ValueQuerier
+type ValueQuerier interface {
+	// QueryValue returns the query value, if the query doesn't return data, ok will be false.
+	QueryValue(ctx context.Context, query string) (value float64, ok bool, err error)
+}
+
+// NewPrometheusAPIValueQuerier returns a ValueQuerier that gets the values using
+// a live Prometheus API.
+func NewPrometheusAPIValueQuerier(api promv1.API) ValueQuerier {
+	return prometheusAPIValueQuerier{api: api}
+}
+
+type prometheusAPIValueQuerier struct {
+	api promv1.API
+}
+
+func (p prometheusAPIValueQuerier) QueryValue(ctx context.Context, query string) (float64, bool, error) {
+	res, _, err := p.api.Query(ctx, query, time.Now())
+	if err != nil {
+		return 0, false, fmt.Errorf("could not query Prometheus: %w", err)
+	}
+
+	vector, ok := res.(prommodel.Vector)
+	if !ok {
+		return 0, false, fmt.Errorf("unexpected Prometheus query result type %q", res.Type())
+	}
+
+	if len(vector) == 0 {
+		return 0, false, nil
+	}
+
+	return float64(vector[0].Value), true, nil
+}
+
+// ValidateSLOFeasibility will check the SLO objective has been met by the historical SLI on the
+// SLO time window, using a live Prometheus to get the SLI error ratio (the worst series if the
+// SLI has multiple). If the SLI error ratio exceeds the error budget it will return
+// ErrSLOObjectiveUnmet, the SLIs without data are not checked.
+func ValidateSLOFeasibility(ctx context.Context, querier ValueQuerier, slo SLO) error {
+	rule, err := factorySLIRecordGenerator(slo, slo.TimeWindow, alert.MWMBAlertGroup{})
+	if err != nil {
+		return fmt.Errorf("could not render SLI query: %w", err)
+	}
+
+	// The redacted fragments are not recorded on the live Prometheus, use the original fragments.
+	query := fmt.Sprintf("max(%s)", redactMarkupRegexp.ReplaceAllString(rule.Expr, "$1"))
+
+	errorRatio, ok, err := querier.QueryValue(ctx, query)
+	if err != nil {
+		return fmt.Errorf("could not get SLI error ratio: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	errorBudgetRatio := (100 - slo.Objective) / 100
+	if errorRatio > errorBudgetRatio {
+		return fmt.Errorf("%w: SLI error ratio is %.4f%% on the last %s, exceeds the %.4f%% error budget of the %v%% objective",
+			ErrSLOObjectiveUnmet, errorRatio*100, timeDurationToPromStr(slo.TimeWindow), errorBudgetRatio*100, slo.Objective)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/sloth/internal/prometheus"
+	"github.com/slok/sloth/internal/prometheus/prometheusmock"
+)
+
+func TestValidateSLOFeasibility(t *testing.T) {
+	getSLO := func() prometheus.SLO {
+		return prometheus.SLO{
+			ID:         "test",
+			TimeWindow: 30 * 24 * time.Hour,
+			Objective:  99,
+			SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+				ErrorQuery: `sum(rate(my_metric{code=~"5.."}[{{.window}}]))`,
+				TotalQuery: `sum(rate(my_metric[{{.window}}]))`,
+			}},
+		}
+	}
+
+	tests := map[string]struct {
+		slo         prometheus.SLO
+		mock        func(m *prometheusmock.ValueQuerier)
+		expErrUnmet bool
+		expErr      bool
+	}{
+		"An SLI error ratio under the error budget should not fail.": {
+			slo: getSLO(),
+			mock: func(m *prometheusmock.ValueQuerier) {
+				expQuery := "max((sum(rate(my_metric{code=~\"5..\"}[30d])))\n/\n(sum(rate(my_metric[30d])))\n)"
+				m.On("QueryValue", mock.Anything, expQuery).Once().Return(0.005, true, nil)
+			},
+		},
+
+		"An SLI error ratio over the error budget should fail with objective unmet.": {
+			slo: getSLO(),
+			mock: func(m *prometheusmock.ValueQuerier) {
+				m.On("QueryValue", mock.Anything, mock.Anything).Once().Return(0.02, true, nil)
+			},
+			expErr:      true,
+			expErrUnmet: true,
+		},
+
+		"An SLI without data should not fail.": {
+			slo: getSLO(),
+			mock: func(m *prometheusmock.ValueQuerier) {
+				m.On("QueryValue", mock.Anything, mock.Anything).Once().Return(0.0, false, nil)
+			},
+		},
+
+		"SLI with redacted fragments should check the original fragments.": {
+			slo: prometheus.SLO{
+				ID:         "test",
+				TimeWindow: 7 * 24 * time.Hour,
+				Objective:  99.9,
+				SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+					ErrorRatioQuery: `sum(<<redact:my_metric{token="secret"}>>)`,
+				}},
+			},
+			mock: func(m *prometheusmock.ValueQuerier) {
+				m.On("QueryValue", mock.Anything, `max((sum(my_metric{token="secret"})))`).Once().Return(0.0001, true, nil)
+			},
+		},
+
+		"Failing getting the SLI error ratio should fail.": {
+			slo: getSLO(),
+			mock: func(m *prometheusmock.ValueQuerier) {
+				m.On("QueryValue", mock.Anything, mock.Anything).Once().Return(0.0, false, fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := prometheusmock.NewValueQuerier(t)
+			test.mock(m)
+
+			err := prometheus.ValidateSLOFeasibility(context.TODO(), m, test.slo)
+
+			if test.expErr {
+				assert.Error(err)
+				assert.Equal(test.expErrUnmet, errors.Is(err, prometheus.ErrSLOObjectiveUnmet))
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
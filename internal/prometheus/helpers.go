@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"math"
 	"sort"
 	"time"
 
@@ -34,6 +35,18 @@ func timeDurationToPromStr(t time.Duration) string {
 	return prommodel.Duration(t).String()
 }
 
+// roundFloat rounds the float to the precision significant digits, so the small ratios (e.g the
+// 0.0001 error budget of a 99.99 objective) are not rounded to 0. If precision is 0 or less the
+// float will not be rounded.
+func roundFloat(f float64, precision int) float64 {
+	if precision <= 0 || f == 0 {
+		return f
+	}
+
+	p := math.Pow(10, float64(precision)-math.Ceil(math.Log10(math.Abs(f))))
+	return math.Round(f*p) / p
+}
+
 // getAlertGroupWindows gets all the time windows from a multiwindow multiburn alert group.
 func getAlertGroupWindows(alerts alert.MWMBAlertGroup) []time.Duration {
 	// Use a map to avoid duplicated windows.
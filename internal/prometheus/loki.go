@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v2"
+
+	"github.com/slok/sloth/internal/log"
+)
+
+// sliLogQLBridgeInterval is the Loki recording rules evaluation interval and the window of
+// the LogQL queries, the recorded metrics are the events count of this interval.
+const sliLogQLBridgeInterval = time.Minute
+
+// NewIOWriterLokiRulesYAMLRepo returns a new IOWriterLokiRulesYAMLRepo.
+func NewIOWriterLokiRulesYAMLRepo(writer io.Writer, logger log.Logger) IOWriterLokiRulesYAMLRepo {
+	return IOWriterLokiRulesYAMLRepo{
+		writer: writer,
+		logger: logger.WithValues(log.Kv{"svc": "storage.IOWriter", "format": "loki-yaml"}),
+	}
+}
+
+// IOWriterLokiRulesYAMLRepo knows to store the Loki recording rules of the LogQL based SLOs
+// grouped in an IOWriter in YAML format, that is compatible with the Loki ruler.
+//
+// The recorded metrics need to be remote written to Prometheus, the SLO SLIs use them.
+type IOWriterLokiRulesYAMLRepo struct {
+	writer io.Writer
+	logger log.Logger
+}
+
+// StoreSLOs will store the Loki recording rules of the SLOs.
+func (i IOWriterLokiRulesYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	groups := []ruleGroupYAMLv2{}
+	for _, slo := range slos {
+		if slo.SLO.SLILogQLBridge == nil {
+			continue
+		}
+
+		rules, err := lokiSLIRecordingRules(slo.SLO)
+		if err != nil {
+			return fmt.Errorf("could not create %q SLO Loki rules: %w", slo.SLO.ID, err)
+		}
+
+		groups = append(groups, ruleGroupYAMLv2{
+			Name:     slo.SLO.GetRuleGroupName(GroupKindLogQLRecordings),
+			Interval: prommodel.Duration(sliLogQLBridgeInterval),
+			Rules:    rules,
+		})
+	}
+
+	if len(groups) == 0 {
+		return ErrNoSLORules
+	}
+
+	rulesYaml, err := yaml.Marshal(ruleGroupsYAMLv2{Groups: groups})
+	if err != nil {
+		return fmt.Errorf("could not format rules: %w", err)
+	}
+
+	rulesYaml = writeTopDisclaimer(rulesYaml)
+	_, err = i.writer.Write(rulesYaml)
+	if err != nil {
+		return fmt.Errorf("could not write Loki rules: %w", err)
+	}
+
+	logger := i.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": len(groups)}).Infof("Loki rules written")
+
+	return nil
+}
+
+// lokiSLIRecordingRules returns the Loki recording rules of the SLO LogQL queries, recorded with
+// the SLO ID labels so the SLI can select them.
+func lokiSLIRecordingRules(slo SLO) ([]rulefmt.Rule, error) {
+	render := func(query string) (string, error) {
+		tpl, err := template.New("logqlExpr").Option("missingkey=error").Parse(query)
+		if err != nil {
+			return "", fmt.Errorf("could not create LogQL expression template data: %w", err)
+		}
+
+		var b bytes.Buffer
+		err = tpl.Execute(&b, map[string]string{
+			tplKeyWindow: timeDurationToPromStr(sliLogQLBridgeInterval),
+		})
+		if err != nil {
+			return "", fmt.Errorf("could not render LogQL expression template: %w", err)
+		}
+
+		return b.String(), nil
+	}
+
+	errorExpr, err := render(slo.SLILogQLBridge.ErrorQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	totalExpr, err := render(slo.SLILogQLBridge.TotalQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return []rulefmt.Rule{
+		{
+			Record: sliLogQLErrorEventsMetric,
+			Expr:   errorExpr,
+			Labels: slo.GetSLOIDPromLabels(),
+		},
+		{
+			Record: sliLogQLTotalEventsMetric,
+			Expr:   totalExpr,
+			Labels: slo.GetSLOIDPromLabels(),
+		},
+	}, nil
+}
@@ -0,0 +1,102 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/slok/sloth/internal/log"
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+func TestIOWriterLokiRulesYAMLRepoStore(t *testing.T) {
+	specYaml := `
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    objective: 99
+    sli:
+      logql:
+        error_query: sum(count_over_time({app="test"} |= "error" [{{.window}}]))
+        total_query: sum(count_over_time({app="test"}[{{.window}}]))
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+  - name: "slo2"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_2{window="{{.window}}"}
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`
+
+	t.Run("LogQL SLOs should store the Loki rules recording the metrics the Prometheus SLI uses.", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo{}, 30*24*time.Hour)
+		sloGroup, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+		require.NoError(err)
+		require.NoError(sloGroup.Validate())
+
+		slos := []prometheus.StorageSLO{}
+		for _, slo := range sloGroup.SLOs {
+			slos = append(slos, prometheus.StorageSLO{SLO: slo})
+		}
+
+		var b bytes.Buffer
+		err = prometheus.NewIOWriterLokiRulesYAMLRepo(&b, log.Noop).StoreSLOs(context.TODO(), slos)
+		require.NoError(err)
+
+		gotRules := struct {
+			Groups []struct {
+				Name     string `yaml:"name"`
+				Interval string `yaml:"interval"`
+				Rules    []struct {
+					Record string            `yaml:"record"`
+					Expr   string            `yaml:"expr"`
+					Labels map[string]string `yaml:"labels"`
+				} `yaml:"rules"`
+			} `yaml:"groups"`
+		}{}
+		err = yaml.Unmarshal(b.Bytes(), &gotRules)
+		require.NoError(err)
+
+		// Only the LogQL SLO has Loki rules.
+		require.Len(gotRules.Groups, 1)
+		group := gotRules.Groups[0]
+		assert.Equal("sloth-slo-logql-recordings-test-svc-slo1", group.Name)
+		assert.Equal("1m", group.Interval)
+		require.Len(group.Rules, 2)
+
+		errorRule, totalRule := group.Rules[0], group.Rules[1]
+		assert.Equal(`sum(count_over_time({app="test"} |= "error" [1m]))`, errorRule.Expr)
+		assert.Equal(`sum(count_over_time({app="test"}[1m]))`, totalRule.Expr)
+		assert.Equal("test-svc-slo1", errorRule.Labels["sloth_id"])
+		assert.Equal("test-svc-slo1", totalRule.Labels["sloth_id"])
+
+		// The Prometheus SLI uses the Loki recorded metrics.
+		sli := sloGroup.SLOs[0].SLI.Events
+		require.NotNil(sli)
+		assert.Equal(`sum(sum_over_time(`+errorRule.Record+`{sloth_id="test-svc-slo1"}[{{.window}}]))`, sli.ErrorQuery)
+		assert.Equal(`sum(sum_over_time(`+totalRule.Record+`{sloth_id="test-svc-slo1"}[{{.window}}]))`, sli.TotalQuery)
+	})
+
+	t.Run("Without LogQL SLOs should not store Loki rules.", func(t *testing.T) {
+		var b bytes.Buffer
+		err := prometheus.NewIOWriterLokiRulesYAMLRepo(&b, log.Noop).StoreSLOs(context.TODO(), []prometheus.StorageSLO{{SLO: prometheus.SLO{ID: "test"}}})
+		assert.ErrorIs(t, err, prometheus.ErrNoSLORules)
+	})
+}
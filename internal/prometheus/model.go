@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -19,15 +21,33 @@ type SLI struct {
 	Raw                  *SLIRaw
 	Events               *SLIEvents
 	DenominatorCorrected *SLIDenominatorCorrectedEvents
+	Precomputed          *SLIPrecomputed
 }
 
 type SLIRaw struct {
-	ErrorRatioQuery string `validate:"required,prom_expr,template_vars"`
+	ErrorRatioQuery string `validate:"omitempty,prom_expr,template_vars"`
+	// ErrorQuery and TotalQuery are an alternative to ErrorRatioQuery, the error
+	// ratio will be `ErrorQuery / TotalQuery`.
+	ErrorQuery string `validate:"omitempty,prom_expr,template_vars"`
+	TotalQuery string `validate:"omitempty,prom_expr,template_vars"`
 }
 
+// SLIEventsMode is the kind of metrics the SLI events queries use.
+type SLIEventsMode string
+
+const (
+	// SLIEventsModeCounter is the default mode, the queries use the `{{.window}}` template
+	// variable to get the events on the window (e.g using `rate`).
+	SLIEventsModeCounter SLIEventsMode = ""
+	// SLIEventsModeGauge is used when the queries are based on gauges, the queries return the
+	// events at a point in time and Sloth will average them over the window.
+	SLIEventsModeGauge SLIEventsMode = "gauge"
+)
+
 type SLIEvents struct {
 	ErrorQuery string `validate:"required,prom_expr,template_vars"`
 	TotalQuery string `validate:"required,prom_expr,template_vars"`
+	Mode       SLIEventsMode
 }
 
 type SLIDenominatorCorrectedEvents struct {
@@ -36,12 +56,31 @@ type SLIDenominatorCorrectedEvents struct {
 	TotalQuery   string  `validate:"required,prom_expr,template_vars"`
 }
 
+type SLIPrecomputed struct {
+	ErrorMetric string `validate:"required,prom_vector_selector"`
+	TotalMetric string `validate:"required,prom_vector_selector"`
+}
+
+// AlertBusinessHours are the business hours of a business hours only alert, the hours are UTC
+// and the end hour is excluded, the days are `day_of_week()` days (0 is Sunday) and both included.
+// The ranges can wrap around (e.g overnight hours 22-6 or days 5-1).
+type AlertBusinessHours struct {
+	StartHour int `validate:"gte=0,lte=23"`
+	EndHour   int `validate:"gte=0,lte=24,nefield=StartHour"`
+	StartDay  int `validate:"gte=0,lte=6"`
+	EndDay    int `validate:"gte=0,lte=6"`
+	// TimeGate when set, the alert only fires on the business hours.
+	TimeGate bool
+}
+
 // AlertMeta is the metadata of an alert settings.
 type AlertMeta struct {
 	Disable     bool
 	Name        string            `validate:"required_if_enabled"`
 	Labels      map[string]string `validate:"dive,keys,prom_label_key,endkeys,required,prom_label_value"`
 	Annotations map[string]string `validate:"dive,keys,prom_annot_key,endkeys,required"`
+	// BusinessHours when set, makes the alert a business hours only alert.
+	BusinessHours *AlertBusinessHours
 }
 
 // SLO represents a service level objective configuration.
@@ -57,6 +96,31 @@ type SLO struct {
 	IDLabels        map[string]string `validate:"dive,keys,prom_label_key,endkeys,required,prom_label_value"`
 	PageAlertMeta   AlertMeta
 	TicketAlertMeta AlertMeta
+	// ObjectiveIDLabel adds the objective as an ID label, so the same SLI can coexist with multiple objectives.
+	ObjectiveIDLabel bool
+	// SLIInvert when set, the SLI ratio is the good events ratio and the error ratio is its complement.
+	SLIInvert bool
+	// SLIMaintenanceGateQuery when set, excludes the SLI while the query returns data.
+	SLIMaintenanceGateQuery string `validate:"omitempty,prom_expr"`
+	// SLILogQLBridge when set, has the LogQL queries that need to be recorded by Loki
+	// as the metrics used by the SLI.
+	SLILogQLBridge *SLILogQLBridge
+	// RecordNameTemplate when set, is the Go template used to name the SLI error recording
+	// rules instead of the default `slo:sli_error:ratio_rate<window>` (check RecordNameTemplateData).
+	RecordNameTemplate string `validate:"omitempty,record_name_tpl"`
+	// GroupNameTemplate when set, is the Go template used to name the SLO rule groups
+	// instead of the default `sloth-slo-<kind>-<id>` (check GroupNameTemplateData).
+	GroupNameTemplate string `validate:"omitempty,group_name_tpl"`
+	// SLIDependsOn when set, is the ID of the SLO of the same group whose SLI recording rules
+	// are used by this SLO SLI, its SLI recording rules will be evaluated after them.
+	SLIDependsOn string
+}
+
+// SLILogQLBridge are the LogQL queries recorded by Loki recording rules, the SLI uses the
+// recorded metrics (`slo:sli_logql_error_events:count1m` and `slo:sli_logql_total_events:count1m`).
+type SLILogQLBridge struct {
+	ErrorQuery string `validate:"required,template_vars"`
+	TotalQuery string `validate:"required,template_vars"`
 }
 
 type SLOGroup struct {
@@ -68,19 +132,151 @@ func (s SLOGroup) Validate() error {
 	return modelSpecValidate.Struct(s)
 }
 
+// Record name roles, the kind of recording rule that is being named.
+const (
+	RecordRoleSLIError         = "sli_error"
+	RecordRoleSLIErrorSmoothed = "sli_error_smoothed"
+	RecordRoleSLISuccess       = "sli_success"
+)
+
+// RecordNameTemplateData is the data available on the SLO record name templates.
+type RecordNameTemplateData struct {
+	ID              string
+	Name            string
+	Service         string
+	Role            string
+	Window          string
+	SmoothingWindow string
+}
+
 // GetSLIErrorMetric returns the SLI error metric.
 func (s SLO) GetSLIErrorMetric(window time.Duration) string {
-	return fmt.Sprintf(sliErrorMetricFmt, timeDurationToPromStr(window))
+	strWindow := timeDurationToPromStr(window)
+	if s.RecordNameTemplate == "" {
+		return fmt.Sprintf(sliErrorMetricFmt, strWindow)
+	}
+
+	// The template is validated with the SLO, it will not fail.
+	name, _ := s.renderRecordName(RecordRoleSLIError, strWindow, "")
+	return name
+}
+
+// GetSLIErrorSmoothedMetric returns the SLI error metric smoothed over the smoothing window.
+func (s SLO) GetSLIErrorSmoothedMetric(window, smoothingWindow time.Duration) string {
+	strWindow := timeDurationToPromStr(window)
+	strSmoothingWindow := timeDurationToPromStr(smoothingWindow)
+	if s.RecordNameTemplate == "" {
+		return fmt.Sprintf(sliErrorSmoothedMetricFmt, strWindow, strSmoothingWindow)
+	}
+
+	// The template is validated with the SLO, it will not fail.
+	name, _ := s.renderRecordName(RecordRoleSLIErrorSmoothed, strWindow, strSmoothingWindow)
+	return name
+}
+
+// GetSLISuccessMetric returns the SLI success metric, the complement of the SLI error metric.
+func (s SLO) GetSLISuccessMetric(window time.Duration) string {
+	strWindow := timeDurationToPromStr(window)
+	if s.RecordNameTemplate == "" {
+		return fmt.Sprintf(sliSuccessMetricFmt, strWindow)
+	}
+
+	// The template is validated with the SLO, it will not fail.
+	name, _ := s.renderRecordName(RecordRoleSLISuccess, strWindow, "")
+	return name
+}
+
+func (s SLO) renderRecordName(role, window, smoothingWindow string) (string, error) {
+	tpl, err := template.New("recordName").Option("missingkey=error").Parse(s.RecordNameTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	var b bytes.Buffer
+	err = tpl.Execute(&b, RecordNameTemplateData{
+		ID:              s.ID,
+		Name:            s.Name,
+		Service:         s.Service,
+		Role:            role,
+		Window:          window,
+		SmoothingWindow: smoothingWindow,
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not render template: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// Rule group kinds, the kind of rules a SLO rule group has.
+const (
+	GroupKindSLIRecordings      = "sli-recordings"
+	GroupKindMetaRecordings     = "meta-recordings"
+	GroupKindAlerts             = "alerts"
+	GroupKindRedactedRecordings = "redacted-recordings"
+	GroupKindLogQLRecordings    = "logql-recordings"
+)
+
+var groupKinds = []string{
+	GroupKindSLIRecordings,
+	GroupKindMetaRecordings,
+	GroupKindAlerts,
+	GroupKindRedactedRecordings,
+	GroupKindLogQLRecordings,
+}
+
+// GroupNameTemplateData is the data available on the SLO rule group name templates.
+type GroupNameTemplateData struct {
+	ID      string
+	Name    string
+	Service string
+	Kind    string
+}
+
+// GetRuleGroupName returns the name of the SLO rule group of the kind.
+func (s SLO) GetRuleGroupName(kind string) string {
+	if s.GroupNameTemplate == "" {
+		return fmt.Sprintf("sloth-slo-%s-%s", kind, s.ID)
+	}
+
+	// The template is validated with the SLO, it will not fail.
+	name, _ := s.renderGroupName(kind)
+	return name
+}
+
+func (s SLO) renderGroupName(kind string) (string, error) {
+	tpl, err := template.New("groupName").Option("missingkey=error").Parse(s.GroupNameTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	var b bytes.Buffer
+	err = tpl.Execute(&b, GroupNameTemplateData{
+		ID:      s.ID,
+		Name:    s.Name,
+		Service: s.Service,
+		Kind:    kind,
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not render template: %w", err)
+	}
+
+	return b.String(), nil
 }
 
 // GetSLOIDPromLabels returns the ID labels of an SLO, these can be used to identify
 // an SLO recorded metrics and alerts.
 func (s SLO) GetSLOIDPromLabels() map[string]string {
-	return mergeLabels(map[string]string{
+	labels := map[string]string{
 		sloIDLabelName:      s.ID,
 		sloNameLabelName:    s.Name,
 		sloServiceLabelName: s.Service,
-	}, s.IDLabels)
+	}
+	if s.ObjectiveIDLabel {
+		labels[sloObjectiveLabelName] = strconv.FormatFloat(s.Objective, 'f', -1, 64)
+	}
+
+	return mergeLabels(labels, s.IDLabels)
 }
 
 var modelSpecValidate = func() *validator.Validate {
@@ -88,15 +284,19 @@ var modelSpecValidate = func() *validator.Validate {
 
 	// More information on prometheus validators logic: https://github.com/prometheus/prometheus/blob/df80dc4d3970121f2f76cba79050983ffb3cdbb0/pkg/rulefmt/rulefmt.go#L188-L208
 	mustRegisterValidation(v, "prom_expr", validatePromExpression)
+	mustRegisterValidation(v, "prom_vector_selector", validatePromVectorSelector)
 	mustRegisterValidation(v, "prom_label_key", validatePromLabelKey)
 	mustRegisterValidation(v, "prom_label_value", validatePromLabelValue)
 	mustRegisterValidation(v, "prom_annot_key", validatePromAnnotKey)
 	mustRegisterValidation(v, "name", validateName)
 	mustRegisterValidation(v, "required_if_enabled", validateRequiredEnabledAlertName)
 	mustRegisterValidation(v, "template_vars", validateTemplateVars)
+	mustRegisterValidation(v, "record_name_tpl", validateRecordNameTemplate)
+	mustRegisterValidation(v, "group_name_tpl", validateGroupNameTemplate)
 	v.RegisterStructValidation(validateOneSLI, SLI{})
 	v.RegisterStructValidation(validateSLOGroup, SLOGroup{})
 	v.RegisterStructValidation(validateSLIEvents, SLIEvents{})
+	v.RegisterStructValidation(validateSLIRaw, SLIRaw{})
 	v.RegisterStructValidation(validateDenominatorCorrected, SLIDenominatorCorrectedEvents{})
 	return v
 }()
@@ -154,22 +354,52 @@ func validatePromExpression(fl validator.FieldLevel) bool {
 		return false
 	}
 
-	// The expressions set by users can have some allowed templated data
-	// we are rendering the expression with fake data so prometheus can
+	return ValidatePromExpr(expr) == nil
+}
+
+// validatePromVectorSelector implements validator.CustomTypeFunc by validating
+// a prometheus vector selector (e.g: `my_metric{job="x"}`), the expressions that
+// can be used on a range vector selector.
+func validatePromVectorSelector(fl validator.FieldLevel) bool {
+	expr, ok := fl.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	e, err := promqlparser.ParseExpr(expr)
+	if err != nil {
+		return false
+	}
+	_, ok = e.(*promqlparser.VectorSelector)
+
+	return ok
+}
+
+// ValidatePromExpr validates a Prometheus expression set by the users (specs, plugins...), these
+// can have some allowed templated data and redacted fragments markup.
+func ValidatePromExpr(expr string) error {
+	// We are rendering the expression with fake data so prometheus can
 	// have a final expr and check if is correct.
 	tpl, err := template.New("expr").Parse(expr)
 	if err != nil {
-		return false
+		return fmt.Errorf("invalid template: %w", err)
 	}
 
 	var tplB bytes.Buffer
 	err = tpl.Execute(&tplB, promExprTplAllowedFakeData)
 	if err != nil {
-		return false
+		return fmt.Errorf("could not render template: %w", err)
+	}
+
+	// The redacted fragments are validated in place.
+	rendered := redactMarkupRegexp.ReplaceAllString(tplB.String(), "($1)")
+
+	_, err = promqlparser.ParseExpr(rendered)
+	if err != nil {
+		return fmt.Errorf("invalid PromQL: %w", err)
 	}
 
-	_, err = promqlparser.ParseExpr(tplB.String())
-	return err == nil
+	return nil
 }
 
 // Names must:
@@ -203,6 +433,51 @@ func validateRequiredEnabledAlertName(fl validator.FieldLevel) bool {
 	return alertMeta.Name != ""
 }
 
+// validateRecordNameTemplate implements validator.CustomTypeFunc by validating the record
+// name template renders legal and different metric names for every window and role of the SLO.
+func validateRecordNameTemplate(fl validator.FieldLevel) bool {
+	slo, ok := fl.Parent().Interface().(SLO)
+	if !ok {
+		return false
+	}
+
+	names := map[string]bool{}
+	for _, r := range []struct{ role, window, smoothingWindow string }{
+		{role: RecordRoleSLIError, window: "5m"},
+		{role: RecordRoleSLIError, window: "1h"},
+		{role: RecordRoleSLIErrorSmoothed, window: "5m", smoothingWindow: "1h"},
+		{role: RecordRoleSLISuccess, window: "5m"},
+	} {
+		name, err := slo.renderRecordName(r.role, r.window, r.smoothingWindow)
+		if err != nil || !prommodel.IsValidLegacyMetricName(prommodel.LabelValue(name)) || names[name] {
+			return false
+		}
+		names[name] = true
+	}
+
+	return true
+}
+
+// validateGroupNameTemplate implements validator.CustomTypeFunc by validating the group
+// name template renders non empty and different group names for every rule group kind of the SLO.
+func validateGroupNameTemplate(fl validator.FieldLevel) bool {
+	slo, ok := fl.Parent().Interface().(SLO)
+	if !ok {
+		return false
+	}
+
+	names := map[string]bool{}
+	for _, kind := range groupKinds {
+		name, err := slo.renderGroupName(kind)
+		if err != nil || strings.TrimSpace(name) == "" || names[name] {
+			return false
+		}
+		names[name] = true
+	}
+
+	return true
+}
+
 var tplWindowRegex = regexp.MustCompile(fmt.Sprintf(`{{ *\.%s *}}`, tplKeyWindow))
 
 // validateTemplateVars implements validator.CustomTypeFunc by validating
@@ -213,6 +488,11 @@ func validateTemplateVars(fl validator.FieldLevel) bool {
 		return false
 	}
 
+	// Gauge based events are not window based.
+	if events, ok := fl.Parent().Interface().(SLIEvents); ok && events.Mode == SLIEventsModeGauge {
+		return true
+	}
+
 	return tplWindowRegex.MatchString(v)
 }
 
@@ -224,6 +504,10 @@ func validateSLIEvents(sl validator.StructLevel) {
 		return
 	}
 
+	if s.Mode != SLIEventsModeCounter && s.Mode != SLIEventsModeGauge {
+		sl.ReportError(s.Mode, "Mode", "Mode", "sli_events_mode", "")
+	}
+
 	// If empty we don't need to check.
 	if s.ErrorQuery == "" || s.TotalQuery == "" {
 		return
@@ -236,6 +520,79 @@ func validateSLIEvents(sl validator.StructLevel) {
 	}
 }
 
+// validateSLIRaw validates that the raw SLI has the error ratio query or both error and total queries.
+func validateSLIRaw(sl validator.StructLevel) {
+	s, ok := sl.Current().Interface().(SLIRaw)
+	if !ok {
+		sl.ReportError(s, "", "SLIRaw", "not_sli_raw", "")
+		return
+	}
+
+	hasRatio := s.ErrorRatioQuery != ""
+	hasQueries := s.ErrorQuery != "" || s.TotalQuery != ""
+	switch {
+	case hasRatio && hasQueries:
+		sl.ReportError(s, "", "", "sli_raw_one_mode", "")
+	case !hasRatio && (s.ErrorQuery == "" || s.TotalQuery == ""):
+		sl.ReportError(s, "", "", "sli_raw_queries_required", "")
+	}
+}
+
+// countShapedAggregations and countShapedFunctions are the PromQL aggregations and functions that
+// return counts or rates, when used on the query root they can't return a ratio.
+var (
+	countShapedAggregations = map[promqlparser.ItemType]bool{
+		promqlparser.SUM:          true,
+		promqlparser.COUNT:        true,
+		promqlparser.COUNT_VALUES: true,
+	}
+	countShapedFunctions = map[string]bool{
+		"count_over_time": true,
+		"sum_over_time":   true,
+		"increase":        true,
+		"rate":            true,
+		"irate":           true,
+	}
+)
+
+// IsCountShapedQuery is a best-effort lint of the raw SLI error ratio queries that clearly return
+// counts (e.g a root `sum(rate(...))`) instead of a 0-1 ratio, unparseable queries are not checked.
+func IsCountShapedQuery(query string) bool {
+	tpl, err := template.New("query").Parse(query)
+	if err != nil {
+		return false
+	}
+	var b bytes.Buffer
+	err = tpl.Execute(&b, promExprTplAllowedFakeData)
+	if err != nil {
+		return false
+	}
+	expr, err := promqlparser.ParseExpr(redactMarkupRegexp.ReplaceAllString(b.String(), "($1)"))
+	if err != nil {
+		return false
+	}
+
+	for {
+		switch e := expr.(type) {
+		case *promqlparser.ParenExpr:
+			expr = e.Expr
+		case *promqlparser.BinaryExpr:
+			// `1 - ratio` is still a ratio, any other binary expression (e.g a division) is not checked.
+			if n, ok := e.LHS.(*promqlparser.NumberLiteral); ok && e.Op == promqlparser.SUB && n.Val == 1 {
+				expr = e.RHS
+				continue
+			}
+			return false
+		case *promqlparser.AggregateExpr:
+			return countShapedAggregations[e.Op]
+		case *promqlparser.Call:
+			return countShapedFunctions[e.Func.Name]
+		default:
+			return false
+		}
+	}
+}
+
 // validateOneSLIType validates only one SLI type is set and configured.
 func validateOneSLI(sl validator.StructLevel) {
 	sli, ok := sl.Current().Interface().(SLI)
@@ -303,6 +660,31 @@ func validateSLOGroup(sl validator.StructLevel) {
 		}
 		sloIDs[slo.ID] = struct{}{}
 	}
+
+	// Check the SLI dependencies exist and don't have cycles.
+	sliDeps := map[string]string{}
+	for _, slo := range sloGroup.SLOs {
+		sliDeps[slo.ID] = slo.SLIDependsOn
+	}
+	for _, slo := range sloGroup.SLOs {
+		if slo.SLIDependsOn == "" {
+			continue
+		}
+
+		if _, ok := sloIDs[slo.SLIDependsOn]; !ok {
+			sl.ReportError(slo.SLIDependsOn, slo.ID, "", "sli_dependency_missing", "")
+			continue
+		}
+
+		visited := map[string]bool{slo.ID: true}
+		for id := slo.SLIDependsOn; id != ""; id = sliDeps[id] {
+			if visited[id] {
+				sl.ReportError(slo.SLIDependsOn, slo.ID, "", "sli_dependency_cycle", "")
+				break
+			}
+			visited[id] = true
+		}
+	}
 }
 
 // SLORules are the prometheus rules required by an SLO.
@@ -310,4 +692,12 @@ type SLORules struct {
 	SLIErrorRecRules []rulefmt.Rule
 	MetadataRecRules []rulefmt.Rule
 	AlertRules       []rulefmt.Rule
+	// RedactedRecRules are the helper recording rules of the redacted SLI query fragments,
+	// these are not stored with the rest of the SLO rules.
+	RedactedRecRules []rulefmt.Rule
+	// SharedRecRules are the shared SLI query recording rules used by the SLO, these can be used by
+	// other SLOs so they are stored on their own group, only once.
+	SharedRecRules []rulefmt.Rule
+	// AlertsLimit is the max number of alerts the alert rules group can produce, 0 is unlimited.
+	AlertsLimit int
 }
@@ -216,6 +216,111 @@ func TestModelValidationSpec(t *testing.T) {
 			expErrMessage: "Key: 'SLOGroup.SLOs[0].SLI.' Error:Field validation for '' failed on the 'one_sli_type' tag",
 		},
 
+		"SLO with raw SLI error and total queries should not fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events = nil
+				s.SLOs[0].SLI.Raw = &prometheus.SLIRaw{
+					ErrorQuery: `sum(rate(total[{{ .window }}])) - sum(rate(good[{{ .window }}]))`,
+					TotalQuery: `sum(rate(total[{{ .window }}]))`,
+				}
+				return s
+			},
+		},
+
+		"SLO with precomputed SLI metrics should not fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events = nil
+				s.SLOs[0].SLI.Precomputed = &prometheus.SLIPrecomputed{
+					ErrorMetric: `slo:errors:rate5m{job="myapp"}`,
+					TotalMetric: `slo:total:rate5m{job="myapp"}`,
+				}
+				return s
+			},
+		},
+
+		"SLO with precomputed SLI aggregated error metric should fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events = nil
+				s.SLOs[0].SLI.Precomputed = &prometheus.SLIPrecomputed{
+					ErrorMetric: `sum(rate(x[5m]))`,
+					TotalMetric: `slo:total:rate5m{job="myapp"}`,
+				}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].SLI.Precomputed.ErrorMetric' Error:Field validation for 'ErrorMetric' failed on the 'prom_vector_selector' tag",
+		},
+
+		"SLO with precomputed SLI range total metric should fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events = nil
+				s.SLOs[0].SLI.Precomputed = &prometheus.SLIPrecomputed{
+					ErrorMetric: `slo:errors:rate5m{job="myapp"}`,
+					TotalMetric: `slo:total:rate5m{job="myapp"}[5m]`,
+				}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].SLI.Precomputed.TotalMetric' Error:Field validation for 'TotalMetric' failed on the 'prom_vector_selector' tag",
+		},
+
+		"SLO with raw SLI error query without total query should fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events = nil
+				s.SLOs[0].SLI.Raw = &prometheus.SLIRaw{
+					ErrorQuery: `sum(rate(total[{{ .window }}])) - sum(rate(good[{{ .window }}]))`,
+				}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].SLI.Raw.' Error:Field validation for '' failed on the 'sli_raw_queries_required' tag",
+		},
+
+		"SLO with raw SLI error ratio query and error queries should fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events = nil
+				s.SLOs[0].SLI.Raw = &prometheus.SLIRaw{
+					ErrorRatioQuery: `sum(rate(errors[{{ .window }}])) / sum(rate(total[{{ .window }}]))`,
+					ErrorQuery:      `sum(rate(errors[{{ .window }}]))`,
+					TotalQuery:      `sum(rate(total[{{ .window }}]))`,
+				}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].SLI.Raw.' Error:Field validation for '' failed on the 'sli_raw_one_mode' tag",
+		},
+
+		"SLO with an SLI depending on another SLO of the group should not fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				dep := s.SLOs[0]
+				dep.ID = "slo2-id"
+				dep.SLIDependsOn = s.SLOs[0].ID
+				s.SLOs = append(s.SLOs, dep)
+				return s
+			},
+		},
+
+		"SLO with an SLI depending on a missing SLO should fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLIDependsOn = "missing-id"
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.slo1-id' Error:Field validation for 'slo1-id' failed on the 'sli_dependency_missing' tag",
+		},
+
+		"SLO with an SLI depending on itself should fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLIDependsOn = s.SLOs[0].ID
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.slo1-id' Error:Field validation for 'slo1-id' failed on the 'sli_dependency_cycle' tag",
+		},
+
 		"SLO SLI event queries must be different.": {
 			slo: func() prometheus.SLOGroup {
 				s := getGoodSLOGroup()
@@ -262,6 +367,27 @@ func TestModelValidationSpec(t *testing.T) {
 			expErrMessage: "Key: 'SLOGroup.SLOs[0].SLI.Events.TotalQuery' Error:Field validation for 'TotalQuery' failed on the 'template_vars' tag",
 		},
 
+		"SLO SLI gauge events queries shouldn't require template vars.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events = &prometheus.SLIEvents{
+					ErrorQuery: `sum(queue_depth{job="myapp",state="expired"})`,
+					TotalQuery: `sum(queue_depth{job="myapp"})`,
+					Mode:       prometheus.SLIEventsModeGauge,
+				}
+				return s
+			},
+		},
+
+		"SLO SLI events mode should be valid.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].SLI.Events.Mode = "histogram"
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].SLI.Events.Mode' Error:Field validation for 'Mode' failed on the 'sli_events_mode' tag",
+		},
+
 		"SLO Objective shouldn't be less than 0.": {
 			slo: func() prometheus.SLOGroup {
 				s := getGoodSLOGroup()
@@ -445,6 +571,111 @@ func TestModelValidationSpec(t *testing.T) {
 			},
 			expErrMessage: "Key: 'SLOGroup.SLOs[0].TicketAlertMeta.Annotations[something]' Error:Field validation for 'Annotations[something]' failed on the 'required' tag",
 		},
+
+		"SLO alert business hours with wrapping ranges should not fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].PageAlertMeta.BusinessHours = &prometheus.AlertBusinessHours{StartHour: 22, EndHour: 6, StartDay: 5, EndDay: 1}
+				return s
+			},
+		},
+
+		"SLO alert business hours should not have an empty hours range.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].PageAlertMeta.BusinessHours = &prometheus.AlertBusinessHours{StartHour: 9, EndHour: 9, StartDay: 1, EndDay: 5}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].PageAlertMeta.BusinessHours.EndHour' Error:Field validation for 'EndHour' failed on the 'nefield' tag",
+		},
+
+		"SLO alert business hours should have valid hours.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].PageAlertMeta.BusinessHours = &prometheus.AlertBusinessHours{StartHour: 9, EndHour: 25, StartDay: 1, EndDay: 5}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].PageAlertMeta.BusinessHours.EndHour' Error:Field validation for 'EndHour' failed on the 'lte' tag",
+		},
+
+		"SLO alert business hours should have valid start days of the week.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].TicketAlertMeta.BusinessHours = &prometheus.AlertBusinessHours{StartHour: 9, EndHour: 17, StartDay: 7, EndDay: 1}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].TicketAlertMeta.BusinessHours.StartDay' Error:Field validation for 'StartDay' failed on the 'lte' tag",
+		},
+
+		"SLO alert business hours should have valid days of the week.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].TicketAlertMeta.BusinessHours = &prometheus.AlertBusinessHours{StartHour: 9, EndHour: 17, StartDay: 1, EndDay: 7}
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].TicketAlertMeta.BusinessHours.EndDay' Error:Field validation for 'EndDay' failed on the 'lte' tag",
+		},
+
+		"SLO record name template rendering legal metric names should not fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].RecordNameTemplate = "team:{{ .Role }}:ratio_rate{{ .Window }}{{ .SmoothingWindow }}"
+				return s
+			},
+		},
+
+		"SLO record name template should render legal metric names.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].RecordNameTemplate = "{{ .Name }}:{{ .Role }}:ratio_rate{{ .Window }}{{ .SmoothingWindow }}"
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].RecordNameTemplate' Error:Field validation for 'RecordNameTemplate' failed on the 'record_name_tpl' tag",
+		},
+
+		"SLO record name template should render different metric names for every window.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].RecordNameTemplate = "slo:{{ .Role }}:ratio"
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].RecordNameTemplate' Error:Field validation for 'RecordNameTemplate' failed on the 'record_name_tpl' tag",
+		},
+
+		"SLO record name template should be a valid template.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].RecordNameTemplate = "slo:{{ .Unknown }}:ratio_rate{{ .Window }}"
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].RecordNameTemplate' Error:Field validation for 'RecordNameTemplate' failed on the 'record_name_tpl' tag",
+		},
+
+		"SLO group name template rendering different group names should not fail.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].GroupNameTemplate = "slo-{{ .Service }}-{{ .Name }}-{{ .Kind }}"
+				return s
+			},
+		},
+
+		"SLO group name template should render different group names for every group kind.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].GroupNameTemplate = "slo-{{ .Service }}-{{ .Name }}"
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].GroupNameTemplate' Error:Field validation for 'GroupNameTemplate' failed on the 'group_name_tpl' tag",
+		},
+
+		"SLO group name template should be a valid template.": {
+			slo: func() prometheus.SLOGroup {
+				s := getGoodSLOGroup()
+				s.SLOs[0].GroupNameTemplate = "slo-{{ .Unknown }}-{{ .Kind }}"
+				return s
+			},
+			expErrMessage: "Key: 'SLOGroup.SLOs[0].GroupNameTemplate' Error:Field validation for 'GroupNameTemplate' failed on the 'group_name_tpl' tag",
+		},
 	}
 
 	for name, test := range tests {
@@ -463,3 +694,47 @@ func TestModelValidationSpec(t *testing.T) {
 		})
 	}
 }
+
+func TestIsCountShapedQuery(t *testing.T) {
+	tests := map[string]struct {
+		query    string
+		expCount bool
+	}{
+		"A ratio query shouldn't be count shaped.": {
+			query: `sum(rate(http_request_duration_seconds_count{code=~"(5..|429)"}[{{.window}}])) / sum(rate(http_request_duration_seconds_count[{{.window}}]))`,
+		},
+
+		"A good ratio query shouldn't be count shaped.": {
+			query: `1 - (sum(rate(ok[{{.window}}])) / sum(rate(total[{{.window}}])))`,
+		},
+
+		"An averaged ratio query shouldn't be count shaped.": {
+			query: `avg(slo:error_ratio:ratio_rate5m)`,
+		},
+
+		"An invalid query shouldn't be count shaped.": {
+			query: `sum(`,
+		},
+
+		"A sum of rates query should be count shaped.": {
+			query:    `sum(rate(http_request_duration_seconds_count{code=~"(5..|429)"}[{{.window}}]))`,
+			expCount: true,
+		},
+
+		"A count query wrapped in parenthesis should be count shaped.": {
+			query:    `(count(up == 0))`,
+			expCount: true,
+		},
+
+		"A good increase query should be count shaped.": {
+			query:    `1 - increase(ok[{{.window}}])`,
+			expCount: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expCount, prometheus.IsCountShapedQuery(test.query))
+		})
+	}
+}
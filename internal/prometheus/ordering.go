@@ -0,0 +1,125 @@
+package prometheus
+
+import (
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	promqlparser "github.com/prometheus/prometheus/promql/parser"
+)
+
+// SortRulesByDependency returns the rules of a group sorted so the recording rules are before
+// the rules that use their metrics, `promtool` and some rulers evaluate the group rules in order.
+//
+// The sort is stable, independent rules keep their order, and the rules in a dependency cycle
+// (or with unparseable expressions) will not be moved.
+func SortRulesByDependency(rules []rulefmt.Rule) []rulefmt.Rule {
+	recorders := map[string][]int{}
+	for i, r := range rules {
+		if r.Record != "" {
+			recorders[r.Record] = append(recorders[r.Record], i)
+		}
+	}
+
+	// Get the rules that every rule depends on.
+	deps := make([][]int, len(rules))
+	for i, r := range rules {
+		for _, metric := range exprMetricNames(r.Expr) {
+			for _, j := range recorders[metric] {
+				if j != i {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+
+	sorted := make([]rulefmt.Rule, 0, len(rules))
+	added := make([]bool, len(rules))
+	for len(sorted) < len(rules) {
+		next := -1
+		for i := range rules {
+			if added[i] {
+				continue
+			}
+
+			ready := true
+			for _, j := range deps[i] {
+				if !added[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+
+		// Dependency cycle, add the first pending rule.
+		if next == -1 {
+			for i := range rules {
+				if !added[i] {
+					next = i
+					break
+				}
+			}
+		}
+
+		added[next] = true
+		sorted = append(sorted, rules[next])
+	}
+
+	return sorted
+}
+
+// exprMetricNames returns the metric names used by a PromQL expression.
+func exprMetricNames(expr string) []string {
+	e, err := promqlparser.ParseExpr(expr)
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+	promqlparser.Inspect(e, func(node promqlparser.Node, _ []promqlparser.Node) error {
+		vs, ok := node.(*promqlparser.VectorSelector)
+		if !ok {
+			return nil
+		}
+
+		if vs.Name != "" {
+			names = append(names, vs.Name)
+			return nil
+		}
+		for _, m := range vs.LabelMatchers {
+			if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+				names = append(names, m.Value)
+			}
+		}
+		return nil
+	})
+
+	return names
+}
+
+// exprLabelValues returns the values of the label equality matchers used by a PromQL expression.
+func exprLabelValues(expr, label string) []string {
+	e, err := promqlparser.ParseExpr(expr)
+	if err != nil {
+		return nil
+	}
+
+	values := []string{}
+	promqlparser.Inspect(e, func(node promqlparser.Node, _ []promqlparser.Node) error {
+		vs, ok := node.(*promqlparser.VectorSelector)
+		if !ok {
+			return nil
+		}
+
+		for _, m := range vs.LabelMatchers {
+			if m.Name == label && m.Type == labels.MatchEqual {
+				values = append(values, m.Value)
+			}
+		}
+		return nil
+	})
+
+	return values
+}
@@ -0,0 +1,85 @@
+package prometheus_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+func TestSortRulesByDependency(t *testing.T) {
+	tests := map[string]struct {
+		rules    []rulefmt.Rule
+		expRules []rulefmt.Rule
+	}{
+		"Having no rules, it should return no rules.": {
+			rules:    []rulefmt.Rule{},
+			expRules: []rulefmt.Rule{},
+		},
+
+		"Having sorted rules, it should keep the order.": {
+			rules: []rulefmt.Rule{
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `sum(rate(errors[5m])) / sum(rate(total[5m]))`},
+				{Record: "slo:sli_error:ratio_rate30d", Expr: `avg_over_time(slo:sli_error:ratio_rate5m[30d])`},
+				{Alert: "SLOBurn", Expr: `slo:sli_error:ratio_rate5m > 0.1`},
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `sum(rate(errors[5m])) / sum(rate(total[5m]))`},
+				{Record: "slo:sli_error:ratio_rate30d", Expr: `avg_over_time(slo:sli_error:ratio_rate5m[30d])`},
+				{Alert: "SLOBurn", Expr: `slo:sli_error:ratio_rate5m > 0.1`},
+			},
+		},
+
+		"Having alerts before the recording rules they use, it should move the recording rules before the alerts.": {
+			rules: []rulefmt.Rule{
+				{Alert: "SLOBurn", Expr: `slo:sli_error:ratio_rate5m{sloth_id="a"} > 0.1`},
+				{Alert: "SLONoData", Expr: `absent({__name__="slo:sli_error:ratio_rate1h"})`},
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `sum(rate(errors[5m])) / sum(rate(total[5m]))`},
+				{Record: "slo:sli_error:ratio_rate1h", Expr: `sum(rate(errors[1h])) / sum(rate(total[1h]))`},
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `sum(rate(errors[5m])) / sum(rate(total[5m]))`},
+				{Alert: "SLOBurn", Expr: `slo:sli_error:ratio_rate5m{sloth_id="a"} > 0.1`},
+				{Record: "slo:sli_error:ratio_rate1h", Expr: `sum(rate(errors[1h])) / sum(rate(total[1h]))`},
+				{Alert: "SLONoData", Expr: `absent({__name__="slo:sli_error:ratio_rate1h"})`},
+			},
+		},
+
+		"Having recording rules before the recording rules they use, it should sort them by dependency.": {
+			rules: []rulefmt.Rule{
+				{Record: "slo:sli_error:ratio_rate30d", Expr: `avg_over_time(slo:sli_error:ratio_rate5m[30d])`},
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `slo:redacted:abc / 2`},
+				{Record: "slo:redacted:abc", Expr: `sum(rate(errors[5m]))`},
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:redacted:abc", Expr: `sum(rate(errors[5m]))`},
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `slo:redacted:abc / 2`},
+				{Record: "slo:sli_error:ratio_rate30d", Expr: `avg_over_time(slo:sli_error:ratio_rate5m[30d])`},
+			},
+		},
+
+		"Having a dependency cycle, it should keep the order.": {
+			rules: []rulefmt.Rule{
+				{Record: "a", Expr: `b`},
+				{Record: "b", Expr: `a`},
+				{Alert: "SLOBurn", Expr: `a > 1`},
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "a", Expr: `b`},
+				{Record: "b", Expr: `a`},
+				{Alert: "SLOBurn", Expr: `a > 1`},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotRules := prometheus.SortRulesByDependency(test.rules)
+			assert.Equal(test.expRules, gotRules)
+		})
+	}
+}
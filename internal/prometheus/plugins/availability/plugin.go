@@ -0,0 +1,45 @@
+package availability
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+const (
+	SLIPluginVersion = "prometheus/v1"
+	SLIPluginID      = "sloth_availability"
+)
+
+var queryTpl = template.Must(template.New("").Parse(`
+1 - (
+  sum(avg_over_time(up{ {{.selector}} }[{{"{{.window}}"}}]))
+  /
+  count(avg_over_time(up{ {{.selector}} }[{{"{{.window}}"}}]))
+)`))
+
+// SLIPlugin is the first-party availability plugin.
+//
+// It will return an Sloth error ratio raw query based on the Prometheus `up` metric of the
+// targets matched by the `selector` option (e.g: `job="my-app"`). Good events are the
+// time the targets have been up and total events all the targets.
+func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (string, error) {
+	// Get selector.
+	selector := strings.TrimSpace(options["selector"])
+	selector = strings.Trim(selector, "{}")
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return "", fmt.Errorf("selector option is required")
+	}
+
+	// Create query.
+	var b bytes.Buffer
+	err := queryTpl.Execute(&b, map[string]string{"selector": selector})
+	if err != nil {
+		return "", fmt.Errorf("could not execute template: %w", err)
+	}
+
+	return b.String(), nil
+}
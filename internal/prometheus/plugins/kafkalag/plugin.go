@@ -0,0 +1,71 @@
+package kafkalag
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	SLIPluginVersion = "prometheus/v1"
+	SLIPluginID      = "sloth_kafka_lag"
+)
+
+const defaultLagMetric = "kafka_consumergroup_lag"
+
+var queryTpl = template.Must(template.New("").Parse(`
+(
+  sum(count_over_time(({{.metric}}{ {{.filter}} } > {{.threshold}})[{{"{{.window}}"}}:]))
+  or
+  vector(0)
+)
+/
+sum(count_over_time({{.metric}}{ {{.filter}} }[{{"{{.window}}"}}:]))`))
+
+// SLIPlugin is the first-party Kafka consumer lag plugin.
+//
+// It will return an Sloth error ratio raw query based on the consumer lag metric (by default
+// `kafka_consumergroup_lag`, can be changed with the `lag_metric` option) of the series matched by
+// the `filter` option (e.g: `consumergroup="my-app"`). Good events are the lag samples under (or equal)
+// the `threshold` option and total events all the lag samples.
+func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (string, error) {
+	// Get threshold.
+	strThreshold := strings.TrimSpace(options["threshold"])
+	if strThreshold == "" {
+		return "", fmt.Errorf("threshold option is required")
+	}
+	threshold, err := strconv.ParseFloat(strThreshold, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid threshold option: %w", err)
+	}
+	if threshold < 0 {
+		return "", fmt.Errorf("threshold option can't be negative")
+	}
+
+	// Get lag metric.
+	metric := strings.TrimSpace(options["lag_metric"])
+	if metric == "" {
+		metric = defaultLagMetric
+	}
+
+	// Get filter.
+	filter := strings.TrimSpace(options["filter"])
+	filter = strings.Trim(filter, "{}")
+	filter = strings.TrimSpace(filter)
+
+	// Create query.
+	var b bytes.Buffer
+	err = queryTpl.Execute(&b, map[string]string{
+		"metric":    metric,
+		"filter":    filter,
+		"threshold": strconv.FormatFloat(threshold, 'f', -1, 64),
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not execute template: %w", err)
+	}
+
+	return b.String(), nil
+}
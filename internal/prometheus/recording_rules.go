@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -18,7 +19,32 @@ import (
 type sliRulesgenFunc func(slo SLO, window time.Duration, alerts alert.MWMBAlertGroup) (*rulefmt.Rule, error)
 
 type sliRecordingRulesGenerator struct {
-	genFunc sliRulesgenFunc
+	genFunc         sliRulesgenFunc
+	smoothingWindow time.Duration
+	objectiveLabels bool
+	successRules    bool
+}
+
+// WithSmoothingWindow returns a copy of the generator that will additionally generate a smoothed
+// companion recording rule for every SLI recording rule, averaging the SLI over the smoothing window.
+// A zero smoothing window disables the smoothed rules.
+func (s sliRecordingRulesGenerator) WithSmoothingWindow(window time.Duration) sliRecordingRulesGenerator {
+	s.smoothingWindow = window
+	return s
+}
+
+// WithObjectiveLabels returns a copy of the generator that will set the SLO objective and error
+// budget (in percent) as `sloth_objective` and `sloth_error_budget` labels on the SLI recording rules.
+func (s sliRecordingRulesGenerator) WithObjectiveLabels(enabled bool) sliRecordingRulesGenerator {
+	s.objectiveLabels = enabled
+	return s
+}
+
+// WithSuccessRules returns a copy of the generator that will additionally generate a success companion
+// recording rule for every SLI recording rule window, with the complement of the SLI error ratio.
+func (s sliRecordingRulesGenerator) WithSuccessRules(enabled bool) sliRecordingRulesGenerator {
+	s.successRules = enabled
+	return s
 }
 
 // OptimizedSLIRecordingRulesGenerator knows how to generate the SLI prometheus recording rules
@@ -55,14 +81,100 @@ func (s sliRecordingRulesGenerator) GenerateSLIRecordingRules(_ context.Context,
 		rules = append(rules, *rule)
 	}
 
+	// Generate the smoothed companion rules.
+	if s.smoothingWindow != 0 {
+		for _, window := range windows {
+			rule, err := smoothedSLIRecordGenerator(slo, window, s.smoothingWindow)
+			if err != nil {
+				return nil, fmt.Errorf("could not create %q SLO smoothed rule for window %s: %w", slo.ID, window, err)
+			}
+			rules = append(rules, *rule)
+		}
+	}
+
+	// Generate the success companion rules.
+	if s.successRules {
+		for _, window := range windows {
+			rules = append(rules, successSLIRecordGenerator(slo, window))
+		}
+	}
+
+	if s.objectiveLabels {
+		objectiveLabels, err := sloObjectiveLabels(slo)
+		if err != nil {
+			return nil, fmt.Errorf("could not get %q SLO objective labels: %w", slo.ID, err)
+		}
+		for i := range rules {
+			rules[i].Labels = mergeLabels(rules[i].Labels, objectiveLabels)
+		}
+	}
+
 	return rules, nil
 }
 
+// sloObjectiveLabels returns the SLO objective and error budget percent labels.
+func sloObjectiveLabels(slo SLO) (map[string]string, error) {
+	// Round so the error budget is the same as the literal (e.g 100-99.9 = 0.1).
+	errorBudget, err := strconv.ParseFloat(strconv.FormatFloat(100-slo.Objective, 'f', 10, 64), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid error budget: %w", err)
+	}
+
+	return map[string]string{
+		sloObjectiveLabelName:   strconv.FormatFloat(slo.Objective, 'f', -1, 64),
+		sloErrorBudgetLabelName: strconv.FormatFloat(errorBudget, 'f', -1, 64),
+	}, nil
+}
+
 const (
 	tplKeyWindow = "window"
 )
 
 func factorySLIRecordGenerator(slo SLO, window time.Duration, alerts alert.MWMBAlertGroup) (*rulefmt.Rule, error) {
+	rule, err := sliTypeRecordGenerator(slo, window, alerts)
+	if err != nil {
+		return nil, err
+	}
+
+	if slo.SLIInvert {
+		rule.Expr = fmt.Sprintf("1 - (\n%s\n)\n", strings.TrimSpace(rule.Expr))
+	}
+
+	if slo.SLIMaintenanceGateQuery == "" {
+		return rule, nil
+	}
+
+	return maintenanceGateSLIRecord(slo, window, *rule)
+}
+
+// maintenanceGateSLIRecord excludes the SLI from the recording rule while the maintenance
+// gate query returns data.
+func maintenanceGateSLIRecord(slo SLO, window time.Duration, rule rulefmt.Rule) (*rulefmt.Rule, error) {
+	// Render the gate with our templated data.
+	tpl, err := template.New("sliGateExpr").Option("missingkey=error").Parse(slo.SLIMaintenanceGateQuery)
+	if err != nil {
+		return nil, fmt.Errorf("could not create SLI maintenance gate expression template data: %w", err)
+	}
+
+	var b bytes.Buffer
+	err = tpl.Execute(&b, map[string]string{
+		tplKeyWindow: timeDurationToPromStr(window),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not render SLI maintenance gate expression template: %w", err)
+	}
+
+	const sliExprFmt = `(
+%s
+)
+unless on()
+(%s)
+`
+	rule.Expr = fmt.Sprintf(sliExprFmt, strings.TrimSpace(rule.Expr), b.String())
+	return &rule, nil
+}
+
+func sliTypeRecordGenerator(slo SLO, window time.Duration, alerts alert.MWMBAlertGroup) (*rulefmt.Rule, error) {
 	switch {
 	// Event based SLI.
 	case slo.SLI.Events != nil:
@@ -72,6 +184,9 @@ func factorySLIRecordGenerator(slo SLO, window time.Duration, alerts alert.MWMBA
 		return rawSLIRecordGenerator(slo, window, alerts)
 	case slo.SLI.DenominatorCorrected != nil:
 		return denominatorCorrectedSLIRecordGenerator(slo, window, alerts)
+	// Precomputed recording rules based SLI.
+	case slo.SLI.Precomputed != nil:
+		return precomputedSLIRecordGenerator(slo, window, alerts)
 	}
 
 	return nil, fmt.Errorf("invalid SLI type")
@@ -80,6 +195,10 @@ func factorySLIRecordGenerator(slo SLO, window time.Duration, alerts alert.MWMBA
 func rawSLIRecordGenerator(slo SLO, window time.Duration, _ alert.MWMBAlertGroup) (*rulefmt.Rule, error) {
 	// Render with our templated data.
 	sliExprTpl := fmt.Sprintf(`(%s)`, slo.SLI.Raw.ErrorRatioQuery)
+	// Group both queries so any operator on them (e.g `total - good`) is evaluated before the division.
+	if slo.SLI.Raw.ErrorRatioQuery == "" {
+		sliExprTpl = fmt.Sprintf("(%s)\n/\n(%s)\n", slo.SLI.Raw.ErrorQuery, slo.SLI.Raw.TotalQuery)
+	}
 	tpl, err := template.New("sliExpr").Option("missingkey=error").Parse(sliExprTpl)
 	if err != nil {
 		return nil, fmt.Errorf("could not create SLI expression template data: %w", err)
@@ -108,10 +227,17 @@ func rawSLIRecordGenerator(slo SLO, window time.Duration, _ alert.MWMBAlertGroup
 }
 
 func eventsSLIRecordGenerator(slo SLO, window time.Duration, _ alert.MWMBAlertGroup) (*rulefmt.Rule, error) {
-	const sliExprTplFmt = `(%s)
+	sliExprTplFmt := `(%s)
 /
 (%s)
 `
+	// Gauges don't have the window, so we average them over the window.
+	if slo.SLI.Events.Mode == SLIEventsModeGauge {
+		sliExprTplFmt = `(avg_over_time((%s)[{{.window}}:]))
+/
+(avg_over_time((%s)[{{.window}}:]))
+`
+	}
 	// Generate our first level of template by assembling the error and total expressions.
 	sliExprTpl := fmt.Sprintf(sliExprTplFmt, slo.SLI.Events.ErrorQuery, slo.SLI.Events.TotalQuery)
 
@@ -201,6 +327,47 @@ slo:numerator_correction:ratio{{.window}}{{.filter}}
 	}, nil
 }
 
+// precomputedSLIRecordGenerator gets the SLI recording rule from already precomputed error and
+// total rates. These rates don't depend on the window, so instead of templating the queries we
+// average the rates over the window time range. Like the other SLI types, the SLI is a single
+// ratio per SLO, so the rates of all the selected series (e.g instances) are summed dropping
+// their labels.
+func precomputedSLIRecordGenerator(slo SLO, window time.Duration, _ alert.MWMBAlertGroup) (*rulefmt.Rule, error) {
+	const sliExprTplFmt = `(sum(avg_over_time({{.errorMetric}}[{{.window}}])))
+/
+(sum(avg_over_time({{.totalMetric}}[{{.window}}])))
+`
+
+	// Render with our templated data.
+	tpl, err := template.New("sliExpr").Option("missingkey=error").Parse(sliExprTplFmt)
+	if err != nil {
+		return nil, fmt.Errorf("could not create SLI expression template data: %w", err)
+	}
+
+	strWindow := timeDurationToPromStr(window)
+	var b bytes.Buffer
+	err = tpl.Execute(&b, map[string]string{
+		tplKeyWindow:  strWindow,
+		"errorMetric": slo.SLI.Precomputed.ErrorMetric,
+		"totalMetric": slo.SLI.Precomputed.TotalMetric,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not render SLI expression template: %w", err)
+	}
+
+	return &rulefmt.Rule{
+		Record: slo.GetSLIErrorMetric(window),
+		Expr:   b.String(),
+		Labels: mergeLabels(
+			slo.GetSLOIDPromLabels(),
+			map[string]string{
+				sloWindowLabelName: strWindow,
+			},
+			slo.Labels,
+		),
+	}, nil
+}
+
 // optimizedSLIRecordGenerator gets a SLI recording rule from other SLI recording rules. This optimization
 // will make Prometheus consume less CPU and memory, however the result will be less accurate. Used wisely
 // is a good tradeoff. For example on calculating informative metrics like total period window (30d).
@@ -256,26 +423,118 @@ count_over_time(sum({{.metric}}{{.filter}})[{{.window}}:])
 	}, nil
 }
 
-type metadataRecordingRulesGenerator bool
+// successSLIRecordGenerator gets the SLI success ratio recording rule from the SLI error ratio recording
+// rule of the same window, so dashboards and reports don't need to invert the error ratio.
+func successSLIRecordGenerator(slo SLO, window time.Duration) rulefmt.Rule {
+	return rulefmt.Rule{
+		Record: slo.GetSLISuccessMetric(window),
+		Expr:   fmt.Sprintf("1 - %s%s\n", slo.GetSLIErrorMetric(window), labelsToPromFilter(slo.GetSLOIDPromLabels())),
+		Labels: mergeLabels(
+			slo.GetSLOIDPromLabels(),
+			map[string]string{
+				sloWindowLabelName: timeDurationToPromStr(window),
+			},
+			slo.Labels,
+		),
+	}
+}
+
+// smoothedSLIRecordGenerator gets a smoothed SLI recording rule from the SLI recording rule of the same
+// window, averaging it over the smoothing window. Normally used on dashboards to remove the noise of
+// the instantaneous SLI.
+func smoothedSLIRecordGenerator(slo SLO, window, smoothingWindow time.Duration) (*rulefmt.Rule, error) {
+	const sliExprTplFmt = `avg_over_time({{.metric}}{{.filter}}[{{.smoothingWindow}}])
+`
+
+	// Render with our templated data.
+	tpl, err := template.New("sliExpr").Option("missingkey=error").Parse(sliExprTplFmt)
+	if err != nil {
+		return nil, fmt.Errorf("could not create SLI expression template data: %w", err)
+	}
+
+	strWindow := timeDurationToPromStr(window)
+	strSmoothingWindow := timeDurationToPromStr(smoothingWindow)
+	var b bytes.Buffer
+	err = tpl.Execute(&b, map[string]string{
+		"metric":          slo.GetSLIErrorMetric(window),
+		"filter":          labelsToPromFilter(slo.GetSLOIDPromLabels()),
+		"smoothingWindow": strSmoothingWindow,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not render SLI expression template: %w", err)
+	}
+
+	return &rulefmt.Rule{
+		Record: slo.GetSLIErrorSmoothedMetric(window, smoothingWindow),
+		Expr:   b.String(),
+		Labels: mergeLabels(
+			slo.GetSLOIDPromLabels(),
+			map[string]string{
+				sloWindowLabelName: strWindow,
+			},
+			slo.Labels,
+		),
+	}, nil
+}
+
+// Metatada Recordings.
+const (
+	metricSLOObjectiveRatio                  = "slo:objective:ratio"
+	metricSLOErrorBudgetRatio                = "slo:error_budget:ratio"
+	metricSLOTimePeriodDays                  = "slo:time_period:days"
+	metricSLOCurrentBurnRateRatio            = "slo:current_burn_rate:ratio"
+	metricSLOPeriodBurnRateRatio             = "slo:period_burn_rate:ratio"
+	metricSLOPeriodErrorBudgetRemainingRatio = "slo:period_error_budget_remaining:ratio"
+	metricSLOSLIDataAgeSeconds               = "slo:sli_data_age:seconds"
+	metricSLOInfo                            = "sloth_slo_info"
+	metricSLOPolicyInfo                      = "sloth_slo_policy_info"
+	metricSLOObjectiveTarget                 = "sloth_slo_objective_target"
+)
+
+type metadataRecordingRulesGenerator struct {
+	freshnessWindow    time.Duration
+	policyRule         bool
+	objectiveDriftRule bool
+	descriptionLabel   bool
+}
+
+// WithFreshnessWindow returns a copy of the generator that will additionally generate a freshness
+// recording rule with the seconds since the SLI last reported data, when the SLI has not reported
+// during the window, the age is the window. A zero freshness window disables the freshness rule.
+func (m metadataRecordingRulesGenerator) WithFreshnessWindow(window time.Duration) metadataRecordingRulesGenerator {
+	m.freshnessWindow = window
+	return m
+}
+
+// WithPolicyRule returns a copy of the generator that will additionally generate an error budget
+// policy info recording rule, with the SLO objective, period window and error budget percent labels.
+func (m metadataRecordingRulesGenerator) WithPolicyRule(enabled bool) metadataRecordingRulesGenerator {
+	m.policyRule = enabled
+	return m
+}
+
+// WithObjectiveDriftRule returns a copy of the generator that will additionally generate an objective
+// target recording rule, with the SLO objective percent as the value and only the SLO ID labels, so
+// the objective changes are shown as steps of the same series.
+func (m metadataRecordingRulesGenerator) WithObjectiveDriftRule(enabled bool) metadataRecordingRulesGenerator {
+	m.objectiveDriftRule = enabled
+	return m
+}
+
+// WithDescriptionLabel returns a copy of the generator that will add the SLO description as the
+// `sloth_description` label of the info recording rule, so it can be shown on dashboards.
+func (m metadataRecordingRulesGenerator) WithDescriptionLabel(enabled bool) metadataRecordingRulesGenerator {
+	m.descriptionLabel = enabled
+	return m
+}
 
 // MetadataRecordingRulesGenerator knows how to generate the metadata prometheus recording rules
 // from an SLO.
-const MetadataRecordingRulesGenerator = metadataRecordingRulesGenerator(false)
+var MetadataRecordingRulesGenerator = metadataRecordingRulesGenerator{}
 
 func (m metadataRecordingRulesGenerator) GenerateMetadataRecordingRules(_ context.Context, info info.Info, slo SLO, alerts alert.MWMBAlertGroup) ([]rulefmt.Rule, error) {
 	labels := mergeLabels(slo.GetSLOIDPromLabels(), slo.Labels)
 
-	// Metatada Recordings.
-	const (
-		metricSLOObjectiveRatio                  = "slo:objective:ratio"
-		metricSLOErrorBudgetRatio                = "slo:error_budget:ratio"
-		metricSLOTimePeriodDays                  = "slo:time_period:days"
-		metricSLOCurrentBurnRateRatio            = "slo:current_burn_rate:ratio"
-		metricSLOPeriodBurnRateRatio             = "slo:period_burn_rate:ratio"
-		metricSLOPeriodErrorBudgetRemainingRatio = "slo:period_error_budget_remaining:ratio"
-		metricSLOInfo                            = "sloth_slo_info"
-	)
-
 	sloObjectiveRatio := slo.Objective / 100
 
 	sloFilter := labelsToPromFilter(slo.GetSLOIDPromLabels())
@@ -306,6 +565,23 @@ func (m metadataRecordingRulesGenerator) GenerateMetadataRecordingRules(_ contex
 		return nil, fmt.Errorf("could not render period burn rate prometheus metadata recording rule expression: %w", err)
 	}
 
+	infoLabels := map[string]string{
+		sloVersionLabelName:   info.Version,
+		sloModeLabelName:      string(info.Mode),
+		sloSpecLabelName:      info.Spec,
+		sloObjectiveLabelName: strconv.FormatFloat(slo.Objective, 'f', -1, 64),
+	}
+
+	// Add the SLO description to the info so it can be shown on dashboards.
+	if m.descriptionLabel && slo.Description != "" {
+		infoLabels[sloDescriptionLabelName] = slo.Description
+	}
+
+	// Add the spec source so the SLOs can be tracked back to their spec file.
+	if info.Source != "" {
+		infoLabels[sloSourceLabelName] = info.Source
+	}
+
 	rules := []rulefmt.Rule{
 		// SLO Objective.
 		{
@@ -353,15 +629,48 @@ func (m metadataRecordingRulesGenerator) GenerateMetadataRecordingRules(_ contex
 		{
 			Record: metricSLOInfo,
 			Expr:   `vector(1)`,
-			Labels: mergeLabels(labels, map[string]string{
-				sloVersionLabelName:   info.Version,
-				sloModeLabelName:      string(info.Mode),
-				sloSpecLabelName:      info.Spec,
-				sloObjectiveLabelName: strconv.FormatFloat(slo.Objective, 'f', -1, 64),
-			}),
+			Labels: mergeLabels(labels, infoLabels),
 		},
 	}
 
+	// SLI freshness, using the shortest SLI window, the most sensitive to missing data.
+	if m.freshnessWindow > 0 {
+		rules = append(rules, rulefmt.Rule{
+			Record: metricSLOSLIDataAgeSeconds,
+			Expr: fmt.Sprintf(`time() - max(max_over_time(timestamp(%s%s)[%s:])) or on() vector(%s)`,
+				slo.GetSLIErrorMetric(alerts.PageQuick.ShortWindow),
+				sloFilter,
+				timeDurationToPromStr(m.freshnessWindow),
+				strconv.FormatFloat(m.freshnessWindow.Seconds(), 'f', -1, 64),
+			),
+			Labels: labels,
+		})
+	}
+
+	// Error budget policy.
+	if m.policyRule {
+		policyLabels, err := sloObjectiveLabels(slo)
+		if err != nil {
+			return nil, err
+		}
+		policyLabels[sloWindowLabelName] = timeDurationToPromStr(slo.TimeWindow)
+
+		rules = append(rules, rulefmt.Rule{
+			Record: metricSLOPolicyInfo,
+			Expr:   `vector(1)`,
+			Labels: mergeLabels(labels, policyLabels),
+		})
+	}
+
+	// Objective drift, the labels don't change with the objective or the SLO labels.
+	if m.objectiveDriftRule {
+		rules = append(rules, rulefmt.Rule{
+			Record: metricSLOObjectiveTarget,
+			Expr:   fmt.Sprintf(`vector(%s)`, strconv.FormatFloat(slo.Objective, 'f', -1, 64)),
+			Labels: slo.GetSLOIDPromLabels(),
+		})
+	}
+
 	if slo.SLI.DenominatorCorrected != nil {
 		windows := getAlertGroupWindows(alerts)
 		windows = append(windows, slo.TimeWindow) // Add the total time window as a handy helper.
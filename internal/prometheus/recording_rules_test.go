@@ -2,11 +2,13 @@ package prometheus_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/slok/sloth/internal/alert"
 	"github.com/slok/sloth/internal/info"
@@ -487,6 +489,670 @@ func TestGenerateSLIRecordingRules(t *testing.T) {
 				},
 			},
 		},
+
+		"Having an SLO with the objective ID label, should add the objective label on the rules and selectors.": {
+			generator: func() generator { return prometheus.OptimizedSLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				Objective:  99.9,
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorRatioQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+				ObjectiveIDLabel: true,
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(rate(my_metric[1h]))",
+					Labels: map[string]string{
+						"sloth_service":   "test-svc",
+						"sloth_slo":       "test-name",
+						"sloth_id":        "test",
+						"sloth_objective": "99.9",
+						"sloth_window":    "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "sum_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_objective=\"99.9\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n/\ncount_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_objective=\"99.9\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n",
+					Labels: map[string]string{
+						"sloth_service":   "test-svc",
+						"sloth_slo":       "test-name",
+						"sloth_id":        "test",
+						"sloth_objective": "99.9",
+						"sloth_window":    "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a maintenance gate, should append the unless clause on the SLI rules.": {
+			generator: func() generator { return prometheus.OptimizedSLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorRatioQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+				SLIMaintenanceGateQuery: `ALERTS{alertname="Maintenance"}`,
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(\n(rate(my_metric[1h]))\n)\nunless on()\n(ALERTS{alertname=\"Maintenance\"})\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "sum_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n/\ncount_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with raw error and total queries, should group both queries on the division.": {
+			generator: func() generator { return prometheus.OptimizedSLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorQuery: `sum(rate(total[{{.window}}])) - sum(rate(good[{{.window}}]))`,
+						TotalQuery: `sum(rate(total[{{.window}}])) + sum(rate(other[{{.window}}]))`,
+					},
+				},
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(sum(rate(total[1h])) - sum(rate(good[1h])))\n/\n(sum(rate(total[1h])) + sum(rate(other[1h])))\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "sum_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n/\ncount_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a templated maintenance gate, should append the unless clause with the window on the SLI rules (Non optimized).": {
+			generator: func() generator { return prometheus.SLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Events: &prometheus.SLIEvents{
+						ErrorQuery: `rate(my_metric[{{.window}}]{error="true"})`,
+						TotalQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+				SLIMaintenanceGateQuery: `max_over_time(maintenance_active[{{.window}}]) > 0`,
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(\n(rate(my_metric[1h]{error=\"true\"}))\n/\n(rate(my_metric[1h]))\n)\nunless on()\n(max_over_time(maintenance_active[1h]) > 0)\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "(\n(rate(my_metric[30d]{error=\"true\"}))\n/\n(rate(my_metric[30d]))\n)\nunless on()\n(max_over_time(maintenance_active[30d]) > 0)\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with an inverted SLI, should use the complement of the SLI ratio as the error ratio (Non optimized).": {
+			generator: func() generator { return prometheus.SLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Events: &prometheus.SLIEvents{
+						ErrorQuery: `rate(my_metric[{{.window}}]{error="false"})`,
+						TotalQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+				SLIInvert: true,
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "1 - (\n(rate(my_metric[1h]{error=\"false\"}))\n/\n(rate(my_metric[1h]))\n)\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "1 - (\n(rate(my_metric[30d]{error=\"false\"}))\n/\n(rate(my_metric[30d]))\n)\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with an inverted raw SLI and a maintenance gate, should invert the SLI ratio before the unless clause.": {
+			generator: func() generator { return prometheus.SLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorRatioQuery: `avg_over_time(healthy[{{.window}}])`,
+					},
+				},
+				SLIInvert:               true,
+				SLIMaintenanceGateQuery: `maintenance_active`,
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(\n1 - (\n(avg_over_time(healthy[1h]))\n)\n)\nunless on()\n(maintenance_active)\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "(\n1 - (\n(avg_over_time(healthy[30d]))\n)\n)\nunless on()\n(maintenance_active)\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having objective labels enabled, should set the objective and error budget labels on the SLI rules.": {
+			generator: func() generator {
+				return prometheus.OptimizedSLIRecordingRulesGenerator.WithObjectiveLabels(true)
+			},
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				Objective:  99.9,
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorRatioQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(rate(my_metric[1h]))",
+					Labels: map[string]string{
+						"sloth_service":      "test-svc",
+						"sloth_slo":          "test-name",
+						"sloth_id":           "test",
+						"sloth_window":       "1h",
+						"sloth_objective":    "99.9",
+						"sloth_error_budget": "0.1",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "sum_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n/\ncount_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n",
+					Labels: map[string]string{
+						"sloth_service":      "test-svc",
+						"sloth_slo":          "test-name",
+						"sloth_id":           "test",
+						"sloth_window":       "30d",
+						"sloth_objective":    "99.9",
+						"sloth_error_budget": "0.1",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a smoothing window, should create the SLI rules and the smoothed companion rules.": {
+			generator: func() generator {
+				return prometheus.OptimizedSLIRecordingRulesGenerator.WithSmoothingWindow(10 * time.Minute)
+			},
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorRatioQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+				Labels: map[string]string{
+					"kind": "test",
+				},
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(rate(my_metric[1h]))",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "sum_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n/\ncount_over_time(sum(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate1h:smoothed10m",
+					Expr:   "avg_over_time(slo:sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"}[10m])\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d:smoothed10m",
+					Expr:   "avg_over_time(slo:sli_error:ratio_rate30d{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"}[10m])\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with a record name template, should name the SLI rules with the template and use them on the selectors.": {
+			generator: func() generator {
+				return prometheus.OptimizedSLIRecordingRulesGenerator.WithSmoothingWindow(10 * time.Minute)
+			},
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Raw: &prometheus.SLIRaw{
+						ErrorRatioQuery: `rate(my_metric[{{.window}}])`,
+					},
+				},
+				Labels: map[string]string{
+					"kind": "test",
+				},
+				RecordNameTemplate: "{{ .Role }}:ratio_rate{{ .Window }}{{ with .SmoothingWindow }}:smoothed{{ . }}{{ end }}",
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "sli_error:ratio_rate1h",
+					Expr:   "(rate(my_metric[1h]))",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "sli_error:ratio_rate30d",
+					Expr:   "sum_over_time(sum(sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n/\ncount_over_time(sum(sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"})[30d:])\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+				{
+					Record: "sli_error_smoothed:ratio_rate1h:smoothed10m",
+					Expr:   "avg_over_time(sli_error:ratio_rate1h{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"}[10m])\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "sli_error_smoothed:ratio_rate30d:smoothed10m",
+					Expr:   "avg_over_time(sli_error:ratio_rate30d{sloth_id=\"test\", sloth_service=\"test-svc\", sloth_slo=\"test-name\"}[10m])\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with SLI(events) in counter mode, should use the window on the queries (Non optimized).": {
+			generator: func() generator { return prometheus.SLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Events: &prometheus.SLIEvents{
+						ErrorQuery: `sum(rate(queue_items_expired_total[{{.window}}]))`,
+						TotalQuery: `sum(rate(queue_items_total[{{.window}}]))`,
+					},
+				},
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(sum(rate(queue_items_expired_total[1h])))\n/\n(sum(rate(queue_items_total[1h])))\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "(sum(rate(queue_items_expired_total[30d])))\n/\n(sum(rate(queue_items_total[30d])))\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with SLI(events) in gauge mode, should average the queries over the window (Non optimized).": {
+			generator: func() generator { return prometheus.SLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Events: &prometheus.SLIEvents{
+						ErrorQuery: `sum(queue_items{state="expired"})`,
+						TotalQuery: `sum(queue_items)`,
+						Mode:       prometheus.SLIEventsModeGauge,
+					},
+				},
+			},
+			alertGroup: alert.MWMBAlertGroup{
+				PageQuick:   alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				PageSlow:    alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketQuick: alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+				TicketSlow:  alert.MWMBAlert{ShortWindow: 1 * time.Hour, LongWindow: 1 * time.Hour},
+			},
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(avg_over_time((sum(queue_items{state=\"expired\"}))[1h:]))\n/\n(avg_over_time((sum(queue_items))[1h:]))\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "(avg_over_time((sum(queue_items{state=\"expired\"}))[30d:]))\n/\n(avg_over_time((sum(queue_items))[30d:]))\n",
+					Labels: map[string]string{
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
+
+		"Having an SLO with SLI(precomputed) and its mwmb alerts should create the recording rules.": {
+			generator: func() generator { return prometheus.SLIRecordingRulesGenerator },
+			slo: prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				TimeWindow: 30 * 24 * time.Hour,
+				SLI: prometheus.SLI{
+					Precomputed: &prometheus.SLIPrecomputed{
+						ErrorMetric: `job:http_requests_5xx:rate5m{job="test"}`,
+						TotalMetric: `job:http_requests:rate5m{job="test"}`,
+					},
+				},
+				Labels: map[string]string{
+					"kind": "test",
+				},
+			},
+			alertGroup: getAlertGroup(),
+			expRules: []rulefmt.Rule{
+				{
+					Record: "slo:sli_error:ratio_rate5m",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[5m])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[5m])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "5m",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30m",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[30m])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[30m])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30m",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate1h",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[1h])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[1h])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate2h",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[2h])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[2h])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "2h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate6h",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[6h])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[6h])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "6h",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate1d",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[1d])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[1d])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "1d",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate3d",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[3d])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[3d])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "3d",
+					},
+				},
+				{
+					Record: "slo:sli_error:ratio_rate30d",
+					Expr:   "(sum(avg_over_time(job:http_requests_5xx:rate5m{job=\"test\"}[30d])))\n/\n(sum(avg_over_time(job:http_requests:rate5m{job=\"test\"}[30d])))\n",
+					Labels: map[string]string{
+						"kind":          "test",
+						"sloth_service": "test-svc",
+						"sloth_slo":     "test-name",
+						"sloth_id":      "test",
+						"sloth_window":  "30d",
+					},
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -504,6 +1170,74 @@ func TestGenerateSLIRecordingRules(t *testing.T) {
 	}
 }
 
+func TestGenerateSLIRecordingRulesSuccess(t *testing.T) {
+	slo := prometheus.SLO{
+		ID:         "test",
+		Name:       "test-name",
+		Service:    "test-svc",
+		TimeWindow: 30 * 24 * time.Hour,
+		SLI: prometheus.SLI{
+			Raw: &prometheus.SLIRaw{
+				ErrorRatioQuery: `rate(my_errors[{{.window}}]) / rate(my_total[{{.window}}])`,
+			},
+		},
+		Labels: map[string]string{"kind": "test"},
+	}
+
+	tests := map[string]struct {
+		successRules bool
+		expSuccess   bool
+	}{
+		"Without success rules it shouldn't generate the success rules.": {
+			successRules: false,
+			expSuccess:   false,
+		},
+
+		"Having success rules it should generate a success rule complementing every SLI error rule.": {
+			successRules: true,
+			expSuccess:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			gen := prometheus.OptimizedSLIRecordingRulesGenerator.WithSuccessRules(test.successRules)
+			gotRules, err := gen.GenerateSLIRecordingRules(context.TODO(), slo, getAlertGroup())
+			require.NoError(err)
+
+			errorRules := map[string]rulefmt.Rule{}
+			successRules := map[string]rulefmt.Rule{}
+			for _, r := range gotRules {
+				switch {
+				case strings.HasPrefix(r.Record, "slo:sli_error:"):
+					errorRules[r.Labels["sloth_window"]] = r
+				case strings.HasPrefix(r.Record, "slo:sli_success:"):
+					successRules[r.Labels["sloth_window"]] = r
+				}
+			}
+			require.NotEmpty(errorRules)
+
+			if !test.expSuccess {
+				assert.Empty(successRules)
+				return
+			}
+
+			require.Len(successRules, len(errorRules))
+			for window, errorRule := range errorRules {
+				expRule := rulefmt.Rule{
+					Record: "slo:sli_success:ratio_rate" + window,
+					Expr:   "1 - " + errorRule.Record + `{sloth_id="test", sloth_service="test-svc", sloth_slo="test-name"}` + "\n",
+					Labels: errorRule.Labels,
+				}
+				assert.Equal(expRule, successRules[window])
+			}
+		})
+	}
+}
+
 func TestGenerateMetaRecordingRules(t *testing.T) {
 	tests := map[string]struct {
 		info       info.Info
@@ -628,3 +1362,294 @@ slo:error_budget:ratio{sloth_id="test", sloth_service="test-svc", sloth_slo="tes
 		})
 	}
 }
+
+func TestGenerateMetaRecordingRulesDescription(t *testing.T) {
+	tests := map[string]struct {
+		description    string
+		expDescription string
+		enabled        bool
+		expLabel       bool
+	}{
+		"Having an SLO without description shouldn't add the description label on the info rule.": {
+			description: "",
+			enabled:     true,
+			expLabel:    false,
+		},
+
+		"Having an SLO with description and the description label disabled shouldn't add the description label on the info rule.": {
+			description: "Availability of the payments API.",
+			expLabel:    false,
+		},
+
+		"Having an SLO with description should add the description label on the info rule.": {
+			description:    "Availability of the payments API.",
+			enabled:        true,
+			expDescription: "Availability of the payments API.",
+			expLabel:       true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			slo := prometheus.SLO{
+				ID:          "test",
+				Name:        "test-name",
+				Service:     "test-svc",
+				Description: test.description,
+				Objective:   99.9,
+				TimeWindow:  30 * 24 * time.Hour,
+			}
+			gotRules, err := prometheus.MetadataRecordingRulesGenerator.WithDescriptionLabel(test.enabled).GenerateMetadataRecordingRules(context.TODO(), info.Info{}, slo, getAlertGroup())
+			require.NoError(err)
+
+			var infoRule *rulefmt.Rule
+			for _, r := range gotRules {
+				r := r
+				if r.Record == "sloth_slo_info" {
+					infoRule = &r
+				}
+				if r.Record != "sloth_slo_info" {
+					assert.NotContains(r.Labels, "sloth_description")
+				}
+			}
+			require.NotNil(infoRule)
+
+			gotDescription, ok := infoRule.Labels["sloth_description"]
+			assert.Equal(test.expLabel, ok)
+			assert.Equal(test.expDescription, gotDescription)
+		})
+	}
+}
+
+func TestGenerateMetaRecordingRulesSource(t *testing.T) {
+	tests := map[string]struct {
+		source    string
+		expSource string
+		expLabel  bool
+	}{
+		"Having an execution without source shouldn't add the source label on the info rule.": {
+			source:   "",
+			expLabel: false,
+		},
+
+		"Having an execution with source should add the source label on the info rule.": {
+			source:    "slos/payments/api.yaml",
+			expSource: "slos/payments/api.yaml",
+			expLabel:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			slo := prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				Objective:  99.9,
+				TimeWindow: 30 * 24 * time.Hour,
+			}
+			gotRules, err := prometheus.MetadataRecordingRulesGenerator.GenerateMetadataRecordingRules(context.TODO(), info.Info{Source: test.source}, slo, getAlertGroup())
+			require.NoError(err)
+
+			var infoRule *rulefmt.Rule
+			for _, r := range gotRules {
+				r := r
+				if r.Record == "sloth_slo_info" {
+					infoRule = &r
+				}
+				if r.Record != "sloth_slo_info" {
+					assert.NotContains(r.Labels, "sloth_source")
+				}
+			}
+			require.NotNil(infoRule)
+
+			gotSource, ok := infoRule.Labels["sloth_source"]
+			assert.Equal(test.expLabel, ok)
+			assert.Equal(test.expSource, gotSource)
+		})
+	}
+}
+
+func TestGenerateMetaRecordingRulesFreshness(t *testing.T) {
+	tests := map[string]struct {
+		freshnessWindow time.Duration
+		expRule         *rulefmt.Rule
+	}{
+		"Without freshness window it shouldn't generate the freshness rule.": {
+			freshnessWindow: 0,
+		},
+
+		"Having a freshness window it should generate the freshness rule using the SLI metric.": {
+			freshnessWindow: 10 * time.Minute,
+			expRule: &rulefmt.Rule{
+				Record: "slo:sli_data_age:seconds",
+				Expr:   `time() - max(max_over_time(timestamp(slo:sli_error:ratio_rate5m{sloth_id="test", sloth_service="test-svc", sloth_slo="test-name"})[10m:])) or on() vector(600)`,
+				Labels: map[string]string{
+					"kind":          "test",
+					"sloth_service": "test-svc",
+					"sloth_slo":     "test-name",
+					"sloth_id":      "test",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			slo := prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				Objective:  99.9,
+				TimeWindow: 30 * 24 * time.Hour,
+				Labels:     map[string]string{"kind": "test"},
+			}
+			gen := prometheus.MetadataRecordingRulesGenerator.WithFreshnessWindow(test.freshnessWindow)
+			gotRules, err := gen.GenerateMetadataRecordingRules(context.TODO(), info.Info{}, slo, getAlertGroup())
+			require.NoError(err)
+
+			var gotRule *rulefmt.Rule
+			for _, r := range gotRules {
+				r := r
+				if r.Record == "slo:sli_data_age:seconds" {
+					gotRule = &r
+				}
+			}
+			assert.Equal(test.expRule, gotRule)
+		})
+	}
+}
+
+func TestGenerateMetaRecordingRulesPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policyRule bool
+		expRule    *rulefmt.Rule
+	}{
+		"Without policy rule it shouldn't generate the policy rule.": {
+			policyRule: false,
+		},
+
+		"Having the policy rule enabled it should generate the policy rule with the SLO policy labels.": {
+			policyRule: true,
+			expRule: &rulefmt.Rule{
+				Record: "sloth_slo_policy_info",
+				Expr:   `vector(1)`,
+				Labels: map[string]string{
+					"kind":               "test",
+					"sloth_service":      "test-svc",
+					"sloth_slo":          "test-name",
+					"sloth_id":           "test",
+					"sloth_objective":    "99.9",
+					"sloth_window":       "30d",
+					"sloth_error_budget": "0.1",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			slo := prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				Objective:  99.9,
+				TimeWindow: 30 * 24 * time.Hour,
+				Labels:     map[string]string{"kind": "test"},
+			}
+			gen := prometheus.MetadataRecordingRulesGenerator.WithPolicyRule(test.policyRule)
+			gotRules, err := gen.GenerateMetadataRecordingRules(context.TODO(), info.Info{}, slo, getAlertGroup())
+			require.NoError(err)
+
+			var gotRule *rulefmt.Rule
+			for _, r := range gotRules {
+				r := r
+				if r.Record == "sloth_slo_policy_info" {
+					gotRule = &r
+				}
+			}
+			assert.Equal(test.expRule, gotRule)
+		})
+	}
+}
+
+func TestGenerateMetaRecordingRulesObjectiveDrift(t *testing.T) {
+	tests := map[string]struct {
+		objectiveDriftRule bool
+		objective          float64
+		expRule            *rulefmt.Rule
+	}{
+		"Without objective drift rule it shouldn't generate the objective target rule.": {
+			objectiveDriftRule: false,
+			objective:          99.9,
+		},
+
+		"Having the objective drift rule enabled it should generate the objective target rule with the objective value.": {
+			objectiveDriftRule: true,
+			objective:          99.9,
+			expRule: &rulefmt.Rule{
+				Record: "sloth_slo_objective_target",
+				Expr:   `vector(99.9)`,
+				Labels: map[string]string{
+					"sloth_service": "test-svc",
+					"sloth_slo":     "test-name",
+					"sloth_id":      "test",
+				},
+			},
+		},
+
+		"Having the objective drift rule enabled with a different objective it should keep the same labels.": {
+			objectiveDriftRule: true,
+			objective:          99.95,
+			expRule: &rulefmt.Rule{
+				Record: "sloth_slo_objective_target",
+				Expr:   `vector(99.95)`,
+				Labels: map[string]string{
+					"sloth_service": "test-svc",
+					"sloth_slo":     "test-name",
+					"sloth_id":      "test",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			slo := prometheus.SLO{
+				ID:         "test",
+				Name:       "test-name",
+				Service:    "test-svc",
+				Objective:  test.objective,
+				TimeWindow: 30 * 24 * time.Hour,
+				Labels:     map[string]string{"kind": "test"},
+			}
+			gen := prometheus.MetadataRecordingRulesGenerator.WithObjectiveDriftRule(test.objectiveDriftRule)
+			gotRules, err := gen.GenerateMetadataRecordingRules(context.TODO(), info.Info{}, slo, getAlertGroup())
+			require.NoError(err)
+
+			var gotRule *rulefmt.Rule
+			for _, r := range gotRules {
+				r := r
+				if r.Record == "sloth_slo_objective_target" {
+					gotRule = &r
+				}
+			}
+			assert.Equal(test.expRule, gotRule)
+		})
+	}
+}
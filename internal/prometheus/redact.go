@@ -0,0 +1,170 @@
+package prometheus
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+
+	"github.com/slok/sloth/internal/alert"
+)
+
+// redactMarkupRegexp matches the fragments of the SLI queries that need to be redacted from
+// the generated rules, e.g: `sum(<<redact:rate(http_requests_total{token="secret"}[5m])>>)`.
+var redactMarkupRegexp = regexp.MustCompile(`<<redact:(.+?)>>`)
+
+// RedactSLO replaces the SLI query fragments marked with the `<<redact:FRAGMENT>>` markup
+// with a reference to a helper recording rule that records the fragment expression.
+//
+// This way the sensitive fragments (auth tokens, internal hostnames...) are excluded from the
+// generated SLO rules and only live on the returned helper rules, that can be stored apart.
+// The helper rules of the fragments using the `{{.window}}` template variable are window based
+// templates, these need to be rendered for the SLI windows (check RenderRedactedRules).
+func RedactSLO(slo SLO) (SLO, []rulefmt.Rule, error) {
+	var rules []rulefmt.Rule
+	recorded := map[string]bool{}
+	var err error
+	redact := func(query string) string {
+		return redactMarkupRegexp.ReplaceAllStringFunc(query, func(m string) string {
+			fragment := strings.TrimSpace(redactMarkupRegexp.FindStringSubmatch(m)[1])
+
+			hash := fmt.Sprintf("%x", sha256.Sum256([]byte(fragment)))
+			record := sloRedactedMetricPrefix + hash[:12]
+			if tplWindowRegex.MatchString(fragment) {
+				record += ":{{." + tplKeyWindow + "}}"
+			} else if strings.Contains(fragment, "{{") {
+				// The fragment is not on the error, it's sensitive.
+				err = fmt.Errorf("%q redacted fragment can only use the window template variable", record)
+				return m
+			}
+			if !recorded[record] {
+				recorded[record] = true
+				rules = append(rules, rulefmt.Rule{
+					Record: record,
+					Expr:   fragment,
+				})
+			}
+
+			return record
+		})
+	}
+
+	// Copy the SLI so we don't mutate the original SLO queries.
+	sli := SLI{}
+	switch {
+	case slo.SLI.Raw != nil:
+		sli.Raw = &SLIRaw{
+			ErrorRatioQuery: redact(slo.SLI.Raw.ErrorRatioQuery),
+			ErrorQuery:      redact(slo.SLI.Raw.ErrorQuery),
+			TotalQuery:      redact(slo.SLI.Raw.TotalQuery),
+		}
+	case slo.SLI.Events != nil:
+		sli.Events = &SLIEvents{
+			ErrorQuery: redact(slo.SLI.Events.ErrorQuery),
+			TotalQuery: redact(slo.SLI.Events.TotalQuery),
+			Mode:       slo.SLI.Events.Mode,
+		}
+	case slo.SLI.DenominatorCorrected != nil:
+		sli.DenominatorCorrected = &SLIDenominatorCorrectedEvents{
+			TotalQuery: redact(slo.SLI.DenominatorCorrected.TotalQuery),
+		}
+		if q := slo.SLI.DenominatorCorrected.ErrorQuery; q != nil {
+			rq := redact(*q)
+			sli.DenominatorCorrected.ErrorQuery = &rq
+		}
+		if q := slo.SLI.DenominatorCorrected.SuccessQuery; q != nil {
+			rq := redact(*q)
+			sli.DenominatorCorrected.SuccessQuery = &rq
+		}
+	case slo.SLI.Precomputed != nil:
+		sli.Precomputed = &SLIPrecomputed{
+			ErrorMetric: redact(slo.SLI.Precomputed.ErrorMetric),
+			TotalMetric: redact(slo.SLI.Precomputed.TotalMetric),
+		}
+	default:
+		return slo, nil, nil
+	}
+	if err != nil {
+		return slo, nil, err
+	}
+
+	slo.SLI = sli
+	return slo, rules, nil
+}
+
+// RenderRedactedRules renders the window based redacted helper rules for the SLI windows used by
+// the SLO rules (e.g the optimized rules don't use the SLO period window), the helper rules that
+// are not window based are returned as they are.
+func RenderRedactedRules(redactedRules []rulefmt.Rule, slo SLO, alerts alert.MWMBAlertGroup, sloRules SLORules) ([]rulefmt.Rule, error) {
+	if len(redactedRules) == 0 {
+		return redactedRules, nil
+	}
+
+	exprs := []string{}
+	for _, rules := range [][]rulefmt.Rule{sloRules.SLIErrorRecRules, sloRules.MetadataRecRules, sloRules.AlertRules, sloRules.SharedRecRules} {
+		for _, r := range rules {
+			exprs = append(exprs, r.Expr)
+		}
+	}
+	used := func(record string) bool {
+		re := regexp.MustCompile(regexp.QuoteMeta(record) + `($|[^a-zA-Z0-9_:])`)
+		for _, expr := range exprs {
+			if re.MatchString(expr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	windows := getAlertGroupWindows(alerts)
+	windows = append(windows, slo.TimeWindow)
+
+	rules := []rulefmt.Rule{}
+	rendered := map[string]bool{}
+	for _, r := range redactedRules {
+		if !tplWindowRegex.MatchString(r.Record) {
+			rules = append(rules, r)
+			continue
+		}
+
+		for _, window := range windows {
+			data := map[string]string{tplKeyWindow: timeDurationToPromStr(window)}
+			record, err := renderRedactedTpl(r.Record, data)
+			if err != nil {
+				return nil, fmt.Errorf("could not render %q redacted rule name: %w", r.Record, err)
+			}
+			if rendered[record] || !used(record) {
+				continue
+			}
+			rendered[record] = true
+
+			// The error is not wrapped, it could have the sensitive fragment.
+			expr, err := renderRedactedTpl(r.Expr, data)
+			if err != nil {
+				return nil, fmt.Errorf("could not render %q redacted rule fragment, it can only use the window template variable", r.Record)
+			}
+			rules = append(rules, rulefmt.Rule{Record: record, Expr: expr})
+		}
+	}
+
+	return rules, nil
+}
+
+func renderRedactedTpl(tplStr string, data map[string]string) (string, error) {
+	tpl, err := template.New("redacted").Option("missingkey=error").Parse(tplStr)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	err = tpl.Execute(&b, data)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,168 @@
+package prometheus_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/sloth/internal/alert"
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+func TestRedactSLO(t *testing.T) {
+	tests := map[string]struct {
+		slo      prometheus.SLO
+		expSLO   prometheus.SLO
+		expRules []rulefmt.Rule
+		expErr   bool
+	}{
+		"An SLO without redact markup should not be changed.": {
+			slo: prometheus.SLO{ID: "test", SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+				ErrorRatioQuery: `sum(rate(my_errors[{{.window}}]))`,
+			}}},
+			expSLO: prometheus.SLO{ID: "test", SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+				ErrorRatioQuery: `sum(rate(my_errors[{{.window}}]))`,
+			}}},
+		},
+
+		"An SLO with redacted fragments should replace them with the helper rule names.": {
+			slo: prometheus.SLO{ID: "test", SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+				ErrorQuery: `<<redact:rate(http_requests_total{token="s3cr3t"}[5m])>> / on() group_left <<redact: count(up{instance="internal.host:9090"}) >>`,
+				TotalQuery: `sum(rate(http_requests_total[{{.window}}])) + <<redact:rate(http_requests_total{token="s3cr3t"}[5m])>>`,
+			}}},
+			expSLO: prometheus.SLO{ID: "test", SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+				ErrorQuery: `slo:redacted:96a797e9ece3 / on() group_left slo:redacted:50c27b9909c4`,
+				TotalQuery: `sum(rate(http_requests_total[{{.window}}])) + slo:redacted:96a797e9ece3`,
+			}}},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:redacted:96a797e9ece3", Expr: `rate(http_requests_total{token="s3cr3t"}[5m])`},
+				{Record: "slo:redacted:50c27b9909c4", Expr: `count(up{instance="internal.host:9090"})`},
+			},
+		},
+
+		"An SLO with redacted fragments on denominator corrected SLI should replace them.": {
+			slo: prometheus.SLO{ID: "test", SLI: prometheus.SLI{DenominatorCorrected: &prometheus.SLIDenominatorCorrectedEvents{
+				ErrorQuery: strPtr(`<<redact:sum(my_errors{token="s3cr3t"})>>`),
+				TotalQuery: `sum(rate(my_total[{{.window}}]))`,
+			}}},
+			expSLO: prometheus.SLO{ID: "test", SLI: prometheus.SLI{DenominatorCorrected: &prometheus.SLIDenominatorCorrectedEvents{
+				ErrorQuery: strPtr(`slo:redacted:de065a553207`),
+				TotalQuery: `sum(rate(my_total[{{.window}}]))`,
+			}}},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:redacted:de065a553207", Expr: `sum(my_errors{token="s3cr3t"})`},
+			},
+		},
+
+		"An SLO with redacted fragments using the window template variable should replace them with window based helper rule names.": {
+			slo: prometheus.SLO{ID: "test", SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+				ErrorRatioQuery: `<<redact:rate(my_errors{token="s3cr3t"}[{{.window}}])>> / rate(my_total[{{.window}}])`,
+			}}},
+			expSLO: prometheus.SLO{ID: "test", SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+				ErrorRatioQuery: `slo:redacted:f56942abb81b:{{.window}} / rate(my_total[{{.window}}])`,
+			}}},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:redacted:f56942abb81b:{{.window}}", Expr: `rate(my_errors{token="s3cr3t"}[{{.window}}])`},
+			},
+		},
+
+		"An SLO with redacted fragments using other template variables should fail.": {
+			slo: prometheus.SLO{ID: "test", SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{
+				ErrorRatioQuery: `<<redact:rate(my_errors{token="{{.token}}"}[5m])>>`,
+			}}},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotSLO, gotRules, err := prometheus.RedactSLO(test.slo)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expSLO, gotSLO)
+				assert.Equal(test.expRules, gotRules)
+			}
+		})
+	}
+}
+
+func TestRenderRedactedRules(t *testing.T) {
+	slo := prometheus.SLO{ID: "test", TimeWindow: 30 * 24 * time.Hour}
+	alerts := alert.MWMBAlertGroup{
+		PageQuick:   alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+		PageSlow:    alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+		TicketQuick: alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+		TicketSlow:  alert.MWMBAlert{ShortWindow: 5 * time.Minute, LongWindow: 1 * time.Hour},
+	}
+
+	tests := map[string]struct {
+		redactedRules []rulefmt.Rule
+		sloRules      prometheus.SLORules
+		expRules      []rulefmt.Rule
+		expErr        bool
+	}{
+		"Not having redacted rules should not render rules.": {},
+
+		"Not window based redacted rules should be returned as they are.": {
+			redactedRules: []rulefmt.Rule{
+				{Record: "slo:redacted:96a797e9ece3", Expr: `rate(http_requests_total{token="s3cr3t"}[5m])`},
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:redacted:96a797e9ece3", Expr: `rate(http_requests_total{token="s3cr3t"}[5m])`},
+			},
+		},
+
+		"Window based redacted rules should render a rule for every SLI window used by the SLO rules.": {
+			redactedRules: []rulefmt.Rule{
+				{Record: "slo:redacted:f56942abb81b:{{.window}}", Expr: `rate(my_errors{token="s3cr3t"}[{{.window}}])`},
+			},
+			sloRules: prometheus.SLORules{
+				SLIErrorRecRules: []rulefmt.Rule{
+					{Record: "slo:sli_error:ratio_rate5m", Expr: `slo:redacted:f56942abb81b:5m / rate(my_total[5m])`},
+					{Record: "slo:sli_error:ratio_rate1h", Expr: `slo:redacted:f56942abb81b:1h / rate(my_total[1h])`},
+					{Record: "slo:sli_error:ratio_rate30d", Expr: `sum_over_time(slo:sli_error:ratio_rate5m[30d]) / ignoring (sloth_window) count_over_time(slo:sli_error:ratio_rate5m[30d])`},
+				},
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:redacted:f56942abb81b:5m", Expr: `rate(my_errors{token="s3cr3t"}[5m])`},
+				{Record: "slo:redacted:f56942abb81b:1h", Expr: `rate(my_errors{token="s3cr3t"}[1h])`},
+			},
+		},
+
+		"Window based redacted rules used by the shared rules should be rendered.": {
+			redactedRules: []rulefmt.Rule{
+				{Record: "slo:redacted:f56942abb81b:{{.window}}", Expr: `rate(my_errors{token="s3cr3t"}[{{.window}}])`},
+			},
+			sloRules: prometheus.SLORules{
+				SharedRecRules: []rulefmt.Rule{
+					{Record: "slo:shared:1234:5m", Expr: `slo:redacted:f56942abb81b:5m`},
+				},
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:redacted:f56942abb81b:5m", Expr: `rate(my_errors{token="s3cr3t"}[5m])`},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotRules, err := prometheus.RenderRedactedRules(test.redactedRules, slo, alerts, test.sloRules)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expRules, gotRules)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string { return &s }
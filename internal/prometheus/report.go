@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/slok/sloth/internal/log"
+)
+
+// NewIOWriterRulesReportJSONRepo returns a new IOWriterRulesReportJSONRepo.
+func NewIOWriterRulesReportJSONRepo(writer io.Writer, logger log.Logger) IOWriterRulesReportJSONRepo {
+	return IOWriterRulesReportJSONRepo{
+		writer: writer,
+		logger: logger.WithValues(log.Kv{"svc": "storage.IOWriter", "format": "report-json"}),
+	}
+}
+
+// IOWriterRulesReportJSONRepo knows how to store a machine-readable JSON report of the generated
+// rule groups, with the rule names and a content hash per group, so changes can be detected.
+type IOWriterRulesReportJSONRepo struct {
+	writer io.Writer
+	logger log.Logger
+}
+
+type rulesReport struct {
+	Groups []rulesReportGroup `json:"groups"`
+}
+
+type rulesReportGroup struct {
+	Name  string   `json:"name"`
+	SLO   string   `json:"slo"`
+	Hash  string   `json:"hash"`
+	Rules []string `json:"rules"`
+}
+
+// StoreSLOs will store the report of the SLO rule groups.
+func (i IOWriterRulesReportJSONRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slos are required")
+	}
+
+	report := rulesReport{Groups: []rulesReportGroup{}}
+	for _, slo := range slos {
+		for _, group := range getSLORuleGroups([]StorageSLO{slo}) {
+			// Hash the group as it's written so any change on the rules changes the hash.
+			data, err := yaml.Marshal(group)
+			if err != nil {
+				return fmt.Errorf("could not format %q rule group: %w", group.Name, err)
+			}
+			hash := sha256.Sum256(data)
+
+			rules := make([]string, 0, len(group.Rules))
+			for _, r := range group.Rules {
+				name := r.Record
+				if r.Alert != "" {
+					name = r.Alert
+				}
+				rules = append(rules, name)
+			}
+
+			report.Groups = append(report.Groups, rulesReportGroup{
+				Name:  group.Name,
+				SLO:   slo.SLO.ID,
+				Hash:  hex.EncodeToString(hash[:]),
+				Rules: rules,
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not format rules report: %w", err)
+	}
+
+	_, err = i.writer.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("could not write rules report: %w", err)
+	}
+
+	logger := i.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": len(report.Groups)}).Infof("Rules report written")
+
+	return nil
+}
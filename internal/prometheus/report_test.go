@@ -0,0 +1,107 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/log"
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+type testRulesReport struct {
+	Groups []struct {
+		Name  string   `json:"name"`
+		SLO   string   `json:"slo"`
+		Hash  string   `json:"hash"`
+		Rules []string `json:"rules"`
+	} `json:"groups"`
+}
+
+func getTestReportSLOs(sliExpr string) []prometheus.StorageSLO {
+	return []prometheus.StorageSLO{
+		{
+			SLO: prometheus.SLO{ID: "test1"},
+			Rules: prometheus.SLORules{
+				SLIErrorRecRules: []rulefmt.Rule{
+					{Record: "slo:sli_error:ratio_rate5m", Expr: sliExpr},
+					{Record: "slo:sli_error:ratio_rate30m", Expr: "test-expr30m"},
+				},
+				AlertRules: []rulefmt.Rule{
+					{Alert: "testAlert", Expr: "test-alert-expr"},
+				},
+			},
+		},
+		{
+			SLO: prometheus.SLO{ID: "test2"},
+			Rules: prometheus.SLORules{
+				MetadataRecRules: []rulefmt.Rule{
+					{Record: "slo:objective:ratio", Expr: "vector(0.99)"},
+				},
+			},
+		},
+	}
+}
+
+func storeTestRulesReport(t *testing.T, slos []prometheus.StorageSLO) testRulesReport {
+	var b bytes.Buffer
+	err := prometheus.NewIOWriterRulesReportJSONRepo(&b, log.Noop).StoreSLOs(context.TODO(), slos)
+	require.NoError(t, err)
+
+	report := testRulesReport{}
+	err = json.Unmarshal(b.Bytes(), &report)
+	require.NoError(t, err)
+
+	return report
+}
+
+func TestIOWriterRulesReportJSONRepoStore(t *testing.T) {
+	t.Run("Having 0 SLOs should fail.", func(t *testing.T) {
+		var b bytes.Buffer
+		err := prometheus.NewIOWriterRulesReportJSONRepo(&b, log.Noop).StoreSLOs(context.TODO(), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Having SLOs should enumerate the generated groups with their rules.", func(t *testing.T) {
+		assert := assert.New(t)
+
+		report := storeTestRulesReport(t, getTestReportSLOs("test-expr5m"))
+
+		type group struct {
+			name  string
+			slo   string
+			rules []string
+		}
+		gotGroups := []group{}
+		for _, g := range report.Groups {
+			assert.Len(g.Hash, 64)
+			gotGroups = append(gotGroups, group{name: g.Name, slo: g.SLO, rules: g.Rules})
+		}
+
+		expGroups := []group{
+			{name: "sloth-slo-sli-recordings-test1", slo: "test1", rules: []string{"slo:sli_error:ratio_rate5m", "slo:sli_error:ratio_rate30m"}},
+			{name: "sloth-slo-alerts-test1", slo: "test1", rules: []string{"testAlert"}},
+			{name: "sloth-slo-meta-recordings-test2", slo: "test2", rules: []string{"slo:objective:ratio"}},
+		}
+		assert.Equal(expGroups, gotGroups)
+	})
+
+	t.Run("Changing a rule should change only the hash of its group.", func(t *testing.T) {
+		assert := assert.New(t)
+
+		report1 := storeTestRulesReport(t, getTestReportSLOs("test-expr5m"))
+		report2 := storeTestRulesReport(t, getTestReportSLOs("test-expr5m"))
+		report3 := storeTestRulesReport(t, getTestReportSLOs("test-changed-expr5m"))
+
+		require.Len(t, report3.Groups, 3)
+		assert.Equal(report1, report2)
+		assert.NotEqual(report1.Groups[0].Hash, report3.Groups[0].Hash)
+		assert.Equal(report1.Groups[1].Hash, report3.Groups[1].Hash)
+		assert.Equal(report1.Groups[2].Hash, report3.Groups[2].Hash)
+	})
+}
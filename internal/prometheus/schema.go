@@ -0,0 +1,104 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	prommodel "github.com/prometheus/common/model"
+
+	prometheusv1 "github.com/slok/sloth/pkg/prometheus/api/v1"
+)
+
+// jsonSchemaTypeOverrides are the schemas of the spec types that have a custom YAML unmarshaling.
+var jsonSchemaTypeOverrides = map[reflect.Type]map[string]any{
+	reflect.TypeOf(prommodel.Duration(0)):     {"type": "string"},
+	reflect.TypeOf(prometheusv1.Objective(0)): {"type": []string{"number", "string"}},
+}
+
+// jsonSchemaOptionalFields are the spec fields without `omitempty` that are not always required.
+var jsonSchemaOptionalFields = map[reflect.Type]map[string]bool{
+	// The alerting name is only required when any of the alerts is enabled.
+	reflect.TypeOf(prometheusv1.Alerting{}): {"name": true},
+}
+
+// SpecJSONSchema returns the JSON schema of the native Sloth SLO spec, derived from the spec types.
+//
+// The fields without `omitempty` are required (except the maps, slices and pointers) and the
+// unknown fields are not allowed.
+func SpecJSONSchema() ([]byte, error) {
+	schema := jsonSchemaForType(reflect.TypeOf(prometheusv1.Spec{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = fmt.Sprintf("Sloth %s SLO spec", prometheusv1.Version)
+
+	// The SLOs list is optional on the types but a spec needs at least one.
+	schema["required"] = append(schema["required"].([]string), "slos")
+	schema["properties"].(map[string]any)["slos"].(map[string]any)["minItems"] = 1
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal JSON schema: %w", err)
+	}
+
+	return data, nil
+}
+
+func jsonSchemaForType(t reflect.Type) map[string]any {
+	if s, ok := jsonSchemaTypeOverrides[t]; ok {
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonSchemaForType(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+
+			properties[name] = jsonSchemaForType(f.Type)
+			optional := strings.Contains(opts, "omitempty") || jsonSchemaOptionalFields[t][name]
+			switch f.Type.Kind() {
+			case reflect.Ptr, reflect.Map, reflect.Slice:
+				optional = true
+			}
+			if !optional {
+				required = append(required, name)
+			}
+		}
+
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
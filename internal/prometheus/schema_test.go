@@ -0,0 +1,135 @@
+package prometheus_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+func TestSpecJSONSchema(t *testing.T) {
+	tests := map[string]struct {
+		specYaml string
+		expErr   bool
+	}{
+		"A correct spec should be valid.": {
+			specYaml: `
+version: "prometheus/v1"
+service: "myservice"
+labels:
+  owner: myteam
+slos:
+  - name: "requests-availability"
+    objective: 99.9
+    timeWindow: 30d
+    sli:
+      events:
+        error_query: sum(rate(http_request_duration_seconds_count{job="myservice",code=~"(5..|429)"}[{{.window}}]))
+        total_query: sum(rate(http_request_duration_seconds_count{job="myservice"}[{{.window}}]))
+    alerting:
+      name: MyServiceHighErrorRate
+      page_alert:
+        labels:
+          tier: "1"
+      ticket_alert:
+        disable: true
+  - name: "requests-latency"
+    objective: 3nines
+    sli:
+      plugin:
+        id: "sloth-common/http/latency"
+    alerting:
+      name: MyServiceHighLatency
+`,
+		},
+
+		"A spec with an unknown field should be invalid.": {
+			specYaml: `
+version: "prometheus/v1"
+service: "myservice"
+slos:
+  - name: "requests-availability"
+    objectiv: 99.9
+    sli:
+      raw:
+        error_ratio_query: sum(rate(errors[{{.window}}])) / sum(rate(total[{{.window}}]))
+    alerting:
+      name: MyServiceHighErrorRate
+`,
+			expErr: true,
+		},
+
+		"A spec without a required field should be invalid.": {
+			specYaml: `
+version: "prometheus/v1"
+service: "myservice"
+slos:
+  - name: "requests-availability"
+    objective: 99.9
+    sli:
+      events:
+        error_query: sum(rate(errors[{{.window}}]))
+    alerting:
+      name: MyServiceHighErrorRate
+`,
+			expErr: true,
+		},
+
+		"A spec without SLOs should be invalid.": {
+			specYaml: `
+version: "prometheus/v1"
+service: "myservice"
+slos: []
+`,
+			expErr: true,
+		},
+
+		"A spec with an invalid field type should be invalid.": {
+			specYaml: `
+version: "prometheus/v1"
+service: "myservice"
+slos:
+  - name: "requests-availability"
+    objective: 99.9
+    sli:
+      raw:
+        error_ratio_query: sum(rate(errors[{{.window}}])) / sum(rate(total[{{.window}}]))
+    alerting:
+      name: MyServiceHighErrorRate
+      page_alert:
+        disable: "nope"
+`,
+			expErr: true,
+		},
+	}
+
+	schemaData, err := prometheus.SpecJSONSchema()
+	require.NoError(t, err)
+	schema := spec.Schema{}
+	require.NoError(t, json.Unmarshal(schemaData, &schema))
+	validator := validate.NewSchemaValidator(&schema, nil, "", strfmt.Default)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var spec any
+			require.NoError(yaml.Unmarshal([]byte(test.specYaml), &spec))
+
+			res := validator.Validate(spec)
+			if test.expErr {
+				assert.False(res.IsValid())
+			} else {
+				assert.True(res.IsValid(), res.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package prometheus
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+)
+
+// sharedMetricRegexp matches the shared SLI queries recording rules metric names, e.g: `slo:shared:96a797e9ece3_rate5m`.
+var sharedMetricRegexp = regexp.MustCompile(`^` + regexp.QuoteMeta(sloSharedMetricPrefix) + `([0-9a-f]{12})_rate(.+)$`)
+
+// SharedRecordingsGroupName is the name of the rule group of the shared SLI queries recording rules,
+// the shared rules don't belong to a single SLO so they are stored on their own group.
+const SharedRecordingsGroupName = "sloth-slo-shared-recordings"
+
+// SharedSLIQueries are the SLI event queries shared by multiple SLOs, indexed by their shared hash.
+type SharedSLIQueries map[string]string
+
+// ShareSLIQueries replaces the event SLI queries used by multiple SLOs of the group with a reference
+// to a shared recording rule, so the SLOs with the same base queries don't scan the same series
+// multiple times.
+//
+// The shared recording rules are window based, use SharedSLIQueries.RecordingRules to get the shared
+// rules of the windows used by the SLO rules. Gauge based events are not rates, so they are not shared.
+func ShareSLIQueries(slos []SLO) ([]SLO, SharedSLIQueries) {
+	// Get the number of SLOs that use every query.
+	uses := map[string]int{}
+	for _, slo := range slos {
+		if slo.SLI.Events == nil || slo.SLI.Events.Mode == SLIEventsModeGauge {
+			continue
+		}
+		uses[strings.TrimSpace(slo.SLI.Events.ErrorQuery)]++
+		if total := strings.TrimSpace(slo.SLI.Events.TotalQuery); total != strings.TrimSpace(slo.SLI.Events.ErrorQuery) {
+			uses[total]++
+		}
+	}
+
+	shared := SharedSLIQueries{}
+	share := func(query string) string {
+		q := strings.TrimSpace(query)
+		if uses[q] < 2 {
+			return query
+		}
+
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(q)))[:12]
+		shared[hash] = q
+
+		// Remove the shared ownership label so the shared series match with the not shared ones.
+		return fmt.Sprintf("sum without (%s) (%s%s_rate{{.%s}})", sloSharedLabelName, sloSharedMetricPrefix, hash, tplKeyWindow)
+	}
+
+	res := make([]SLO, 0, len(slos))
+	for _, slo := range slos {
+		if slo.SLI.Events != nil && slo.SLI.Events.Mode != SLIEventsModeGauge {
+			// Copy the SLI so we don't mutate the original SLO queries.
+			events := *slo.SLI.Events
+			events.ErrorQuery = share(events.ErrorQuery)
+			events.TotalQuery = share(events.TotalQuery)
+			slo.SLI.Events = &events
+		}
+		res = append(res, slo)
+	}
+
+	return res, shared
+}
+
+// RecordingRules returns the shared recording rules of the windows referenced by the rules, the
+// rules are returned in the same order they are referenced.
+//
+// The shared rules don't have SLO labels, they have the shared label to mark them as owned by Sloth.
+func (s SharedSLIQueries) RecordingRules(rules []rulefmt.Rule) ([]rulefmt.Rule, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	sharedRules := []rulefmt.Rule{}
+	recorded := map[string]bool{}
+	for _, rule := range rules {
+		for _, metric := range exprMetricNames(rule.Expr) {
+			match := sharedMetricRegexp.FindStringSubmatch(metric)
+			if match == nil || recorded[metric] {
+				continue
+			}
+			query, ok := s[match[1]]
+			if !ok {
+				continue
+			}
+
+			tpl, err := template.New("sharedExpr").Option("missingkey=error").Parse(query)
+			if err != nil {
+				return nil, fmt.Errorf("could not create shared SLI query expression template data: %w", err)
+			}
+			var b bytes.Buffer
+			err = tpl.Execute(&b, map[string]string{tplKeyWindow: match[2]})
+			if err != nil {
+				return nil, fmt.Errorf("could not render shared SLI query expression template: %w", err)
+			}
+
+			recorded[metric] = true
+			sharedRules = append(sharedRules, rulefmt.Rule{
+				Record: metric,
+				Expr:   b.String(),
+				Labels: map[string]string{sloSharedLabelName: "true"},
+			})
+		}
+	}
+
+	return sharedRules, nil
+}
+
+// MergeSharedRecRules merges the shared recording rules of multiple SLOs, the shared rules used
+// by multiple SLOs are only returned once (the first one).
+func MergeSharedRecRules(rules ...[]rulefmt.Rule) []rulefmt.Rule {
+	merged := []rulefmt.Rule{}
+	recorded := map[string]bool{}
+	for _, rs := range rules {
+		for _, r := range rs {
+			if recorded[r.Record] {
+				continue
+			}
+			recorded[r.Record] = true
+			merged = append(merged, r)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,128 @@
+package prometheus_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/prometheus"
+)
+
+func TestShareSLIQueries(t *testing.T) {
+	eventsSLO := func(id, errorQuery, totalQuery string) prometheus.SLO {
+		return prometheus.SLO{ID: id, SLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+			ErrorQuery: errorQuery,
+			TotalQuery: totalQuery,
+		}}}
+	}
+	gaugeSLO := func(id, errorQuery, totalQuery string) prometheus.SLO {
+		slo := eventsSLO(id, errorQuery, totalQuery)
+		slo.SLI.Events.Mode = prometheus.SLIEventsModeGauge
+		return slo
+	}
+
+	tests := map[string]struct {
+		slos     []prometheus.SLO
+		sliRules []rulefmt.Rule
+		expSLOs  []prometheus.SLO
+		expRules []rulefmt.Rule
+	}{
+		"SLOs without shared queries should not be changed.": {
+			slos: []prometheus.SLO{
+				eventsSLO("a", `sum(rate(errors_a[{{.window}}]))`, `sum(rate(total_a[{{.window}}]))`),
+				eventsSLO("b", `sum(rate(errors_b[{{.window}}]))`, `sum(rate(total_b[{{.window}}]))`),
+				{ID: "c", SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{ErrorRatioQuery: `sum(rate(total_a[{{.window}}]))`}}},
+			},
+			expSLOs: []prometheus.SLO{
+				eventsSLO("a", `sum(rate(errors_a[{{.window}}]))`, `sum(rate(total_a[{{.window}}]))`),
+				eventsSLO("b", `sum(rate(errors_b[{{.window}}]))`, `sum(rate(total_b[{{.window}}]))`),
+				{ID: "c", SLI: prometheus.SLI{Raw: &prometheus.SLIRaw{ErrorRatioQuery: `sum(rate(total_a[{{.window}}]))`}}},
+			},
+			expRules: nil,
+		},
+
+		"SLOs with identical base queries should reference a single shared rule per window.": {
+			slos: []prometheus.SLO{
+				eventsSLO("a", `sum(rate(errors_a[{{.window}}]))`, `sum(rate(total[{{.window}}]))`),
+				eventsSLO("b", `sum(rate(errors_b[{{.window}}]))`, ` sum(rate(total[{{.window}}])) `),
+				eventsSLO("c", `sum(rate(errors_c[{{.window}}]))`, `sum(rate(total_c[{{.window}}]))`),
+			},
+			sliRules: []rulefmt.Rule{
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `(sum(rate(errors_a[5m]))) / (sum without (sloth_shared) (slo:shared:5ac9e2a78486_rate5m))`},
+				{Record: "slo:sli_error:ratio_rate1h", Expr: `(sum(rate(errors_a[1h]))) / (sum without (sloth_shared) (slo:shared:5ac9e2a78486_rate1h))`},
+				{Record: "slo:sli_error:ratio_rate5m", Expr: `(sum(rate(errors_b[5m]))) / (sum without (sloth_shared) (slo:shared:5ac9e2a78486_rate5m))`},
+				{Record: "slo:sli_error:ratio_rate1h", Expr: `(sum(rate(errors_b[1h]))) / (sum without (sloth_shared) (slo:shared:5ac9e2a78486_rate1h))`},
+				{Record: "slo:sli_error:ratio_rate30d", Expr: `avg_over_time(slo:sli_error:ratio_rate5m[30d])`},
+			},
+			expSLOs: []prometheus.SLO{
+				eventsSLO("a", `sum(rate(errors_a[{{.window}}]))`, `sum without (sloth_shared) (slo:shared:5ac9e2a78486_rate{{.window}})`),
+				eventsSLO("b", `sum(rate(errors_b[{{.window}}]))`, `sum without (sloth_shared) (slo:shared:5ac9e2a78486_rate{{.window}})`),
+				eventsSLO("c", `sum(rate(errors_c[{{.window}}]))`, `sum(rate(total_c[{{.window}}]))`),
+			},
+			expRules: []rulefmt.Rule{
+				{Record: "slo:shared:5ac9e2a78486_rate5m", Expr: `sum(rate(total[5m]))`, Labels: map[string]string{"sloth_shared": "true"}},
+				{Record: "slo:shared:5ac9e2a78486_rate1h", Expr: `sum(rate(total[1h]))`, Labels: map[string]string{"sloth_shared": "true"}},
+			},
+		},
+
+		"SLOs with gauge based events should not share their queries.": {
+			slos: []prometheus.SLO{
+				gaugeSLO("a", `sum(errors_a)`, `sum(total)`),
+				gaugeSLO("b", `sum(errors_b)`, `sum(total)`),
+			},
+			expSLOs: []prometheus.SLO{
+				gaugeSLO("a", `sum(errors_a)`, `sum(total)`),
+				gaugeSLO("b", `sum(errors_b)`, `sum(total)`),
+			},
+			expRules: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			gotSLOs, shared := prometheus.ShareSLIQueries(test.slos)
+			assert.Equal(test.expSLOs, gotSLOs)
+
+			gotRules, err := shared.RecordingRules(test.sliRules)
+			require.NoError(err)
+			assert.Equal(test.expRules, gotRules)
+		})
+	}
+}
+
+func TestMergeSharedRecRules(t *testing.T) {
+	ruleA5m := rulefmt.Rule{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: `sum(rate(a[5m]))`}
+	ruleA1h := rulefmt.Rule{Record: "slo:shared:aaaaaaaaaaaa_rate1h", Expr: `sum(rate(a[1h]))`}
+	ruleB5m := rulefmt.Rule{Record: "slo:shared:bbbbbbbbbbbb_rate5m", Expr: `sum(rate(b[5m]))`}
+
+	tests := map[string]struct {
+		rules    [][]rulefmt.Rule
+		expRules []rulefmt.Rule
+	}{
+		"Not having shared rules should return empty.": {
+			rules:    [][]rulefmt.Rule{nil, nil},
+			expRules: []rulefmt.Rule{},
+		},
+
+		"Shared rules used by multiple SLOs should be returned once in order.": {
+			rules: [][]rulefmt.Rule{
+				{ruleA5m, ruleA1h},
+				{ruleB5m, ruleA5m},
+				nil,
+			},
+			expRules: []rulefmt.Rule{ruleA5m, ruleA1h, ruleB5m},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotRules := prometheus.MergeSharedRecRules(test.rules...)
+			assert.Equal(t, test.expRules, gotRules)
+		})
+	}
+}
@@ -2,15 +2,23 @@ package prometheus
 
 import (
 	"context"
+	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	prommodel "github.com/prometheus/common/model"
 	"github.com/traefik/yaegi/interp"
 	"github.com/traefik/yaegi/stdlib"
+	"gopkg.in/yaml.v2"
 
 	"github.com/slok/sloth/internal/log"
 	"github.com/slok/sloth/pkg/prometheus/plugin/v1"
@@ -56,6 +64,12 @@ func (f fileManager) ReadFile(_ context.Context, path string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
+var (
+	//go:embed plugins
+	// Raw embedded first-party SLI plugins.
+	embeddedSLIPlugins embed.FS
+)
+
 type SLIPlugin struct {
 	ID   string
 	Func plugin.SLIPlugin
@@ -64,7 +78,9 @@ type SLIPlugin struct {
 type FileSLIPluginRepoConfig struct {
 	FileManager FileManager
 	Paths       []string
-	Logger      log.Logger
+	// Timeout is the max execution time of a plugin, if 0 plugins can run without time limit.
+	Timeout time.Duration
+	Logger  log.Logger
 }
 
 func (c *FileSLIPluginRepoConfig) defaults() error {
@@ -90,6 +106,7 @@ func NewFileSLIPluginRepo(config FileSLIPluginRepoConfig) (*FileSLIPluginRepo, e
 		fileManager:  config.FileManager,
 		pluginLoader: sliPluginLoader{},
 		paths:        config.Paths,
+		timeout:      config.Timeout,
 		logger:       config.Logger,
 	}
 
@@ -110,38 +127,60 @@ func NewFileSLIPluginRepo(config FileSLIPluginRepoConfig) (*FileSLIPluginRepo, e
 // - The plugin can't import anything apart from the Go standard library.
 // - `reflect` and `unsafe` packages can't be used.
 //
+// Optionally, a `plugin.yaml` manifest can be on the same directory declaring the plugin
+// options schema, the options will be type checked and coerced before calling the plugin.
+//
 // These rules provide multiple things:
 // - Easy discovery of plugins without the need to provide extra data (import paths, path sanitization...).
 // - Safety because we don't allow adding external packages easily.
 // - Force keeping the plugins simple, small and without smart code.
 // - Force avoiding DRY in small plugins and embrace WET to have independent plugins.
+//
+// Apart from the plugins on the paths, Sloth first-party plugins are always loaded.
 type FileSLIPluginRepo struct {
 	pluginLoader sliPluginLoader
 	fileManager  FileManager
 	paths        []string
+	timeout      time.Duration
 	plugins      map[string]SLIPlugin
 	mu           sync.RWMutex
 	logger       log.Logger
 }
 
-var sliPluginNameRegex = regexp.MustCompile("plugin.go$")
+var (
+	sliPluginNameRegex         = regexp.MustCompile("plugin.go$")
+	sliPluginManifestNameRegex = regexp.MustCompile(`plugin\.yaml$`)
+	sliPluginFilesRegex        = regexp.MustCompile(sliPluginNameRegex.String() + "|" + sliPluginManifestNameRegex.String())
+)
+
+const sliPluginManifestFileName = "plugin.yaml"
 
 // Reload will reload all the plugins again from the paths.
 func (f *FileSLIPluginRepo) Reload(ctx context.Context) error {
 	// Discover plugins.
 	paths := map[string]struct{}{}
+	manifestPaths := map[string]string{}
 	for _, path := range f.paths {
-		discoveredPaths, err := f.fileManager.FindFiles(ctx, path, sliPluginNameRegex)
+		discoveredPaths, err := f.fileManager.FindFiles(ctx, path, sliPluginFilesRegex)
 		if err != nil {
 			return fmt.Errorf("could not discover SLI plugins: %w", err)
 		}
 		for _, dPath := range discoveredPaths {
+			if sliPluginManifestNameRegex.MatchString(dPath) {
+				manifestPaths[filepath.Dir(dPath)] = dPath
+				continue
+			}
 			paths[dPath] = struct{}{}
 		}
 	}
 
 	// Load the plugins.
 	plugins := map[string]SLIPlugin{}
+	err := f.loadEmbeddedPlugins(ctx, plugins)
+	if err != nil {
+		return fmt.Errorf("could not load first-party SLI plugins: %w", err)
+	}
+
 	for path := range paths {
 		pluginData, err := f.fileManager.ReadFile(ctx, path)
 		if err != nil {
@@ -154,6 +193,20 @@ func (f *FileSLIPluginRepo) Reload(ctx context.Context) error {
 			return fmt.Errorf("could not load %q plugin: %w", path, err)
 		}
 
+		// Check the options with the plugin manifest schema if present.
+		if manifestPath, ok := manifestPaths[filepath.Dir(path)]; ok {
+			manifestData, err := f.fileManager.ReadFile(ctx, manifestPath)
+			if err != nil {
+				return fmt.Errorf("could not read %q plugin manifest: %w", manifestPath, err)
+			}
+
+			manifest, err := loadSLIPluginManifest(manifestData)
+			if err != nil {
+				return fmt.Errorf("invalid %q plugin manifest: %w", manifestPath, err)
+			}
+			plugin.Func = withSLIPluginOptionsSchema(plugin.ID, manifest.Options, plugin.Func)
+		}
+
 		// Check collision.
 		_, ok := plugins[plugin.ID]
 		if ok {
@@ -164,6 +217,14 @@ func (f *FileSLIPluginRepo) Reload(ctx context.Context) error {
 		f.logger.WithValues(log.Kv{"plugin-id": plugin.ID, "plugin-path": path}).Debugf("SLI plugin loaded")
 	}
 
+	// Limit the plugins execution time if required.
+	if f.timeout > 0 {
+		for id, p := range plugins {
+			p.Func = withSLIPluginTimeout(p.ID, f.timeout, p.Func)
+			plugins[id] = p
+		}
+	}
+
 	// Set loaded plugins.
 	f.mu.Lock()
 	f.plugins = plugins
@@ -174,6 +235,52 @@ func (f *FileSLIPluginRepo) Reload(ctx context.Context) error {
 	return nil
 }
 
+// loadEmbeddedPlugins loads the first-party plugins that are embedded in the binary.
+func (f *FileSLIPluginRepo) loadEmbeddedPlugins(ctx context.Context, plugins map[string]SLIPlugin) error {
+	return fs.WalkDir(embeddedSLIPlugins, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !sliPluginNameRegex.MatchString(path) {
+			return nil
+		}
+
+		pluginData, err := embeddedSLIPlugins.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read %q plugin data: %w", path, err)
+		}
+
+		plugin, err := f.pluginLoader.LoadRawSLIPlugin(ctx, string(pluginData))
+		if err != nil {
+			return fmt.Errorf("could not load %q plugin: %w", path, err)
+		}
+
+		manifestPath := filepath.Join(filepath.Dir(path), sliPluginManifestFileName)
+		manifestData, err := embeddedSLIPlugins.ReadFile(manifestPath)
+		switch {
+		case err == nil:
+			manifest, err := loadSLIPluginManifest(manifestData)
+			if err != nil {
+				return fmt.Errorf("invalid %q plugin manifest: %w", manifestPath, err)
+			}
+			plugin.Func = withSLIPluginOptionsSchema(plugin.ID, manifest.Options, plugin.Func)
+		case !errors.Is(err, fs.ErrNotExist):
+			return fmt.Errorf("could not read %q plugin manifest: %w", manifestPath, err)
+		}
+
+		_, ok := plugins[plugin.ID]
+		if ok {
+			return fmt.Errorf("2 or more plugins with the same %q ID have been loaded", plugin.ID)
+		}
+
+		plugins[plugin.ID] = *plugin
+		f.logger.WithValues(log.Kv{"plugin-id": plugin.ID, "plugin-path": path}).Debugf("First-party SLI plugin loaded")
+
+		return nil
+	})
+}
+
 func (f *FileSLIPluginRepo) ListSLIPlugins(_ context.Context) (map[string]SLIPlugin, error) {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -193,6 +300,146 @@ func (f *FileSLIPluginRepo) GetSLIPlugin(_ context.Context, id string) (*SLIPlug
 	return &p, nil
 }
 
+// withSLIPluginTimeout wraps an SLI plugin func so the execution is cancelled when the
+// timeout is reached. The plugin receives the cancelled context, however a plugin that
+// ignores the context will keep running in the background until it ends.
+func withSLIPluginTimeout(id string, timeout time.Duration, f pluginv1.SLIPlugin) pluginv1.SLIPlugin {
+	return func(ctx context.Context, meta, labels, options map[string]string) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		type result struct {
+			query string
+			err   error
+		}
+		resC := make(chan result, 1)
+		go func() {
+			query, err := f(ctx, meta, labels, options)
+			resC <- result{query: query, err: err}
+		}()
+
+		select {
+		case res := <-resC:
+			return res.query, res.err
+		case <-ctx.Done():
+			return "", fmt.Errorf("%q plugin execution timed out after %s: %w", id, timeout, ctx.Err())
+		}
+	}
+}
+
+// SLI plugin options schema types.
+const (
+	sliPluginOptionTypeString   = "string"
+	sliPluginOptionTypeInt      = "int"
+	sliPluginOptionTypeFloat    = "float"
+	sliPluginOptionTypeBool     = "bool"
+	sliPluginOptionTypeDuration = "duration"
+)
+
+// sliPluginManifest is the optional `plugin.yaml` manifest of an SLI plugin.
+type sliPluginManifest struct {
+	// Options is the schema of the plugin options by option name.
+	Options map[string]sliPluginOptionSchema `yaml:"options"`
+}
+
+type sliPluginOptionSchema struct {
+	Type     string `yaml:"type"`
+	Required bool   `yaml:"required"`
+}
+
+func loadSLIPluginManifest(data []byte) (*sliPluginManifest, error) {
+	m := &sliPluginManifest{}
+	err := yaml.UnmarshalStrict(data, m)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal YAML manifest: %w", err)
+	}
+
+	for name, o := range m.Options {
+		switch o.Type {
+		case "":
+			o.Type = sliPluginOptionTypeString
+			m.Options[name] = o
+		case sliPluginOptionTypeString, sliPluginOptionTypeInt, sliPluginOptionTypeFloat, sliPluginOptionTypeBool, sliPluginOptionTypeDuration:
+		default:
+			return nil, fmt.Errorf("%q option has an unknown %q type", name, o.Type)
+		}
+	}
+
+	return m, nil
+}
+
+// coerceSLIPluginOption checks the option value is of the schema type and returns it in
+// its canonical form, so plugins can convert them without caring about the user format.
+func coerceSLIPluginOption(optType, value string) (string, error) {
+	v := strings.TrimSpace(value)
+	switch optType {
+	case sliPluginOptionTypeInt:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("an integer is required")
+		}
+		return strconv.FormatInt(i, 10), nil
+	case sliPluginOptionTypeFloat:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "", fmt.Errorf("a number is required")
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case sliPluginOptionTypeBool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", fmt.Errorf("a boolean is required")
+		}
+		return strconv.FormatBool(b), nil
+	case sliPluginOptionTypeDuration:
+		d, err := prommodel.ParseDuration(v)
+		if err != nil {
+			return "", fmt.Errorf("a duration is required (e.g 5m)")
+		}
+		return d.String(), nil
+	}
+
+	return value, nil
+}
+
+// withSLIPluginOptionsSchema wraps an SLI plugin func so the options are checked and coerced
+// with the plugin manifest options schema before calling the plugin.
+func withSLIPluginOptionsSchema(id string, schema map[string]sliPluginOptionSchema, f pluginv1.SLIPlugin) pluginv1.SLIPlugin {
+	// Manifests without options schema don't check the options.
+	if schema == nil {
+		return f
+	}
+
+	return func(ctx context.Context, meta, labels, options map[string]string) (string, error) {
+		coerced := make(map[string]string, len(options))
+		for name, value := range options {
+			o, ok := schema[name]
+			if !ok {
+				return "", fmt.Errorf("%q plugin doesn't have a %q option", id, name)
+			}
+
+			v, err := coerceSLIPluginOption(o.Type, value)
+			if err != nil {
+				return "", fmt.Errorf("%q plugin %q option has an invalid %q value, %s", id, name, value, err)
+			}
+			coerced[name] = v
+		}
+
+		missing := []string{}
+		for name, o := range schema {
+			if _, ok := options[name]; o.Required && !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return "", fmt.Errorf("%q plugin required options are missing: %s", id, strings.Join(missing, ", "))
+		}
+
+		return f(ctx, meta, labels, coerced)
+	}
+}
+
 // sliPluginLoader knows how to load Go SLI plugins using Yaegi.
 type sliPluginLoader struct{}
 
@@ -3,6 +3,7 @@ package prometheus_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,12 +14,43 @@ import (
 )
 
 func TestSLIPluginLoader(t *testing.T) {
+	const optionsPluginSrc = `
+package testplugin
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	SLIPluginID      = "test_plugin"
+	SLIPluginVersion = "prometheus/v1"
+)
+
+func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (string, error) {
+	return fmt.Sprintf("test{threshold=%q,enabled=%q,window=%q}", options["threshold"], options["enabled"], options["window"]), nil
+}
+`
+
+	const optionsPluginManifest = `
+options:
+  threshold:
+    type: int
+    required: true
+  enabled:
+    type: bool
+  window:
+    type: duration
+`
+
 	tests := map[string]struct {
 		pluginSrc   string
+		manifest    string
 		pluginID    string
 		meta        map[string]string
 		labels      map[string]string
 		options     map[string]string
+		timeout     time.Duration
 		expPluginID string
 		expSLIQuery string
 		expErrLoad  bool
@@ -106,6 +138,87 @@ func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (st
 			expPluginID: "test_plugin",
 			expErr:      true,
 		},
+
+		"Plugin that takes longer than the timeout should return an error.": {
+			pluginSrc: `
+package testplugin
+
+import "context"
+
+import "time"
+
+const (
+	SLIPluginID      = "test_plugin"
+	SLIPluginVersion = "prometheus/v1"
+)
+
+func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (string, error) {
+	time.Sleep(500 * time.Millisecond)
+	return "something", nil
+}
+		`,
+			timeout:     10 * time.Millisecond,
+			expPluginID: "test_plugin",
+			expErr:      true,
+		},
+
+		"Plugin that ends before the timeout should return a correct SLI.": {
+			pluginSrc: `
+package testplugin
+
+import "context"
+
+const (
+	SLIPluginID      = "test_plugin"
+	SLIPluginVersion = "prometheus/v1"
+)
+
+func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (string, error) {
+	return "something", nil
+}
+		`,
+			timeout:     5 * time.Second,
+			expPluginID: "test_plugin",
+			expSLIQuery: "something",
+		},
+
+		"Plugin with a manifest should coerce the options with the schema types.": {
+			pluginSrc:   optionsPluginSrc,
+			manifest:    optionsPluginManifest,
+			options:     map[string]string{"threshold": " 100 ", "enabled": "T", "window": "60m"},
+			expPluginID: "test_plugin",
+			expSLIQuery: `test{threshold="100",enabled="true",window="1h"}`,
+		},
+
+		"Plugin with a manifest should fail on a wrong typed option.": {
+			pluginSrc:   optionsPluginSrc,
+			manifest:    optionsPluginManifest,
+			options:     map[string]string{"threshold": "1k"},
+			expPluginID: "test_plugin",
+			expErr:      true,
+		},
+
+		"Plugin with a manifest should fail on a missing required option.": {
+			pluginSrc:   optionsPluginSrc,
+			manifest:    optionsPluginManifest,
+			options:     map[string]string{"enabled": "true"},
+			expPluginID: "test_plugin",
+			expErr:      true,
+		},
+
+		"Plugin with a manifest should fail on an option missing on the schema.": {
+			pluginSrc:   optionsPluginSrc,
+			manifest:    optionsPluginManifest,
+			options:     map[string]string{"threshold": "100", "treshold": "100"},
+			expPluginID: "test_plugin",
+			expErr:      true,
+		},
+
+		"Plugin with a manifest with an unknown option type should fail on load.": {
+			pluginSrc:  optionsPluginSrc,
+			manifest:   "options: {threshold: {type: integer}}",
+			expErrLoad: true,
+		},
 	}
 
 	for name, test := range tests {
@@ -115,13 +228,19 @@ func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (st
 
 			// Mock the plugin files.
 			mfm := &prometheusmock.FileManager{}
-			mfm.On("FindFiles", mock.Anything, "./", mock.Anything).Once().Return([]string{"testplugin/test.go"}, nil)
+			files := []string{"testplugin/test.go"}
+			if test.manifest != "" {
+				files = append(files, "testplugin/plugin.yaml")
+				mfm.On("ReadFile", mock.Anything, "testplugin/plugin.yaml").Once().Return([]byte(test.manifest), nil)
+			}
+			mfm.On("FindFiles", mock.Anything, "./", mock.Anything).Once().Return(files, nil)
 			mfm.On("ReadFile", mock.Anything, "testplugin/test.go").Once().Return([]byte(test.pluginSrc), nil)
 
 			// Create repository and load plugins.
 			config := prometheus.FileSLIPluginRepoConfig{
 				FileManager: mfm,
 				Paths:       []string{"./"},
+				Timeout:     test.timeout,
 			}
 			repo, err := prometheus.NewFileSLIPluginRepo(config)
 			if test.expErrLoad {
@@ -146,3 +265,90 @@ func SLIPlugin(ctx context.Context, meta, labels, options map[string]string) (st
 		})
 	}
 }
+
+func TestFileSLIPluginRepoFirstPartyPlugins(t *testing.T) {
+	tests := map[string]struct {
+		pluginID    string
+		meta        map[string]string
+		labels      map[string]string
+		options     map[string]string
+		expSLIQuery string
+		expErr      bool
+	}{
+		"Availability plugin without selector should fail.": {
+			pluginID: "sloth_availability",
+			options:  map[string]string{},
+			expErr:   true,
+		},
+
+		"Availability plugin should return an up based SLI.": {
+			pluginID: "sloth_availability",
+			options:  map[string]string{"selector": `{job="my-app",env="prod"}`},
+			expSLIQuery: `
+1 - (
+  sum(avg_over_time(up{ job="my-app",env="prod" }[{{.window}}]))
+  /
+  count(avg_over_time(up{ job="my-app",env="prod" }[{{.window}}]))
+)`,
+		},
+
+		"Kafka lag plugin without threshold should fail.": {
+			pluginID: "sloth_kafka_lag",
+			options:  map[string]string{"filter": `consumergroup="my-app"`},
+			expErr:   true,
+		},
+
+		"Kafka lag plugin with invalid threshold should fail.": {
+			pluginID: "sloth_kafka_lag",
+			options:  map[string]string{"threshold": "1k"},
+			expErr:   true,
+		},
+
+		"Kafka lag plugin should return a lag threshold based SLI.": {
+			pluginID: "sloth_kafka_lag",
+			options:  map[string]string{"threshold": "1000", "filter": `{consumergroup="my-app",topic="orders"}`},
+			expSLIQuery: `
+(
+  sum(count_over_time((kafka_consumergroup_lag{ consumergroup="my-app",topic="orders" } > 1000)[{{.window}}:]))
+  or
+  vector(0)
+)
+/
+sum(count_over_time(kafka_consumergroup_lag{ consumergroup="my-app",topic="orders" }[{{.window}}:]))`,
+		},
+
+		"Kafka lag plugin with custom lag metric should return a lag threshold based SLI.": {
+			pluginID: "sloth_kafka_lag",
+			options:  map[string]string{"threshold": "250.5", "lag_metric": "kafka_consumer_lag_sum"},
+			expSLIQuery: `
+(
+  sum(count_over_time((kafka_consumer_lag_sum{  } > 250.5)[{{.window}}:]))
+  or
+  vector(0)
+)
+/
+sum(count_over_time(kafka_consumer_lag_sum{  }[{{.window}}:]))`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// First-party plugins are loaded without paths.
+			repo, err := prometheus.NewFileSLIPluginRepo(prometheus.FileSLIPluginRepoConfig{})
+			require.NoError(err)
+
+			plugin, err := repo.GetSLIPlugin(context.TODO(), test.pluginID)
+			require.NoError(err)
+
+			gotSLIQuery, err := plugin.Func(context.TODO(), test.meta, test.labels, test.options)
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expSLIQuery, gotSLIQuery)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package prometheus
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	prommodel "github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
 	prometheusv1 "github.com/slok/sloth/pkg/prometheus/api/v1"
@@ -16,10 +20,62 @@ type SLIPluginRepo interface {
 	GetSLIPlugin(ctx context.Context, id string) (*SLIPlugin, error)
 }
 
+// SLIPluginRef is the reference of an SLO to an SLI plugin.
+type SLIPluginRef struct {
+	PluginID string
+	SLOID    string
+	// Source is the optional origin of the SLO (e.g the spec file path).
+	Source string
+}
+
+// CheckSLIPluginRefs checks that all the referenced SLI plugins exist on the repository, returning
+// an error with every missing plugin and the SLO that references it.
+func CheckSLIPluginRefs(ctx context.Context, repo SLIPluginRepo, refs []SLIPluginRef) error {
+	missing := []string{}
+	for _, ref := range refs {
+		_, err := repo.GetSLIPlugin(ctx, ref.PluginID)
+		if err == nil {
+			continue
+		}
+
+		msg := fmt.Sprintf("%q used by %q SLO", ref.PluginID, ref.SLOID)
+		if ref.Source != "" {
+			msg += fmt.Sprintf(" (%s)", ref.Source)
+		}
+		missing = append(missing, msg)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing SLI plugins: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// IDGenerator knows how to generate the ID of an SLO.
+type IDGenerator interface {
+	GenerateSLOID(ctx context.Context, service, name string) (string, error)
+}
+
+// IDGeneratorFunc is a helper to use functions as IDGenerators.
+type IDGeneratorFunc func(ctx context.Context, service, name string) (string, error)
+
+// GenerateSLOID satisfies IDGenerator interface.
+func (i IDGeneratorFunc) GenerateSLOID(ctx context.Context, service, name string) (string, error) {
+	return i(ctx, service, name)
+}
+
+// DefaultIDGenerator generates the SLO IDs using `{service}-{name}` format.
+var DefaultIDGenerator = IDGeneratorFunc(func(_ context.Context, service, name string) (string, error) {
+	return fmt.Sprintf("%s-%s", service, name), nil
+})
+
 // YAMLSpecLoader knows how to load YAML specs and converts them to a model.
 type YAMLSpecLoader struct {
-	windowPeriod time.Duration
-	pluginsRepo  SLIPluginRepo
+	windowPeriod      time.Duration
+	pluginsRepo       SLIPluginRepo
+	idGenerator       IDGenerator
+	windowPlaceholder string
 }
 
 // NewYAMLSpecLoader returns a YAML spec loader.
@@ -27,16 +83,75 @@ func NewYAMLSpecLoader(pluginsRepo SLIPluginRepo, windowPeriod time.Duration) YA
 	return YAMLSpecLoader{
 		windowPeriod: windowPeriod,
 		pluginsRepo:  pluginsRepo,
+		idGenerator:  DefaultIDGenerator,
 	}
 }
 
+// WithIDGenerator returns a copy of the loader that will use a custom SLO ID generator.
+func (y YAMLSpecLoader) WithIDGenerator(idGenerator IDGenerator) YAMLSpecLoader {
+	y.idGenerator = idGenerator
+	return y
+}
+
+// sliLogQLBridgeQueryFmt is the Prometheus SLI query of the LogQL recorded metrics, these are the
+// events count of a minute, so we get the window events adding them.
+const sliLogQLBridgeQueryFmt = "sum(sum_over_time(%s%s[{{.%s}}]))"
+
+// sliFreshnessBucketsQueryFmt is the Prometheus query of the freshness SLI histogram buckets
+// events on the window.
+const sliFreshnessBucketsQueryFmt = `sum(rate(%s_bucket{%s}[{{.%s}}]))`
+
+// mapSpecSLIFreshness maps the freshness SLI to an events SLI, the bad events are the total
+// events (`+Inf` bucket) minus the fresh ones (threshold bucket).
+func mapSpecSLIFreshness(spec prometheusv1.SLIFreshness) (*SLIEvents, error) {
+	metric := strings.TrimSuffix(spec.HistogramMetric, "_bucket")
+	if metric == "" {
+		return nil, fmt.Errorf("histogram metric is required")
+	}
+	if spec.Threshold <= 0 {
+		return nil, fmt.Errorf("threshold is required")
+	}
+
+	bucketFilter := func(le string) string {
+		if spec.Filter == "" {
+			return fmt.Sprintf("le=%q", le)
+		}
+		return fmt.Sprintf("%s,le=%q", spec.Filter, le)
+	}
+	threshold := strconv.FormatFloat(time.Duration(spec.Threshold).Seconds(), 'f', -1, 64)
+	totalQuery := fmt.Sprintf(sliFreshnessBucketsQueryFmt, metric, bucketFilter("+Inf"), tplKeyWindow)
+	freshQuery := fmt.Sprintf(sliFreshnessBucketsQueryFmt, metric, bucketFilter(threshold), tplKeyWindow)
+
+	return &SLIEvents{
+		ErrorQuery: fmt.Sprintf("%s\n-\n%s", totalQuery, freshQuery),
+		TotalQuery: totalQuery,
+	}, nil
+}
+
+// WithWindowPlaceholder returns a copy of the loader that will replace the custom window placeholder
+// token (e.g `$__range`) of the SLI queries with the `{{.window}}` template variable.
+func (y YAMLSpecLoader) WithWindowPlaceholder(placeholder string) YAMLSpecLoader {
+	y.windowPlaceholder = placeholder
+	return y
+}
+
 var specTypeV1Regex = regexp.MustCompile(`(?m)^version: +['"]?prometheus\/v1['"]? *$`)
 
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// NormalizeSpecData strips the leading UTF-8 BOM and converts CRLF line endings to LF, this
+// way the specs authored on Windows can be detected and loaded like the rest.
+func NormalizeSpecData(data []byte) []byte {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+}
+
 func (y YAMLSpecLoader) IsSpecType(_ context.Context, data []byte) bool {
-	return specTypeV1Regex.Match(data)
+	return specTypeV1Regex.Match(NormalizeSpecData(data))
 }
 
 func (y YAMLSpecLoader) LoadSpec(ctx context.Context, data []byte) (*SLOGroup, error) {
+	data = NormalizeSpecData(data)
 	if len(data) == 0 {
 		return nil, fmt.Errorf("spec is required")
 	}
@@ -65,43 +180,162 @@ func (y YAMLSpecLoader) LoadSpec(ctx context.Context, data []byte) (*SLOGroup, e
 	return m, nil
 }
 
+// SLIPluginRefs returns the SLI plugins referenced by the spec SLOs without executing them, so
+// the plugins can be checked before loading the specs.
+func (y YAMLSpecLoader) SLIPluginRefs(ctx context.Context, data []byte) ([]SLIPluginRef, error) {
+	s := prometheusv1.Spec{}
+	err := yaml.Unmarshal(NormalizeSpecData(data), &s)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshall YAML spec correctly: %w", err)
+	}
+
+	refs := []SLIPluginRef{}
+	for _, specSLO := range s.SLOs {
+		if specSLO.SLI.Plugin == nil {
+			continue
+		}
+
+		id, err := y.sloID(ctx, s.Service, specSLO)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, SLIPluginRef{PluginID: specSLO.SLI.Plugin.ID, SLOID: id})
+	}
+
+	return refs, nil
+}
+
+// sloID returns the spec SLO explicit ID or the generated one if missing.
+func (y YAMLSpecLoader) sloID(ctx context.Context, service string, specSLO prometheusv1.SLO) (string, error) {
+	if specSLO.ID != "" {
+		return specSLO.ID, nil
+	}
+
+	id, err := y.idGenerator.GenerateSLOID(ctx, service, specSLO.Name)
+	if err != nil {
+		return "", fmt.Errorf("could not generate %q SLO ID: %w", specSLO.Name, err)
+	}
+
+	return id, nil
+}
+
 func (y YAMLSpecLoader) mapSpecToModel(ctx context.Context, spec prometheusv1.Spec) (*SLOGroup, error) {
+	// Custom window placeholders are replaced with the window template variable.
+	tplQuery := func(query string) string {
+		if y.windowPlaceholder == "" {
+			return query
+		}
+		return strings.ReplaceAll(query, y.windowPlaceholder, fmt.Sprintf("{{.%s}}", tplKeyWindow))
+	}
+	tplQueryPtr := func(query *string) *string {
+		if query == nil {
+			return nil
+		}
+		q := tplQuery(*query)
+		return &q
+	}
+
 	models := make([]SLO, 0, len(spec.SLOs))
 	for _, specSLO := range spec.SLOs {
+		id, err := y.sloID(ctx, spec.Service, specSLO)
+		if err != nil {
+			return nil, err
+		}
+
 		slo := SLO{
-			ID:              fmt.Sprintf("%s-%s", spec.Service, specSLO.Name),
+			ID:              id,
 			Name:            specSLO.Name,
 			Description:     specSLO.Description,
 			Service:         spec.Service,
 			TimeWindow:      y.windowPeriod,
-			Objective:       specSLO.Objective,
+			Objective:       float64(specSLO.Objective),
 			Labels:          mergeLabels(spec.Labels, specSLO.Labels),
 			PageAlertMeta:   AlertMeta{Disable: true},
 			TicketAlertMeta: AlertMeta{Disable: true},
 		}
 
+		// Override the default SLO period if required.
+		if specSLO.TimeWindow != 0 {
+			slo.TimeWindow = time.Duration(specSLO.TimeWindow)
+		}
+
+		// Derive the objective from the allowed downtime on the SLO time window.
+		if specSLO.AllowedDowntime != 0 {
+			objective, err := allowedDowntimeToObjective(time.Duration(specSLO.AllowedDowntime), slo.TimeWindow)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %q SLO allowed downtime: %w", specSLO.Name, err)
+			}
+			if specSLO.Objective != 0 {
+				return nil, fmt.Errorf("invalid %q SLO: objective and allowed downtime can't be used at the same time", specSLO.Name)
+			}
+			slo.Objective = objective
+		}
+
 		// Set SLIs.
 		if specSLO.SLI.Events != nil {
+			mode, err := mapSpecSLIEventsMode(specSLO.SLI.Events.Mode)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %q SLO events SLI: %w", specSLO.Name, err)
+			}
 			slo.SLI.Events = &SLIEvents{
-				ErrorQuery: specSLO.SLI.Events.ErrorQuery,
-				TotalQuery: specSLO.SLI.Events.TotalQuery,
+				ErrorQuery: tplQuery(specSLO.SLI.Events.ErrorQuery),
+				TotalQuery: tplQuery(specSLO.SLI.Events.TotalQuery),
+				Mode:       mode,
 			}
 		}
 
 		if specSLO.SLI.Raw != nil {
 			slo.SLI.Raw = &SLIRaw{
-				ErrorRatioQuery: specSLO.SLI.Raw.ErrorRatioQuery,
+				ErrorRatioQuery: tplQuery(specSLO.SLI.Raw.ErrorRatioQuery),
+				ErrorQuery:      tplQuery(specSLO.SLI.Raw.ErrorQuery),
+				TotalQuery:      tplQuery(specSLO.SLI.Raw.TotalQuery),
+			}
+		}
+
+		if specSLO.SLI.Precomputed != nil {
+			slo.SLI.Precomputed = &SLIPrecomputed{
+				ErrorMetric: specSLO.SLI.Precomputed.ErrorMetric,
+				TotalMetric: specSLO.SLI.Precomputed.TotalMetric,
 			}
 		}
 
 		if specSLO.SLI.DenominatorCorrected != nil {
 			slo.SLI.DenominatorCorrected = &SLIDenominatorCorrectedEvents{
-				ErrorQuery:   specSLO.SLI.DenominatorCorrected.ErrorQuery,
-				SuccessQuery: specSLO.SLI.DenominatorCorrected.SuccessQuery,
-				TotalQuery:   specSLO.SLI.DenominatorCorrected.TotalQuery,
+				ErrorQuery:   tplQueryPtr(specSLO.SLI.DenominatorCorrected.ErrorQuery),
+				SuccessQuery: tplQueryPtr(specSLO.SLI.DenominatorCorrected.SuccessQuery),
+				TotalQuery:   tplQuery(specSLO.SLI.DenominatorCorrected.TotalQuery),
 			}
 		}
 
+		if specSLO.SLI.LogQL != nil {
+			if slo.SLI.Events != nil {
+				return nil, fmt.Errorf("invalid %q SLO SLI: logql and events SLIs can't be used at the same time", specSLO.Name)
+			}
+
+			// The SLI uses the metrics recorded from the LogQL queries by Loki.
+			filter := labelsToPromFilter(map[string]string{sloIDLabelName: id})
+			slo.SLI.Events = &SLIEvents{
+				ErrorQuery: fmt.Sprintf(sliLogQLBridgeQueryFmt, sliLogQLErrorEventsMetric, filter, tplKeyWindow),
+				TotalQuery: fmt.Sprintf(sliLogQLBridgeQueryFmt, sliLogQLTotalEventsMetric, filter, tplKeyWindow),
+			}
+			slo.SLILogQLBridge = &SLILogQLBridge{
+				ErrorQuery: tplQuery(specSLO.SLI.LogQL.ErrorQuery),
+				TotalQuery: tplQuery(specSLO.SLI.LogQL.TotalQuery),
+			}
+		}
+
+		if specSLO.SLI.Freshness != nil {
+			if slo.SLI.Events != nil {
+				return nil, fmt.Errorf("invalid %q SLO SLI: freshness and events or logql SLIs can't be used at the same time", specSLO.Name)
+			}
+
+			events, err := mapSpecSLIFreshness(*specSLO.SLI.Freshness)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %q SLO freshness SLI: %w", specSLO.Name, err)
+			}
+			slo.SLI.Events = events
+		}
+
 		if specSLO.SLI.Plugin != nil {
 			plugin, err := y.pluginsRepo.GetSLIPlugin(ctx, specSLO.SLI.Plugin.ID)
 			if err != nil {
@@ -111,7 +345,7 @@ func (y YAMLSpecLoader) mapSpecToModel(ctx context.Context, spec prometheusv1.Sp
 			meta := map[string]string{
 				prometheuspluginv1.SLIPluginMetaService:   spec.Service,
 				prometheuspluginv1.SLIPluginMetaSLO:       specSLO.Name,
-				prometheuspluginv1.SLIPluginMetaObjective: fmt.Sprintf("%f", specSLO.Objective),
+				prometheuspluginv1.SLIPluginMetaObjective: fmt.Sprintf("%f", slo.Objective),
 			}
 
 			rawQuery, err := plugin.Func(ctx, meta, spec.Labels, specSLO.SLI.Plugin.Options)
@@ -119,25 +353,51 @@ func (y YAMLSpecLoader) mapSpecToModel(ctx context.Context, spec prometheusv1.Sp
 				return nil, fmt.Errorf("plugin %q execution error: %w", specSLO.SLI.Plugin.ID, err)
 			}
 
+			// Fail early with the plugin context, invalid queries would fail later on the rules generation.
+			err = ValidatePromExpr(rawQuery)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %q returned an invalid %q SLO SLI query: %w", specSLO.SLI.Plugin.ID, specSLO.Name, err)
+			}
+
 			slo.SLI.Raw = &SLIRaw{
 				ErrorRatioQuery: rawQuery,
 			}
 		}
 
+		slo.SLIDependsOn = specSLO.SLI.DependsOn
+		slo.SLIInvert = specSLO.SLI.Invert
+
+		if specSLO.SLI.MaintenanceGate != nil {
+			slo.SLIMaintenanceGateQuery = tplQuery(specSLO.SLI.MaintenanceGate.Query)
+			if slo.SLIMaintenanceGateQuery == "" {
+				slo.SLIMaintenanceGateQuery = defaultSLIMaintenanceGateQuery
+			}
+		}
+
 		// Set alerts.
 		if !specSLO.Alerting.PageAlert.Disable {
+			businessHours, err := mapSpecAlertBusinessHours(specSLO.Alerting.PageAlert.BusinessHours)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %q SLO page alert business hours: %w", specSLO.Name, err)
+			}
 			slo.PageAlertMeta = AlertMeta{
-				Name:        specSLO.Alerting.Name,
-				Labels:      mergeLabels(specSLO.Alerting.Labels, specSLO.Alerting.PageAlert.Labels),
-				Annotations: mergeLabels(specSLO.Alerting.Annotations, specSLO.Alerting.PageAlert.Annotations),
+				Name:          specSLO.Alerting.Name,
+				Labels:        mergeLabels(specSLO.Alerting.Labels, specSLO.Alerting.PageAlert.Labels),
+				Annotations:   mergeLabels(specSLO.Alerting.Annotations, specSLO.Alerting.PageAlert.Annotations),
+				BusinessHours: businessHours,
 			}
 		}
 
 		if !specSLO.Alerting.TicketAlert.Disable {
+			businessHours, err := mapSpecAlertBusinessHours(specSLO.Alerting.TicketAlert.BusinessHours)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %q SLO ticket alert business hours: %w", specSLO.Name, err)
+			}
 			slo.TicketAlertMeta = AlertMeta{
-				Name:        specSLO.Alerting.Name,
-				Labels:      mergeLabels(specSLO.Alerting.Labels, specSLO.Alerting.TicketAlert.Labels),
-				Annotations: mergeLabels(specSLO.Alerting.Annotations, specSLO.Alerting.TicketAlert.Annotations),
+				Name:          specSLO.Alerting.Name,
+				Labels:        mergeLabels(specSLO.Alerting.Labels, specSLO.Alerting.TicketAlert.Labels),
+				Annotations:   mergeLabels(specSLO.Alerting.Annotations, specSLO.Alerting.TicketAlert.Annotations),
+				BusinessHours: businessHours,
 			}
 		}
 
@@ -146,3 +406,88 @@ func (y YAMLSpecLoader) mapSpecToModel(ctx context.Context, spec prometheusv1.Sp
 
 	return &SLOGroup{SLOs: models}, nil
 }
+
+// allowedDowntimeToObjective returns the objective percentage of an allowed downtime on a
+// time window (e.g 43m on 30d is 99.9004629630).
+func allowedDowntimeToObjective(downtime, window time.Duration) (float64, error) {
+	if downtime <= 0 || downtime >= window {
+		return 0, fmt.Errorf("allowed downtime must be in the (0, %s) range", prommodel.Duration(window))
+	}
+
+	objective := 100 * (1 - float64(downtime)/float64(window))
+
+	// Round to avoid floating point noise on the objective.
+	objective, err := strconv.ParseFloat(strconv.FormatFloat(objective, 'f', 10, 64), 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not format objective: %w", err)
+	}
+
+	return objective, nil
+}
+
+// Default alert business hours, Monday to Friday from 9 to 17 UTC.
+const (
+	defaultAlertBusinessHours = "9-17"
+	defaultAlertBusinessDays  = "1-5"
+)
+
+func mapSpecAlertBusinessHours(spec *prometheusv1.AlertBusinessHours) (*AlertBusinessHours, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	hours, days := spec.Hours, spec.Days
+	if hours == "" {
+		hours = defaultAlertBusinessHours
+	}
+	if days == "" {
+		days = defaultAlertBusinessDays
+	}
+
+	startHour, endHour, err := parseIntRange(hours)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hours: %w", err)
+	}
+	startDay, endDay, err := parseIntRange(days)
+	if err != nil {
+		return nil, fmt.Errorf("invalid days: %w", err)
+	}
+
+	return &AlertBusinessHours{
+		StartHour: startHour,
+		EndHour:   endHour,
+		StartDay:  startDay,
+		EndDay:    endDay,
+		TimeGate:  spec.TimeGate,
+	}, nil
+}
+
+// parseIntRange parses `start-end` form ranges (e.g 9-17).
+func parseIntRange(r string) (start, end int, err error) {
+	rawStart, rawEnd, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("%q is not in `start-end` form", r)
+	}
+
+	start, err = strconv.Atoi(strings.TrimSpace(rawStart))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %q range start: %w", r, err)
+	}
+	end, err = strconv.Atoi(strings.TrimSpace(rawEnd))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %q range end: %w", r, err)
+	}
+
+	return start, end, nil
+}
+
+func mapSpecSLIEventsMode(mode string) (SLIEventsMode, error) {
+	switch mode {
+	case "", "counter":
+		return SLIEventsModeCounter, nil
+	case "gauge":
+		return SLIEventsModeGauge, nil
+	}
+
+	return "", fmt.Errorf("unknown %q mode", mode)
+}
@@ -0,0 +1,279 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/slok/sloth/internal/log"
+	pluginv1 "github.com/slok/sloth/pkg/prometheus/plugin/v1"
+)
+
+type SpecPolicyPlugin struct {
+	ID   string
+	Func pluginv1.SpecPolicyPlugin
+}
+
+type FileSpecPolicyPluginRepoConfig struct {
+	FileManager FileManager
+	Paths       []string
+	Logger      log.Logger
+}
+
+func (c *FileSpecPolicyPluginRepoConfig) defaults() error {
+	if c.FileManager == nil {
+		c.FileManager = fileManager{}
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "storage.FileSpecPolicyPlugin"})
+
+	return nil
+}
+
+// FileSpecPolicyPluginRepo will provide the spec policy plugins loaded from files.
+//
+// The plugins follow the same rules as the SLI plugins (check FileSLIPluginRepo), except
+// the plugin must be in a `policy.go` file inside a directory, so both plugin types can be
+// on the same paths.
+type FileSpecPolicyPluginRepo struct {
+	pluginLoader specPolicyPluginLoader
+	fileManager  FileManager
+	paths        []string
+	plugins      []SpecPolicyPlugin
+	logger       log.Logger
+}
+
+func NewFileSpecPolicyPluginRepo(config FileSpecPolicyPluginRepoConfig) (*FileSpecPolicyPluginRepo, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	f := &FileSpecPolicyPluginRepo{
+		fileManager:  config.FileManager,
+		pluginLoader: specPolicyPluginLoader{},
+		paths:        config.Paths,
+		logger:       config.Logger,
+	}
+
+	err = f.load(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not load plugins: %w", err)
+	}
+
+	return f, nil
+}
+
+var specPolicyPluginNameRegex = regexp.MustCompile("policy.go$")
+
+func (f *FileSpecPolicyPluginRepo) load(ctx context.Context) error {
+	// Discover plugins.
+	paths := map[string]struct{}{}
+	for _, path := range f.paths {
+		discoveredPaths, err := f.fileManager.FindFiles(ctx, path, specPolicyPluginNameRegex)
+		if err != nil {
+			return fmt.Errorf("could not discover spec policy plugins: %w", err)
+		}
+		for _, dPath := range discoveredPaths {
+			paths[dPath] = struct{}{}
+		}
+	}
+
+	// Load the plugins.
+	plugins := map[string]SpecPolicyPlugin{}
+	for path := range paths {
+		pluginData, err := f.fileManager.ReadFile(ctx, path)
+		if err != nil {
+			return fmt.Errorf("could not read %q plugin data: %w", path, err)
+		}
+
+		plugin, err := f.pluginLoader.LoadRawSpecPolicyPlugin(ctx, string(pluginData))
+		if err != nil {
+			return fmt.Errorf("could not load %q plugin: %w", path, err)
+		}
+
+		_, ok := plugins[plugin.ID]
+		if ok {
+			return fmt.Errorf("2 or more plugins with the same %q ID have been loaded", plugin.ID)
+		}
+
+		plugins[plugin.ID] = *plugin
+		f.logger.WithValues(log.Kv{"plugin-id": plugin.ID, "plugin-path": path}).Debugf("Spec policy plugin loaded")
+	}
+
+	// The plugins are applied by ID order, so the result doesn't depend on the discovery.
+	f.plugins = make([]SpecPolicyPlugin, 0, len(plugins))
+	for _, p := range plugins {
+		f.plugins = append(f.plugins, p)
+	}
+	sort.Slice(f.plugins, func(i, j int) bool { return f.plugins[i].ID < f.plugins[j].ID })
+
+	f.logger.WithValues(log.Kv{"plugins": len(f.plugins)}).Infof("Spec policy plugins loaded")
+
+	return nil
+}
+
+// ListSpecPolicyPlugins returns the spec policy plugins in the order they need to be applied.
+func (f *FileSpecPolicyPluginRepo) ListSpecPolicyPlugins(_ context.Context) ([]SpecPolicyPlugin, error) {
+	return f.plugins, nil
+}
+
+// ApplySpecPolicyPlugins applies the spec policy plugins in order to the loaded SLO group, the
+// first plugin rejecting the SLOs will fail the apply.
+func ApplySpecPolicyPlugins(ctx context.Context, plugins []SpecPolicyPlugin, sloGroup SLOGroup) (*SLOGroup, error) {
+	slos := sloGroup.SLOs
+	for _, p := range plugins {
+		policySLOs := make([]map[string]string, 0, len(slos))
+		for _, slo := range slos {
+			policySLOs = append(policySLOs, sloToSpecPolicySLO(slo))
+		}
+
+		gotPolicySLOs, err := p.Func(ctx, policySLOs)
+		if err != nil {
+			return nil, fmt.Errorf("%q spec policy plugin rejected the SLOs: %w", p.ID, err)
+		}
+
+		if len(gotPolicySLOs) != len(slos) {
+			return nil, fmt.Errorf("%q spec policy plugin returned %d SLOs, expected %d", p.ID, len(gotPolicySLOs), len(slos))
+		}
+
+		newSLOs := make([]SLO, 0, len(slos))
+		for i, slo := range slos {
+			slo, err := specPolicySLOToSLO(slo, gotPolicySLOs[i])
+			if err != nil {
+				return nil, fmt.Errorf("%q spec policy plugin returned an invalid %q SLO: %w", p.ID, slos[i].ID, err)
+			}
+			newSLOs = append(newSLOs, slo)
+		}
+		slos = newSLOs
+	}
+
+	return &SLOGroup{SLOs: slos}, nil
+}
+
+func sloToSpecPolicySLO(slo SLO) map[string]string {
+	s := map[string]string{
+		pluginv1.SpecPolicySLOID:          slo.ID,
+		pluginv1.SpecPolicySLOName:        slo.Name,
+		pluginv1.SpecPolicySLOService:     slo.Service,
+		pluginv1.SpecPolicySLODescription: slo.Description,
+		pluginv1.SpecPolicySLOObjective:   strconv.FormatFloat(slo.Objective, 'f', -1, 64),
+	}
+	for k, v := range slo.Labels {
+		s[pluginv1.SpecPolicySLOLabelPrefix+k] = v
+	}
+
+	return s
+}
+
+func specPolicySLOToSLO(slo SLO, policySLO map[string]string) (SLO, error) {
+	for key, v := range map[string]string{
+		pluginv1.SpecPolicySLOID:      slo.ID,
+		pluginv1.SpecPolicySLOName:    slo.Name,
+		pluginv1.SpecPolicySLOService: slo.Service,
+	} {
+		if policySLO[key] != v {
+			return slo, fmt.Errorf("%s can't be changed", key)
+		}
+	}
+
+	objective, err := strconv.ParseFloat(policySLO[pluginv1.SpecPolicySLOObjective], 64)
+	if err != nil {
+		return slo, fmt.Errorf("invalid objective: %w", err)
+	}
+
+	var labels map[string]string
+	for k, v := range policySLO {
+		switch {
+		case strings.HasPrefix(k, pluginv1.SpecPolicySLOLabelPrefix):
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[strings.TrimPrefix(k, pluginv1.SpecPolicySLOLabelPrefix)] = v
+		case k == pluginv1.SpecPolicySLOID, k == pluginv1.SpecPolicySLOName, k == pluginv1.SpecPolicySLOService,
+			k == pluginv1.SpecPolicySLODescription, k == pluginv1.SpecPolicySLOObjective:
+		default:
+			return slo, fmt.Errorf("unknown %q key", k)
+		}
+	}
+
+	slo.Description = policySLO[pluginv1.SpecPolicySLODescription]
+	slo.Objective = objective
+	slo.Labels = labels
+
+	return slo, nil
+}
+
+// specPolicyPluginLoader knows how to load Go spec policy plugins using Yaegi.
+type specPolicyPluginLoader struct{}
+
+// LoadRawSpecPolicyPlugin knows how to load spec policy plugins using Yaegi from source data,
+// like the SLI plugins, only the standard library can be imported.
+//
+// The load process will search for:
+// - A function called `SpecPolicyPlugin` to obtain the plugin func.
+// - A constant called `SpecPolicyPluginID` to obtain the plugin ID.
+// - A constant called `SpecPolicyPluginVersion` to obtain the plugin version.
+func (s specPolicyPluginLoader) LoadRawSpecPolicyPlugin(ctx context.Context, src string) (*SpecPolicyPlugin, error) {
+	// For each plugin we need to use an independent interpreter to avoid name collisions.
+	yaegiInterp, err := sliPluginLoader{}.newYaeginInterpreter()
+	if err != nil {
+		return nil, fmt.Errorf("could not create a new Yaegi interpreter: %w", err)
+	}
+
+	_, err = yaegiInterp.EvalWithContext(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("could not evaluate plugin source code: %w", err)
+	}
+
+	// Discover package name.
+	packageMatch := packageRegexp.FindStringSubmatch(src)
+	if len(packageMatch) != 2 {
+		return nil, fmt.Errorf("invalid plugin source code, could not get package name")
+	}
+	packageName := packageMatch[1]
+
+	// Get plugin version and check if is a known one.
+	pluginVerTmp, err := yaegiInterp.EvalWithContext(ctx, fmt.Sprintf("%s.SpecPolicyPluginVersion", packageName))
+	if err != nil {
+		return nil, fmt.Errorf("could not get plugin version: %w", err)
+	}
+
+	pluginVer, ok := pluginVerTmp.Interface().(pluginv1.SpecPolicyPluginVersion)
+	if !ok || (pluginVer != pluginv1.Version) {
+		return nil, fmt.Errorf("unsuported plugin version: %s", pluginVer)
+	}
+
+	// Get plugin ID.
+	pluginIDTmp, err := yaegiInterp.EvalWithContext(ctx, fmt.Sprintf("%s.SpecPolicyPluginID", packageName))
+	if err != nil {
+		return nil, fmt.Errorf("could not get plugin ID: %w", err)
+	}
+
+	pluginID, ok := pluginIDTmp.Interface().(pluginv1.SpecPolicyPluginID)
+	if !ok {
+		return nil, fmt.Errorf("invalid spec policy plugin ID type")
+	}
+
+	// Get plugin logic.
+	pluginFuncTmp, err := yaegiInterp.EvalWithContext(ctx, fmt.Sprintf("%s.SpecPolicyPlugin", packageName))
+	if err != nil {
+		return nil, fmt.Errorf("could not get plugin: %w", err)
+	}
+
+	pluginFunc, ok := pluginFuncTmp.Interface().(pluginv1.SpecPolicyPlugin)
+	if !ok {
+		return nil, fmt.Errorf("invalid spec policy plugin type")
+	}
+
+	return &SpecPolicyPlugin{
+		ID:   pluginID,
+		Func: pluginFunc,
+	}, nil
+}
@@ -0,0 +1,169 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/sloth/internal/prometheus"
+	"github.com/slok/sloth/internal/prometheus/prometheusmock"
+)
+
+func TestApplySpecPolicyPlugins(t *testing.T) {
+	const ownerPolicySrc = `
+package ownerpolicy
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	SpecPolicyPluginID      = "owner_policy"
+	SpecPolicyPluginVersion = "prometheus/v1"
+)
+
+func SpecPolicyPlugin(ctx context.Context, slos []map[string]string) ([]map[string]string, error) {
+	for _, slo := range slos {
+		if slo["label.owner"] == "" {
+			return nil, fmt.Errorf("%s SLO is missing the owner label", slo["id"])
+		}
+	}
+	return slos, nil
+}
+`
+
+	tests := map[string]struct {
+		pluginSrc  string
+		slos       []prometheus.SLO
+		expSLOs    []prometheus.SLO
+		expErrLoad bool
+		expErr     bool
+	}{
+		"Plugin without version should fail on load.": {
+			pluginSrc: `
+package testpolicy
+
+import "context"
+
+const SpecPolicyPluginID = "test_policy"
+
+func SpecPolicyPlugin(ctx context.Context, slos []map[string]string) ([]map[string]string, error) {
+	return slos, nil
+}
+`,
+			expErrLoad: true,
+		},
+
+		"A policy rejecting owner-less SLOs should fail with SLOs without owner.": {
+			pluginSrc: ownerPolicySrc,
+			slos: []prometheus.SLO{
+				{ID: "svc-slo1", Name: "slo1", Service: "svc", Objective: 99.9, Labels: map[string]string{"owner": "team-a"}},
+				{ID: "svc-slo2", Name: "slo2", Service: "svc", Objective: 99.9},
+			},
+			expErr: true,
+		},
+
+		"A policy rejecting owner-less SLOs should not modify SLOs with owner.": {
+			pluginSrc: ownerPolicySrc,
+			slos: []prometheus.SLO{
+				{ID: "svc-slo1", Name: "slo1", Service: "svc", Objective: 99.9, Labels: map[string]string{"owner": "team-a"}},
+			},
+			expSLOs: []prometheus.SLO{
+				{ID: "svc-slo1", Name: "slo1", Service: "svc", Objective: 99.9, Labels: map[string]string{"owner": "team-a"}},
+			},
+		},
+
+		"A policy should be able to set the SLOs defaults.": {
+			pluginSrc: `
+package defaultspolicy
+
+import "context"
+
+const (
+	SpecPolicyPluginID      = "defaults_policy"
+	SpecPolicyPluginVersion = "prometheus/v1"
+)
+
+func SpecPolicyPlugin(ctx context.Context, slos []map[string]string) ([]map[string]string, error) {
+	for _, slo := range slos {
+		if slo["label.tier"] == "" {
+			slo["label.tier"] = "2"
+		}
+		if slo["description"] == "" {
+			slo["description"] = "Managed SLO."
+		}
+	}
+	return slos, nil
+}
+`,
+			slos: []prometheus.SLO{
+				{ID: "svc-slo1", Name: "slo1", Service: "svc", Objective: 99.9},
+				{ID: "svc-slo2", Name: "slo2", Service: "svc", Objective: 99, Description: "Custom.", Labels: map[string]string{"tier": "1"}},
+			},
+			expSLOs: []prometheus.SLO{
+				{ID: "svc-slo1", Name: "slo1", Service: "svc", Objective: 99.9, Description: "Managed SLO.", Labels: map[string]string{"tier": "2"}},
+				{ID: "svc-slo2", Name: "slo2", Service: "svc", Objective: 99, Description: "Custom.", Labels: map[string]string{"tier": "1"}},
+			},
+		},
+
+		"A policy changing the SLO IDs should fail.": {
+			pluginSrc: `
+package idpolicy
+
+import "context"
+
+const (
+	SpecPolicyPluginID      = "id_policy"
+	SpecPolicyPluginVersion = "prometheus/v1"
+)
+
+func SpecPolicyPlugin(ctx context.Context, slos []map[string]string) ([]map[string]string, error) {
+	for _, slo := range slos {
+		slo["id"] = "other"
+	}
+	return slos, nil
+}
+`,
+			slos: []prometheus.SLO{
+				{ID: "svc-slo1", Name: "slo1", Service: "svc", Objective: 99.9},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mock the plugin files.
+			mfm := &prometheusmock.FileManager{}
+			mfm.On("FindFiles", mock.Anything, "./", mock.Anything).Once().Return([]string{"testpolicy/policy.go"}, nil)
+			mfm.On("ReadFile", mock.Anything, "testpolicy/policy.go").Once().Return([]byte(test.pluginSrc), nil)
+
+			repo, err := prometheus.NewFileSpecPolicyPluginRepo(prometheus.FileSpecPolicyPluginRepoConfig{
+				FileManager: mfm,
+				Paths:       []string{"./"},
+			})
+			if test.expErrLoad {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			plugins, err := repo.ListSpecPolicyPlugins(context.TODO())
+			require.NoError(err)
+
+			gotGroup, err := prometheus.ApplySpecPolicyPlugins(context.TODO(), plugins, prometheus.SLOGroup{SLOs: test.slos})
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expSLOs, gotGroup.SLOs)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package prometheus_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"testing"
 	"time"
@@ -58,6 +59,36 @@ slos:
 			expErr: true,
 		},
 
+		"Spec with CRLF line endings and UTF-8 BOM should load correctly.": {
+			specYaml: "\ufeffversion: \"prometheus/v1\"\r\n" +
+				"service: test-svc\r\n" +
+				"slos:\r\n" +
+				"  - name: slo1\r\n" +
+				"    objective: 99.9\r\n" +
+				"    sli:\r\n" +
+				"      raw:\r\n" +
+				"        error_ratio_query: test_expr_ratio\r\n" +
+				"    alerting:\r\n" +
+				"      page_alert:\r\n" +
+				"        disable: true\r\n" +
+				"      ticket_alert:\r\n" +
+				"        disable: true\r\n",
+			windowPeriod: 30 * 24 * time.Hour,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:              "test-svc-slo1",
+					Name:            "slo1",
+					Service:         "test-svc",
+					TimeWindow:      30 * 24 * time.Hour,
+					SLI:             prometheus.SLI{Raw: &prometheus.SLIRaw{ErrorRatioQuery: "test_expr_ratio"}},
+					Objective:       99.9,
+					Labels:          map[string]string{},
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
 		"Spec without SLOs should fail.": {
 			specYaml: `
 service: test-svc
@@ -113,6 +144,33 @@ slos:
 			expErr: true,
 		},
 
+		"Spec with SLI plugin that returns an invalid PromQL query should fail.": {
+			plugins: map[string]prometheus.SLIPlugin{
+				"test_plugin": {
+					ID: "test_plugin",
+					Func: func(_ context.Context, _ map[string]string, _ map[string]string, _ map[string]string) (string, error) {
+						return `sum(rate(http_requests_total{code=~"5.."}[{{.window}}])`, nil
+					},
+				},
+			},
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo"
+    objective: 99
+    sli:
+      plugin:
+        id: test_plugin
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expErr: true,
+		},
+
 		"Spec with SLI plugin should use the plugin correctly.": {
 			windowPeriod: 30 * 24 * time.Hour,
 			plugins: map[string]prometheus.SLIPlugin{
@@ -206,44 +264,62 @@ slos:
 			}},
 		},
 
-		"Correct spec should return the models correctly.": {
+		"Spec with precomputed SLI should load the precomputed metrics.": {
 			windowPeriod: 30 * 24 * time.Hour,
 			specYaml: `
+service: test-svc
 version: "prometheus/v1"
-service: "test-svc"
-labels:
-  owner: "myteam"
 slos:
-  - name: "slo1"
-    labels:
-      category: test
-    objective: 99.99
-    description: "This is a test."
+  - name: "slo-test"
+    objective: 99
     sli:
-      events:
-        error_query: test_expr_error_1
-        total_query: test_expr_total_1
+      precomputed:
+        error_metric: job:http_requests_5xx:rate5m
+        total_metric: job:http_requests:rate5m
     alerting:
-      name: testAlert
-      labels:
-        tier: "1"
-      annotations:
-        runbook: http://whatever.com
       page_alert:
-        labels:
-          severity: slack
-          channel: "#a-myteam"
-        annotations:
-          message: "This is very important."
+        disable: true
       ticket_alert:
-        labels:
-          severity: slack
-          channel: "#a-not-so-important"
-        annotations:
-          message: "This is not very important."
-  - name: "slo2"
-    labels:
-      category: test2
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
+					SLI: prometheus.SLI{
+						Precomputed: &prometheus.SLIPrecomputed{
+							ErrorMetric: "job:http_requests_5xx:rate5m",
+							TotalMetric: "job:http_requests:rate5m",
+						},
+					},
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with SLOs with custom time windows should override the default SLO period of these SLOs.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-latency"
+    objective: 99
+    timeWindow: 7d
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_1
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+  - name: "slo-availability"
     objective: 99.9
     sli:
       raw:
@@ -256,115 +332,1055 @@ slos:
 `,
 			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
 				{
-					ID:          "test-svc-slo1",
-					Name:        "slo1",
-					Service:     "test-svc",
-					Description: "This is a test.",
-					TimeWindow:  30 * 24 * time.Hour,
-					SLI: prometheus.SLI{
-						Events: &prometheus.SLIEvents{
-							ErrorQuery: "test_expr_error_1",
-							TotalQuery: "test_expr_total_1",
-						},
-					},
-					Objective: 99.99,
-					Labels: map[string]string{
-						"owner":    "myteam",
-						"category": "test",
-					},
-					PageAlertMeta: prometheus.AlertMeta{
-						Disable: false,
-						Name:    "testAlert",
-						Labels: map[string]string{
-							"tier":     "1",
-							"severity": "slack",
-							"channel":  "#a-myteam",
-						},
-						Annotations: map[string]string{
-							"message": "This is very important.",
-							"runbook": "http://whatever.com",
-						},
-					},
-					TicketAlertMeta: prometheus.AlertMeta{
-						Disable: false,
-						Name:    "testAlert",
-						Labels: map[string]string{
-							"tier":     "1",
-							"severity": "slack",
-							"channel":  "#a-not-so-important",
-						},
-						Annotations: map[string]string{
-							"message": "This is not very important.",
-							"runbook": "http://whatever.com",
-						},
-					},
+					ID:              "test-svc-slo-latency",
+					Name:            "slo-latency",
+					Service:         "test-svc",
+					TimeWindow:      7 * 24 * time.Hour,
+					Labels:          map[string]string{},
+					SLI:             prometheus.SLI{Raw: &prometheus.SLIRaw{ErrorRatioQuery: "test_expr_ratio_1"}},
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
 				},
 				{
-					ID:         "test-svc-slo2",
-					Name:       "slo2",
+					ID:              "test-svc-slo-availability",
+					Name:            "slo-availability",
+					Service:         "test-svc",
+					TimeWindow:      30 * 24 * time.Hour,
+					Labels:          map[string]string{},
+					SLI:             prometheus.SLI{Raw: &prometheus.SLIRaw{ErrorRatioQuery: "test_expr_ratio_2"}},
+					Objective:       99.9,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with an invalid SLO time window should fail.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    timeWindow: 7days
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio
+`,
+			expErr: true,
+		},
+
+		"Spec with gauge mode events SLI should load the gauge mode.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      events:
+        mode: gauge
+        error_query: sum(queue_items{state="expired"})
+        total_query: sum(queue_items)
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
 					Service:    "test-svc",
 					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
 					SLI: prometheus.SLI{
-						Raw: &prometheus.SLIRaw{
-							ErrorRatioQuery: "test_expr_ratio_2",
+						Events: &prometheus.SLIEvents{
+							ErrorQuery: `sum(queue_items{state="expired"})`,
+							TotalQuery: "sum(queue_items)",
+							Mode:       prometheus.SLIEventsModeGauge,
 						},
 					},
-					Objective: 99.9,
-					Labels: map[string]string{
-						"owner":    "myteam",
-						"category": "test2",
-					},
+					Objective:       99,
 					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
 					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
 				},
 			}},
 		},
-	}
-
-	for name, test := range tests {
-		t.Run(name, func(t *testing.T) {
-			assert := assert.New(t)
-
-			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo(test.plugins), test.windowPeriod)
-			gotModel, err := loader.LoadSpec(context.TODO(), []byte(test.specYaml))
-
-			if test.expErr {
-				assert.Error(err)
-			} else if assert.NoError(err) {
-				assert.Equal(test.expModel, gotModel)
-			}
-		})
-	}
-}
-
-func TestYAMLIsSpecType(t *testing.T) {
-	tests := map[string]struct {
-		specYaml string
-		exp      bool
-	}{
-		"An empty spec type shouldn't match": {
-			specYaml: ``,
-			exp:      false,
-		},
-
-		"An wrong spec type shouldn't match": {
-			specYaml: `{`,
-			exp:      false,
-		},
 
-		"An incorrect spec version type shouldn't match": {
-			specYaml: `version: "prometheus/v2"`,
-			exp:      false,
+		"Spec with an unknown events SLI mode should fail.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      events:
+        mode: histogram
+        error_query: test_expr_error
+        total_query: test_expr_total
+`,
+			expErr: true,
 		},
 
-		"An correct spec type should match": {
-			specYaml: `version: "prometheus/v1"`,
-			exp:      true,
+		"Spec with logql and events SLIs at the same time should fail.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      events:
+        error_query: test_expr_error
+        total_query: test_expr_total
+      logql:
+        error_query: test_logql_error
+        total_query: test_logql_total
+`,
+			expErr: true,
 		},
 
-		"An correct spec type should match (no quotes)": {
-			specYaml: `version: prometheus/v1`,
-			exp:      true,
+		"Spec with freshness SLI should load the histogram buckets based events SLI.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 95
+    sli:
+      freshness:
+        histogram_metric: records_processing_age_seconds_bucket
+        filter: job="processor"
+        threshold: 5m
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
+					SLI: prometheus.SLI{
+						Events: &prometheus.SLIEvents{
+							ErrorQuery: `sum(rate(records_processing_age_seconds_bucket{job="processor",le="+Inf"}[{{.window}}]))
+-
+sum(rate(records_processing_age_seconds_bucket{job="processor",le="300"}[{{.window}}]))`,
+							TotalQuery: `sum(rate(records_processing_age_seconds_bucket{job="processor",le="+Inf"}[{{.window}}]))`,
+						},
+					},
+					Objective:       95,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with freshness SLI without filter and bucket suffix should load the histogram buckets based events SLI.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 95
+    sli:
+      freshness:
+        histogram_metric: records_processing_age_seconds
+        threshold: 500ms
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
+					SLI: prometheus.SLI{
+						Events: &prometheus.SLIEvents{
+							ErrorQuery: `sum(rate(records_processing_age_seconds_bucket{le="+Inf"}[{{.window}}]))
+-
+sum(rate(records_processing_age_seconds_bucket{le="0.5"}[{{.window}}]))`,
+							TotalQuery: `sum(rate(records_processing_age_seconds_bucket{le="+Inf"}[{{.window}}]))`,
+						},
+					},
+					Objective:       95,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with an inverted SLI should load the inverted SLI.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      invert: true
+      raw:
+        error_ratio_query: avg_over_time(healthy[{{.window}}])
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{
+							ErrorRatioQuery: "avg_over_time(healthy[{{.window}}])",
+						},
+					},
+					SLIInvert:       true,
+					Objective:       99,
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with business hours alerts should load the alerts business hours with the defaults.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio
+    alerting:
+      name: testAlert
+      page_alert:
+        business_hours:
+          time_gate: true
+          hours: 8-16
+          days: 1-4
+      ticket_alert:
+        business_hours: {}
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{
+							ErrorRatioQuery: "test_expr_ratio",
+						},
+					},
+					Objective: 99,
+					PageAlertMeta: prometheus.AlertMeta{
+						Name:          "testAlert",
+						Labels:        map[string]string{},
+						Annotations:   map[string]string{},
+						BusinessHours: &prometheus.AlertBusinessHours{StartHour: 8, EndHour: 16, StartDay: 1, EndDay: 4, TimeGate: true},
+					},
+					TicketAlertMeta: prometheus.AlertMeta{
+						Name:          "testAlert",
+						Labels:        map[string]string{},
+						Annotations:   map[string]string{},
+						BusinessHours: &prometheus.AlertBusinessHours{StartHour: 9, EndHour: 17, StartDay: 1, EndDay: 5},
+					},
+				},
+			}},
+		},
+
+		"Spec with invalid business hours should fail.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio
+    alerting:
+      name: testAlert
+      page_alert:
+        business_hours:
+          hours: "9"
+`,
+			expErr: true,
+		},
+
+		"Spec with freshness SLI without threshold should fail.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 95
+    sli:
+      freshness:
+        histogram_metric: records_processing_age_seconds_bucket
+`,
+			expErr: true,
+		},
+
+		"Spec with freshness and events SLIs at the same time should fail.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 95
+    sli:
+      events:
+        error_query: test_expr_error
+        total_query: test_expr_total
+      freshness:
+        histogram_metric: records_processing_age_seconds_bucket
+        threshold: 5m
+`,
+			expErr: true,
+		},
+
+		"Spec with a maintenance gate without query should load the default maintenance gate query.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio
+      maintenance_gate: {}
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{ErrorRatioQuery: "test_expr_ratio"},
+					},
+					SLIMaintenanceGateQuery: `ALERTS{alertname="Maintenance"}`,
+					Objective:               99,
+					PageAlertMeta:           prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta:         prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Spec with a maintenance gate should load the maintenance gate query.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+service: test-svc
+version: "prometheus/v1"
+slos:
+  - name: "slo-test"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio
+      maintenance_gate:
+        query: maintenance_active > 0
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:         "test-svc-slo-test",
+					Name:       "slo-test",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					Labels:     map[string]string{},
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{ErrorRatioQuery: "test_expr_ratio"},
+					},
+					SLIMaintenanceGateQuery: "maintenance_active > 0",
+					Objective:               99,
+					PageAlertMeta:           prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta:         prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+
+		"Correct spec should return the models correctly.": {
+			windowPeriod: 30 * 24 * time.Hour,
+			specYaml: `
+version: "prometheus/v1"
+service: "test-svc"
+labels:
+  owner: "myteam"
+slos:
+  - name: "slo1"
+    labels:
+      category: test
+    objective: 99.99
+    description: "This is a test."
+    sli:
+      events:
+        error_query: test_expr_error_1
+        total_query: test_expr_total_1
+    alerting:
+      name: testAlert
+      labels:
+        tier: "1"
+      annotations:
+        runbook: http://whatever.com
+      page_alert:
+        labels:
+          severity: slack
+          channel: "#a-myteam"
+        annotations:
+          message: "This is very important."
+      ticket_alert:
+        labels:
+          severity: slack
+          channel: "#a-not-so-important"
+        annotations:
+          message: "This is not very important."
+  - name: "slo2"
+    labels:
+      category: test2
+    objective: 99.9
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_2
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`,
+			expModel: &prometheus.SLOGroup{SLOs: []prometheus.SLO{
+				{
+					ID:          "test-svc-slo1",
+					Name:        "slo1",
+					Service:     "test-svc",
+					Description: "This is a test.",
+					TimeWindow:  30 * 24 * time.Hour,
+					SLI: prometheus.SLI{
+						Events: &prometheus.SLIEvents{
+							ErrorQuery: "test_expr_error_1",
+							TotalQuery: "test_expr_total_1",
+						},
+					},
+					Objective: 99.99,
+					Labels: map[string]string{
+						"owner":    "myteam",
+						"category": "test",
+					},
+					PageAlertMeta: prometheus.AlertMeta{
+						Disable: false,
+						Name:    "testAlert",
+						Labels: map[string]string{
+							"tier":     "1",
+							"severity": "slack",
+							"channel":  "#a-myteam",
+						},
+						Annotations: map[string]string{
+							"message": "This is very important.",
+							"runbook": "http://whatever.com",
+						},
+					},
+					TicketAlertMeta: prometheus.AlertMeta{
+						Disable: false,
+						Name:    "testAlert",
+						Labels: map[string]string{
+							"tier":     "1",
+							"severity": "slack",
+							"channel":  "#a-not-so-important",
+						},
+						Annotations: map[string]string{
+							"message": "This is not very important.",
+							"runbook": "http://whatever.com",
+						},
+					},
+				},
+				{
+					ID:         "test-svc-slo2",
+					Name:       "slo2",
+					Service:    "test-svc",
+					TimeWindow: 30 * 24 * time.Hour,
+					SLI: prometheus.SLI{
+						Raw: &prometheus.SLIRaw{
+							ErrorRatioQuery: "test_expr_ratio_2",
+						},
+					},
+					Objective: 99.9,
+					Labels: map[string]string{
+						"owner":    "myteam",
+						"category": "test2",
+					},
+					PageAlertMeta:   prometheus.AlertMeta{Disable: true},
+					TicketAlertMeta: prometheus.AlertMeta{Disable: true},
+				},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo(test.plugins), test.windowPeriod)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(test.specYaml))
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expModel, gotModel)
+			}
+		})
+	}
+}
+
+func TestYAMLoadSpecIDGenerator(t *testing.T) {
+	hashedIDGen := prometheus.IDGeneratorFunc(func(_ context.Context, service, name string) (string, error) {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(service+"/"+name)))[:16], nil
+	})
+
+	tests := map[string]struct {
+		idGenerator prometheus.IDGenerator
+		expIDs      []string
+		expErr      bool
+	}{
+		"Using the default ID generator should use service and SLO name.": {
+			idGenerator: prometheus.DefaultIDGenerator,
+			expIDs:      []string{"test-svc-slo1", "test-svc-slo2"},
+		},
+
+		"Using a custom ID generator should set the custom IDs.": {
+			idGenerator: hashedIDGen,
+			expIDs: []string{
+				fmt.Sprintf("%x", sha256.Sum256([]byte("test-svc/slo1")))[:16],
+				fmt.Sprintf("%x", sha256.Sum256([]byte("test-svc/slo2")))[:16],
+			},
+		},
+
+		"An error on the ID generator should fail.": {
+			idGenerator: prometheus.IDGeneratorFunc(func(_ context.Context, _, _ string) (string, error) {
+				return "", fmt.Errorf("something")
+			}),
+			expErr: true,
+		},
+	}
+
+	specYaml := `
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_1
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+  - name: "slo2"
+    objective: 99.9
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_2
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo{}, 30*24*time.Hour).WithIDGenerator(test.idGenerator)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				gotIDs := []string{}
+				for _, slo := range gotModel.SLOs {
+					gotIDs = append(gotIDs, slo.ID)
+				}
+				assert.Equal(test.expIDs, gotIDs)
+			}
+		})
+	}
+}
+
+func TestYAMLoadSpecExplicitID(t *testing.T) {
+	tests := map[string]struct {
+		specYaml string
+		expIDs   []string
+	}{
+		"Without explicit IDs, the IDs should be generated from the SLO names.": {
+			specYaml: `
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_1
+    alerting:
+      name: Alert1
+`,
+			expIDs: []string{"test-svc-slo1"},
+		},
+
+		"With explicit IDs, the IDs should be kept when the SLOs are renamed.": {
+			specYaml: `
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - id: "test-svc-slo1"
+    name: "slo1-renamed"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_1
+    alerting:
+      name: Alert1Renamed
+`,
+			expIDs: []string{"test-svc-slo1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo{}, 30*24*time.Hour)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(test.specYaml))
+
+			if assert.NoError(err) {
+				gotIDs := []string{}
+				for _, slo := range gotModel.SLOs {
+					gotIDs = append(gotIDs, slo.ID)
+				}
+				assert.Equal(test.expIDs, gotIDs)
+			}
+		})
+	}
+}
+
+func TestCheckSLIPluginRefs(t *testing.T) {
+	specYaml := `
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    objective: 99
+    sli:
+      plugin:
+        id: test_plugin
+    alerting:
+      name: Alert1
+  - id: "custom-id"
+    name: "slo2"
+    objective: 99
+    sli:
+      plugin:
+        id: test_plugin_missing
+    alerting:
+      name: Alert2
+  - name: "slo3"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_1
+    alerting:
+      name: Alert3
+`
+
+	tests := map[string]struct {
+		plugins map[string]prometheus.SLIPlugin
+		expErr  string
+	}{
+		"Having all the referenced plugins, it should not fail.": {
+			plugins: map[string]prometheus.SLIPlugin{
+				"test_plugin":         {ID: "test_plugin"},
+				"test_plugin_missing": {ID: "test_plugin_missing"},
+			},
+		},
+
+		"Missing referenced plugins, it should fail with the plugins and their SLOs.": {
+			plugins: map[string]prometheus.SLIPlugin{},
+			expErr:  `missing SLI plugins: "test_plugin" used by "test-svc-slo1" SLO (test.yaml), "test_plugin_missing" used by "custom-id" SLO (test.yaml)`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo(test.plugins), 30*24*time.Hour)
+			refs, err := loader.SLIPluginRefs(context.TODO(), []byte(specYaml))
+			if !assert.NoError(err) {
+				return
+			}
+			for i := range refs {
+				refs[i].Source = "test.yaml"
+			}
+
+			err = prometheus.CheckSLIPluginRefs(context.TODO(), testMemPluginsRepo(test.plugins), refs)
+			if test.expErr != "" {
+				assert.EqualError(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestYAMLoadSpecWindowPlaceholder(t *testing.T) {
+	tests := map[string]struct {
+		placeholder string
+		expSLI      prometheus.SLI
+	}{
+		"Without a custom window placeholder the queries should not be changed.": {
+			expSLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+				ErrorQuery: `sum(rate(http_request_duration_seconds_count{code=~"5.."}[$__range]))`,
+				TotalQuery: `sum(rate(http_request_duration_seconds_count[$__range]))`,
+			}},
+		},
+
+		"With a custom window placeholder the queries should use the window template variable.": {
+			placeholder: "$__range",
+			expSLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+				ErrorQuery: `sum(rate(http_request_duration_seconds_count{code=~"5.."}[{{.window}}]))`,
+				TotalQuery: `sum(rate(http_request_duration_seconds_count[{{.window}}]))`,
+			}},
+		},
+
+		"With a custom window placeholder that is not used the queries should not be changed.": {
+			placeholder: "$__interval",
+			expSLI: prometheus.SLI{Events: &prometheus.SLIEvents{
+				ErrorQuery: `sum(rate(http_request_duration_seconds_count{code=~"5.."}[$__range]))`,
+				TotalQuery: `sum(rate(http_request_duration_seconds_count[$__range]))`,
+			}},
+		},
+	}
+
+	specYaml := `
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    objective: 99
+    sli:
+      events:
+        error_query: sum(rate(http_request_duration_seconds_count{code=~"5.."}[$__range]))
+        total_query: sum(rate(http_request_duration_seconds_count[$__range]))
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo{}, 30*24*time.Hour).WithWindowPlaceholder(test.placeholder)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+
+			if assert.NoError(err) && assert.Len(gotModel.SLOs, 1) {
+				assert.Equal(test.expSLI, gotModel.SLOs[0].SLI)
+			}
+		})
+	}
+}
+
+func TestYAMLoadSpecAlertsDisable(t *testing.T) {
+	tests := map[string]struct {
+		pageDisable   bool
+		ticketDisable bool
+		expPage       prometheus.AlertMeta
+		expTicket     prometheus.AlertMeta
+	}{
+		"Without disabled alerts both alerts should be enabled.": {
+			pageDisable:   false,
+			ticketDisable: false,
+			expPage:       prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "pageteam"}, Annotations: map[string]string{}},
+			expTicket:     prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "ticketteam"}, Annotations: map[string]string{}},
+		},
+
+		"Disabling the page alert should only disable the page alert.": {
+			pageDisable:   true,
+			ticketDisable: false,
+			expPage:       prometheus.AlertMeta{Disable: true},
+			expTicket:     prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "ticketteam"}, Annotations: map[string]string{}},
+		},
+
+		"Disabling the ticket alert should only disable the ticket alert.": {
+			pageDisable:   false,
+			ticketDisable: true,
+			expPage:       prometheus.AlertMeta{Name: "MyAlert", Labels: map[string]string{"severity": "pageteam"}, Annotations: map[string]string{}},
+			expTicket:     prometheus.AlertMeta{Disable: true},
+		},
+
+		"Disabling both alerts should disable both alerts.": {
+			pageDisable:   true,
+			ticketDisable: true,
+			expPage:       prometheus.AlertMeta{Disable: true},
+			expTicket:     prometheus.AlertMeta{Disable: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			specYaml := fmt.Sprintf(`
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    objective: 99
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio
+    alerting:
+      name: MyAlert
+      page_alert:
+        disable: %t
+        labels:
+          severity: pageteam
+      ticket_alert:
+        disable: %t
+        labels:
+          severity: ticketteam
+`, test.pageDisable, test.ticketDisable)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo{}, 30*24*time.Hour)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+
+			if assert.NoError(err) && assert.Len(gotModel.SLOs, 1) {
+				assert.Equal(test.expPage, gotModel.SLOs[0].PageAlertMeta)
+				assert.Equal(test.expTicket, gotModel.SLOs[0].TicketAlertMeta)
+			}
+		})
+	}
+}
+
+func TestYAMLoadSpecObjectiveNines(t *testing.T) {
+	tests := map[string]struct {
+		objective    string
+		expObjective float64
+		expErr       bool
+	}{
+		"A percentage objective should be loaded as it is.": {
+			objective:    "99.9",
+			expObjective: 99.9,
+		},
+
+		"A full nines objective should be loaded as the percentage.": {
+			objective:    "3nines",
+			expObjective: 99.9,
+		},
+
+		"A single nine objective should be loaded as the percentage.": {
+			objective:    "1nines",
+			expObjective: 90,
+		},
+
+		"A half nines objective should add a 5 to the nines (4.5nines is 99.995).": {
+			objective:    "4.5nines",
+			expObjective: 99.995,
+		},
+
+		"A half nines objective with a single nine should add a 5 to the nine (1.5nines is 95).": {
+			objective:    "1.5nines",
+			expObjective: 95,
+		},
+
+		"A nines objective that is not full or half should fail.": {
+			objective: "4.2nines",
+			expErr:    true,
+		},
+
+		"A zero nines objective should fail.": {
+			objective: "0nines",
+			expErr:    true,
+		},
+
+		"An invalid nines objective should fail.": {
+			objective: "threenines",
+			expErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			specYaml := fmt.Sprintf(`
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    objective: %s
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_1
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`, test.objective)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo{}, 30*24*time.Hour)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) && assert.Len(gotModel.SLOs, 1) {
+				assert.Equal(test.expObjective, gotModel.SLOs[0].Objective)
+			}
+		})
+	}
+}
+
+func TestYAMLoadSpecAllowedDowntime(t *testing.T) {
+	tests := map[string]struct {
+		objectiveFields string
+		expObjective    float64
+		expErr          bool
+	}{
+		"An allowed downtime on the default SLO period should derive the objective.": {
+			objectiveFields: "allowedDowntime: 43m",
+			expObjective:    99.900462963,
+		},
+
+		"An allowed downtime on a custom SLO time window should derive the objective with the time window.": {
+			objectiveFields: "allowedDowntime: 1h\n    timeWindow: 28d",
+			expObjective:    99.8511904762,
+		},
+
+		"An allowed downtime that is an exact budget should derive the exact objective.": {
+			objectiveFields: "allowedDowntime: 432m",
+			expObjective:    99,
+		},
+
+		"An allowed downtime with an objective should fail.": {
+			objectiveFields: "allowedDowntime: 43m\n    objective: 99.9",
+			expErr:          true,
+		},
+
+		"An allowed downtime greater than the SLO time window should fail.": {
+			objectiveFields: "allowedDowntime: 31d",
+			expErr:          true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			specYaml := fmt.Sprintf(`
+version: "prometheus/v1"
+service: "test-svc"
+slos:
+  - name: "slo1"
+    %s
+    sli:
+      raw:
+        error_ratio_query: test_expr_ratio_1
+    alerting:
+      page_alert:
+        disable: true
+      ticket_alert:
+        disable: true
+`, test.objectiveFields)
+
+			loader := prometheus.NewYAMLSpecLoader(testMemPluginsRepo{}, 30*24*time.Hour)
+			gotModel, err := loader.LoadSpec(context.TODO(), []byte(specYaml))
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) && assert.Len(gotModel.SLOs, 1) {
+				assert.Equal(test.expObjective, gotModel.SLOs[0].Objective)
+			}
+		})
+	}
+}
+
+func TestYAMLIsSpecType(t *testing.T) {
+	tests := map[string]struct {
+		specYaml string
+		exp      bool
+	}{
+		"An empty spec type shouldn't match": {
+			specYaml: ``,
+			exp:      false,
+		},
+
+		"An wrong spec type shouldn't match": {
+			specYaml: `{`,
+			exp:      false,
+		},
+
+		"An incorrect spec version type shouldn't match": {
+			specYaml: `version: "prometheus/v2"`,
+			exp:      false,
+		},
+
+		"An correct spec type should match": {
+			specYaml: `version: "prometheus/v1"`,
+			exp:      true,
+		},
+
+		"An correct spec type should match (no quotes)": {
+			specYaml: `version: prometheus/v1`,
+			exp:      true,
 		},
 
 		"An correct spec type should match (single quotes)": {
@@ -372,6 +1388,11 @@ func TestYAMLIsSpecType(t *testing.T) {
 			exp:      true,
 		},
 
+		"An correct spec type should match (CRLF and UTF-8 BOM)": {
+			specYaml: "\ufeffversion: \"prometheus/v1\"\r\nservice: test\r\n",
+			exp:      true,
+		},
+
 		"An correct spec type should match (multiple spaces)": {
 			specYaml: `version:         "prometheus/v1"      `,
 			exp:      true,
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	prommodel "github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"gopkg.in/yaml.v2"
 
+	"github.com/slok/sloth/internal/alert"
 	"github.com/slok/sloth/internal/info"
 	"github.com/slok/sloth/internal/log"
 )
@@ -36,6 +38,8 @@ type IOWriterGroupedRulesYAMLRepo struct {
 type StorageSLO struct {
 	SLO   SLO
 	Rules SLORules
+	// Alerts are the MWMB alerts the SLO rules were generated from, the SLI windows are based on these.
+	Alerts alert.MWMBAlertGroup
 }
 
 // StoreSLOs will store the recording and alert prometheus rules, if grouped is false it will
@@ -46,37 +50,340 @@ func (i IOWriterGroupedRulesYAMLRepo) StoreSLOs(ctx context.Context, slos []Stor
 		return fmt.Errorf("slo rules required")
 	}
 
-	ruleGroups := ruleGroupsYAMLv2{}
+	ruleGroups := ruleGroupsYAMLv2{Groups: getSLORuleGroups(slos)}
+
+	// If we don't have anything to store, error so we can increase the reliability
+	// because maybe this was due to an unintended error (typos, misconfig, too many disable...).
+	if len(ruleGroups.Groups) == 0 {
+		return ErrNoSLORules
+	}
+
+	// Convert to YAML (Prometheus rule format).
+	rulesYaml, err := yaml.Marshal(ruleGroups)
+	if err != nil {
+		return fmt.Errorf("could not format rules: %w", err)
+	}
+
+	rulesYaml = writeTopDisclaimer(rulesYaml)
+	_, err = i.writer.Write(rulesYaml)
+	if err != nil {
+		return fmt.Errorf("could not write top disclaimer: %w", err)
+	}
+
+	logger := i.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": len(ruleGroups.Groups)}).Infof("Prometheus rules written")
+
+	return nil
+}
+
+// getSLORuleGroups returns the rule groups of the SLOs, every SLO will have its SLI recordings,
+// metadata recordings and alerts split in different groups. The shared SLI recordings of all
+// the SLOs are on a single group.
+func getSLORuleGroups(slos []StorageSLO) []ruleGroupYAMLv2 {
+	groups := []ruleGroupYAMLv2{}
+
+	sharedRules := make([][]rulefmt.Rule, 0, len(slos))
+	for _, slo := range slos {
+		sharedRules = append(sharedRules, slo.Rules.SharedRecRules)
+	}
+	if shared := MergeSharedRecRules(sharedRules...); len(shared) > 0 {
+		groups = append(groups, ruleGroupYAMLv2{
+			Name:  SharedRecordingsGroupName,
+			Rules: shared,
+		})
+	}
+
 	for _, slo := range slos {
 		if len(slo.Rules.SLIErrorRecRules) > 0 {
-			ruleGroups.Groups = append(ruleGroups.Groups, ruleGroupYAMLv2{
-				Name:  fmt.Sprintf("sloth-slo-sli-recordings-%s", slo.SLO.ID),
+			groups = append(groups, ruleGroupYAMLv2{
+				Name:  slo.SLO.GetRuleGroupName(GroupKindSLIRecordings),
 				Rules: slo.Rules.SLIErrorRecRules,
 			})
 		}
 
 		if len(slo.Rules.MetadataRecRules) > 0 {
-			ruleGroups.Groups = append(ruleGroups.Groups, ruleGroupYAMLv2{
-				Name:  fmt.Sprintf("sloth-slo-meta-recordings-%s", slo.SLO.ID),
+			groups = append(groups, ruleGroupYAMLv2{
+				Name:  slo.SLO.GetRuleGroupName(GroupKindMetaRecordings),
 				Rules: slo.Rules.MetadataRecRules,
 			})
 		}
 
 		if len(slo.Rules.AlertRules) > 0 {
-			ruleGroups.Groups = append(ruleGroups.Groups, ruleGroupYAMLv2{
-				Name:  fmt.Sprintf("sloth-slo-alerts-%s", slo.SLO.ID),
+			groups = append(groups, ruleGroupYAMLv2{
+				Name:  slo.SLO.GetRuleGroupName(GroupKindAlerts),
+				Limit: slo.Rules.AlertsLimit,
 				Rules: slo.Rules.AlertRules,
 			})
 		}
 	}
 
+	return groups
+}
+
+func NewIOWriterRedactedRulesYAMLRepo(writer io.Writer, logger log.Logger) IOWriterRedactedRulesYAMLRepo {
+	return IOWriterRedactedRulesYAMLRepo{
+		writer: writer,
+		logger: logger.WithValues(log.Kv{"svc": "storage.IOWriter", "format": "redacted-yaml"}),
+	}
+}
+
+// IOWriterRedactedRulesYAMLRepo knows to store only the SLO redacted helper recording rules
+// grouped in an IOWriter in YAML format, that is compatible with Prometheus.
+//
+// These rules have the sensitive query fragments, so they are stored apart from the SLO rules.
+type IOWriterRedactedRulesYAMLRepo struct {
+	writer io.Writer
+	logger log.Logger
+}
+
+// StoreSLOs will store the redacted helper recording rules of the SLOs.
+func (i IOWriterRedactedRulesYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	groups := []ruleGroupYAMLv2{}
+	for _, slo := range slos {
+		if len(slo.Rules.RedactedRecRules) > 0 {
+			groups = append(groups, ruleGroupYAMLv2{
+				Name:  slo.SLO.GetRuleGroupName(GroupKindRedactedRecordings),
+				Rules: slo.Rules.RedactedRecRules,
+			})
+		}
+	}
+
+	if len(groups) == 0 {
+		return ErrNoSLORules
+	}
+
+	rulesYaml, err := yaml.Marshal(ruleGroupsYAMLv2{Groups: groups})
+	if err != nil {
+		return fmt.Errorf("could not format rules: %w", err)
+	}
+
+	rulesYaml = writeTopDisclaimer(rulesYaml)
+	_, err = i.writer.Write(rulesYaml)
+	if err != nil {
+		return fmt.Errorf("could not write redacted rules: %w", err)
+	}
+
+	logger := i.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": len(groups)}).Infof("Prometheus redacted rules written")
+
+	return nil
+}
+
+// NewIOWriterMergedRulesYAMLRepo returns a new IOWriterMergedRulesYAMLRepo.
+func NewIOWriterMergedRulesYAMLRepo(writer io.Writer, existing []byte, logger log.Logger) IOWriterMergedRulesYAMLRepo {
+	return IOWriterMergedRulesYAMLRepo{
+		writer:   writer,
+		existing: existing,
+		logger:   logger.WithValues(log.Kv{"svc": "storage.IOWriter", "format": "merged-yaml"}),
+	}
+}
+
+// IOWriterMergedRulesYAMLRepo knows to store all the SLO rules merged into an existing Prometheus
+// rules file, the Sloth rule groups of the stored SLOs are replaced and the rest of the groups (including
+// the Sloth groups of other SLOs) are left intact, so the groups of the deleted SLOs need to be removed manually.
+//
+// A group is owned by Sloth when all its rules have a Sloth marker label (`sloth_id`, `sloth_severity`
+// or `sloth_shared`).
+type IOWriterMergedRulesYAMLRepo struct {
+	writer   io.Writer
+	existing []byte
+	logger   log.Logger
+}
+
+// StoreSLOs will store the existing rule groups not owned by the SLOs followed by the SLO rule groups.
+func (i IOWriterMergedRulesYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slo rules required")
+	}
+
+	sloGroups := getSLORuleGroups(slos)
+	if len(sloGroups) == 0 {
+		return ErrNoSLORules
+	}
+
+	// Load the existing groups twice, raw to keep the non Sloth groups as they are, and
+	// as rules to check the groups ownership.
+	rawGroups := struct {
+		Groups []yaml.MapSlice `yaml:"groups"`
+	}{}
+	err := yaml.Unmarshal(i.existing, &rawGroups)
+	if err != nil {
+		return fmt.Errorf("could not load existing rules: %w", err)
+	}
+	existingGroups := ruleGroupsYAMLv2{}
+	err = yaml.Unmarshal(i.existing, &existingGroups)
+	if err != nil {
+		return fmt.Errorf("could not load existing rules: %w", err)
+	}
+
+	sloGroupNames := map[string]struct{}{}
+	for _, g := range sloGroups {
+		sloGroupNames[g.Name] = struct{}{}
+	}
+	sloIDs := map[string]struct{}{}
+	for _, slo := range slos {
+		sloIDs[slo.SLO.ID] = struct{}{}
+	}
+
+	groups := []interface{}{}
+	replaced := 0
+	for idx, g := range existingGroups.Groups {
+		_, generated := sloGroupNames[g.Name]
+		if !isSlothRuleGroup(g) {
+			if generated {
+				return fmt.Errorf("existing %q rule group is not owned by Sloth and has the same name as a generated group", g.Name)
+			}
+			groups = append(groups, rawGroups.Groups[idx])
+			continue
+		}
+
+		// The shared rules can be used by the SLOs that are not being generated (e.g only some SLOs
+		// generated), keep the existing ones along with the generated.
+		if g.Name == SharedRecordingsGroupName && generated {
+			sloGroups[0].Rules = MergeSharedRecRules(sloGroups[0].Rules, g.Rules)
+			replaced++
+			continue
+		}
+
+		// Only replace the groups of the generated SLOs, the rest of SLOs groups are kept.
+		if generated || ruleGroupHasSLOIDs(g, sloIDs) {
+			replaced++
+			continue
+		}
+
+		groups = append(groups, rawGroups.Groups[idx])
+	}
+	for _, g := range sloGroups {
+		groups = append(groups, g)
+	}
+
+	rulesYaml, err := yaml.Marshal(struct {
+		Groups []interface{} `yaml:"groups"`
+	}{Groups: groups})
+	if err != nil {
+		return fmt.Errorf("could not format rules: %w", err)
+	}
+
+	_, err = i.writer.Write(rulesYaml)
+	if err != nil {
+		return fmt.Errorf("could not write merged rules: %w", err)
+	}
+
+	logger := i.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": len(sloGroups), "replaced-groups": replaced, "kept-groups": len(groups) - len(sloGroups)}).Infof("Prometheus rules merged")
+
+	return nil
+}
+
+// isSlothRuleGroup returns true if all the group rules have been generated by Sloth.
+func isSlothRuleGroup(group ruleGroupYAMLv2) bool {
+	if len(group.Rules) == 0 {
+		return false
+	}
+
+	for _, r := range group.Rules {
+		_, hasID := r.Labels[sloIDLabelName]
+		_, hasSeverity := r.Labels[sloSeverityLabelName]
+		_, hasShared := r.Labels[sloSharedLabelName]
+		if !hasID && !hasSeverity && !hasShared {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ruleGroupHasSLOIDs returns true if any of the group rules belongs to one of the SLO IDs, using
+// the `sloth_id` label or, on the rules without it (e.g alerts), the `sloth_id` selectors of the expression.
+func ruleGroupHasSLOIDs(group ruleGroupYAMLv2, ids map[string]struct{}) bool {
+	for _, r := range group.Rules {
+		ruleIDs := []string{}
+		if id, ok := r.Labels[sloIDLabelName]; ok {
+			ruleIDs = append(ruleIDs, id)
+		} else {
+			ruleIDs = exprLabelValues(r.Expr, sloIDLabelName)
+		}
+
+		for _, id := range ruleIDs {
+			if _, ok := ids[id]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// VMAlertConfig is the configuration of the vmalert specific rule fields.
+type VMAlertConfig struct {
+	// Debug enables the vmalert debug mode on the alert rules.
+	Debug bool
+	// UpdateEntriesLimit sets the number of state updates vmalert stores per rule (0 uses vmalert default).
+	UpdateEntriesLimit int
+}
+
+func NewIOWriterGroupedRulesVMAlertYAMLRepo(writer io.Writer, config VMAlertConfig, logger log.Logger) IOWriterGroupedRulesVMAlertYAMLRepo {
+	return IOWriterGroupedRulesVMAlertYAMLRepo{
+		writer: writer,
+		config: config,
+		logger: logger.WithValues(log.Kv{"svc": "storage.IOWriter", "format": "vmalert-yaml"}),
+	}
+}
+
+// IOWriterGroupedRulesVMAlertYAMLRepo knows to store all the SLO rules (recordings and alerts)
+// grouped in an IOWriter in YAML format, that is compatible with VictoriaMetrics vmalert.
+//
+// The groups are set with `prometheus` type, so vmalert evaluates the expressions as PromQL
+// instead of MetricsQL, this way the generated queries have the same behavior as in Prometheus.
+type IOWriterGroupedRulesVMAlertYAMLRepo struct {
+	writer io.Writer
+	config VMAlertConfig
+	logger log.Logger
+}
+
+// StoreSLOs will store the recording and alert prometheus rules as vmalert rule groups.
+func (i IOWriterGroupedRulesVMAlertYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slo rules required")
+	}
+
+	ruleGroups := vmalertRuleGroupsYAML{}
+	for _, group := range getSLORuleGroups(slos) {
+		vmGroup := vmalertRuleGroupYAML{
+			Name:     group.Name,
+			Type:     vmalertGroupTypePrometheus,
+			Interval: group.Interval,
+			Limit:    group.Limit,
+		}
+
+		for _, r := range group.Rules {
+			vmRule := vmalertRuleYAML{
+				Record:      r.Record,
+				Alert:       r.Alert,
+				Expr:        r.Expr,
+				For:         r.For,
+				Labels:      r.Labels,
+				Annotations: r.Annotations,
+			}
+
+			// vmalert debug and state entries only apply to alerts.
+			if r.Alert != "" {
+				vmRule.Debug = i.config.Debug
+				vmRule.UpdateEntriesLimit = i.config.UpdateEntriesLimit
+			}
+
+			vmGroup.Rules = append(vmGroup.Rules, vmRule)
+		}
+
+		ruleGroups.Groups = append(ruleGroups.Groups, vmGroup)
+	}
+
 	// If we don't have anything to store, error so we can increase the reliability
 	// because maybe this was due to an unintended error (typos, misconfig, too many disable...).
 	if len(ruleGroups.Groups) == 0 {
 		return ErrNoSLORules
 	}
 
-	// Convert to YAML (Prometheus rule format).
 	rulesYaml, err := yaml.Marshal(ruleGroups)
 	if err != nil {
 		return fmt.Errorf("could not format rules: %w", err)
@@ -89,11 +396,314 @@ func (i IOWriterGroupedRulesYAMLRepo) StoreSLOs(ctx context.Context, slos []Stor
 	}
 
 	logger := i.logger.WithCtxValues(ctx)
-	logger.WithValues(log.Kv{"groups": len(ruleGroups.Groups)}).Infof("Prometheus rules written")
+	logger.WithValues(log.Kv{"groups": len(ruleGroups.Groups)}).Infof("vmalert rules written")
+
+	return nil
+}
+
+const (
+	// CortexNamespaceFromService uses the SLO service as the Cortex/Mimir rules namespace.
+	CortexNamespaceFromService = "service"
+	// CortexNamespaceFromName uses the SLO name as the Cortex/Mimir rules namespace.
+	CortexNamespaceFromName = "name"
+	// CortexNamespaceFromStatic uses a static value as the Cortex/Mimir rules namespace.
+	CortexNamespaceFromStatic = "static"
+)
+
+// CortexConfig is the configuration of the Cortex/Mimir ruler specific fields.
+type CortexConfig struct {
+	// NamespaceFrom is the source of the rules namespace (service, name or static).
+	NamespaceFrom string
+	// StaticNamespace is the namespace used when the namespace source is static.
+	StaticNamespace string
+}
+
+func (c CortexConfig) namespace(slo SLO) (string, error) {
+	switch c.NamespaceFrom {
+	case CortexNamespaceFromService, "":
+		return slo.Service, nil
+	case CortexNamespaceFromName:
+		return slo.Name, nil
+	case CortexNamespaceFromStatic:
+		if c.StaticNamespace == "" {
+			return "", fmt.Errorf("static namespace is required")
+		}
+		return c.StaticNamespace, nil
+	}
+
+	return "", fmt.Errorf("unknown %q namespace source", c.NamespaceFrom)
+}
+
+func NewIOWriterGroupedRulesCortexYAMLRepo(writer io.Writer, config CortexConfig, logger log.Logger) IOWriterGroupedRulesCortexYAMLRepo {
+	return IOWriterGroupedRulesCortexYAMLRepo{
+		writer: writer,
+		config: config,
+		logger: logger.WithValues(log.Kv{"svc": "storage.IOWriter", "format": "cortex-yaml"}),
+	}
+}
+
+// IOWriterGroupedRulesCortexYAMLRepo knows to store all the SLO rules (recordings and alerts)
+// grouped in an IOWriter in YAML format, that is compatible with Cortex/Mimir ruler API.
+//
+// The ruler API buckets the rule groups under a namespace and its tools load a namespace per
+// file, so all the SLOs need to be on the same namespace, to store multiple namespaces use
+// NamespacedFilesRulesYAMLRepo with the Cortex namespace.
+type IOWriterGroupedRulesCortexYAMLRepo struct {
+	writer io.Writer
+	config CortexConfig
+	logger log.Logger
+}
+
+// StoreSLOs will store the recording and alert prometheus rules as Cortex/Mimir namespaced rule groups.
+func (i IOWriterGroupedRulesCortexYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slo rules required")
+	}
+
+	namespace := ""
+	for _, slo := range slos {
+		ns, err := i.config.namespace(slo.SLO)
+		if err != nil {
+			return fmt.Errorf("could not get %q SLO namespace: %w", slo.SLO.ID, err)
+		}
+		if namespace != "" && ns != namespace {
+			return fmt.Errorf("%q and %q namespaces can't be stored on the same rules file, the namespaces need to be stored on different files", namespace, ns)
+		}
+		namespace = ns
+	}
+
+	groups := getSLORuleGroups(slos)
+
+	// If we don't have anything to store, error so we can increase the reliability
+	// because maybe this was due to an unintended error (typos, misconfig, too many disable...).
+	if len(groups) == 0 {
+		return ErrNoSLORules
+	}
+
+	rulesYaml, err := yaml.Marshal(cortexRuleNamespaceYAML{Namespace: namespace, Groups: groups})
+	if err != nil {
+		return fmt.Errorf("could not format rules: %w", err)
+	}
+
+	rulesYaml = writeTopDisclaimer(rulesYaml)
+	_, err = i.writer.Write(rulesYaml)
+	if err != nil {
+		return fmt.Errorf("could not write top disclaimer: %w", err)
+	}
+
+	logger := i.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": len(groups), "namespace": namespace}).Infof("Cortex rules written")
+
+	return nil
+}
+
+// FileCreator creates the files where the rules are written, the path is relative to the output root.
+type FileCreator func(ctx context.Context, path string) (io.Writer, error)
+
+func NewNamespacedFilesRulesYAMLRepo(create FileCreator, config CortexConfig, logger log.Logger) NamespacedFilesRulesYAMLRepo {
+	return NamespacedFilesRulesYAMLRepo{
+		create: create,
+		config: config,
+		logger: logger.WithValues(log.Kv{"svc": "storage.NamespacedFiles", "format": "ruler-files-yaml"}),
+	}
+}
+
+// NamespacedFilesRulesYAMLRepo knows to store all the SLO rules (recordings and alerts) grouped
+// in a Prometheus rules YAML file per namespace named `<namespace>.yaml`, the layout of the central
+// rulers that load the namespaces from rule files (e.g: Cortex/Mimir ruler local storage).
+type NamespacedFilesRulesYAMLRepo struct {
+	create          FileCreator
+	config          CortexConfig
+	cortexNamespace bool
+	logger          log.Logger
+}
+
+// WithCortexNamespace returns a copy of the repository that sets the file namespace on the
+// rules files, the Cortex/Mimir ruler API tools format (e.g: `mimirtool rules load`).
+func (n NamespacedFilesRulesYAMLRepo) WithCortexNamespace(enabled bool) NamespacedFilesRulesYAMLRepo {
+	n.cortexNamespace = enabled
+	return n
+}
+
+// StoreSLOs will store the recording and alert prometheus rules on the SLOs namespace files.
+func (n NamespacedFilesRulesYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slo rules required")
+	}
+
+	// Bucket the SLOs by namespace keeping the order.
+	namespaces := []string{}
+	namespaceSLOs := map[string][]StorageSLO{}
+	for _, slo := range slos {
+		ns, err := n.config.namespace(slo.SLO)
+		if err != nil {
+			return fmt.Errorf("could not get %q SLO namespace: %w", slo.SLO.ID, err)
+		}
+		if ns == "" || strings.ContainsAny(ns, `/\`) || ns == "." || ns == ".." {
+			return fmt.Errorf("%q SLO namespace %q can't be used as a file name", slo.SLO.ID, ns)
+		}
+		if _, ok := namespaceSLOs[ns]; !ok {
+			namespaces = append(namespaces, ns)
+		}
+		namespaceSLOs[ns] = append(namespaceSLOs[ns], slo)
+	}
+
+	totalGroups := 0
+	files := 0
+	for _, ns := range namespaces {
+		groups := getSLORuleGroups(namespaceSLOs[ns])
+		if len(groups) == 0 {
+			continue
+		}
+		totalGroups += len(groups)
+
+		var rules interface{} = ruleGroupsYAMLv2{Groups: groups}
+		if n.cortexNamespace {
+			rules = cortexRuleNamespaceYAML{Namespace: ns, Groups: groups}
+		}
+		rulesYaml, err := yaml.Marshal(rules)
+		if err != nil {
+			return fmt.Errorf("could not format rules: %w", err)
+		}
+
+		out, err := n.create(ctx, ns+".yaml")
+		if err != nil {
+			return fmt.Errorf("could not create %q namespace file: %w", ns, err)
+		}
+
+		_, err = out.Write(writeTopDisclaimer(rulesYaml))
+		if err != nil {
+			return fmt.Errorf("could not write %q namespace rules: %w", ns, err)
+		}
+		files++
+	}
+
+	// If we don't have anything to store, error so we can increase the reliability
+	// because maybe this was due to an unintended error (typos, misconfig, too many disable...).
+	if totalGroups == 0 {
+		return ErrNoSLORules
+	}
+
+	logger := n.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": totalGroups, "files": files}).Infof("Namespaced rule files written")
+
+	return nil
+}
+
+// Kind split rule files names.
+const (
+	KindSplitRecordingFile = "recording.yaml"
+	KindSplitAlertsFile    = "alerts.yaml"
+)
+
+func NewKindSplitFilesRulesYAMLRepo(create FileCreator, logger log.Logger) KindSplitFilesRulesYAMLRepo {
+	return KindSplitFilesRulesYAMLRepo{
+		create: create,
+		logger: logger.WithValues(log.Kv{"svc": "storage.KindSplitFiles", "format": "yaml"}),
+	}
+}
+
+// KindSplitFilesRulesYAMLRepo knows to store the SLO recording rules and the SLO alert rules in
+// different Prometheus rules YAML files (`recording.yaml` and `alerts.yaml`), so they can be
+// deployed to different rulers.
+type KindSplitFilesRulesYAMLRepo struct {
+	create FileCreator
+	logger log.Logger
+}
+
+// StoreSLOs will store the recording and the alert prometheus rules on their kind files, the
+// kinds without rules will not have a file.
+func (k KindSplitFilesRulesYAMLRepo) StoreSLOs(ctx context.Context, slos []StorageSLO) error {
+	if len(slos) == 0 {
+		return fmt.Errorf("slo rules required")
+	}
+
+	recordingSLOs := make([]StorageSLO, 0, len(slos))
+	alertSLOs := make([]StorageSLO, 0, len(slos))
+	for _, slo := range slos {
+		recordingSLOs = append(recordingSLOs, StorageSLO{SLO: slo.SLO, Rules: SLORules{
+			SLIErrorRecRules: slo.Rules.SLIErrorRecRules,
+			MetadataRecRules: slo.Rules.MetadataRecRules,
+			SharedRecRules:   slo.Rules.SharedRecRules,
+		}})
+		alertSLOs = append(alertSLOs, StorageSLO{SLO: slo.SLO, Rules: SLORules{
+			AlertRules:  slo.Rules.AlertRules,
+			AlertsLimit: slo.Rules.AlertsLimit,
+		}})
+	}
+
+	totalGroups := 0
+	for _, f := range []struct {
+		path string
+		slos []StorageSLO
+	}{
+		{path: KindSplitRecordingFile, slos: recordingSLOs},
+		{path: KindSplitAlertsFile, slos: alertSLOs},
+	} {
+		groups := getSLORuleGroups(f.slos)
+		if len(groups) == 0 {
+			continue
+		}
+		totalGroups += len(groups)
+
+		rulesYaml, err := yaml.Marshal(ruleGroupsYAMLv2{Groups: groups})
+		if err != nil {
+			return fmt.Errorf("could not format rules: %w", err)
+		}
+
+		out, err := k.create(ctx, f.path)
+		if err != nil {
+			return fmt.Errorf("could not create %q file: %w", f.path, err)
+		}
+
+		_, err = out.Write(writeTopDisclaimer(rulesYaml))
+		if err != nil {
+			return fmt.Errorf("could not write %q rules: %w", f.path, err)
+		}
+	}
+
+	// If we don't have anything to store, error so we can increase the reliability
+	// because maybe this was due to an unintended error (typos, misconfig, too many disable...).
+	if totalGroups == 0 {
+		return ErrNoSLORules
+	}
+
+	logger := k.logger.WithCtxValues(ctx)
+	logger.WithValues(log.Kv{"groups": totalGroups}).Infof("Kind split rule files written")
 
 	return nil
 }
 
+type cortexRuleNamespaceYAML struct {
+	Namespace string            `yaml:"namespace"`
+	Groups    []ruleGroupYAMLv2 `yaml:"groups"`
+}
+
+const vmalertGroupTypePrometheus = "prometheus"
+
+type vmalertRuleGroupsYAML struct {
+	Groups []vmalertRuleGroupYAML `yaml:"groups"`
+}
+
+type vmalertRuleGroupYAML struct {
+	Name     string             `yaml:"name"`
+	Type     string             `yaml:"type,omitempty"`
+	Interval prommodel.Duration `yaml:"interval,omitempty"`
+	Limit    int                `yaml:"limit,omitempty"`
+	Rules    []vmalertRuleYAML  `yaml:"rules"`
+}
+
+type vmalertRuleYAML struct {
+	Record             string             `yaml:"record,omitempty"`
+	Alert              string             `yaml:"alert,omitempty"`
+	Expr               string             `yaml:"expr"`
+	For                prommodel.Duration `yaml:"for,omitempty"`
+	Labels             map[string]string  `yaml:"labels,omitempty"`
+	Annotations        map[string]string  `yaml:"annotations,omitempty"`
+	Debug              bool               `yaml:"debug,omitempty"`
+	UpdateEntriesLimit int                `yaml:"update_entries_limit,omitempty"`
+}
+
 var disclaimer = fmt.Sprintf(`
 ---
 # Code generated by Sloth (%s): https://github.com/slok/sloth.
@@ -114,5 +724,6 @@ type ruleGroupsYAMLv2 struct {
 type ruleGroupYAMLv2 struct {
 	Name     string             `yaml:"name"`
 	Interval prommodel.Duration `yaml:"interval,omitempty"`
+	Limit    int                `yaml:"limit,omitempty"`
 	Rules    []rulefmt.Rule     `yaml:"rules"`
 }
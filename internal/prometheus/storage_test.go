@@ -3,6 +3,7 @@ package prometheus_test
 import (
 	"bytes"
 	"context"
+	"io"
 	"testing"
 
 	"github.com/prometheus/prometheus/model/rulefmt"
@@ -121,6 +122,97 @@ groups:
 `,
 		},
 
+		"Having an alerts limit should render the limit only on the alert rules group.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "test1"},
+					Rules: prometheus.SLORules{
+						MetadataRecRules: []rulefmt.Rule{
+							{
+								Record: "test:record",
+								Expr:   "test-expr",
+							},
+						},
+						AlertRules: []rulefmt.Rule{
+							{
+								Alert: "testAlert",
+								Expr:  "test-expr",
+							},
+						},
+						AlertsLimit: 10,
+					},
+				},
+			},
+			expYAML: `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-meta-recordings-test1
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: sloth-slo-alerts-test1
+  limit: 10
+  rules:
+  - alert: testAlert
+    expr: test-expr
+`,
+		},
+
+		"Having a group name template should name the rule groups with the template.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{
+						ID:                "test1",
+						Name:              "availability",
+						Service:           "svc1",
+						GroupNameTemplate: "slo-{{ .Service }}-{{ .Name }}-{{ .Kind }}",
+					},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{
+							{
+								Record: "test:record-sli",
+								Expr:   "test-expr",
+							},
+						},
+						MetadataRecRules: []rulefmt.Rule{
+							{
+								Record: "test:record",
+								Expr:   "test-expr",
+							},
+						},
+						AlertRules: []rulefmt.Rule{
+							{
+								Alert: "testAlert",
+								Expr:  "test-expr",
+							},
+						},
+					},
+				},
+			},
+			expYAML: `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: slo-svc1-availability-sli-recordings
+  rules:
+  - record: test:record-sli
+    expr: test-expr
+- name: slo-svc1-availability-meta-recordings
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: slo-svc1-availability-alerts
+  rules:
+  - alert: testAlert
+    expr: test-expr
+`,
+		},
+
 		"Having a multiple SLO alert and recording rules should render correctly.": {
 			slos: []prometheus.StorageSLO{
 				{
@@ -274,3 +366,892 @@ groups:
 		})
 	}
 }
+
+func TestIOWriterMergedRulesYAMLRepoStore(t *testing.T) {
+	slos := []prometheus.StorageSLO{
+		{
+			SLO: prometheus.SLO{ID: "test1"},
+			Rules: prometheus.SLORules{
+				SLIErrorRecRules: []rulefmt.Rule{
+					{
+						Record: "slo:sli_error:ratio_rate5m",
+						Expr:   "new-expr",
+						Labels: map[string]string{"sloth_id": "test1"},
+					},
+				},
+				AlertRules: []rulefmt.Rule{
+					{
+						Alert:  "testAlert",
+						Expr:   "new-alert-expr",
+						Labels: map[string]string{"sloth_severity": "page"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		existing string
+		slos     []prometheus.StorageSLO
+		expYAML  string
+		expErr   bool
+	}{
+		"Having 0 SLO rules should fail.": {
+			existing: "",
+			slos:     []prometheus.StorageSLO{},
+			expErr:   true,
+		},
+
+		"Having an empty existing file should store the SLO rules.": {
+			existing: "",
+			slos:     slos,
+			expYAML: `groups:
+- name: sloth-slo-sli-recordings-test1
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: new-expr
+    labels:
+      sloth_id: test1
+- name: sloth-slo-alerts-test1
+  rules:
+  - alert: testAlert
+    expr: new-alert-expr
+    labels:
+      sloth_severity: page
+`,
+		},
+
+		"Having an existing file with Sloth and manual groups should replace only the generated SLOs Sloth groups.": {
+			existing: `
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+groups:
+- name: manual-recordings
+  interval: 1m
+  rules:
+  - record: job:up:sum
+    expr: sum(up) by (job)
+- name: sloth-slo-sli-recordings-test1
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: old-expr
+    labels:
+      sloth_id: test1
+- name: sloth-slo-alerts-removed
+  rules:
+  - alert: removedAlert
+    expr: old-alert-expr
+    labels:
+      sloth_severity: ticket
+- name: manual-alerts
+  rules:
+  - alert: InstanceDown
+    expr: up == 0
+    keep_firing_for: 5m
+    labels:
+      severity: page
+  - record: sloth:mixed
+    expr: up
+    labels:
+      sloth_id: test1
+`,
+			slos: slos,
+			expYAML: `groups:
+- name: manual-recordings
+  interval: 1m
+  rules:
+  - record: job:up:sum
+    expr: sum(up) by (job)
+- name: sloth-slo-alerts-removed
+  rules:
+  - alert: removedAlert
+    expr: old-alert-expr
+    labels:
+      sloth_severity: ticket
+- name: manual-alerts
+  rules:
+  - alert: InstanceDown
+    expr: up == 0
+    keep_firing_for: 5m
+    labels:
+      severity: page
+  - record: sloth:mixed
+    expr: up
+    labels:
+      sloth_id: test1
+- name: sloth-slo-sli-recordings-test1
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: new-expr
+    labels:
+      sloth_id: test1
+- name: sloth-slo-alerts-test1
+  rules:
+  - alert: testAlert
+    expr: new-alert-expr
+    labels:
+      sloth_severity: page
+`,
+		},
+
+		"Having shared SLI rules should replace the existing shared rules group.": {
+			existing: `
+groups:
+- name: manual-recordings
+  rules:
+  - record: job:up:sum
+    expr: sum(up) by (job)
+- name: sloth-slo-shared-recordings
+  rules:
+  - record: slo:shared:aaaaaaaaaaaa_rate5m
+    expr: old-shared-expr
+    labels:
+      sloth_shared: "true"
+`,
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "test1"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "slo:sli_error:ratio_rate5m", Expr: "new-expr", Labels: map[string]string{"sloth_id": "test1"}}},
+						SharedRecRules:   []rulefmt.Rule{{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: "new-shared-expr", Labels: map[string]string{"sloth_shared": "true"}}},
+					},
+				},
+				{
+					SLO: prometheus.SLO{ID: "test2"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "slo:sli_error:ratio_rate5m", Expr: "new-expr", Labels: map[string]string{"sloth_id": "test2"}}},
+						SharedRecRules:   []rulefmt.Rule{{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: "new-shared-expr", Labels: map[string]string{"sloth_shared": "true"}}},
+					},
+				},
+			},
+			expYAML: `groups:
+- name: manual-recordings
+  rules:
+  - record: job:up:sum
+    expr: sum(up) by (job)
+- name: sloth-slo-shared-recordings
+  rules:
+  - record: slo:shared:aaaaaaaaaaaa_rate5m
+    expr: new-shared-expr
+    labels:
+      sloth_shared: "true"
+- name: sloth-slo-sli-recordings-test1
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: new-expr
+    labels:
+      sloth_id: test1
+- name: sloth-slo-sli-recordings-test2
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: new-expr
+    labels:
+      sloth_id: test2
+`,
+		},
+
+		"Having a subset of the SLOs should keep the existing groups of the other SLOs and their shared rules.": {
+			existing: `
+groups:
+- name: sloth-slo-shared-recordings
+  rules:
+  - record: slo:shared:aaaaaaaaaaaa_rate5m
+    expr: old-shared-expr
+    labels:
+      sloth_shared: "true"
+  - record: slo:shared:bbbbbbbbbbbb_rate5m
+    expr: other-shared-expr
+    labels:
+      sloth_shared: "true"
+- name: sloth-slo-sli-recordings-test1
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: old-expr
+    labels:
+      sloth_id: test1
+- name: sloth-slo-alerts-test1-old
+  rules:
+  - alert: oldAlert
+    expr: slo:sli_error:ratio_rate5m{sloth_id="test1"} > 0.1
+    labels:
+      sloth_severity: page
+- name: sloth-slo-sli-recordings-test2
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: other-expr
+    labels:
+      sloth_id: test2
+- name: sloth-slo-alerts-test2
+  rules:
+  - alert: otherAlert
+    expr: slo:sli_error:ratio_rate5m{sloth_id="test2"} > 0.1
+    labels:
+      sloth_severity: page
+`,
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "test1"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "slo:sli_error:ratio_rate5m", Expr: "new-expr", Labels: map[string]string{"sloth_id": "test1"}}},
+						SharedRecRules:   []rulefmt.Rule{{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: "new-shared-expr", Labels: map[string]string{"sloth_shared": "true"}}},
+					},
+				},
+			},
+			expYAML: `groups:
+- name: sloth-slo-sli-recordings-test2
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: other-expr
+    labels:
+      sloth_id: test2
+- name: sloth-slo-alerts-test2
+  rules:
+  - alert: otherAlert
+    expr: slo:sli_error:ratio_rate5m{sloth_id="test2"} > 0.1
+    labels:
+      sloth_severity: page
+- name: sloth-slo-shared-recordings
+  rules:
+  - record: slo:shared:aaaaaaaaaaaa_rate5m
+    expr: new-shared-expr
+    labels:
+      sloth_shared: "true"
+  - record: slo:shared:bbbbbbbbbbbb_rate5m
+    expr: other-shared-expr
+    labels:
+      sloth_shared: "true"
+- name: sloth-slo-sli-recordings-test1
+  rules:
+  - record: slo:sli_error:ratio_rate5m
+    expr: new-expr
+    labels:
+      sloth_id: test1
+`,
+		},
+
+		"Having an existing manual group with the same name as a generated group should fail.": {
+			existing: `
+groups:
+- name: sloth-slo-alerts-test1
+  rules:
+  - alert: InstanceDown
+    expr: up == 0
+`,
+			slos:   slos,
+			expErr: true,
+		},
+
+		"Having an invalid existing file should fail.": {
+			existing: "groups: {",
+			slos:     slos,
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotYAML bytes.Buffer
+			repo := prometheus.NewIOWriterMergedRulesYAMLRepo(&gotYAML, []byte(test.existing), log.Noop)
+			err := repo.StoreSLOs(context.TODO(), test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expYAML, gotYAML.String())
+			}
+		})
+	}
+}
+
+func TestIOWriterGroupedRulesVMAlertYAMLRepoStore(t *testing.T) {
+	tests := map[string]struct {
+		config  prometheus.VMAlertConfig
+		slos    []prometheus.StorageSLO
+		expYAML string
+		expErr  bool
+	}{
+		"Having 0 SLO rules should fail.": {
+			slos:   []prometheus.StorageSLO{},
+			expErr: true,
+		},
+
+		"Having 0 SLO rules generated should fail.": {
+			slos: []prometheus.StorageSLO{
+				{},
+			},
+			expErr: true,
+		},
+
+		"Having SLO rules should render vmalert prometheus type groups.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "test1"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{
+							{
+								Record: "test:record",
+								Expr:   "test-expr",
+								Labels: map[string]string{"test-label": "one"},
+							},
+						},
+						AlertRules: []rulefmt.Rule{
+							{
+								Alert:       "testAlert",
+								Expr:        "test-expr",
+								Labels:      map[string]string{"test-label": "one"},
+								Annotations: map[string]string{"test-annot": "one"},
+							},
+						},
+					},
+				},
+			},
+			expYAML: `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-sli-recordings-test1
+  type: prometheus
+  rules:
+  - record: test:record
+    expr: test-expr
+    labels:
+      test-label: one
+- name: sloth-slo-alerts-test1
+  type: prometheus
+  rules:
+  - alert: testAlert
+    expr: test-expr
+    labels:
+      test-label: one
+    annotations:
+      test-annot: one
+`,
+		},
+
+		"Having vmalert options should only render them on the alert rules and groups.": {
+			config: prometheus.VMAlertConfig{
+				Debug:              true,
+				UpdateEntriesLimit: 5,
+			},
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "test1"},
+					Rules: prometheus.SLORules{
+						MetadataRecRules: []rulefmt.Rule{
+							{
+								Record: "test:record",
+								Expr:   "test-expr",
+							},
+						},
+						AlertRules: []rulefmt.Rule{
+							{
+								Alert: "testAlert",
+								Expr:  "test-expr",
+							},
+						},
+						AlertsLimit: 10,
+					},
+				},
+			},
+			expYAML: `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-meta-recordings-test1
+  type: prometheus
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: sloth-slo-alerts-test1
+  type: prometheus
+  limit: 10
+  rules:
+  - alert: testAlert
+    expr: test-expr
+    debug: true
+    update_entries_limit: 5
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotYAML bytes.Buffer
+			repo := prometheus.NewIOWriterGroupedRulesVMAlertYAMLRepo(&gotYAML, test.config, log.Noop)
+			err := repo.StoreSLOs(context.TODO(), test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expYAML, gotYAML.String())
+			}
+		})
+	}
+}
+
+func TestIOWriterGroupedRulesCortexYAMLRepoStore(t *testing.T) {
+	getSLOs := func() []prometheus.StorageSLO {
+		return []prometheus.StorageSLO{
+			{
+				SLO: prometheus.SLO{ID: "svc1-slo1", Name: "slo1", Service: "svc1"},
+				Rules: prometheus.SLORules{
+					SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+				},
+			},
+			{
+				SLO: prometheus.SLO{ID: "svc2-slo2", Name: "slo2", Service: "svc2"},
+				Rules: prometheus.SLORules{
+					AlertRules: []rulefmt.Rule{{Alert: "testAlert", Expr: "test-expr"}},
+				},
+			},
+			{
+				SLO: prometheus.SLO{ID: "svc1-slo3", Name: "slo3", Service: "svc1"},
+				Rules: prometheus.SLORules{
+					MetadataRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		config  prometheus.CortexConfig
+		slos    []prometheus.StorageSLO
+		expYAML string
+		expErr  bool
+	}{
+		"Having 0 SLO rules should fail.": {
+			slos:   []prometheus.StorageSLO{},
+			expErr: true,
+		},
+
+		"Having 0 SLO rules generated should fail.": {
+			slos:   []prometheus.StorageSLO{{SLO: prometheus.SLO{Service: "svc1"}}},
+			expErr: true,
+		},
+
+		"Having an unknown namespace source should fail.": {
+			config: prometheus.CortexConfig{NamespaceFrom: "unknown"},
+			slos:   getSLOs(),
+			expErr: true,
+		},
+
+		"Having a static namespace source without namespace should fail.": {
+			config: prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromStatic},
+			slos:   getSLOs(),
+			expErr: true,
+		},
+
+		"Having the service namespace source with multiple SLO services should fail.": {
+			config: prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromService},
+			slos:   getSLOs(),
+			expErr: true,
+		},
+
+		"Having the name namespace source with multiple SLO names should fail.": {
+			config: prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromName},
+			slos:   getSLOs(),
+			expErr: true,
+		},
+
+		"Having the service namespace source with a single SLO service, should store the rule groups on the service namespace.": {
+			config: prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromService},
+			slos:   []prometheus.StorageSLO{getSLOs()[0], getSLOs()[2]},
+			expYAML: `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+namespace: svc1
+groups:
+- name: sloth-slo-sli-recordings-svc1-slo1
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: sloth-slo-meta-recordings-svc1-slo3
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+		},
+
+		"Having the static namespace source, should store all the rule groups on the same namespace.": {
+			config: prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromStatic, StaticNamespace: "slos"},
+			slos:   getSLOs(),
+			expYAML: `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+namespace: slos
+groups:
+- name: sloth-slo-sli-recordings-svc1-slo1
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: sloth-slo-alerts-svc2-slo2
+  rules:
+  - alert: testAlert
+    expr: test-expr
+- name: sloth-slo-meta-recordings-svc1-slo3
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotYAML bytes.Buffer
+			repo := prometheus.NewIOWriterGroupedRulesCortexYAMLRepo(&gotYAML, test.config, log.Noop)
+			err := repo.StoreSLOs(context.TODO(), test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expYAML, gotYAML.String())
+			}
+		})
+	}
+}
+
+func TestNamespacedFilesRulesYAMLRepoStore(t *testing.T) {
+	getSLOs := func() []prometheus.StorageSLO {
+		return []prometheus.StorageSLO{
+			{
+				SLO: prometheus.SLO{ID: "svc1-slo1", Name: "slo1", Service: "svc1"},
+				Rules: prometheus.SLORules{
+					SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+				},
+			},
+			{
+				SLO: prometheus.SLO{ID: "svc2-slo2", Name: "slo2", Service: "svc2"},
+				Rules: prometheus.SLORules{
+					AlertRules: []rulefmt.Rule{{Alert: "testAlert", Expr: "test-expr"}},
+				},
+			},
+			{
+				SLO: prometheus.SLO{ID: "svc1-slo3", Name: "slo3", Service: "svc1"},
+				Rules: prometheus.SLORules{
+					MetadataRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		config          prometheus.CortexConfig
+		cortexNamespace bool
+		slos            []prometheus.StorageSLO
+		expFiles        map[string]string
+		expErr          bool
+	}{
+		"Having 0 SLO rules should fail.": {
+			slos:   []prometheus.StorageSLO{},
+			expErr: true,
+		},
+
+		"Having 0 SLO rules generated should fail.": {
+			slos:   []prometheus.StorageSLO{{SLO: prometheus.SLO{Service: "svc1"}}},
+			expErr: true,
+		},
+
+		"Having a namespace that is not a valid file name should fail.": {
+			config: prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromStatic, StaticNamespace: "team/slos"},
+			slos:   getSLOs(),
+			expErr: true,
+		},
+
+		"Having the service namespace source, should write a rules file per SLO service.": {
+			slos: getSLOs(),
+			expFiles: map[string]string{
+				"svc1.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-sli-recordings-svc1-slo1
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: sloth-slo-meta-recordings-svc1-slo3
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+				"svc2.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-alerts-svc2-slo2
+  rules:
+  - alert: testAlert
+    expr: test-expr
+`,
+			},
+		},
+
+		"Having the Cortex namespace, should write a Cortex rules namespace file per SLO service.": {
+			cortexNamespace: true,
+			slos:            getSLOs(),
+			expFiles: map[string]string{
+				"svc1.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+namespace: svc1
+groups:
+- name: sloth-slo-sli-recordings-svc1-slo1
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: sloth-slo-meta-recordings-svc1-slo3
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+				"svc2.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+namespace: svc2
+groups:
+- name: sloth-slo-alerts-svc2-slo2
+  rules:
+  - alert: testAlert
+    expr: test-expr
+`,
+			},
+		},
+
+		"Having shared SLI rules, should write the shared rules used by the SLOs of every file.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "svc1-slo1", Name: "slo1", Service: "svc1"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+						SharedRecRules:   []rulefmt.Rule{{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: "test-shared-expr"}},
+					},
+				},
+				{
+					SLO: prometheus.SLO{ID: "svc2-slo2", Name: "slo2", Service: "svc2"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+						SharedRecRules:   []rulefmt.Rule{{Record: "slo:shared:aaaaaaaaaaaa_rate5m", Expr: "test-shared-expr"}},
+					},
+				},
+			},
+			expFiles: map[string]string{
+				"svc1.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-shared-recordings
+  rules:
+  - record: slo:shared:aaaaaaaaaaaa_rate5m
+    expr: test-shared-expr
+- name: sloth-slo-sli-recordings-svc1-slo1
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+				"svc2.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-shared-recordings
+  rules:
+  - record: slo:shared:aaaaaaaaaaaa_rate5m
+    expr: test-shared-expr
+- name: sloth-slo-sli-recordings-svc2-slo2
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+			},
+		},
+
+		"Having the static namespace source, should write all the rules in the same file.": {
+			config: prometheus.CortexConfig{NamespaceFrom: prometheus.CortexNamespaceFromStatic, StaticNamespace: "slos"},
+			slos:   getSLOs()[1:],
+			expFiles: map[string]string{
+				"slos.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-alerts-svc2-slo2
+  rules:
+  - alert: testAlert
+    expr: test-expr
+- name: sloth-slo-meta-recordings-svc1-slo3
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			files := map[string]*bytes.Buffer{}
+			create := func(_ context.Context, path string) (io.Writer, error) {
+				files[path] = &bytes.Buffer{}
+				return files[path], nil
+			}
+			repo := prometheus.NewNamespacedFilesRulesYAMLRepo(create, test.config, log.Noop).WithCortexNamespace(test.cortexNamespace)
+			err := repo.StoreSLOs(context.TODO(), test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				gotFiles := map[string]string{}
+				for path, b := range files {
+					gotFiles[path] = b.String()
+				}
+				assert.Equal(test.expFiles, gotFiles)
+			}
+		})
+	}
+}
+
+func TestKindSplitFilesRulesYAMLRepoStore(t *testing.T) {
+	tests := map[string]struct {
+		slos     []prometheus.StorageSLO
+		expFiles map[string]string
+		expErr   bool
+	}{
+		"Having 0 SLO rules should fail.": {
+			slos:   []prometheus.StorageSLO{},
+			expErr: true,
+		},
+
+		"Having 0 SLO rules generated should fail.": {
+			slos:   []prometheus.StorageSLO{{SLO: prometheus.SLO{ID: "svc1-slo1"}}},
+			expErr: true,
+		},
+
+		"Having recording and alert rules, should write the recordings and the alerts in different files.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "svc1-slo1", Name: "slo1", Service: "svc1"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+						MetadataRecRules: []rulefmt.Rule{{Record: "test:record2", Expr: "test-expr2"}},
+						AlertRules:       []rulefmt.Rule{{Alert: "testAlert", Expr: "test-expr"}},
+						AlertsLimit:      5,
+					},
+				},
+				{
+					SLO: prometheus.SLO{ID: "svc1-slo2", Name: "slo2", Service: "svc1"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+					},
+				},
+			},
+			expFiles: map[string]string{
+				"recording.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-sli-recordings-svc1-slo1
+  rules:
+  - record: test:record
+    expr: test-expr
+- name: sloth-slo-meta-recordings-svc1-slo1
+  rules:
+  - record: test:record2
+    expr: test-expr2
+- name: sloth-slo-sli-recordings-svc1-slo2
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+				"alerts.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-alerts-svc1-slo1
+  limit: 5
+  rules:
+  - alert: testAlert
+    expr: test-expr
+`,
+			},
+		},
+
+		"Having only recording rules, should write only the recordings file.": {
+			slos: []prometheus.StorageSLO{
+				{
+					SLO: prometheus.SLO{ID: "svc1-slo1", Name: "slo1", Service: "svc1"},
+					Rules: prometheus.SLORules{
+						SLIErrorRecRules: []rulefmt.Rule{{Record: "test:record", Expr: "test-expr"}},
+					},
+				},
+			},
+			expFiles: map[string]string{
+				"recording.yaml": `
+---
+# Code generated by Sloth (dev): https://github.com/slok/sloth.
+# DO NOT EDIT.
+
+groups:
+- name: sloth-slo-sli-recordings-svc1-slo1
+  rules:
+  - record: test:record
+    expr: test-expr
+`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			files := map[string]*bytes.Buffer{}
+			create := func(_ context.Context, path string) (io.Writer, error) {
+				files[path] = &bytes.Buffer{}
+				return files[path], nil
+			}
+			repo := prometheus.NewKindSplitFilesRulesYAMLRepo(create, log.Noop)
+			err := repo.StoreSLOs(context.TODO(), test.slos)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				gotFiles := map[string]string{}
+				for path, b := range files {
+					gotFiles[path] = b.String()
+				}
+				assert.Equal(test.expFiles, gotFiles)
+			}
+		})
+	}
+}
@@ -182,7 +182,8 @@ type Alerting struct {
 // Alert configures specific SLO alert.
 type Alert struct {
 	// Disable disables the alert and makes Sloth not generating this alert. This
-	// can be helpful for example to disable ticket(warning) alerts.
+	// can be helpful for example to disable ticket(warning) alerts. The page and ticket
+	// alerts are disabled independently, per SLO.
 	Disable bool `json:"disable,omitempty"`
 
 	// Labels are the Prometheus labels for the specific alert. For example can be
@@ -23,6 +23,10 @@ type Spec struct {
 	Page PageWindow `yaml:"page"`
 	// Ticket represents the configuration for the ticket alerting windows.
 	Ticket TicketWindow `yaml:"ticket"`
+	// Scalable allows using the windows on the SLO periods without windows, scaling the
+	// windows proportionally to the SLO period (e.g: 7d SLO period windows are 7/30 of the
+	// 30d ones). Only one scalable windows can be loaded.
+	Scalable bool `yaml:"scalable,omitempty"`
 }
 
 // PageWindow represents the configuration for page alerting.
@@ -54,6 +54,15 @@
 //	          disable: true
 package v1
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	prometheusmodel "github.com/prometheus/common/model"
+)
+
 const Version = "prometheus/v1"
 
 //go:generate gomarkdoc -o ./README.md ./
@@ -74,12 +83,23 @@ type Spec struct {
 // SLO is the configuration/declaration of the service level objective of
 // a service.
 type SLO struct {
+	// ID is optional and overrides the generated SLO ID (`{service}-{name}`), set it to keep
+	// the SLO identity (e.g the `sloth_id` label) when the SLO is renamed.
+	ID string `yaml:"id,omitempty"`
 	// Name is the name of the SLO.
 	Name string `yaml:"name"`
 	// Description is the description of the SLO.
 	Description string `yaml:"description,omitempty"`
-	// Objective is target of the SLO the percentage (0, 100] (e.g 99.9).
-	Objective float64 `yaml:"objective"`
+	// Objective is target of the SLO the percentage (0, 100] (e.g 99.9), it can also be
+	// set using the nines shorthand (e.g 3nines, 4.5nines).
+	Objective Objective `yaml:"objective,omitempty"`
+	// AllowedDowntime is an alternative to the objective, it's the max bad time allowed on the
+	// SLO time window (e.g 43m per 30d), the objective percentage will be derived from it.
+	// Objective and AllowedDowntime are mutually exclusive.
+	AllowedDowntime prometheusmodel.Duration `yaml:"allowedDowntime,omitempty"`
+	// TimeWindow is the SLO time window (e.g 7d, 30d), this is optional and overrides the
+	// default SLO period only for this SLO. Needs to be one of the SLO periods of the windows catalog.
+	TimeWindow prometheusmodel.Duration `yaml:"timeWindow,omitempty"`
 	// Labels are the Prometheus labels that will have all the recording and
 	// alerting rules for this specific SLO. These labels are merged with the
 	// previous level labels.
@@ -91,6 +111,55 @@ type SLO struct {
 	Alerting Alerting `yaml:"alerting"`
 }
 
+// Objective is the SLO objective percentage, apart from the percentage it accepts the
+// nines shorthand (`<n>nines`), where `3nines` is 99.9 and half nines add a 5 to the nines,
+// so `4.5nines` is 99.995.
+type Objective float64
+
+// UnmarshalYAML satisfies yaml.Unmarshaler interface.
+func (o *Objective) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	nines, ok := strings.CutSuffix(s, "nines")
+	if !ok {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid objective %q: %w", s, err)
+		}
+		*o = Objective(f)
+		return nil
+	}
+
+	n, err := strconv.ParseFloat(nines, 64)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid objective %q nines", s)
+	}
+
+	// Only full and half nines are allowed.
+	full, frac := math.Modf(n)
+	var budget float64
+	switch frac {
+	case 0:
+		budget = math.Pow(10, 2-full)
+	case 0.5:
+		budget = 5 * math.Pow(10, 1-full)
+	default:
+		return fmt.Errorf("invalid objective %q nines, only full or half nines are supported", s)
+	}
+
+	// Round to get the same value as the percentage literal (e.g 99.9).
+	f, err := strconv.ParseFloat(strconv.FormatFloat(100-budget, 'f', 10, 64), 64)
+	if err != nil {
+		return fmt.Errorf("invalid objective %q: %w", s, err)
+	}
+	*o = Objective(f)
+
+	return nil
+}
+
 // SLI will tell what is good or bad for the SLO.
 // All SLIs will be get based on time windows, that's why Sloth needs the queries to
 // use `{{.window}}` template variable.
@@ -105,13 +174,36 @@ type SLI struct {
 	Plugin *SLIPlugin `yaml:"plugin,omitempty"`
 	// DenominatorCorrected is the denominator corrected events SLI type.
 	DenominatorCorrected *SLIDenominatorCorrected `yaml:"denominator_corrected,omitempty"`
+	// Precomputed is the precomputed recording rules SLI type.
+	Precomputed *SLIPrecomputed `yaml:"precomputed,omitempty"`
+	// LogQL is the Loki logs based SLI type.
+	LogQL *SLILogQL `yaml:"logql,omitempty"`
+	// Freshness is the age histogram based freshness SLI type.
+	Freshness *SLIFreshness `yaml:"freshness,omitempty"`
+	// Invert is optional and inverts the SLI queries semantics, the queries measure the good
+	// events (e.g the events queries error query selects the good events, a raw ratio is `1` when
+	// healthy), and Sloth will use the complement (`1 - ratio`) as the SLI error ratio.
+	Invert bool `yaml:"invert,omitempty"`
+	// MaintenanceGate is optional and excludes the maintenance periods from the SLI.
+	MaintenanceGate *SLIMaintenanceGate `yaml:"maintenance_gate,omitempty"`
+	// DependsOn is optional and is the ID (`{service}-{name}`) of the spec SLO whose
+	// Sloth generated recording rules are used by this SLI queries. The SLI recording
+	// rules will be generated on the same rule group after the dependency ones, so
+	// they are evaluated in order.
+	DependsOn string `yaml:"depends_on,omitempty"`
 }
 
 // SLIRaw is a error ratio SLI already calculated. Normally this will be used when the SLI
 // is already calculated by other recording rule, system...
 type SLIRaw struct {
 	// ErrorRatioQuery is a Prometheus query that will get the raw error ratio (0-1) for the SLO.
-	ErrorRatioQuery string `yaml:"error_ratio_query"`
+	ErrorRatioQuery string `yaml:"error_ratio_query,omitempty"`
+	// ErrorQuery is an alternative to ErrorRatioQuery, it's a Prometheus query that will be
+	// divided by TotalQuery to get the error ratio. Requires both queries to be set and can be
+	// any expression (e.g `total - good`), the division is grouped correctly.
+	ErrorQuery string `yaml:"error_query,omitempty"`
+	// TotalQuery is the divisor Prometheus query of ErrorQuery.
+	TotalQuery string `yaml:"total_query,omitempty"`
 }
 
 // SLIEvents is an SLI that is calculated as the division of bad events and total events, giving
@@ -125,6 +217,65 @@ type SLIEvents struct {
 	// for the SLO (e.g "all http requests"...).
 	// Requires the usage of `{{.window}}` template variable.
 	TotalQuery string `yaml:"total_query"`
+	// Mode is the kind of metrics used by the queries, by default `counter`.
+	// With `gauge` mode, the queries are gauge based (e.g "queue depth") and don't use the
+	// `{{.window}}` template variable, Sloth will average them over the time windows.
+	Mode string `yaml:"mode,omitempty"`
+}
+
+// SLIPrecomputed is an SLI that is calculated as the division of bad events and total events
+// rates, that have already been precomputed by other recording rules. Because the rates are
+// already calculated, the queries don't use the `{{.window}}` template variable, Sloth will
+// aggregate these over the required time windows. All the selected series rates are summed into
+// a single SLO ratio (their labels are dropped), use the selectors to scope the SLO series.
+type SLIPrecomputed struct {
+	// ErrorMetric is a Prometheus vector selector that will get a precomputed recording rule with the
+	// rate of events that we consider that are bad for the SLO (e.g `job:http_requests_5xx:rate5m`).
+	// Aggregations and functions are not allowed, the selector is averaged over the time windows.
+	ErrorMetric string `yaml:"error_metric"`
+	// TotalMetric is a Prometheus vector selector that will get a precomputed recording rule with the
+	// rate of total events for the SLO (e.g `job:http_requests:rate5m{job="api"}`).
+	TotalMetric string `yaml:"total_metric"`
+}
+
+// SLILogQL is an SLI that is calculated as the division of bad events and total events
+// that are on Loki logs. The LogQL queries are recorded by Loki recording rules (Loki ruler
+// remote writing to Prometheus), and the SLI uses the recorded metrics.
+type SLILogQL struct {
+	// ErrorQuery is a LogQL metric query that will get the number/count of events
+	// that we consider that are bad for the SLO (e.g "error log lines").
+	// Requires the usage of `{{.window}}` template variable.
+	ErrorQuery string `yaml:"error_query"`
+	// TotalQuery is a LogQL metric query that will get the total number/count of events
+	// for the SLO (e.g "all log lines").
+	// Requires the usage of `{{.window}}` template variable.
+	TotalQuery string `yaml:"total_query"`
+}
+
+// SLIFreshness is an SLI that is calculated from an age histogram (e.g the time since the
+// records arrival until they are processed), the events older than the freshness threshold
+// are the bad events (e.g "95% of records processed within 5 minutes of arrival"). Sloth will
+// generate the histogram buckets based events queries.
+type SLIFreshness struct {
+	// HistogramMetric is the age Prometheus histogram metric name in seconds, with or without
+	// the `_bucket` suffix (e.g `records_processing_age_seconds_bucket`).
+	HistogramMetric string `yaml:"histogram_metric"`
+	// Filter is an optional Prometheus label filter for the histogram metric
+	// (e.g `job="processor",env="prod"`).
+	Filter string `yaml:"filter,omitempty"`
+	// Threshold is the max age an event can have to be fresh (e.g 5m), it needs to be one of
+	// the histogram bucket `le` boundaries.
+	Threshold prometheusmodel.Duration `yaml:"threshold"`
+}
+
+// SLIMaintenanceGate will exclude the SLI while the gate query returns data (e.g: on planned
+// maintenance periods), so the errors in these periods don't count for the SLO. The SLI
+// is excluded using an `unless on()` clause with the gate query.
+type SLIMaintenanceGate struct {
+	// Query is a Prometheus query that will return data while on maintenance periods.
+	// Can use the `{{.window}}` template variable.
+	// By default `ALERTS{alertname="Maintenance"}`.
+	Query string `yaml:"query,omitempty"`
 }
 
 // SLIPlugin will use the SLI returned by the SLI plugin selected along with the options.
@@ -166,6 +317,9 @@ type Alerting struct {
 	Labels map[string]string `yaml:"labels,omitempty"`
 	// Annotations are the Prometheus annotations that will have all the alerts generated by
 	// this SLO.
+	// The annotations can use the `<<sloth:budget_burned_minutes>>` helper to get the error budget
+	// minutes burned in the alert window and `<<sloth:alert_window>>` to get the alert window, the
+	// `<<sloth:{quick,slow}_{short,long}_window>>` helpers get the windows of the alert tier.
 	Annotations map[string]string `yaml:"annotations,omitempty"`
 	// Page alert refers to the critical alert (check multiwindow-multiburn alerts).
 	PageAlert Alert `yaml:"page_alert,omitempty"`
@@ -176,11 +330,35 @@ type Alerting struct {
 // Alert configures specific SLO alert.
 type Alert struct {
 	// Disable disables the alert and makes Sloth not generating this alert. This
-	// can be helpful for example to disable ticket(warning) alerts.
+	// can be helpful for example to disable ticket(warning) alerts. The page and ticket
+	// alerts are disabled independently, per SLO.
 	Disable bool `yaml:"disable,omitempty"`
 	// Labels are the Prometheus labels for the specific alert. For example can be
 	// useful to route the Page alert to specific Slack channel.
 	Labels map[string]string `yaml:"labels,omitempty"`
 	// Annotations are the Prometheus annotations for the specific alert.
 	Annotations map[string]string `yaml:"annotations,omitempty"`
+	// BusinessHours is optional and makes the alert a business hours only alert (e.g for
+	// non-critical SLOs that shouldn't page at night), check AlertBusinessHours.
+	BusinessHours *AlertBusinessHours `yaml:"business_hours,omitempty"`
+}
+
+// AlertBusinessHours sets the `business_hours_only: "true"` label on the alert, so it can be
+// routed accordingly, and optionally a PromQL time gate so the alert only fires on the
+// business hours.
+//
+// The business hours are always UTC, time zones (and daylight saving time) are not supported
+// because the PromQL `hour()` and `day_of_week()` functions are UTC based.
+type AlertBusinessHours struct {
+	// TimeGate adds a time gate to the alert expression using `hour()` and `day_of_week()`, so
+	// the alert only fires on the business hours.
+	TimeGate bool `yaml:"time_gate,omitempty"`
+	// Hours is the UTC hours range of the business hours in `start-end` form, the end hour
+	// excluded (e.g 8-16), by default `9-17`. The range can wrap around midnight (e.g 22-6).
+	Hours string `yaml:"hours,omitempty"`
+	// Days is the UTC days of the week range of the business hours in `start-end` form, both
+	// included and 0 is Sunday (e.g 1-4), by default `1-5` (Monday to Friday). The range can wrap
+	// around the week (e.g 5-1). The days apply to the UTC day of every hour, e.g with `22-6` hours
+	// and `1-5` days, Saturday 02:00 is not a business hour.
+	Days string `yaml:"days,omitempty"`
 }
@@ -29,3 +29,26 @@ const (
 //
 // This is the type the SLI plugins need to implement.
 type SLIPlugin = func(ctx context.Context, meta, labels, options map[string]string) (query string, err error)
+
+// SpecPolicyPluginVersion is the version of the spec policy plugin (e.g: `prometheus/v1`).
+type SpecPolicyPluginVersion = string
+
+// SpecPolicyPluginID is the ID of the spec policy plugin.
+type SpecPolicyPluginID = string
+
+// Spec policy SLO keys, the SLO labels use the label prefix (e.g: `label.owner`).
+const (
+	SpecPolicySLOID          = "id"
+	SpecPolicySLOName        = "name"
+	SpecPolicySLOService     = "service"
+	SpecPolicySLODescription = "description"
+	SpecPolicySLOObjective   = "objective"
+	SpecPolicySLOLabelPrefix = "label."
+)
+
+// SpecPolicyPlugin knows how to check and transform the loaded SLOs of a spec before generating
+// them, it can reject the spec returning an error or return the SLOs with the description,
+// objective and labels changed (the ID, name and service can't be changed).
+//
+// This is the type the spec policy plugins need to implement.
+type SpecPolicyPlugin = func(ctx context.Context, slos []map[string]string) ([]map[string]string, error)
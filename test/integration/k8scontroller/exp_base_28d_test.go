@@ -15,6 +15,9 @@ func getBase28DayPromOpPrometheusRule(slothVersion string) *monitoringv1.Prometh
 				"app.kubernetes.io/component":  "SLO",
 				"app.kubernetes.io/managed-by": "sloth",
 			},
+			Annotations: map[string]string{
+				"sloth.slok.dev/spec-source": "PrometheusServiceLevel/test01",
+			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: "sloth.slok.dev/v1",
@@ -72,6 +72,9 @@ func getBasePromOpPrometheusRule(slothVersion string) *monitoringv1.PrometheusRu
 				"app.kubernetes.io/component":  "SLO",
 				"app.kubernetes.io/managed-by": "sloth",
 			},
+			Annotations: map[string]string{
+				"sloth.slok.dev/spec-source": "PrometheusServiceLevel/test01",
+			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: "sloth.slok.dev/v1",
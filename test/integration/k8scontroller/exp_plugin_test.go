@@ -56,6 +56,9 @@ func getPluginPromOpPrometheusRule(slothVersion string) *monitoringv1.Prometheus
 				"app.kubernetes.io/component":  "SLO",
 				"app.kubernetes.io/managed-by": "sloth",
 			},
+			Annotations: map[string]string{
+				"sloth.slok.dev/spec-source": "PrometheusServiceLevel/test01",
+			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: "sloth.slok.dev/v1",
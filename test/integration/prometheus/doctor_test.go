@@ -0,0 +1,80 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/sloth/test/integration/prometheus"
+)
+
+func TestPrometheusDoctor(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		doctorCmdArgs string
+		expOut        []string
+		expErr        bool
+	}{
+		"A healthy environment should pass all the checks.": {
+			doctorCmdArgs: "--input ./testdata/validate/good",
+			expOut: []string{
+				"[PASS] SLI plugins",
+				"[PASS] SLO period windows",
+				"[PASS] SLO specs discovery",
+			},
+		},
+
+		"A broken plugins path should report the plugins failure.": {
+			doctorCmdArgs: "--input ./testdata/validate/good --sli-plugins-path ./missing-plugins",
+			expOut: []string{
+				"[FAIL] SLI plugins",
+				"[PASS] SLO period windows",
+				"[PASS] SLO specs discovery",
+			},
+			expErr: true,
+		},
+
+		"A missing SLO period in the windows catalog should report the windows failure.": {
+			doctorCmdArgs: "--input ./testdata/validate/good --slo-period-windows-path ./windows --default-slo-period 30d",
+			expOut: []string{
+				"[PASS] SLI plugins",
+				"[FAIL] SLO period windows",
+			},
+			expErr: true,
+		},
+
+		"An input without specs should report the discovery failure.": {
+			doctorCmdArgs: "--input ./windows-missing",
+			expOut: []string{
+				"[FAIL] SLO specs discovery",
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, _, err := prometheus.RunSlothDoctor(ctx, config, test.doctorCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			for _, exp := range test.expOut {
+				assert.Contains(string(out), exp)
+			}
+		})
+	}
+}
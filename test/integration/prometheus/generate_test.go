@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -75,6 +77,11 @@ func TestPrometheusGenerate(t *testing.T) {
 			expOut:     expectLoader.mustLoadExp("./testdata/out-base-no-recordings.yaml.tpl"),
 		},
 
+		"Generate without metadata rules should generate the correct SLI recording and alert rules for all the SLOs.": {
+			genCmdArgs: "--input ./testdata/in-base.yaml --omit-metadata-rules",
+			expOut:     expectLoader.mustLoadExp("./testdata/out-base-no-metadata.yaml.tpl"),
+		},
+
 		"Generate with extra labels should generate the correct rules for all the SLOs.": {
 			genCmdArgs: "--input ./testdata/in-base.yaml -l exk1=exv1 -l exk2=exv2",
 			expOut:     expectLoader.mustLoadExp("./testdata/out-base-extra-labels.yaml.tpl"),
@@ -105,6 +112,16 @@ func TestPrometheusGenerate(t *testing.T) {
 			expOut:     expectLoader.mustLoadExp("./testdata/out-base-28d.yaml.tpl"),
 		},
 
+		"Generate using a 28 day time window front-matter should generate Prometheus rules.": {
+			genCmdArgs: "--input ./testdata/in-base-front-matter-28d.yaml",
+			expOut:     expectLoader.mustLoadExp("./testdata/out-base-28d.yaml.tpl"),
+		},
+
+		"Generate using a 7 day time window and catalog front-matter should load the catalog relative to the spec file.": {
+			genCmdArgs: "--input ./testdata/in-base-front-matter-7d-catalog.yaml",
+			expOut:     expectLoader.mustLoadExp("./testdata/out-base-custom-windows-7d.yaml.tpl"),
+		},
+
 		"Generate using custom 7 day time window should generate Prometheus rules.": {
 			genCmdArgs: "--default-slo-period 7d --input ./testdata/in-base.yaml --slo-period-windows-path ./windows",
 			expOut:     expectLoader.mustLoadExp("./testdata/out-base-custom-windows-7d.yaml.tpl"),
@@ -133,3 +150,403 @@ func TestPrometheusGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusGenerateOnlySLO(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		genCmdArgs string
+		expIDs     []string
+		expNoIDs   []string
+		expErr     bool
+	}{
+		"Generate with SLO IDs should generate only the rules of those SLOs.": {
+			genCmdArgs: "--input ./testdata/in-multifile.yaml --only-slo svc01-slo1,svc02-slo02",
+			expIDs:     []string{"svc01-slo1", "svc02-slo02"},
+			expNoIDs:   []string{"svc01-slo02", "svc02-slo1"},
+		},
+
+		"Generate with repeated SLO IDs flags should generate only the rules of those SLOs (Kubernetes).": {
+			genCmdArgs: "--input ./testdata/in-multifile-k8s.yaml --only-slo svc01-slo1 --only-slo svc02-slo1",
+			expIDs:     []string{"svc01-slo1", "svc02-slo1"},
+			expNoIDs:   []string{"svc01-slo02", "svc02-slo02"},
+		},
+
+		"Generate with an unknown SLO ID should fail.": {
+			genCmdArgs: "--input ./testdata/in-multifile.yaml --only-slo svc01-slo1,svc01-unknown",
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			out, _, err := prometheus.RunSlothGenerate(ctx, config, test.genCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				for _, id := range test.expIDs {
+					assert.Contains(string(out), "sloth_id: "+id+"\n")
+				}
+				for _, id := range test.expNoIDs {
+					assert.NotContains(string(out), "sloth_id: "+id+"\n")
+				}
+			}
+		})
+	}
+}
+
+func TestPrometheusGenerateInputEncoding(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		genCmdArgs     string
+		expDescription string
+		expErr         bool
+	}{
+		"Generate with a latin-1 spec without the input encoding should fail.": {
+			genCmdArgs: "--input ./testdata/in-latin1.yaml",
+			expErr:     true,
+		},
+
+		"Generate with a latin-1 spec and the latin-1 input encoding should transcode the spec.": {
+			genCmdArgs:     "--input ./testdata/in-latin1.yaml --input-encoding latin-1 --slo-description-label",
+			expDescription: "sloth_description: Disponibilité des requêtes.\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			out, _, err := prometheus.RunSlothGenerate(ctx, config, test.genCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Contains(string(out), test.expDescription)
+			}
+		})
+	}
+}
+
+func TestPrometheusGenerateContentInclude(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		genCmdArgs string
+		expFiles   []string
+		expErr     bool
+	}{
+		"Generate with a content filter should generate only the files with a matching key value.": {
+			genCmdArgs: "--input ./testdata/validate/good --content-include service=^svc02$",
+			expFiles:   []string{"good-multi-k8s.yaml", "good-multi.yaml"},
+		},
+
+		"Generate with a negated content filter should generate only the files without a matching key value.": {
+			genCmdArgs: "--input ./testdata/validate/good --content-include service!=^svc02$",
+			expFiles:   []string{"good-aa.yaml", "good-ab.yaml", "good-ba.yaml", "good-k8s.yaml", "good-openslo.yaml"},
+		},
+
+		"Generate with multiple content filters should generate only the files matching all of them.": {
+			genCmdArgs: "--input ./testdata/validate/good --content-include service=^svc01$ --content-include kind!=PrometheusServiceLevel --fs-exclude openslo",
+			expFiles:   []string{"good-aa.yaml", "good-ab.yaml", "good-ba.yaml", "good-multi.yaml"},
+		},
+
+		"Generate with content filters that don't match any file should fail.": {
+			genCmdArgs: "--input ./testdata/validate/good --content-include service=^svc03$",
+			expErr:     true,
+		},
+
+		"Generate with an invalid content filter should fail.": {
+			genCmdArgs: "--input ./testdata/validate/good --content-include service",
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			outDir := t.TempDir()
+			_, _, err := prometheus.RunSlothGenerate(ctx, config, test.genCmdArgs+" --out "+outDir)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			entries, err := os.ReadDir(outDir)
+			require.NoError(err)
+			gotFiles := []string{}
+			for _, e := range entries {
+				gotFiles = append(gotFiles, e.Name())
+			}
+			assert.Equal(test.expFiles, gotFiles)
+		})
+	}
+}
+
+func TestPrometheusGenerateSpecPolicyPlugins(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		genCmdArgs   string
+		expOutSubstr string
+		expErr       bool
+	}{
+		"Generate with a policy plugin rejecting owner-less SLOs should fail with SLOs without owner.": {
+			genCmdArgs: "--input ./testdata/in-base.yaml --spec-policy-plugins-path ./policy",
+			expErr:     true,
+		},
+
+		"Generate with a policy plugin rejecting owner-less SLOs should generate the SLOs with owner.": {
+			genCmdArgs:   "--input ./testdata/in-owner.yaml --spec-policy-plugins-path ./policy",
+			expOutSubstr: "sloth-slo-sli-recordings-svc01-slo1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			out, _, err := prometheus.RunSlothGenerate(ctx, config, test.genCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Contains(string(out), test.expOutSubstr)
+			}
+		})
+	}
+}
+
+func TestPrometheusGenerateTimeout(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		genCmdArgs   string
+		expErrSubstr string
+	}{
+		"Generate with a slow plugin should be cancelled at the timeout.": {
+			genCmdArgs:   "--timeout 1s --input ./testdata/in-plugin-slow.yaml",
+			expErrSubstr: `"generate" command timed out after 1s`,
+		},
+
+		"Generate ending before the timeout should not fail.": {
+			genCmdArgs: "--timeout 1m --input ./testdata/in-base.yaml",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			start := time.Now()
+			_, stderr, err := prometheus.RunSlothGenerate(ctx, config, test.genCmdArgs)
+
+			if test.expErrSubstr != "" {
+				assert.Error(err)
+				assert.Contains(string(stderr), test.expErrSubstr)
+				assert.Less(time.Since(start), 20*time.Second)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestPrometheusGenerateRulerFiles(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		genCmdArgs string
+		expFiles   map[string]string
+		expErr     bool
+	}{
+		"Generate ruler files to the stdout should fail.": {
+			genCmdArgs: "--input ./testdata/in-base.yaml --rules-format ruler-files",
+			expErr:     true,
+		},
+
+		"Generate ruler files with the spec hash should fail.": {
+			genCmdArgs: "--input ./testdata/validate/good --rules-format ruler-files --spec-hash --out " + t.TempDir(),
+			expErr:     true,
+		},
+
+		"Generate ruler files should write a rules file per service namespace.": {
+			genCmdArgs: "--input ./testdata/validate/good --rules-format ruler-files",
+			expFiles: map[string]string{
+				"svc01.yaml": "sloth-slo-sli-recordings-svc01-slo1",
+				"svc02.yaml": "sloth-slo-sli-recordings-svc02-slo1",
+			},
+		},
+
+		"Generate ruler files with a static namespace should write a single rules file.": {
+			genCmdArgs: "--input ./testdata/validate/good --rules-format ruler-files --namespace-from static:slos",
+			expFiles: map[string]string{
+				"slos.yaml": "sloth-slo-sli-recordings-svc02-slo1",
+			},
+		},
+
+		"Generate Cortex rules should write a Cortex rules namespace file per service namespace.": {
+			genCmdArgs: "--input ./testdata/validate/good --rules-format cortex",
+			expFiles: map[string]string{
+				"svc01.yaml": "namespace: svc01\ngroups:\n- name: sloth-slo-sli-recordings-svc01-slo1",
+				"svc02.yaml": "namespace: svc02\ngroups:\n- name: sloth-slo-sli-recordings-svc02-slo1",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			args := test.genCmdArgs
+			outDir := t.TempDir()
+			if !test.expErr {
+				args += " --out " + outDir
+			}
+			_, _, err := prometheus.RunSlothGenerate(ctx, config, args)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			files, err := os.ReadDir(outDir)
+			require.NoError(err)
+			gotFiles := []string{}
+			for _, f := range files {
+				gotFiles = append(gotFiles, f.Name())
+			}
+			expFiles := []string{}
+			for f, expSubstr := range test.expFiles {
+				expFiles = append(expFiles, f)
+				data, err := os.ReadFile(filepath.Join(outDir, f))
+				require.NoError(err)
+				assert.Contains(string(data), expSubstr)
+			}
+			assert.ElementsMatch(expFiles, gotFiles)
+		})
+	}
+}
+
+func TestPrometheusGenerateSplitByKind(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		genCmdArgs   string
+		expFileKinds map[string]string
+		expErr       bool
+	}{
+		"Generate split by kind to the stdout should fail.": {
+			genCmdArgs: "--input ./testdata/in-base.yaml --split-by-kind",
+			expErr:     true,
+		},
+
+		"Generate split by kind with the spec hash should fail.": {
+			genCmdArgs: "--input ./testdata/in-base.yaml --split-by-kind --spec-hash --out " + t.TempDir(),
+			expErr:     true,
+		},
+
+		"Generate split by kind should write the recording and alert rules in different files.": {
+			genCmdArgs: "--input ./testdata/in-base.yaml --split-by-kind",
+			expFileKinds: map[string]string{
+				"recording.yaml": "record:",
+				"alerts.yaml":    "alert:",
+			},
+		},
+
+		"Generate split by kind with a directory input should write all the rules in the kind files.": {
+			genCmdArgs: "--input ./testdata/validate/good --split-by-kind",
+			expFileKinds: map[string]string{
+				"recording.yaml": "record:",
+				"alerts.yaml":    "alert:",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			args := test.genCmdArgs
+			outDir := t.TempDir()
+			if !test.expErr {
+				args += " --out " + outDir
+			}
+			_, _, err := prometheus.RunSlothGenerate(ctx, config, args)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			files, err := os.ReadDir(outDir)
+			require.NoError(err)
+			gotFiles := []string{}
+			for _, f := range files {
+				gotFiles = append(gotFiles, f.Name())
+			}
+			expFiles := []string{}
+			for f, expKind := range test.expFileKinds {
+				expFiles = append(expFiles, f)
+				data, err := os.ReadFile(filepath.Join(outDir, f))
+				require.NoError(err)
+
+				// Every file should only have its kind rules.
+				assert.Contains(string(data), expKind)
+				for _, kind := range []string{"record:", "alert:"} {
+					if kind != expKind {
+						assert.NotContains(string(data), kind)
+					}
+				}
+			}
+			assert.ElementsMatch(expFiles, gotFiles)
+		})
+	}
+}
@@ -61,3 +61,31 @@ func RunSlothValidate(ctx context.Context, config Config, cmdArgs string) (stdou
 
 	return testutils.RunSloth(ctx, env, config.Binary, fmt.Sprintf("validate %s", cmdArgs), true)
 }
+
+func RunSlothDoctor(ctx context.Context, config Config, cmdArgs string) (stdout, stderr []byte, err error) {
+	env := []string{
+		fmt.Sprintf("SLOTH_SLI_PLUGINS_PATH=%s", "./"),
+	}
+
+	return testutils.RunSloth(ctx, env, config.Binary, fmt.Sprintf("doctor %s", cmdArgs), true)
+}
+
+func RunSlothInfo(ctx context.Context, config Config, cmdArgs string) (stdout, stderr []byte, err error) {
+	env := []string{
+		fmt.Sprintf("SLOTH_SLI_PLUGINS_PATH=%s", "./"),
+	}
+
+	return testutils.RunSloth(ctx, env, config.Binary, fmt.Sprintf("info %s", cmdArgs), true)
+}
+
+func RunSlothTest(ctx context.Context, config Config, cmdArgs string) (stdout, stderr []byte, err error) {
+	env := []string{
+		fmt.Sprintf("SLOTH_SLI_PLUGINS_PATH=%s", "./"),
+	}
+
+	return testutils.RunSloth(ctx, env, config.Binary, fmt.Sprintf("test %s", cmdArgs), true)
+}
+
+func RunSlothSchema(ctx context.Context, config Config, cmdArgs string) (stdout, stderr []byte, err error) {
+	return testutils.RunSloth(ctx, []string{}, config.Binary, fmt.Sprintf("schema %s", cmdArgs), true)
+}
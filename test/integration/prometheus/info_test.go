@@ -0,0 +1,77 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/sloth/test/integration/prometheus"
+)
+
+func TestPrometheusInfoInventory(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		infoCmdArgs string
+		expOut      string
+		expErr      bool
+	}{
+		"Discovering multiple spec files should show the CSV inventory of all the SLOs.": {
+			infoCmdArgs: "inventory --input ./testdata/inventory",
+			expOut: `file,service,slo,id,objective,window,owner
+testdata/inventory/payments.yaml,payments,requests-availability,payments-requests-availability,99.9,30d,team-payments
+testdata/inventory/payments.yaml,payments,requests-latency,payments-requests-latency,99,7d,team-payments
+testdata/inventory/search.yaml,search,queries-availability,search-queries-availability,99.95,30d,team-search
+`,
+		},
+
+		"Discovering multiple spec files should show the JSON inventory of all the SLOs.": {
+			infoCmdArgs: "inventory --input ./testdata/inventory --fs-include search --output json",
+			expOut: `[
+  {
+    "file": "testdata/inventory/search.yaml",
+    "service": "search",
+    "slo": "queries-availability",
+    "id": "search-queries-availability",
+    "objective": 99.95,
+    "window": "30d",
+    "owner": "team-search"
+  }
+]
+`,
+		},
+
+		"A custom owner label should be used as the owner.": {
+			infoCmdArgs: "inventory --input ./testdata/inventory --fs-include search --owner-label team",
+			expOut: `file,service,slo,id,objective,window,owner
+testdata/inventory/search.yaml,search,queries-availability,search-queries-availability,99.95,30d,
+`,
+		},
+
+		"An input without specs should fail.": {
+			infoCmdArgs: "inventory --input ./windows",
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, _, err := prometheus.RunSlothInfo(ctx, config, test.infoCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expOut, string(out))
+			}
+		})
+	}
+}
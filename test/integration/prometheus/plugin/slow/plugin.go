@@ -0,0 +1,17 @@
+package slow
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	SLIPluginVersion = "prometheus/v1"
+	SLIPluginID      = "integration_test_slow"
+)
+
+// SLIPlugin simulates a hung plugin that ignores the context.
+func SLIPlugin(_ context.Context, _, _, _ map[string]string) (string, error) {
+	time.Sleep(time.Minute)
+	return `sum(rate(integration_test_slow[{{.window}}]))`, nil
+}
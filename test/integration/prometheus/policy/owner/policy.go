@@ -0,0 +1,21 @@
+package owner
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	SpecPolicyPluginID      = "integration_test_owner"
+	SpecPolicyPluginVersion = "prometheus/v1"
+)
+
+func SpecPolicyPlugin(ctx context.Context, slos []map[string]string) ([]map[string]string, error) {
+	for _, slo := range slos {
+		if slo["label.owner"] == "" {
+			return nil, fmt.Errorf("%q SLO is missing the owner label", slo["id"])
+		}
+	}
+
+	return slos, nil
+}
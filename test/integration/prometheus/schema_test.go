@@ -0,0 +1,60 @@
+package prometheus_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/sloth/test/integration/prometheus"
+)
+
+func TestPrometheusSchema(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		schemaCmdArgs string
+		expTitle      string
+		expErr        bool
+	}{
+		"The default format should show the JSON schema of the spec.": {
+			schemaCmdArgs: "",
+			expTitle:      "Sloth prometheus/v1 SLO spec",
+		},
+
+		"The JSON schema format should show the JSON schema of the spec.": {
+			schemaCmdArgs: "--format jsonschema",
+			expTitle:      "Sloth prometheus/v1 SLO spec",
+		},
+
+		"An unknown format should fail.": {
+			schemaCmdArgs: "--format openapi",
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, _, err := prometheus.RunSlothSchema(ctx, config, test.schemaCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				schema := map[string]any{}
+				if assert.NoError(json.Unmarshal(out, &schema)) {
+					assert.Equal(test.expTitle, schema["title"])
+					assert.Equal("http://json-schema.org/draft-07/schema#", schema["$schema"])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/sloth/test/integration/prometheus"
+)
+
+func TestPrometheusTest(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Tests.
+	tests := map[string]struct {
+		testCmdArgs string
+		expOut      []string
+		expNotOut   []string
+		expErr      bool
+	}{
+		"Samples burning the error budget fast should fire the page and ticket alerts.": {
+			testCmdArgs: "--input ./testdata/in-base.yaml --data ./testdata/ruletest/samples-fast-burn.yaml",
+			expOut: []string{
+				`myServiceAlert  1m0s`,
+				`sloth_severity="page"`,
+				`sloth_severity="ticket"`,
+			},
+		},
+
+		"Precomputed samples of multiple series should aggregate them into a single SLO alert per severity.": {
+			testCmdArgs: "--input ./testdata/in-precomputed.yaml --data ./testdata/ruletest/samples-precomputed-fast-burn.yaml",
+			expOut: []string{
+				`myServiceAlert  0s`,
+				`sloth_severity="page"`,
+				`sloth_severity="ticket"`,
+			},
+			expNotOut: []string{`instance=`},
+		},
+
+		"Samples without errors should not fire alerts.": {
+			testCmdArgs: "--input ./testdata/in-base.yaml --data ./testdata/ruletest/samples-ok.yaml",
+			expOut:      []string{"No alerts fired."},
+		},
+
+		"Missing data file should fail.": {
+			testCmdArgs: "--input ./testdata/in-base.yaml --data ./testdata/ruletest/missing.yaml",
+			expErr:      true,
+		},
+
+		"Invalid spec should fail.": {
+			testCmdArgs: "--input ./testdata/in-invalid-version.yaml --data ./testdata/ruletest/samples-ok.yaml",
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, _, err := prometheus.RunSlothTest(ctx, config, test.testCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				for _, exp := range test.expOut {
+					assert.Contains(string(out), exp)
+				}
+				for _, exp := range test.expNotOut {
+					assert.NotContains(string(out), exp)
+				}
+			}
+		})
+	}
+}
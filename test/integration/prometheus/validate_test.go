@@ -2,9 +2,13 @@ package prometheus_test
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/slok/sloth/test/integration/prometheus"
 )
@@ -45,9 +49,85 @@ func TestPrometheusValidate(t *testing.T) {
 			expErr:     true,
 		},
 
+		"Discovery of multiple inputs should validate correctly.": {
+			valCmdArgs: "--input ./testdata/validate/good --input ./testdata/validate --fs-exclude bad",
+		},
+
+		"Discovery of multiple inputs with a bad one should validate with failures.": {
+			valCmdArgs: "--input ./testdata/validate/good --input ./testdata/validate/bad",
+			expErr:     true,
+		},
+
 		"Discovery of all specs excluding bad and including a bad one should validate correctly because exclude has preference.": {
 			valCmdArgs: "--input ./testdata/validate --fs-exclude bad --fs-include .*-aa.*",
 		},
+
+		"Discovery of good specs in OpenSLO strict mode should validate correctly.": {
+			valCmdArgs: "--input ./testdata/validate/good --strict-openslo",
+		},
+
+		"An OpenSLO spec with an unknown field should validate correctly.": {
+			valCmdArgs: "--input ./testdata/in-openslo-unknown-field.yaml",
+		},
+
+		"An OpenSLO spec with an unknown field in OpenSLO strict mode should validate with failures.": {
+			valCmdArgs: "--input ./testdata/in-openslo-unknown-field.yaml --strict-openslo",
+			expErr:     true,
+		},
+
+		"Checking the SLI plugins of specs with existing plugins should validate correctly.": {
+			valCmdArgs: "--input ./testdata/in-plugin.yaml --check-sli-plugins",
+		},
+
+		"Checking the SLI plugins of specs with a missing plugin should fail.": {
+			valCmdArgs: "--input ./testdata/in-plugin-missing.yaml --check-sli-plugins",
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Run with context to stop on test end.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			_, _, err := prometheus.RunSlothValidate(ctx, config, test.valCmdArgs)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestPrometheusValidateFollowSymlinks(t *testing.T) {
+	// Tests config.
+	config := prometheus.NewConfig(t)
+
+	// Input directory with a symlinked specs directory and a symlink loop.
+	goodPath, err := filepath.Abs("./testdata/validate/good")
+	require.NoError(t, err)
+	inputPath := t.TempDir()
+	require.NoError(t, os.Symlink(goodPath, filepath.Join(inputPath, "shared")))
+	require.NoError(t, os.Symlink(inputPath, filepath.Join(inputPath, "loop")))
+
+	// Tests.
+	tests := map[string]struct {
+		valCmdArgs string
+		expErr     bool
+	}{
+		"Not following symlinks should ignore the symlinked directories.": {
+			valCmdArgs: fmt.Sprintf("--input %s", inputPath),
+			expErr:     true,
+		},
+
+		"Following symlinks should discover the symlinked directories specs once.": {
+			valCmdArgs: fmt.Sprintf("--input %s --follow-symlinks", inputPath),
+		},
 	}
 
 	for name, test := range tests {